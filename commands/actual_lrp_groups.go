@@ -1,12 +1,13 @@
 package commands
 
 import (
-	"encoding/json"
+	"context"
 	"io"
 
 	"code.cloudfoundry.org/bbs"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"github.com/spf13/cobra"
 )
 
@@ -43,8 +44,12 @@ func actualLRPGroups(cmd *cobra.Command, args []string) error {
 		return NewCFDotError(cmd, err)
 	}
 
+	ctx, cancel := commandContext()
+	defer cancel()
+
 	err = ActualLRPGroups(
-		cmd.OutOrStdout(),
+		ctx,
+		newPrinter(cmd),
 		cmd.OutOrStderr(),
 		bbsClient,
 		actualLRPGroupsDomainFlag,
@@ -64,23 +69,21 @@ func ValidateActualLRPGroupsArguments(args []string) error {
 	return nil
 }
 
-func ActualLRPGroups(stdout, stderr io.Writer, bbsClient bbs.Client, domain, cellID string) error {
+func ActualLRPGroups(ctx context.Context, printer Printer, stderr io.Writer, bbsClient bbs.Client, domain, cellID string) error {
 	logger := globalLogger.Session("actual-lrp-groups")
 
-	encoder := json.NewEncoder(stdout)
-
 	actualLRPFilter := models.ActualLRPFilter{
 		CellID: cellID,
 		Domain: domain,
 	}
 
-	actualLRPGroups, err := bbsClient.ActualLRPGroups(logger, actualLRPFilter)
+	actualLRPGroups, err := diego.ActualLRPGroups(ctx, logger, bbsClient, actualLRPFilter)
 	if err != nil {
 		return err
 	}
 
 	for _, actualLRPGroup := range actualLRPGroups {
-		err = encoder.Encode(actualLRPGroup)
+		err = printer.Print(actualLRPGroup)
 		if err != nil {
 			logger.Error("failed-to-marshal", err)
 		}
@@ -1,7 +1,7 @@
 package commands
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -10,6 +10,7 @@ import (
 	"code.cloudfoundry.org/bbs"
 
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"github.com/spf13/cobra"
 )
 
@@ -46,7 +47,10 @@ func actualLRPGroupsByProcessGuid(cmd *cobra.Command, args []string) error {
 		return NewCFDotError(cmd, err)
 	}
 
-	err = ActualLRPGroupsForGuid(cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, processGuid, index)
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	err = ActualLRPGroupsForGuid(ctx, newPrinter(cmd), cmd.OutOrStderr(), bbsClient, processGuid, index)
 	if err != nil {
 		return NewCFDotError(cmd, err)
 	}
@@ -79,18 +83,17 @@ func ValidateActualLRPGroupsForGuidArgs(args []string, indexFlag string) (string
 	return args[0], index, nil
 }
 
-func ActualLRPGroupsForGuid(stdout, stderr io.Writer, bbsClient bbs.Client, processGuid string, index int) error {
+func ActualLRPGroupsForGuid(ctx context.Context, printer Printer, stderr io.Writer, bbsClient bbs.Client, processGuid string, index int) error {
 	logger := globalLogger.Session("actual-lrp-groups-for-guid")
 
-	encoder := json.NewEncoder(stdout)
 	if index < 0 {
-		actualLRPGroups, err := bbsClient.ActualLRPGroupsByProcessGuid(logger, processGuid)
+		actualLRPGroups, err := diego.ActualLRPGroupsByProcessGuid(ctx, logger, bbsClient, processGuid)
 		if err != nil {
 			return err
 		}
 
 		for _, group := range actualLRPGroups {
-			err = encoder.Encode(group)
+			err = printer.Print(group)
 			if err != nil {
 				logger.Error("failed-to-marshal", err)
 			}
@@ -98,11 +101,11 @@ func ActualLRPGroupsForGuid(stdout, stderr io.Writer, bbsClient bbs.Client, proc
 
 		return nil
 	} else {
-		actualLRPGroup, err := bbsClient.ActualLRPGroupByProcessGuidAndIndex(logger, processGuid, index)
+		actualLRPGroup, err := diego.ActualLRPGroupByProcessGuidAndIndex(ctx, logger, bbsClient, processGuid, index)
 		if err != nil {
 			return err
 		}
 
-		return encoder.Encode(actualLRPGroup)
+		return printer.Print(actualLRPGroup)
 	}
 }
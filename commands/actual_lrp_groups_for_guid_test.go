@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"math"
@@ -102,7 +103,7 @@ var _ = Describe("ActualLRPGroupsForGuid", func() {
 		})
 
 		It("writes the json representation of the actual lrp groups to stdout", func() {
-			err := commands.ActualLRPGroupsForGuid(stdout, stderr, fakeBBSClient, "guid", -math.MaxInt64)
+			err := commands.ActualLRPGroupsForGuid(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "guid", -math.MaxInt64)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeBBSClient.ActualLRPGroupsByProcessGuidCallCount()).To(Equal(1))
@@ -125,7 +126,7 @@ var _ = Describe("ActualLRPGroupsForGuid", func() {
 			})
 
 			It("returns the error", func() {
-				err := commands.ActualLRPGroupsForGuid(stdout, stderr, fakeBBSClient, "guid", -math.MaxInt64)
+				err := commands.ActualLRPGroupsForGuid(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "guid", -math.MaxInt64)
 				Expect(err).To(HaveOccurred())
 			})
 		})
@@ -145,7 +146,7 @@ var _ = Describe("ActualLRPGroupsForGuid", func() {
 			})
 
 			It("writes the json representation of the actual lrp group to stdout", func() {
-				err := commands.ActualLRPGroupsForGuid(stdout, stderr, fakeBBSClient, "guid", 2)
+				err := commands.ActualLRPGroupsForGuid(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "guid", 2)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(fakeBBSClient.ActualLRPGroupByProcessGuidAndIndexCallCount()).To(Equal(1))
@@ -165,7 +166,7 @@ var _ = Describe("ActualLRPGroupsForGuid", func() {
 				})
 
 				It("returns the error", func() {
-					err := commands.ActualLRPGroupsForGuid(stdout, stderr, fakeBBSClient, "guid", 2)
+					err := commands.ActualLRPGroupsForGuid(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "guid", 2)
 					Expect(err).To(HaveOccurred())
 				})
 			})
@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"context"
 	"encoding/json"
 
 	"code.cloudfoundry.org/bbs/fake_bbs"
@@ -44,7 +45,7 @@ var _ = Describe("ActualLRPGroups", func() {
 		})
 
 		It("prints a json stream of all the actual lrp groups", func() {
-			err := commands.ActualLRPGroups(stdout, stderr, fakeBBSClient, "domain-1", "cell-1")
+			err := commands.ActualLRPGroups(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "domain-1", "cell-1")
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeBBSClient.ActualLRPGroupsCallCount()).To(Equal(1))
@@ -69,7 +70,7 @@ var _ = Describe("ActualLRPGroups", func() {
 		})
 
 		It("fails with a relevant error", func() {
-			err := commands.ActualLRPGroups(stdout, stderr, fakeBBSClient, "", "")
+			err := commands.ActualLRPGroups(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "", "")
 			Expect(err).To(HaveOccurred())
 
 			Expect(err).To(Equal(models.ErrUnknownError))
@@ -1,12 +1,13 @@
 package commands
 
 import (
-	"encoding/json"
+	"context"
 	"io"
 
 	"code.cloudfoundry.org/bbs"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"github.com/spf13/cobra"
 )
 
@@ -50,8 +51,12 @@ func actualLRPs(cmd *cobra.Command, args []string) error {
 		index = &actualLRPsIndexFlag
 	}
 
+	ctx, cancel := commandContext()
+	defer cancel()
+
 	err = ActualLRPs(
-		cmd.OutOrStdout(),
+		ctx,
+		newPrinter(cmd),
 		cmd.OutOrStderr(),
 		bbsClient,
 		actualLRPsDomainFlag,
@@ -73,11 +78,9 @@ func ValidateActualLRPsArguments(args []string) error {
 	return nil
 }
 
-func ActualLRPs(stdout, stderr io.Writer, bbsClient bbs.Client, domain, cellID, processGuid string, index *int32) error {
+func ActualLRPs(ctx context.Context, printer Printer, stderr io.Writer, bbsClient bbs.Client, domain, cellID, processGuid string, index *int32) error {
 	logger := globalLogger.Session("actual-lrps")
 
-	encoder := json.NewEncoder(stdout)
-
 	actualLRPFilter := models.ActualLRPFilter{
 		CellID:      cellID,
 		Domain:      domain,
@@ -85,13 +88,13 @@ func ActualLRPs(stdout, stderr io.Writer, bbsClient bbs.Client, domain, cellID,
 		Index:       index,
 	}
 
-	actualLRPs, err := bbsClient.ActualLRPs(logger, actualLRPFilter)
+	actualLRPs, err := diego.ActualLRPs(ctx, logger, bbsClient, actualLRPFilter)
 	if err != nil {
 		return err
 	}
 
 	for _, actualLRP := range actualLRPs {
-		err = encoder.Encode(actualLRP)
+		err = printer.Print(actualLRP)
 		if err != nil {
 			logger.Error("failed-to-marshal", err)
 		}
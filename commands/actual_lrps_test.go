@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"context"
 	"encoding/json"
 
 	"code.cloudfoundry.org/bbs/fake_bbs"
@@ -42,7 +43,7 @@ var _ = Describe("ActualLRPs", func() {
 
 		It("prints a json stream of all the actual lrps", func() {
 			index := int32(4)
-			err := commands.ActualLRPs(stdout, stderr, fakeBBSClient, "domain-1", "cell-1", "pg-2", &index)
+			err := commands.ActualLRPs(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "domain-1", "cell-1", "pg-2", &index)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeBBSClient.ActualLRPsCallCount()).To(Equal(1))
@@ -72,7 +73,7 @@ var _ = Describe("ActualLRPs", func() {
 		})
 
 		It("fails with a relevant error", func() {
-			err := commands.ActualLRPs(stdout, stderr, fakeBBSClient, "", "", "", nil)
+			err := commands.ActualLRPs(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "", "", "", nil)
 			Expect(err).To(HaveOccurred())
 
 			Expect(err).To(Equal(models.ErrUnknownError))
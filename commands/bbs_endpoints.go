@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"github.com/spf13/cobra"
+)
+
+var bbsEndpointsCmd = &cobra.Command{
+	Use:   "bbs-endpoints",
+	Short: "Ping each BBS endpoint",
+	Long:  "Ping each of the BBS URLs given with --bbsURL and show which one is the active leader",
+	RunE:  bbsEndpoints,
+}
+
+type BBSEndpoint = diego.BBSEndpoint
+
+var errNoActiveBBSEndpoint = errors.New("None of the BBS endpoints is active")
+
+func init() {
+	AddBBSAndTimeoutFlags(bbsEndpointsCmd)
+	RootCmd.AddCommand(bbsEndpointsCmd)
+}
+
+func bbsEndpoints(cmd *cobra.Command, args []string) error {
+	err := ValidateBBSEndpointsArguments(args)
+	if err != nil {
+		return NewCFDotValidationError(cmd, err)
+	}
+
+	urls, bbsClients, err := helpers.NewBBSEndpointClients(cmd, Config)
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	err = BBSEndpoints(ctx, newPrinter(cmd), cmd.OutOrStderr(), urls, bbsClients)
+	if err != nil {
+		return NewCFDotComponentError(cmd, err)
+	}
+
+	return nil
+}
+
+func ValidateBBSEndpointsArguments(args []string) error {
+	if len(args) > 0 {
+		return errExtraArguments
+	}
+	return nil
+}
+
+func BBSEndpoints(ctx context.Context, printer Printer, stderr io.Writer, urls []string, bbsClients []bbs.Client) error {
+	logger := globalLogger.Session("bbs-endpoints")
+
+	endpoints, err := diego.BBSEndpoints(ctx, logger, urls, bbsClients)
+
+	active := false
+	for _, endpoint := range endpoints {
+		active = active || endpoint.Active
+		if err := printer.Print(endpoint); err != nil {
+			logger.Error("failed-to-marshal", err)
+			return err
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !active {
+		return errNoActiveBBSEndpoint
+	}
+
+	return nil
+}
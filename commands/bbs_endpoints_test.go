@@ -0,0 +1,64 @@
+package commands_test
+
+import (
+	"context"
+	"encoding/json"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/cfdot/commands"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("BBSEndpoints", func() {
+	var (
+		leader, follower *fake_bbs.FakeClient
+		urls             []string
+		stdout, stderr   *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		stdout = gbytes.NewBuffer()
+		stderr = gbytes.NewBuffer()
+
+		leader = &fake_bbs.FakeClient{}
+		leader.PingReturns(true)
+		follower = &fake_bbs.FakeClient{}
+		follower.PingReturns(false)
+
+		urls = []string{"https://bbs-0:8889", "https://bbs-1:8889"}
+	})
+
+	Context("ValidateBBSEndpointsArguments", func() {
+		It("rejects extra arguments", func() {
+			err := commands.ValidateBBSEndpointsArguments([]string{"extra-arg"})
+			Expect(err).To(MatchError("Too many arguments specified"))
+		})
+	})
+
+	It("prints whether each endpoint is the active leader", func() {
+		err := commands.BBSEndpoints(context.Background(), commands.NewJSONPrinter(stdout), stderr, urls, []bbs.Client{follower, leader})
+		Expect(err).NotTo(HaveOccurred())
+
+		decoder := json.NewDecoder(stdout)
+
+		var endpoint commands.BBSEndpoint
+		Expect(decoder.Decode(&endpoint)).To(Succeed())
+		Expect(endpoint).To(Equal(commands.BBSEndpoint{URL: "https://bbs-0:8889", Active: false}))
+
+		Expect(decoder.Decode(&endpoint)).To(Succeed())
+		Expect(endpoint).To(Equal(commands.BBSEndpoint{URL: "https://bbs-1:8889", Active: true}))
+	})
+
+	Context("when no endpoint is active", func() {
+		It("prints every endpoint and returns an error", func() {
+			err := commands.BBSEndpoints(context.Background(), commands.NewJSONPrinter(stdout), stderr, urls, []bbs.Client{follower, follower})
+			Expect(err).To(MatchError("None of the BBS endpoints is active"))
+			Expect(stdout).To(gbytes.Say(`"url":"https://bbs-0:8889","active":false`))
+			Expect(stdout).To(gbytes.Say(`"url":"https://bbs-1:8889","active":false`))
+		})
+	})
+})
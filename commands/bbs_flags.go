@@ -21,7 +21,8 @@ var (
 
 func AddBBSFlags(cmd *cobra.Command) {
 	AddTLSFlags(cmd)
-	cmd.Flags().StringVar(&bbsUrl, "bbsURL", "", "URL of BBS server to target [environment variable equivalent: BBS_URL]")
+	cmd.Flags().StringVar(&bbsUrl, "bbsURL", "", "URL of BBS server to target, or a comma-separated list of URLs to fail over between [environment variable equivalent: BBS_URL]")
+	addRetryFlags(cmd)
 	cmd.PreRunE = BBSPrehook
 }
 
@@ -29,6 +30,9 @@ func BBSPrehook(cmd *cobra.Command, args []string) error {
 	if err := setBBSFlags(cmd, args); err != nil {
 		return err
 	}
+	if err := setRetryFlags(cmd, args); err != nil {
+		return err
+	}
 	return tlsPreHook(cmd, args)
 }
 
@@ -46,26 +50,34 @@ func setBBSFlags(cmd *cobra.Command, args []string) error {
 
 	Config.BBSUrl = bbsUrl
 
-	var parsedURL *url.URL
-	if parsedURL, err = url.Parse(Config.BBSUrl); err != nil {
-		returnErr = NewCFDotValidationError(
-			cmd,
-			fmt.Errorf(
-				"The value '%s' is not a valid BBS URL. Please specify one with the '--bbsURL' flag or the 'BBS_URL' environment variable.",
-				Config.BBSUrl),
-		)
+	bbsURLs := Config.BBSURLs()
+	if len(bbsURLs) == 0 {
+		returnErr = NewCFDotValidationError(cmd, errMissingBBSUrl)
 		return returnErr
 	}
 
-	if parsedURL.Scheme != "https" {
-		returnErr = NewCFDotValidationError(
-			cmd,
-			fmt.Errorf(
-				"The URL '%s' does not have an 'https' scheme. Please "+
-					"specify one with the '--bbsURL' flag or the 'BBS_URL' environment "+
-					"variable.", Config.BBSUrl),
-		)
-		return returnErr
+	for _, bbsURL := range bbsURLs {
+		var parsedURL *url.URL
+		if parsedURL, err = url.Parse(bbsURL); err != nil {
+			returnErr = NewCFDotValidationError(
+				cmd,
+				fmt.Errorf(
+					"The value '%s' is not a valid BBS URL. Please specify one with the '--bbsURL' flag or the 'BBS_URL' environment variable.",
+					bbsURL),
+			)
+			return returnErr
+		}
+
+		if parsedURL.Scheme != "https" {
+			returnErr = NewCFDotValidationError(
+				cmd,
+				fmt.Errorf(
+					"The URL '%s' does not have an 'https' scheme. Please "+
+						"specify one with the '--bbsURL' flag or the 'BBS_URL' environment "+
+						"variable.", bbsURL),
+			)
+			return returnErr
+		}
 	}
 
 	return nil
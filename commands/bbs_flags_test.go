@@ -97,6 +97,30 @@ var _ = Describe("BBS Flags", func() {
 			})
 		})
 
+		Context("when the --bbsURL is a comma-separated list", func() {
+			BeforeEach(func() {
+				parseFlagsErr := dummyCmd.ParseFlags(replaceFlagValue(validFlags, "--bbsURL", "https://bbs-0.example.com, https://bbs-1.example.com"))
+				Expect(parseFlagsErr).NotTo(HaveOccurred())
+			})
+
+			It("accepts every URL", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(commands.Config.BBSURLs()).To(Equal([]string{"https://bbs-0.example.com", "https://bbs-1.example.com"}))
+			})
+
+			Context("and one of them is not https", func() {
+				BeforeEach(func() {
+					parseFlagsErr := dummyCmd.ParseFlags(replaceFlagValue(validFlags, "--bbsURL", "https://bbs-0.example.com,nohttp.com"))
+					Expect(parseFlagsErr).NotTo(HaveOccurred())
+				})
+
+				It("returns an error message naming it", func() {
+					Expect(err).To(MatchError(
+						"The URL 'nohttp.com' does not have an 'https' scheme. Please specify one with the '--bbsURL' flag or the 'BBS_URL' environment variable."))
+				})
+			})
+		})
+
 		Context("when a BBS_URL environment variable is specified", func() {
 			AfterEach(func() {
 				os.Unsetenv("BBS_URL")
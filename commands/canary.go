@@ -0,0 +1,188 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"github.com/spf13/cobra"
+)
+
+var canaryCmd = &cobra.Command{
+	Use:   "canary",
+	Short: "Measure how long Diego takes to run a small LRP and task",
+	Long:  "Desire a single instance LRP and a task in a dedicated domain, time each phase of their lifecycle from the event streams, UNCLAIMED, CLAIMED and RUNNING for the LRP and PENDING, RUNNING and COMPLETED for the task, remove them and print the report. The command fails if either does not get through its lifecycle, within 5 minutes unless --timeout is set. Diego cannot place work on a given cell: --cell-id requires the placement tags of that cell, and a warning is written to stderr if a canary runs on another cell with the same tags",
+	RunE:  canary,
+}
+
+// canaryDefaultTimeout bounds a canary run when --timeout is not set, so that
+// a scheduler which never places the canaries does not hang the command.
+const canaryDefaultTimeout = 5 * time.Minute
+
+// CanaryOptions are the flags of canary that make up the canary LRP and task.
+type CanaryOptions struct {
+	Domain        string
+	RootFS        string
+	User          string
+	MemoryMB      int32
+	DiskMB        int32
+	PlacementTags []string
+	CellID        string
+}
+
+// flags
+var canaryFlags CanaryOptions
+
+// errors
+var (
+	errMissingCanaryDomain = errors.New("--domain must not be empty")
+	errMissingCanaryRootFS = errors.New("--rootfs must not be empty")
+	errCanaryFailed        = errors.New("The canary failed")
+)
+
+func init() {
+	AddBBSAndTimeoutFlags(canaryCmd)
+	canaryCmd.Flags().StringVar(&canaryFlags.Domain, "domain", "cfdot-canary", "domain of the canary LRP and task")
+	canaryCmd.Flags().StringVar(&canaryFlags.RootFS, "rootfs", "preloaded:cflinuxfs3", "rootfs of the canary LRP and task")
+	canaryCmd.Flags().StringVar(&canaryFlags.User, "user", "vcap", "user running the canary processes")
+	canaryCmd.Flags().Int32Var(&canaryFlags.MemoryMB, "memory-mb", 32, "memory limit of the canaries in MB")
+	canaryCmd.Flags().Int32Var(&canaryFlags.DiskMB, "disk-mb", 64, "disk limit of the canaries in MB")
+	canaryCmd.Flags().StringArrayVar(&canaryFlags.PlacementTags, "placement-tag", nil, "placement tag required of the cells running the canaries, can be repeated")
+	canaryCmd.Flags().StringVar(&canaryFlags.CellID, "cell-id", "", "cell to target, whose placement tags are required of the cells running the canaries")
+	RootCmd.AddCommand(canaryCmd)
+}
+
+func canary(cmd *cobra.Command, args []string) error {
+	err := ValidateCanaryArguments(args, canaryFlags)
+	if err != nil {
+		return NewCFDotValidationError(cmd, err)
+	}
+
+	bbsClient, err := helpers.NewBBSClient(cmd, Config)
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	if Config.Timeout <= 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, canaryDefaultTimeout)
+		defer cancelTimeout()
+	}
+
+	err = Canary(ctx, newPrinter(cmd), cmd.OutOrStderr(), bbsClient, canaryFlags)
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+
+	return nil
+}
+
+func ValidateCanaryArguments(args []string, options CanaryOptions) error {
+	if len(args) > 0 {
+		return errExtraArguments
+	}
+	if options.Domain == "" {
+		return errMissingCanaryDomain
+	}
+	if options.RootFS == "" {
+		return errMissingCanaryRootFS
+	}
+	if options.MemoryMB < 0 || options.DiskMB < 0 {
+		return errNegativeLRPResources
+	}
+	return nil
+}
+
+// Canary runs a canary LRP and task built from options and prints the report.
+// It fails if the canary failed, with ctx.Err() if it ran out of time.
+func Canary(ctx context.Context, printer Printer, stderr io.Writer, bbsClient bbs.Client, options CanaryOptions) error {
+	logger := globalLogger.Session("canary")
+
+	placementTags := options.PlacementTags
+	if options.CellID != "" {
+		cell, err := diego.CellRegistration(ctx, logger, bbsClient, options.CellID)
+		if err != nil {
+			return err
+		}
+		placementTags = append(append([]string{}, placementTags...), cell.PlacementTags...)
+	}
+
+	guid, err := newGuid("cfdot-canary")
+	if err != nil {
+		return err
+	}
+	lrp, task := NewCanaries(guid, options, placementTags)
+
+	fmt.Fprintf(stderr, "Running canary %s in domain %s\n", guid, options.Domain)
+	report := diego.RunCanary(ctx, logger, bbsClient, lrp, task)
+
+	if options.CellID != "" {
+		warnOtherCell(stderr, "LRP", report.LRP, options.CellID)
+		warnOtherCell(stderr, "task", report.Task, options.CellID)
+	}
+
+	err = printer.Print(report)
+	if err != nil {
+		return err
+	}
+
+	if report.Healthy {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return errCanaryFailed
+}
+
+// NewCanaries builds the canary LRP, a single instance sleeping without a
+// health check, and task, which exits right away, with the given guid.
+func NewCanaries(guid string, options CanaryOptions, placementTags []string) (*models.DesiredLRP, *models.Task) {
+	lrp := &models.DesiredLRP{
+		ProcessGuid:   guid,
+		Domain:        options.Domain,
+		LogGuid:       guid,
+		RootFs:        options.RootFS,
+		Instances:     1,
+		MemoryMb:      options.MemoryMB,
+		DiskMb:        options.DiskMB,
+		PlacementTags: placementTags,
+		Action: models.WrapAction(&models.RunAction{
+			Path: "/bin/sleep",
+			Args: []string{"3600"},
+			User: options.User,
+		}),
+	}
+
+	task := &models.Task{
+		TaskGuid: guid,
+		Domain:   options.Domain,
+		TaskDefinition: &models.TaskDefinition{
+			LogGuid:       guid,
+			RootFs:        options.RootFS,
+			MemoryMb:      options.MemoryMB,
+			DiskMb:        options.DiskMB,
+			PlacementTags: placementTags,
+			Action: models.WrapAction(&models.RunAction{
+				Path: "/bin/true",
+				User: options.User,
+			}),
+		},
+	}
+
+	return lrp, task
+}
+
+func warnOtherCell(w io.Writer, kind string, result diego.CanaryResult, cellID string) {
+	if result.CellID != "" && result.CellID != cellID {
+		fmt.Fprintf(w, "Warning: the canary %s ran on cell %s rather than %s\n", kind, result.CellID, cellID)
+	}
+}
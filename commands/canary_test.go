@@ -0,0 +1,108 @@
+package commands_test
+
+import (
+	"context"
+	"io"
+
+	"code.cloudfoundry.org/bbs/events/eventfakes"
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/commands"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Canary", func() {
+	var options commands.CanaryOptions
+
+	BeforeEach(func() {
+		options = commands.CanaryOptions{
+			Domain:   "cfdot-canary",
+			RootFS:   "preloaded:cflinuxfs3",
+			User:     "vcap",
+			MemoryMB: 32,
+			DiskMB:   64,
+		}
+	})
+
+	Context("ValidateCanaryArguments", func() {
+		It("accepts the defaults", func() {
+			Expect(commands.ValidateCanaryArguments([]string{}, options)).To(Succeed())
+		})
+
+		It("rejects arguments", func() {
+			Expect(commands.ValidateCanaryArguments([]string{"foo"}, options)).To(MatchError("Too many arguments specified"))
+		})
+
+		It("requires a domain", func() {
+			options.Domain = ""
+			Expect(commands.ValidateCanaryArguments([]string{}, options)).To(MatchError("--domain must not be empty"))
+		})
+	})
+
+	Context("NewCanaries", func() {
+		It("builds valid canaries with the placement tags", func() {
+			lrp, task := commands.NewCanaries("canary-guid", options, []string{"isolated"})
+
+			Expect(lrp.Validate()).To(Succeed())
+			Expect(lrp.ProcessGuid).To(Equal("canary-guid"))
+			Expect(lrp.Instances).To(Equal(int32(1)))
+			Expect(lrp.PlacementTags).To(Equal([]string{"isolated"}))
+
+			Expect(task.Validate()).To(Succeed())
+			Expect(task.TaskGuid).To(Equal("canary-guid"))
+			Expect(task.PlacementTags).To(Equal([]string{"isolated"}))
+		})
+	})
+
+	Context("Canary", func() {
+		var (
+			fakeBBSClient  *fake_bbs.FakeClient
+			stdout, stderr *gbytes.Buffer
+		)
+
+		BeforeEach(func() {
+			fakeBBSClient = &fake_bbs.FakeClient{}
+			stdout = gbytes.NewBuffer()
+			stderr = gbytes.NewBuffer()
+
+			closed := &eventfakes.FakeEventSource{}
+			closed.NextReturns(nil, io.EOF)
+			fakeBBSClient.SubscribeToInstanceEventsByCellIDReturns(closed, nil)
+			fakeBBSClient.SubscribeToTaskEventsReturns(closed, nil)
+			fakeBBSClient.TaskByGuidReturns(nil, models.ErrResourceNotFound)
+		})
+
+		It("prints the report and fails when the canary is unhealthy", func() {
+			err := commands.Canary(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, options)
+			Expect(err).To(MatchError("The canary failed"))
+			Expect(stdout).To(gbytes.Say(`"domain":"cfdot-canary","healthy":false`))
+			Expect(stderr).To(gbytes.Say("Running canary cfdot-canary-[0-9a-f]+ in domain cfdot-canary"))
+		})
+
+		It("requires the placement tags of the targeted cell", func() {
+			fakeBBSClient.CellsReturns([]*models.CellPresence{
+				{CellId: "cell-1", PlacementTags: []string{"isolated"}},
+			}, nil)
+			options.CellID = "cell-1"
+			options.PlacementTags = []string{"extra"}
+
+			commands.Canary(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, options)
+
+			_, lrp := fakeBBSClient.DesireLRPArgsForCall(0)
+			Expect(lrp.PlacementTags).To(Equal([]string{"extra", "isolated"}))
+			_, _, _, definition := fakeBBSClient.DesireTaskArgsForCall(0)
+			Expect(definition.PlacementTags).To(Equal([]string{"extra", "isolated"}))
+		})
+
+		It("fails when the targeted cell is not registered", func() {
+			options.CellID = "cell-2"
+
+			err := commands.Canary(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, options)
+			Expect(err).To(MatchError("Cell not found"))
+			Expect(fakeBBSClient.DesireLRPCallCount()).To(Equal(0))
+		})
+	})
+})
@@ -1,12 +1,14 @@
 package commands
 
 import (
+	"context"
 	"io"
 
 	"github.com/spf13/cobra"
 
 	"code.cloudfoundry.org/bbs"
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 )
 
 var cancelTaskCmd = &cobra.Command{
@@ -32,22 +34,20 @@ func cancelTask(cmd *cobra.Command, args []string) error {
 		return NewCFDotError(cmd, err)
 	}
 
-	if err := CancelTaskByGuid(cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, guid); err != nil {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if err := CancelTaskByGuid(ctx, cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, guid); err != nil {
 		return NewCFDotError(cmd, err)
 	}
 
 	return nil
 }
 
-func CancelTaskByGuid(stdout, _ io.Writer, bbsClient bbs.Client, taskGuid string) error {
+func CancelTaskByGuid(ctx context.Context, stdout, _ io.Writer, bbsClient bbs.Client, taskGuid string) error {
 	logger := globalLogger.Session("cancel-task-by-guid")
 
-	err := bbsClient.CancelTask(logger, taskGuid)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return diego.CancelTask(ctx, logger, bbsClient, taskGuid)
 }
 
 func ValidateCancelTaskArgs(args []string) (string, error) {
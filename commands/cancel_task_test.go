@@ -1,6 +1,8 @@
 package commands_test
 
 import (
+	"context"
+
 	"code.cloudfoundry.org/bbs/fake_bbs"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/cfdot/commands"
@@ -26,7 +28,7 @@ var _ = Describe("CancelTask", func() {
 		It("passes through the task guid to the BBS", func() {
 			taskGuid := "task-guid"
 
-			err := commands.CancelTaskByGuid(stdout, stderr, fakeBBSClient, taskGuid)
+			err := commands.CancelTaskByGuid(context.Background(), stdout, stderr, fakeBBSClient, taskGuid)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeBBSClient.CancelTaskCallCount()).To(Equal(1))
@@ -39,7 +41,7 @@ var _ = Describe("CancelTask", func() {
 			It("returns an error back", func() {
 				fakeBBSClient.CancelTaskReturns(models.ErrResourceNotFound)
 
-				err := commands.CancelTaskByGuid(stdout, stderr, fakeBBSClient, "broken")
+				err := commands.CancelTaskByGuid(context.Background(), stdout, stderr, fakeBBSClient, "broken")
 				Expect(err).To(HaveOccurred())
 				Expect(err).To(Equal(models.ErrResourceNotFound))
 			})
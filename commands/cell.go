@@ -1,12 +1,12 @@
 package commands
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
 	"io"
 
 	"code.cloudfoundry.org/bbs"
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 
 	"github.com/spf13/cobra"
 )
@@ -34,8 +34,12 @@ func cell(cmd *cobra.Command, args []string) error {
 		return NewCFDotError(cmd, err)
 	}
 
+	ctx, cancel := commandContext()
+	defer cancel()
+
 	err = Cell(
-		cmd.OutOrStdout(),
+		ctx,
+		newPrinter(cmd),
 		cmd.OutOrStderr(),
 		bbsClient,
 		args[0],
@@ -58,26 +62,18 @@ func ValidateCellArguments(args []string) error {
 	}
 }
 
-func Cell(stdout, stderr io.Writer, bbsClient bbs.Client, cellId string) error {
+func Cell(ctx context.Context, printer Printer, stderr io.Writer, bbsClient bbs.Client, cellId string) error {
 	logger := globalLogger.Session("cell-presence")
 
-	encoder := json.NewEncoder(stdout)
-
-	cells, err := bbsClient.Cells(logger)
+	cell, err := diego.CellRegistration(ctx, logger, bbsClient, cellId)
 	if err != nil {
 		return err
 	}
 
-	for _, cell := range cells {
-		if cell.CellId == cellId {
-			err = encoder.Encode(cell)
-			if err != nil {
-				logger.Error("failed-to-marshal", err)
-			}
-
-			return err
-		}
+	err = printer.Print(cell)
+	if err != nil {
+		logger.Error("failed-to-marshal", err)
 	}
 
-	return errors.New("Cell not found")
+	return err
 }
@@ -1,16 +1,14 @@
 package commands
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
 	"fmt"
 	"io"
-	"time"
 
 	"code.cloudfoundry.org/bbs"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/cfdot/commands/helpers"
-	cfhttp "code.cloudfoundry.org/cfhttp/v2"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"code.cloudfoundry.org/rep"
 	"github.com/spf13/cobra"
 )
@@ -24,6 +22,7 @@ var cellStateCmd = &cobra.Command{
 
 func init() {
 	AddBBSAndTimeoutFlags(cellStateCmd)
+	AddRepTimeoutFlag(cellStateCmd)
 	RootCmd.AddCommand(cellStateCmd)
 }
 
@@ -37,34 +36,37 @@ func cellState(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return NewCFDotError(cmd, err)
 	}
-	cellRegistration, err := FetchCellRegistration(bbsClient, args[0])
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	cellRegistration, err := FetchCellRegistration(ctx, bbsClient, args[0])
 	if err != nil {
 		return NewCFDotError(cmd, err)
 	}
 
-	httpClient := cfhttp.NewClient()
-	stateClient := cfhttp.NewClient(
-		cfhttp.WithRequestTimeout(10 * time.Second),
-	)
-
-	repTLSConfig := &rep.TLSConfig{
-		CaCertFile: Config.CACertFile,
-		CertFile:   Config.CertFile,
-		KeyFile:    Config.KeyFile,
+	repEndpoint := cellRegistration.RepUrl
+	if repEndpoint == "" {
+		repEndpoint = cellRegistration.RepAddress
 	}
-	repClientFactory, err := rep.NewClientFactory(httpClient, stateClient, repTLSConfig)
+
+	repClientFactory, err := helpers.NewRepClientFactory(cmd, Config)
 	if err != nil {
-		return NewCFDotComponentError(cmd, fmt.Errorf("Failed creating rep client factory: %s", err))
+		return NewCFDotRepError(cmd, fmt.Errorf("Failed creating rep client factory: %s", err), repEndpoint)
 	}
 
 	err = FetchCellState(
-		cmd.OutOrStdout(),
+		ctx,
+		newPrinter(cmd),
 		cmd.OutOrStderr(),
 		repClientFactory,
 		cellRegistration,
 	)
+	if isContextError(err) {
+		return NewCFDotRepError(cmd, err, repEndpoint)
+	}
 	if err != nil {
-		return NewCFDotComponentError(cmd, fmt.Errorf("Rep error: Failed to get cell state for cell %s: %s", args[0], err.Error()))
+		return NewCFDotRepError(cmd, fmt.Errorf("Rep error: Failed to get cell state for cell %s: %w", args[0], err), repEndpoint)
 	}
 
 	return nil
@@ -81,39 +83,22 @@ func ValidateCellStateArguments(args []string) error {
 	}
 }
 
-func FetchCellRegistration(bbsClient bbs.Client, cellId string) (*models.CellPresence, error) {
+func FetchCellRegistration(ctx context.Context, bbsClient bbs.Client, cellId string) (*models.CellPresence, error) {
 	logger := globalLogger.Session("fetch-cell-presence")
 
-	cells, err := bbsClient.Cells(logger)
-	if err != nil {
-		return nil, err
-	}
-
-	for _, cell := range cells {
-		if cell.CellId == cellId {
-			return cell, nil
-		}
-	}
-
-	return nil, errors.New("Cell not found")
+	return diego.CellRegistration(ctx, logger, bbsClient, cellId)
 }
 
-func FetchCellState(stdout, stderr io.Writer, clientFactory rep.ClientFactory, registration *models.CellPresence) error {
-	repClient, err := clientFactory.CreateClient(registration.RepAddress, registration.RepUrl)
-	if err != nil {
-		return err
-	}
-
+func FetchCellState(ctx context.Context, printer Printer, stderr io.Writer, clientFactory rep.ClientFactory, registration *models.CellPresence) error {
 	logger := globalLogger.Session("cell-state")
-	encoder := json.NewEncoder(stdout)
 
-	state, err := repClient.State(logger)
+	state, err := diego.CellState(ctx, logger, clientFactory, registration)
 	if err != nil {
 		logger.Error("failed-to-fetch-cell-state", err)
 		return err
 	}
 
-	err = encoder.Encode(state)
+	err = printer.Print(state)
 	if err != nil {
 		logger.Error("failed-to-marshal", err)
 		return err
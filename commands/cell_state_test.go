@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 
@@ -62,7 +63,7 @@ var _ = Describe("CellState", func() {
 		})
 
 		It("returns the cell presence", func() {
-			receivedPresence, err := commands.FetchCellRegistration(fakeBBSClient, cellId)
+			receivedPresence, err := commands.FetchCellRegistration(context.Background(), fakeBBSClient, cellId)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(fakeBBSClient.CellsCallCount()).To(Equal(1))
 
@@ -75,14 +76,14 @@ var _ = Describe("CellState", func() {
 			})
 
 			It("returns the error", func() {
-				_, err := commands.FetchCellRegistration(fakeBBSClient, cellId)
+				_, err := commands.FetchCellRegistration(context.Background(), fakeBBSClient, cellId)
 				Expect(err).To(HaveOccurred())
 			})
 		})
 
 		Context("when the cell doesn't exist", func() {
 			It("returns an error", func() {
-				_, err := commands.FetchCellRegistration(fakeBBSClient, "non-existent")
+				_, err := commands.FetchCellRegistration(context.Background(), fakeBBSClient, "non-existent")
 				Expect(err).To(HaveOccurred())
 			})
 		})
@@ -123,7 +124,7 @@ var _ = Describe("CellState", func() {
 		})
 
 		It("outputs the cell state to stdout", func() {
-			err := commands.FetchCellState(stdout, stderr, fakeRepClientFactory, registration)
+			err := commands.FetchCellState(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeRepClientFactory, registration)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(fakeRepClient.StateCallCount()).To(Equal(1))
 
@@ -139,7 +140,7 @@ var _ = Describe("CellState", func() {
 			})
 
 			It("returns an error", func() {
-				err := commands.FetchCellState(stdout, stderr, fakeRepClientFactory, registration)
+				err := commands.FetchCellState(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeRepClientFactory, registration)
 				Expect(err).To(HaveOccurred())
 				Expect(fakeRepClient.StateCallCount()).To(Equal(1))
 			})
@@ -1,14 +1,14 @@
 package commands
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
-	"time"
 
 	"code.cloudfoundry.org/bbs"
 	"code.cloudfoundry.org/cfdot/commands/helpers"
-	cfhttp "code.cloudfoundry.org/cfhttp/v2"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"code.cloudfoundry.org/rep"
 	"github.com/spf13/cobra"
 )
@@ -22,6 +22,7 @@ var cellStatesCmd = &cobra.Command{
 
 func init() {
 	AddBBSAndTimeoutFlags(cellStatesCmd)
+	AddRepTimeoutFlag(cellStatesCmd)
 	RootCmd.AddCommand(cellStatesCmd)
 }
 
@@ -36,22 +37,15 @@ func cellStates(cmd *cobra.Command, args []string) error {
 		return NewCFDotError(cmd, err)
 	}
 
-	httpClient := cfhttp.NewClient()
-	stateClient := cfhttp.NewClient(
-		cfhttp.WithRequestTimeout(10 * time.Second),
-	)
-
-	repTLSConfig := &rep.TLSConfig{
-		CaCertFile: Config.CACertFile,
-		CertFile:   Config.CertFile,
-		KeyFile:    Config.KeyFile,
-	}
-	repClientFactory, err := rep.NewClientFactory(httpClient, stateClient, repTLSConfig)
+	repClientFactory, err := helpers.NewRepClientFactory(cmd, Config)
 	if err != nil {
-		return NewCFDotComponentError(cmd, fmt.Errorf("Failed creating rep client factory: %s", err))
+		return NewCFDotRepError(cmd, fmt.Errorf("Failed creating rep client factory: %s", err), "")
 	}
 
-	return FetchCellStates(cmd, cmd.OutOrStdout(), cmd.OutOrStderr(), repClientFactory, bbsClient)
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	return FetchCellStates(ctx, cmd, newPrinter(cmd), cmd.OutOrStderr(), repClientFactory, bbsClient)
 }
 
 func ValidateCellStatesArguments(args []string) error {
@@ -63,22 +57,43 @@ func ValidateCellStatesArguments(args []string) error {
 	}
 }
 
-func FetchCellStates(cmd *cobra.Command, stdout, stderr io.Writer, clientFactory rep.ClientFactory, bbsClient bbs.Client) error {
+func FetchCellStates(ctx context.Context, cmd *cobra.Command, printer Printer, stderr io.Writer, clientFactory rep.ClientFactory, bbsClient bbs.Client) error {
 	logger := globalLogger.Session("cell-states")
-	registrations, err := bbsClient.Cells(logger)
+	registrations, err := diego.Cells(ctx, logger, bbsClient)
+	if isContextError(err) {
+		return NewCFDotComponentError(cmd, err)
+	}
 	if err != nil {
 		return NewCFDotComponentError(cmd, fmt.Errorf("BBS error: Failed to get cell registrations from BBS: %s", err))
 	}
-	errs := ""
-	for _, registration := range registrations {
-		err := FetchCellState(stdout, stderr, clientFactory, registration)
-		if err != nil {
-			errs += fmt.Sprintf("Rep error: Failed to get cell state for cell %s: %s\n", registration.CellId, err)
+
+	printed := 0
+	failures, err := diego.EachCellState(ctx, logger, clientFactory, registrations, func(state rep.CellState) error {
+		if err := printer.Print(state); err != nil {
+			logger.Error("failed-to-marshal", err)
+			return err
 		}
+		printed++
+		return nil
+	})
+	if err != nil {
+		return NewCFDotRepError(cmd, err, "")
+	}
+
+	errs := ""
+	for _, failure := range failures {
+		errs += fmt.Sprintf("Rep error: Failed to get cell state for cell %s: %s\n", failure.CellID, failure.Err)
+	}
+
+	if errs == "" {
+		return nil
 	}
 
-	if errs != "" {
-		return NewCFDotComponentError(cmd, errors.New(errs))
+	cfDotErr := NewCFDotRepError(cmd, errors.New(errs), "")
+	if printed > 0 {
+		// The states of the other cells were printed, so the output is
+		// incomplete rather than missing.
+		cfDotErr.exitCode = ExitCodePartialFailure
 	}
-	return nil
+	return cfDotErr
 }
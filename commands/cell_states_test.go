@@ -1,12 +1,14 @@
 package commands_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 
 	"code.cloudfoundry.org/bbs/fake_bbs"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/cfdot/commands"
+	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/rep"
 	"code.cloudfoundry.org/rep/repfakes"
 	. "github.com/onsi/ginkgo"
@@ -88,12 +90,12 @@ var _ = Describe("CellState", func() {
 		})
 
 		It("retrieves the cell registrations", func() {
-			commands.FetchCellStates(cmd, stdout, stderr, fakeRepClientFactory, fakeBBSClient)
+			commands.FetchCellStates(context.Background(), cmd, commands.NewJSONPrinter(stdout), stderr, fakeRepClientFactory, fakeBBSClient)
 			Expect(fakeBBSClient.CellsCallCount()).To(Equal(1))
 		})
 
 		It("outputs the cell state to stdout", func() {
-			commands.FetchCellStates(cmd, stdout, stderr, fakeRepClientFactory, fakeBBSClient)
+			commands.FetchCellStates(context.Background(), cmd, commands.NewJSONPrinter(stdout), stderr, fakeRepClientFactory, fakeBBSClient)
 			Expect(fakeRepClient1.StateCallCount()).To(Equal(1))
 			Expect(fakeRepClient2.StateCallCount()).To(Equal(1))
 
@@ -115,7 +117,7 @@ var _ = Describe("CellState", func() {
 			})
 
 			It("prints an error", func() {
-				err := commands.FetchCellStates(cmd, stdout, stderr, fakeRepClientFactory, fakeBBSClient)
+				err := commands.FetchCellStates(context.Background(), cmd, commands.NewJSONPrinter(stdout), stderr, fakeRepClientFactory, fakeBBSClient)
 				Expect(err).To(MatchError("BBS error: Failed to get cell registrations from BBS: boom"))
 			})
 		})
@@ -126,18 +128,96 @@ var _ = Describe("CellState", func() {
 			})
 
 			It("prints an error", func() {
-				err := commands.FetchCellStates(cmd, stdout, stderr, fakeRepClientFactory, fakeBBSClient)
+				err := commands.FetchCellStates(context.Background(), cmd, commands.NewJSONPrinter(stdout), stderr, fakeRepClientFactory, fakeBBSClient)
 				Expect(fakeRepClient2.StateCallCount()).To(Equal(1))
 				Expect(err).To(MatchError(ContainSubstring("Rep error: Failed to get cell state for cell cell-id1: boom")))
 			})
 
 			It("prints the cell stats of the other cells", func() {
-				commands.FetchCellStates(cmd, stdout, stderr, fakeRepClientFactory, fakeBBSClient)
+				commands.FetchCellStates(context.Background(), cmd, commands.NewJSONPrinter(stdout), stderr, fakeRepClientFactory, fakeBBSClient)
 				var receivedState rep.CellState
 				err := json.NewDecoder(stdout).Decode(&receivedState)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(receivedState).To(Equal(state2))
 			})
+
+			It("exits with the partial failure exit code", func() {
+				err := commands.FetchCellStates(context.Background(), cmd, commands.NewJSONPrinter(stdout), stderr, fakeRepClientFactory, fakeBBSClient)
+				Expect(commands.ExitCode(err)).To(Equal(commands.ExitCodePartialFailure))
+			})
+		})
+
+		Context("when all of the reps fail to respond", func() {
+			BeforeEach(func() {
+				fakeRepClient1.StateReturns(rep.CellState{}, errors.New("boom"))
+				fakeRepClient2.StateReturns(rep.CellState{}, errors.New("boom"))
+			})
+
+			It("exits with the component error exit code", func() {
+				err := commands.FetchCellStates(context.Background(), cmd, commands.NewJSONPrinter(stdout), stderr, fakeRepClientFactory, fakeBBSClient)
+				Expect(commands.ExitCode(err)).To(Equal(commands.ExitCodeComponentError))
+			})
+		})
+
+		Context("when a rep is slow to respond", func() {
+			var release chan struct{}
+
+			BeforeEach(func() {
+				release = make(chan struct{})
+				fakeRepClient2.StateStub = func(lager.Logger) (rep.CellState, error) {
+					<-release
+					return state2, nil
+				}
+			})
+
+			It("prints the states of the other cells as they arrive", func() {
+				errCh := make(chan error, 1)
+				go func() {
+					errCh <- commands.FetchCellStates(context.Background(), cmd, commands.NewJSONPrinter(stdout), stderr, fakeRepClientFactory, fakeBBSClient)
+				}()
+
+				Eventually(stdout).Should(gbytes.Say(`"cell_id":"cell-id1"`))
+				Consistently(errCh).ShouldNot(Receive())
+
+				close(release)
+				Eventually(errCh).Should(Receive(BeNil()))
+				Expect(stdout).To(gbytes.Say(`"cell_id":"cell-id2"`))
+			})
+		})
+
+		Context("when the command is interrupted part way through", func() {
+			var (
+				ctx     context.Context
+				cancel  context.CancelFunc
+				release chan struct{}
+			)
+
+			BeforeEach(func() {
+				ctx, cancel = context.WithCancel(context.Background())
+				release = make(chan struct{})
+				fakeRepClient2.StateStub = func(lager.Logger) (rep.CellState, error) {
+					cancel()
+					<-release
+					return rep.CellState{}, nil
+				}
+			})
+
+			AfterEach(func() {
+				close(release)
+			})
+
+			It("prints the cell states gathered so far", func() {
+				commands.FetchCellStates(ctx, cmd, commands.NewJSONPrinter(stdout), stderr, fakeRepClientFactory, fakeBBSClient)
+				var receivedState rep.CellState
+				err := json.NewDecoder(stdout).Decode(&receivedState)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(receivedState).To(Equal(state1))
+			})
+
+			It("reports the interruption instead of a rep failure", func() {
+				err := commands.FetchCellStates(ctx, cmd, commands.NewJSONPrinter(stdout), stderr, fakeRepClientFactory, fakeBBSClient)
+				Expect(err).To(MatchError("Interrupted"))
+			})
 		})
 	})
 })
@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 
@@ -63,13 +64,13 @@ var _ = Describe("Cell", func() {
 		})
 
 		It("fetches the cell presence", func() {
-			err := commands.Cell(stdout, stderr, fakeBBSClient, cellId)
+			err := commands.Cell(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, cellId)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(fakeBBSClient.CellsCallCount()).To(Equal(1))
 		})
 
 		It("outputs the cell presence to stdout", func() {
-			err := commands.Cell(stdout, stderr, fakeBBSClient, cellId)
+			err := commands.Cell(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, cellId)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(fakeBBSClient.CellsCallCount()).To(Equal(1))
 
@@ -85,7 +86,7 @@ var _ = Describe("Cell", func() {
 			})
 
 			It("returns the error", func() {
-				err := commands.Cell(stdout, stderr, fakeBBSClient, cellId)
+				err := commands.Cell(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, cellId)
 				Expect(err).To(HaveOccurred())
 			})
 		})
@@ -96,14 +97,14 @@ var _ = Describe("Cell", func() {
 			})
 
 			It("returns the error", func() {
-				err := commands.Cell(stdout, stderr, fakeBBSClient, cellId)
+				err := commands.Cell(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, cellId)
 				Expect(err).To(HaveOccurred())
 			})
 		})
 
 		Context("when the cell doesn't exist", func() {
 			It("returns an error", func() {
-				err := commands.Cell(stdout, stderr, fakeBBSClient, "non-existent")
+				err := commands.Cell(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "non-existent")
 				Expect(err).To(HaveOccurred())
 			})
 		})
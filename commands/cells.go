@@ -1,12 +1,13 @@
 package commands
 
 import (
-	"encoding/json"
+	"context"
 	"io"
 
 	"code.cloudfoundry.org/bbs"
 
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"github.com/spf13/cobra"
 )
 
@@ -34,8 +35,12 @@ func cells(cmd *cobra.Command, args []string) error {
 		return NewCFDotError(cmd, err)
 	}
 
+	ctx, cancel := commandContext()
+	defer cancel()
+
 	err = Cells(
-		cmd.OutOrStdout(),
+		ctx,
+		newPrinter(cmd),
 		cmd.OutOrStderr(),
 		bbsClient,
 	)
@@ -53,18 +58,16 @@ func ValidateCellsArguments(args []string) error {
 	return nil
 }
 
-func Cells(stdout, stderr io.Writer, bbsClient bbs.Client) error {
+func Cells(ctx context.Context, printer Printer, stderr io.Writer, bbsClient bbs.Client) error {
 	logger := globalLogger.Session("cell-presences")
 
-	encoder := json.NewEncoder(stdout)
-
-	cellPresences, err := bbsClient.Cells(logger)
+	cellPresences, err := diego.Cells(ctx, logger, bbsClient)
 	if err != nil {
 		return err
 	}
 
 	for _, cellPresence := range cellPresences {
-		err = encoder.Encode(cellPresence)
+		err = printer.Print(cellPresence)
 		if err != nil {
 			logger.Error("failed-to-marshal", err)
 		}
@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"context"
 	"encoding/json"
 
 	"code.cloudfoundry.org/bbs/fake_bbs"
@@ -55,7 +56,7 @@ var _ = Describe("Cells", func() {
 		})
 
 		It("prints a json stream of all the cell presences", func() {
-			err := commands.Cells(stdout, stderr, fakeBBSClient)
+			err := commands.Cells(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeBBSClient.CellsCallCount()).To(Equal(1))
@@ -77,7 +78,7 @@ var _ = Describe("Cells", func() {
 		})
 
 		It("fails with a relevant error", func() {
-			err := commands.Cells(stdout, stderr, fakeBBSClient)
+			err := commands.Cells(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient)
 			Expect(err).To(HaveOccurred())
 
 			Expect(err).To(Equal(models.ErrUnknownError))
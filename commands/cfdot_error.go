@@ -1,6 +1,8 @@
 package commands
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/spf13/cobra"
@@ -8,9 +10,19 @@ import (
 	"code.cloudfoundry.org/bbs/models"
 )
 
+// Components reported in the JSON form of a CFDotError.
+const (
+	ComponentBBS        = "bbs"
+	ComponentLocket     = "locket"
+	ComponentRep        = "rep"
+	ComponentValidation = "validation"
+)
+
 type CFDotError struct {
-	err      error
-	exitCode int
+	err       error
+	exitCode  int
+	component string
+	endpoint  string
 }
 
 func (a CFDotError) Error() string {
@@ -28,34 +40,121 @@ func (a CFDotError) ExitCode() int {
 	return a.exitCode
 }
 
-func NewCFDotError(cmd *cobra.Command, err error) CFDotError {
-	cmd.SilenceUsage = true
+// Component returns the component the failing command was talking to, one of
+// the Component constants, or an empty string when it is not known.
+func (a CFDotError) Component() string {
+	return a.component
+}
 
-	if _, ok := err.(*models.Error); ok {
-		return CFDotError{
-			err:      err,
-			exitCode: 4,
-		}
+// Endpoint returns the URL or address of the component the failing command
+// was talking to, if known.
+func (a CFDotError) Endpoint() string {
+	return a.endpoint
+}
+
+// MarshalJSON encodes the error as written to stderr with --error-format json.
+// Type is the name of the BBS error type, such as ResourceNotFound, and is
+// only set for errors returned by the BBS.
+func (a CFDotError) MarshalJSON() ([]byte, error) {
+	jsonErr := struct {
+		Component string `json:"component,omitempty"`
+		Type      string `json:"type,omitempty"`
+		Message   string `json:"message"`
+		ExitCode  int    `json:"exit_code"`
+		Endpoint  string `json:"endpoint,omitempty"`
+	}{
+		Component: a.component,
+		Message:   a.Error(),
+		ExitCode:  a.exitCode,
+		Endpoint:  a.endpoint,
 	}
 
-	return CFDotError{
-		err:      err,
-		exitCode: 5,
+	if err, ok := a.err.(*models.Error); ok {
+		jsonErr.Type = err.Type.String()
+		jsonErr.Message = err.Message
+	}
+
+	return json.Marshal(jsonErr)
+}
+
+// NewCFDotError returns the error for a failing command. Errors of a known
+// class, such as a missing resource or an unreachable component, get the exit
+// code of their class; other BBS errors exit with ExitCodeComponentError and
+// anything else with ExitCodeFailure.
+func NewCFDotError(cmd *cobra.Command, err error) CFDotError {
+	if _, ok := err.(*models.Error); ok {
+		return newCFDotError(cmd, err, ExitCodeComponentError)
 	}
+
+	return newCFDotError(cmd, err, ExitCodeFailure)
 }
 
+// NewCFDotComponentError is like NewCFDotError for failures of a component,
+// which exit with ExitCodeComponentError unless they are of a known class.
 func NewCFDotComponentError(cmd *cobra.Command, err error) CFDotError {
+	return newCFDotError(cmd, err, ExitCodeComponentError)
+}
+
+// NewCFDotRepError is like NewCFDotComponentError for failures talking to the
+// rep at endpoint, which may be empty when several reps failed.
+func NewCFDotRepError(cmd *cobra.Command, err error, endpoint string) CFDotError {
+	cfDotErr := NewCFDotComponentError(cmd, err)
+	cfDotErr.component, cfDotErr.endpoint = ComponentRep, endpoint
+	return cfDotErr
+}
+
+func newCFDotError(cmd *cobra.Command, err error, defaultExitCode int) CFDotError {
 	cmd.SilenceUsage = true
 
+	component, endpoint := errorTarget(cmd, err)
+
+	if cfDotErr, ok := newContextError(err); ok {
+		cfDotErr.component, cfDotErr.endpoint = component, endpoint
+		return cfDotErr
+	}
+
 	return CFDotError{
-		err:      err,
-		exitCode: 4,
+		err:       err,
+		exitCode:  exitCodeFor(err, defaultExitCode),
+		component: component,
+		endpoint:  endpoint,
+	}
+}
+
+// newContextError reports the command context expiring as a timeout, and an
+// interrupted command as a generic failure.
+func newContextError(err error) (CFDotError, bool) {
+	switch err {
+	case context.DeadlineExceeded:
+		return CFDotError{err: errTimeoutExceeded, exitCode: ExitCodeTimeout}, true
+	case context.Canceled:
+		return CFDotError{err: errInterrupted, exitCode: ExitCodeFailure}, true
+	default:
+		return CFDotError{}, false
+	}
+}
+
+// errorTarget returns the component and endpoint a command failing with err
+// was talking to, judging by the error and by the flags of the command.
+func errorTarget(cmd *cobra.Command, err error) (string, string) {
+	if _, ok := err.(*models.Error); ok {
+		return ComponentBBS, Config.BBSUrl
+	}
+
+	switch {
+	case cmd.Flags().Lookup("bbsURL") != nil:
+		return ComponentBBS, Config.BBSUrl
+	case cmd.Flags().Lookup("locketAPILocation") != nil:
+		return ComponentLocket, Config.LocketApiLocation
+	default:
+		return "", ""
 	}
 }
 
 func NewCFDotValidationError(cmd *cobra.Command, err error) CFDotError {
 	return CFDotError{
-		err:      err,
-		exitCode: 3,
+		err:       err,
+		exitCode:  ExitCodeValidation,
+		component: ComponentValidation,
 	}
 }
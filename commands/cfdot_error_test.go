@@ -1,6 +1,8 @@
 package commands_test
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 
 	"code.cloudfoundry.org/bbs/models"
@@ -44,6 +46,79 @@ var _ = Describe("CfDotError", func() {
 		It("silence the usage message", func() {
 			Expect(cmd.SilenceUsage).To(BeTrue())
 		})
+
+		It("is encoded as JSON with the BBS error type", func() {
+			encoded, jsonErr := json.Marshal(err)
+			Expect(jsonErr).NotTo(HaveOccurred())
+
+			var decoded map[string]interface{}
+			Expect(json.Unmarshal(encoded, &decoded)).To(Succeed())
+			Expect(decoded).To(HaveKeyWithValue("component", "bbs"))
+			Expect(decoded).To(HaveKeyWithValue("type", "Deadlock"))
+			Expect(decoded).To(HaveKeyWithValue("message", "The request failed due to a deadlock"))
+			Expect(decoded).To(HaveKeyWithValue("exit_code", float64(4)))
+		})
+	})
+
+	Context("when a command talking to the BBS fails", func() {
+		var bbsURL string
+
+		BeforeEach(func() {
+			bbsURL = commands.Config.BBSUrl
+			commands.Config.BBSUrl = "https://bbs.service.cf.internal:8889"
+			commands.AddBBSFlags(cmd)
+			err = commands.NewCFDotError(cmd, errors.New("connection refused"))
+		})
+
+		AfterEach(func() {
+			commands.Config.BBSUrl = bbsURL
+		})
+
+		It("reports the BBS as the component and its URL as the endpoint", func() {
+			Expect(err.Component()).To(Equal("bbs"))
+			Expect(err.Endpoint()).To(Equal("https://bbs.service.cf.internal:8889"))
+			Expect(json.Marshal(err)).To(MatchJSON(`{
+				"component": "bbs",
+				"message": "connection refused",
+				"exit_code": 5,
+				"endpoint": "https://bbs.service.cf.internal:8889"
+			}`))
+		})
+	})
+
+	Context("when a command talking to Locket fails", func() {
+		var locketAPILocation string
+
+		BeforeEach(func() {
+			locketAPILocation = commands.Config.LocketApiLocation
+			commands.Config.LocketApiLocation = "locket.service.cf.internal:8891"
+			commands.AddLocketFlags(cmd)
+			err = commands.NewCFDotComponentError(cmd, errors.New("connection refused"))
+		})
+
+		AfterEach(func() {
+			commands.Config.LocketApiLocation = locketAPILocation
+		})
+
+		It("reports Locket as the component and its address as the endpoint", func() {
+			Expect(err.Component()).To(Equal("locket"))
+			Expect(err.Endpoint()).To(Equal("locket.service.cf.internal:8891"))
+		})
+	})
+
+	Context("when a rep error occurs", func() {
+		BeforeEach(func() {
+			err = commands.NewCFDotRepError(cmd, errors.New("connection refused"), "https://cell-1.cell.service.cf.internal:1801")
+		})
+
+		It("reports the rep as the component and its URL as the endpoint", func() {
+			Expect(err.Component()).To(Equal("rep"))
+			Expect(err.Endpoint()).To(Equal("https://cell-1.cell.service.cf.internal:1801"))
+		})
+
+		It("returns an exit code of 4", func() {
+			Expect(err.ExitCode()).To(Equal(4))
+		})
 	})
 
 	Context("when a component error occurs", func() {
@@ -62,6 +137,34 @@ var _ = Describe("CfDotError", func() {
 		})
 	})
 
+	Context("when the command context times out", func() {
+		BeforeEach(func() {
+			err = commands.NewCFDotError(cmd, context.DeadlineExceeded)
+		})
+
+		It("reports the timeout", func() {
+			Expect(err.Error()).To(ContainSubstring("Timeout exceeded"))
+		})
+
+		It("returns an exit code of 9", func() {
+			Expect(err.ExitCode()).To(Equal(9))
+		})
+	})
+
+	Context("when the command is interrupted", func() {
+		BeforeEach(func() {
+			err = commands.NewCFDotComponentError(cmd, context.Canceled)
+		})
+
+		It("reports the interruption", func() {
+			Expect(err.Error()).To(Equal("Interrupted"))
+		})
+
+		It("returns an exit code of 5", func() {
+			Expect(err.ExitCode()).To(Equal(5))
+		})
+	})
+
 	Context("when a validation error occurs", func() {
 		BeforeEach(func() {
 			err = commands.NewCFDotValidationError(cmd, errors.New("some error"))
@@ -80,5 +183,9 @@ var _ = Describe("CfDotError", func() {
 		It("does not silence the usage message", func() {
 			Expect(cmd.SilenceUsage).To(BeFalse())
 		})
+
+		It("reports validation as the component", func() {
+			Expect(err.Component()).To(Equal("validation"))
+		})
 	})
 })
@@ -0,0 +1,165 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"github.com/spf13/cobra"
+)
+
+var chaosCmd = &cobra.Command{
+	Use:   "chaos --domain DOMAIN",
+	Short: "Retire random running instances of a domain",
+	Long:  "Retire a random running actual LRP of the given domain at the given --rate, e.g. 1/5m for one every five minutes, as long as its LRP keeps at most --max-unavailable of its desired instances not running, given as a number or a percentage rounded down, and has run all its instances again since the previous retirement. Every action is printed. The command stops when interrupted, after --max-retirements, or with an error when an LRP does not run all its instances again within --recovery-timeout",
+	RunE:  chaos,
+}
+
+// flags
+var (
+	chaosDomainFlag          string
+	chaosRateFlag            string
+	chaosMaxUnavailableFlag  string
+	chaosRecoveryTimeoutFlag time.Duration
+	chaosMaxRetirementsFlag  int
+	chaosDryRunFlag          bool
+)
+
+// errors
+var (
+	errMissingChaosDomain     = errors.New("--domain is required, chaos only retires the instances of a single domain")
+	errInvalidRecoveryTimeout = errors.New("--recovery-timeout must be positive")
+	errNegativeMaxRetirements = errors.New("--max-retirements must not be negative")
+)
+
+func init() {
+	AddBBSFlags(chaosCmd)
+	chaosCmd.Flags().StringVar(&chaosDomainFlag, "domain", "", "domain whose instances are retired")
+	chaosCmd.Flags().StringVar(&chaosRateFlag, "rate", "1/5m", "number of instances retired per duration, as N/DURATION")
+	chaosCmd.Flags().StringVar(&chaosMaxUnavailableFlag, "max-unavailable", "10%", "number or percentage of the desired instances of an LRP that may be not running")
+	chaosCmd.Flags().DurationVar(&chaosRecoveryTimeoutFlag, "recovery-timeout", 2*time.Minute, "time an LRP may take to run all its instances again after a retirement")
+	chaosCmd.Flags().IntVar(&chaosMaxRetirementsFlag, "max-retirements", 0, "stop after retiring that many instances, 0 for no limit")
+	chaosCmd.Flags().BoolVar(&chaosDryRunFlag, "dry-run", false, "only print the instances that would be retired")
+	RootCmd.AddCommand(chaosCmd)
+}
+
+func chaos(cmd *cobra.Command, args []string) error {
+	config, err := ValidateChaosArguments(args, chaosDomainFlag, chaosRateFlag, chaosMaxUnavailableFlag, chaosRecoveryTimeoutFlag, chaosMaxRetirementsFlag)
+	if err != nil {
+		return NewCFDotValidationError(cmd, err)
+	}
+	config.DryRun = chaosDryRunFlag
+
+	bbsClient, err := helpers.NewBBSClient(cmd, Config)
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	err = Chaos(ctx, newPrinter(cmd), bbsClient, config)
+	if err != nil && !isInterrupted(err) {
+		return NewCFDotError(cmd, err)
+	}
+	return nil
+}
+
+func ValidateChaosArguments(args []string, domain, rate, maxUnavailable string, recoveryTimeout time.Duration, maxRetirements int) (diego.ChaosConfig, error) {
+	if len(args) > 0 {
+		return diego.ChaosConfig{}, errExtraArguments
+	}
+	if domain == "" {
+		return diego.ChaosConfig{}, errMissingChaosDomain
+	}
+
+	interval, err := parseChaosRate(rate)
+	if err != nil {
+		return diego.ChaosConfig{}, err
+	}
+
+	allowed, err := parseMaxUnavailable(maxUnavailable)
+	if err != nil {
+		return diego.ChaosConfig{}, err
+	}
+
+	if recoveryTimeout <= 0 {
+		return diego.ChaosConfig{}, errInvalidRecoveryTimeout
+	}
+	if maxRetirements < 0 {
+		return diego.ChaosConfig{}, errNegativeMaxRetirements
+	}
+
+	return diego.ChaosConfig{
+		Domain:          domain,
+		Interval:        interval,
+		MaxUnavailable:  allowed,
+		RecoveryTimeout: recoveryTimeout,
+		MaxRetirements:  maxRetirements,
+	}, nil
+}
+
+// Chaos prints every action of diego.Chaos until it stops. Being interrupted
+// is printed as a stop action as well.
+func Chaos(ctx context.Context, printer Printer, bbsClient bbs.Client, config diego.ChaosConfig) error {
+	logger := globalLogger.Session("chaos")
+
+	err := diego.Chaos(ctx, logger, bbsClient, config, func(action diego.ChaosAction) error {
+		return printer.Print(action)
+	})
+	if isInterrupted(err) {
+		printErr := printer.Print(diego.ChaosAction{Time: time.Now(), Action: diego.ChaosStop, Message: "Interrupted"})
+		if printErr != nil {
+			return printErr
+		}
+	}
+	return err
+}
+
+// parseChaosRate parses a rate given as N/DURATION into the interval between
+// two retirements.
+func parseChaosRate(rate string) (time.Duration, error) {
+	invalid := fmt.Errorf("Invalid rate '%s', expected N/DURATION, e.g. 1/5m", rate)
+
+	parts := strings.SplitN(rate, "/", 2)
+	if len(parts) != 2 {
+		return 0, invalid
+	}
+
+	count, err := strconv.Atoi(parts[0])
+	if err != nil || count <= 0 {
+		return 0, invalid
+	}
+	duration, err := time.ParseDuration(parts[1])
+	if err != nil || duration <= 0 {
+		return 0, invalid
+	}
+
+	return duration / time.Duration(count), nil
+}
+
+// parseMaxUnavailable parses a number of instances, or a percentage of the
+// desired instances rounded down.
+func parseMaxUnavailable(value string) (func(desired int32) int32, error) {
+	invalid := fmt.Errorf("Invalid --max-unavailable '%s', expected a number or a percentage", value)
+
+	if percentage := strings.TrimSuffix(value, "%"); percentage != value {
+		percent, err := strconv.Atoi(percentage)
+		if err != nil || percent < 0 || percent > 100 {
+			return nil, invalid
+		}
+		return func(desired int32) int32 { return desired * int32(percent) / 100 }, nil
+	}
+
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 0 {
+		return nil, invalid
+	}
+	return func(int32) int32 { return int32(count) }, nil
+}
@@ -0,0 +1,92 @@
+package commands_test
+
+import (
+	"context"
+	"time"
+
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/commands"
+	"code.cloudfoundry.org/lager"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Chaos", func() {
+	Context("ValidateChaosArguments", func() {
+		It("builds the guard rails from the flags", func() {
+			config, err := commands.ValidateChaosArguments([]string{}, "game-day", "3/15m", "10%", time.Minute, 5)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Domain).To(Equal("game-day"))
+			Expect(config.Interval).To(Equal(5 * time.Minute))
+			Expect(config.RecoveryTimeout).To(Equal(time.Minute))
+			Expect(config.MaxRetirements).To(Equal(5))
+			Expect(config.MaxUnavailable(25)).To(Equal(int32(2)))
+			Expect(config.MaxUnavailable(5)).To(Equal(int32(0)))
+		})
+
+		It("accepts a number of unavailable instances", func() {
+			config, err := commands.ValidateChaosArguments([]string{}, "game-day", "1/5m", "2", time.Minute, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.MaxUnavailable(100)).To(Equal(int32(2)))
+		})
+
+		It("requires a domain", func() {
+			_, err := commands.ValidateChaosArguments([]string{}, "", "1/5m", "10%", time.Minute, 0)
+			Expect(err).To(MatchError("--domain is required, chaos only retires the instances of a single domain"))
+		})
+
+		It("rejects invalid rates", func() {
+			for _, rate := range []string{"5m", "0/5m", "1/0s", "a/5m", "1/five"} {
+				_, err := commands.ValidateChaosArguments([]string{}, "game-day", rate, "10%", time.Minute, 0)
+				Expect(err).To(MatchError("Invalid rate '" + rate + "', expected N/DURATION, e.g. 1/5m"))
+			}
+		})
+
+		It("rejects invalid unavailable instances", func() {
+			for _, maxUnavailable := range []string{"-1", "150%", "ten"} {
+				_, err := commands.ValidateChaosArguments([]string{}, "game-day", "1/5m", maxUnavailable, time.Minute, 0)
+				Expect(err).To(MatchError("Invalid --max-unavailable '" + maxUnavailable + "', expected a number or a percentage"))
+			}
+		})
+
+		It("rejects a non positive recovery timeout", func() {
+			_, err := commands.ValidateChaosArguments([]string{}, "game-day", "1/5m", "10%", 0, 0)
+			Expect(err).To(MatchError("--recovery-timeout must be positive"))
+		})
+	})
+
+	Context("Chaos", func() {
+		var (
+			fakeBBSClient *fake_bbs.FakeClient
+			stdout        *gbytes.Buffer
+		)
+
+		BeforeEach(func() {
+			fakeBBSClient = &fake_bbs.FakeClient{}
+			stdout = gbytes.NewBuffer()
+
+			fakeBBSClient.DesiredLRPSchedulingInfosReturns([]*models.DesiredLRPSchedulingInfo{
+				{DesiredLRPKey: models.NewDesiredLRPKey("process-guid", "game-day", "log-guid"), Instances: 1},
+			}, nil)
+		})
+
+		It("prints every action and a stop action when interrupted", func() {
+			config, err := commands.ValidateChaosArguments([]string{}, "game-day", "1000/1s", "0", time.Minute, 0)
+			Expect(err).NotTo(HaveOccurred())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			fakeBBSClient.ActualLRPsStub = func(lager.Logger, models.ActualLRPFilter) ([]*models.ActualLRP, error) {
+				cancel()
+				return nil, nil
+			}
+
+			err = commands.Chaos(ctx, commands.NewJSONPrinter(stdout), fakeBBSClient, config)
+			Expect(err).To(Equal(context.Canceled))
+			Expect(stdout).To(gbytes.Say(`"action":"skip"`))
+			Expect(stdout).To(gbytes.Say(`"action":"stop","message":"Interrupted"`))
+		})
+	})
+})
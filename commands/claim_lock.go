@@ -6,6 +6,7 @@ import (
 	"io"
 
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"code.cloudfoundry.org/locket/models"
 	"github.com/spf13/cobra"
 )
@@ -46,7 +47,11 @@ func claimLock(cmd *cobra.Command, args []string) error {
 		return NewCFDotComponentError(cmd, err)
 	}
 
+	ctx, cancel := commandContext()
+	defer cancel()
+
 	err = ClaimLock(
+		ctx,
 		cmd.OutOrStdout(),
 		cmd.OutOrStderr(),
 		locketClient,
@@ -105,22 +110,14 @@ func ValidateClaimLocksArguments(cmd *cobra.Command, args []string, lockKey, loc
 }
 
 func ClaimLock(
+	ctx context.Context,
 	stdout, stderr io.Writer,
 	locketClient models.LocketClient,
 	lockKey, lockOwner, lockValue string,
 	ttlInSeconds int64) error {
 	logger := globalLogger.Session("claim-lock")
 
-	req := &models.LockRequest{
-		Resource: &models.Resource{
-			Key:      lockKey,
-			Owner:    lockOwner,
-			Value:    lockValue,
-			TypeCode: models.LOCK,
-		},
-		TtlInSeconds: ttlInSeconds,
-	}
-	_, err := locketClient.Lock(context.Background(), req)
+	err := diego.ClaimLock(ctx, locketClient, lockKey, lockOwner, lockValue, ttlInSeconds)
 	if err != nil {
 		return err
 	}
@@ -6,6 +6,7 @@ import (
 	"io"
 
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"code.cloudfoundry.org/locket/models"
 	"github.com/spf13/cobra"
 )
@@ -38,7 +39,11 @@ func claimPresence(cmd *cobra.Command, args []string) error {
 		return NewCFDotComponentError(cmd, err)
 	}
 
+	ctx, cancel := commandContext()
+	defer cancel()
+
 	err = ClaimPresence(
+		ctx,
 		cmd.OutOrStdout(),
 		cmd.OutOrStderr(),
 		locketClient,
@@ -97,22 +102,14 @@ func ValidateClaimPresenceArguments(cmd *cobra.Command, args []string, lockKey,
 }
 
 func ClaimPresence(
+	ctx context.Context,
 	stdout, stderr io.Writer,
 	locketClient models.LocketClient,
 	lockKey, lockOwner, lockValue string,
 	ttlInSeconds int64) error {
 	logger := globalLogger.Session("claim-presence")
 
-	req := &models.LockRequest{
-		Resource: &models.Resource{
-			Key:      lockKey,
-			Owner:    lockOwner,
-			Value:    lockValue,
-			TypeCode: models.PRESENCE,
-		},
-		TtlInSeconds: ttlInSeconds,
-	}
-	_, err := locketClient.Lock(context.Background(), req)
+	err := diego.ClaimPresence(ctx, locketClient, lockKey, lockOwner, lockValue, ttlInSeconds)
 	if err != nil {
 		return err
 	}
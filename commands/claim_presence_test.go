@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"context"
 	"errors"
 
 	"code.cloudfoundry.org/cfdot/commands"
@@ -32,6 +33,7 @@ var _ = Describe("ClaimPresence", func() {
 
 		It("should claim the lock successfully", func() {
 			err := commands.ClaimPresence(
+				context.Background(),
 				stdout, stderr, fakeLocketClient,
 				"key", "owner", "value", 60)
 			Expect(err).NotTo(HaveOccurred())
@@ -58,6 +60,7 @@ var _ = Describe("ClaimPresence", func() {
 
 		It("should return an error", func() {
 			err := commands.ClaimPresence(
+				context.Background(),
 				stdout, stderr, fakeLocketClient,
 				"key", "owner", "value", 60)
 			Expect(err).To(HaveOccurred())
@@ -0,0 +1,236 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/lager"
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion SHELL",
+	Short: "Generate shell completion scripts",
+	Long: `Generate a completion script for bash, zsh or fish. Besides command and flag names, it completes process guids, task guids and cell ids from the BBS targeted by --bbsURL or BBS_URL, caching them for a minute. To load completions in the current bash session, run:
+
+  source <(cfdot completion bash)`,
+	ValidArgs: completionShells,
+	RunE:      completion,
+}
+
+// completionCacheTTL is how long the process guids, task guids and cell ids
+// fetched to complete an argument are reused by later completions.
+const completionCacheTTL = time.Minute
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// errors
+var (
+	errMissingShell = errors.New("Missing shell, expected one of bash, zsh or fish")
+)
+
+// completionKinds maps the commands whose first argument is a process guid,
+// task guid or cell id to the kind of that argument.
+var completionKinds = map[*cobra.Command]string{
+	desiredLRPCmd:       ShellProcessGuid,
+	retireActualLRPCmd:  ShellProcessGuid,
+	deleteDesiredLRPCmd: ShellProcessGuid,
+	updateDesiredLRPCmd: ShellProcessGuid,
+	taskCmd:             ShellTaskGuid,
+	cancelTaskCmd:       ShellTaskGuid,
+	deleteTaskCmd:       ShellTaskGuid,
+	cellCmd:             ShellCellID,
+	cellStateCmd:        ShellCellID,
+}
+
+// describeArgKinds maps the kinds of resource describe takes to the kind of
+// the id that follows them.
+var describeArgKinds = map[string]string{
+	DescribeLRP:  ShellProcessGuid,
+	DescribeTask: ShellTaskGuid,
+	DescribeCell: ShellCellID,
+}
+
+func init() {
+	for cmd := range completionKinds {
+		cmd.ValidArgsFunction = completeArgs
+	}
+	describeCmd.ValidArgsFunction = completeArgs
+	RootCmd.AddCommand(completionCmd)
+}
+
+func completion(cmd *cobra.Command, args []string) error {
+	err := ValidateCompletionArguments(args)
+	if err != nil {
+		return NewCFDotValidationError(cmd, err)
+	}
+
+	err = Completion(cmd.OutOrStdout(), RootCmd, args[0])
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+	return nil
+}
+
+func ValidateCompletionArguments(args []string) error {
+	switch {
+	case len(args) == 0:
+		return errMissingShell
+	case len(args) > 1:
+		return errExtraArguments
+	}
+
+	for _, shell := range completionShells {
+		if args[0] == shell {
+			return nil
+		}
+	}
+	return fmt.Errorf("Unsupported shell '%s', expected one of bash, zsh or fish", args[0])
+}
+
+// Completion writes the completion script of root for shell to w.
+func Completion(w io.Writer, root *cobra.Command, shell string) error {
+	switch shell {
+	case "bash":
+		return root.GenBashCompletion(w)
+	case "zsh":
+		return root.GenZshCompletion(w)
+	default:
+		return root.GenFishCompletion(w, true)
+	}
+}
+
+// completionArg returns how to complete the positional argument of cmd that
+// follows args: either the fixed values it takes, or the kind of the process
+// guids, task guids or cell ids it takes. Both are empty when the argument is
+// not completed.
+func completionArg(cmd *cobra.Command, args []string) ([]string, string) {
+	if cmd == describeCmd {
+		switch len(args) {
+		case 0:
+			return describeKinds, ""
+		case 1:
+			return nil, describeArgKinds[args[0]]
+		default:
+			return nil, ""
+		}
+	}
+
+	if len(args) > 0 {
+		return nil, ""
+	}
+	return nil, completionKinds[cmd]
+}
+
+// completeArgs completes the positional arguments of the commands taking a
+// process guid, task guid or cell id.
+func completeArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	candidates, kind := completionArg(cmd, args)
+	if kind != "" {
+		candidates = listCompletions(cmd, kind)
+	}
+
+	var values []string
+	for _, value := range candidates {
+		if strings.HasPrefix(value, toComplete) {
+			values = append(values, value)
+		}
+	}
+	return values, cobra.ShellCompDirectiveNoFileComp
+}
+
+// listCompletions returns the values of kind from the on-disk cache, or
+// fetches them from the BBS configured by the flags and environment of cmd.
+// Any failure results in no completions rather than an error.
+func listCompletions(cmd *cobra.Command, kind string) []string {
+	logger := globalLogger.Session("completion", lager.Data{"kind": kind})
+
+	// Completions are requested without running the command, so the
+	// environment variables and TLS flags still have to be applied.
+	if cmd.PreRunE != nil {
+		if err := cmd.PreRunE(cmd, nil); err != nil {
+			return nil
+		}
+	}
+
+	cacheFile := completionCacheFile(kind)
+	if values, ok := readCompletionCache(cacheFile, Config.BBSUrl); ok {
+		return values
+	}
+
+	bbsClient, err := helpers.NewBBSClient(cmd, Config)
+	if err != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shellListingTimeout)
+	defer cancel()
+
+	values, err := fetchShellListing(ctx, logger, bbsClient, kind)
+	if err != nil {
+		logger.Error("failed-to-fetch-listing", err)
+		return nil
+	}
+	sort.Strings(values)
+
+	err = writeCompletionCache(cacheFile, Config.BBSUrl, values)
+	if err != nil {
+		logger.Error("failed-to-write-cache", err)
+	}
+	return values
+}
+
+type completionCache struct {
+	BBSUrl    string    `json:"bbs_url"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Values    []string  `json:"values"`
+}
+
+// completionCacheFile returns the file caching the values of kind, in the
+// user's cache directory.
+func completionCacheFile(kind string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "cfdot", "completion-"+strings.ToLower(kind)+".json")
+}
+
+func readCompletionCache(path, bbsUrl string) ([]string, bool) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var cache completionCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, false
+	}
+	if cache.BBSUrl != bbsUrl || time.Since(cache.FetchedAt) > completionCacheTTL {
+		return nil, false
+	}
+	return cache.Values, true
+}
+
+func writeCompletionCache(path, bbsUrl string, values []string) error {
+	data, err := json.Marshal(completionCache{BBSUrl: bbsUrl, FetchedAt: time.Now(), Values: values})
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
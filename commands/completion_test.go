@@ -0,0 +1,43 @@
+package commands_test
+
+import (
+	"code.cloudfoundry.org/cfdot/commands"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Completion", func() {
+	Context("ValidateCompletionArguments", func() {
+		It("requires a shell", func() {
+			err := commands.ValidateCompletionArguments([]string{})
+			Expect(err).To(MatchError("Missing shell, expected one of bash, zsh or fish"))
+		})
+
+		It("rejects extra arguments", func() {
+			err := commands.ValidateCompletionArguments([]string{"bash", "zsh"})
+			Expect(err).To(MatchError("Too many arguments specified"))
+		})
+
+		It("rejects an unsupported shell", func() {
+			err := commands.ValidateCompletionArguments([]string{"tcsh"})
+			Expect(err).To(MatchError("Unsupported shell 'tcsh', expected one of bash, zsh or fish"))
+		})
+
+		It("accepts bash, zsh and fish", func() {
+			for _, shell := range []string{"bash", "zsh", "fish"} {
+				Expect(commands.ValidateCompletionArguments([]string{shell})).To(Succeed())
+			}
+		})
+	})
+
+	It("generates the completion script of each shell", func() {
+		for _, shell := range []string{"bash", "zsh", "fish"} {
+			out := gbytes.NewBuffer()
+			err := commands.Completion(out, commands.RootCmd, shell)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(gbytes.Say("cfdot"))
+		}
+	})
+})
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+var (
+	errTimeoutExceeded = errors.New("Timeout exceeded: the command did not complete within the configured timeout")
+	errInterrupted     = errors.New("Interrupted")
+)
+
+// commandContext returns the context for a single command invocation. It is
+// cancelled when cfdot receives SIGINT or SIGTERM and, when a timeout was
+// given with --timeout or CFDOT_TIMEOUT, once that timeout has elapsed. Only
+// the first signal is handled; a second one terminates the process as usual.
+func commandContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(signals)
+	}()
+
+	if Config.Timeout <= 0 {
+		return ctx, cancel
+	}
+
+	timeoutCtx, timeoutCancel := context.WithTimeout(ctx, time.Duration(Config.Timeout)*time.Second)
+	return timeoutCtx, func() {
+		timeoutCancel()
+		cancel()
+	}
+}
+
+// isContextError reports whether err was caused by the command context ending
+// rather than by a failing component.
+func isContextError(err error) bool {
+	return err == context.DeadlineExceeded || err == context.Canceled
+}
+
+// isInterrupted reports whether err is the result of the user stopping cfdot,
+// which is the normal way to end a streaming command.
+func isInterrupted(err error) bool {
+	return err == context.Canceled
+}
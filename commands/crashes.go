@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"github.com/spf13/cobra"
+)
+
+var crashesCmd = &cobra.Command{
+	Use:   "crashes",
+	Short: "Report crashing LRPs and cells",
+	Long:  "Scan the actual LRPs for instances that are CRASHED or have crashed before, and report the LRPs with the most crashes along with their crash counts, their most common crash reasons and the time since their last crash, and the cells with a share of crashing instances well above the others",
+	RunE:  crashes,
+}
+
+// flags
+var (
+	crashesDomainFlag string
+	crashesSinceFlag  time.Duration
+	crashesTopFlag    int
+)
+
+// errors
+var (
+	errNegativeCrashesSince = errors.New("--since must not be negative")
+	errNegativeCrashesTop   = errors.New("--top must not be negative")
+)
+
+func init() {
+	AddBBSAndTimeoutFlags(crashesCmd)
+	crashesCmd.Flags().StringVarP(&crashesDomainFlag, "domain", "d", "", "only scan the actual lrps of the given domain")
+	crashesCmd.Flags().DurationVar(&crashesSinceFlag, "since", 0, "only count the instances whose state changed within this duration, e.g. 1h, 0 for all")
+	crashesCmd.Flags().IntVar(&crashesTopFlag, "top", 10, "number of LRPs with the most crashes to report, 0 for all")
+	RootCmd.AddCommand(crashesCmd)
+}
+
+func crashes(cmd *cobra.Command, args []string) error {
+	err := ValidateCrashesArguments(args, crashesSinceFlag, crashesTopFlag)
+	if err != nil {
+		return NewCFDotValidationError(cmd, err)
+	}
+
+	bbsClient, err := helpers.NewBBSClient(cmd, Config)
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	err = Crashes(ctx, newPrinter(cmd), bbsClient, crashesDomainFlag, crashesSinceFlag, crashesTopFlag)
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+	return nil
+}
+
+func ValidateCrashesArguments(args []string, since time.Duration, top int) error {
+	switch {
+	case len(args) > 0:
+		return errExtraArguments
+	case since < 0:
+		return errNegativeCrashesSince
+	case top < 0:
+		return errNegativeCrashesTop
+	default:
+		return nil
+	}
+}
+
+// Crashes prints the crash report of the actual LRPs of domain, or of every
+// domain if empty, keeping the top offenders unless top is 0.
+func Crashes(ctx context.Context, printer Printer, bbsClient bbs.Client, domain string, since time.Duration, top int) error {
+	logger := globalLogger.Session("crashes")
+
+	actualLRPs, err := diego.ActualLRPs(ctx, logger, bbsClient, models.ActualLRPFilter{Domain: domain})
+	if err != nil {
+		return err
+	}
+
+	report := diego.AnalyzeCrashes(actualLRPs, time.Now(), since)
+	if top > 0 && len(report.Offenders) > top {
+		report.Offenders = report.Offenders[:top]
+	}
+
+	return printer.Print(report)
+}
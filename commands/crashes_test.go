@@ -0,0 +1,78 @@
+package commands_test
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/commands"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Crashes", func() {
+	Context("ValidateCrashesArguments", func() {
+		It("accepts no arguments", func() {
+			Expect(commands.ValidateCrashesArguments([]string{}, time.Hour, 10)).To(Succeed())
+		})
+
+		It("rejects arguments", func() {
+			Expect(commands.ValidateCrashesArguments([]string{"foo"}, 0, 10)).To(MatchError("Too many arguments specified"))
+		})
+
+		It("rejects a negative --since or --top", func() {
+			Expect(commands.ValidateCrashesArguments([]string{}, -time.Hour, 10)).To(MatchError("--since must not be negative"))
+			Expect(commands.ValidateCrashesArguments([]string{}, 0, -1)).To(MatchError("--top must not be negative"))
+		})
+	})
+
+	Context("Crashes", func() {
+		var (
+			fakeBBSClient *fake_bbs.FakeClient
+			stdout        *gbytes.Buffer
+		)
+
+		BeforeEach(func() {
+			fakeBBSClient = &fake_bbs.FakeClient{}
+			stdout = gbytes.NewBuffer()
+
+			var actualLRPs []*models.ActualLRP
+			for i, guid := range []string{"guid-1", "guid-2", "guid-3"} {
+				actualLRPs = append(actualLRPs, &models.ActualLRP{
+					ActualLRPKey: models.NewActualLRPKey(guid, 0, "cf-apps"),
+					State:        models.ActualLRPStateCrashed,
+					CrashCount:   int32(i + 1),
+					Since:        time.Now().UnixNano(),
+				})
+			}
+			fakeBBSClient.ActualLRPsReturns(actualLRPs, nil)
+		})
+
+		It("prints the top offenders of the domain", func() {
+			err := commands.Crashes(context.Background(), commands.NewJSONPrinter(stdout), fakeBBSClient, "cf-apps", 0, 2)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, filter := fakeBBSClient.ActualLRPsArgsForCall(0)
+			Expect(filter).To(Equal(models.ActualLRPFilter{Domain: "cf-apps"}))
+
+			var report diego.CrashReport
+			Expect(json.Unmarshal(stdout.Contents(), &report)).To(Succeed())
+			Expect(report.CrashingInstances).To(Equal(3))
+			Expect(report.Offenders).To(HaveLen(2))
+			Expect(report.Offenders[0].ProcessGuid).To(Equal("guid-3"))
+			Expect(report.Offenders[1].ProcessGuid).To(Equal("guid-2"))
+		})
+
+		It("returns the BBS errors", func() {
+			fakeBBSClient.ActualLRPsReturns(nil, models.ErrUnknownError)
+
+			err := commands.Crashes(context.Background(), commands.NewJSONPrinter(stdout), fakeBBSClient, "", 0, 0)
+			Expect(err).To(Equal(models.ErrUnknownError))
+		})
+	})
+})
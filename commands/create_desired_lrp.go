@@ -1,29 +1,28 @@
 package commands
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
-	"os"
-	"strings"
 
 	"code.cloudfoundry.org/bbs"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"github.com/spf13/cobra"
 )
 
 var createDesiredLRPCmd = &cobra.Command{
-	Use:   "create-desired-lrp (SPEC|@FILE)",
+	Use:   "create-desired-lrp (SPEC|@FILE|-)",
 	Short: "Create a desired LRP",
-	Long:  "Create a desired LRP from the given spec. Spec can either be json encoded desired-lrp, e.g. '{\"process_guid\":\"some-guid\"}' or a file containing json encoded desired-lrp, e.g. @/path/to/spec/file",
+	Long:  "Create a desired LRP from the given spec. Spec can either be json or yaml encoded desired-lrp, e.g. '{\"process_guid\":\"some-guid\"}', a file containing json or yaml encoded desired-lrp, e.g. @/path/to/spec/file, or - to read it from stdin. A yaml spec with several documents separated by --- creates a desired LRP for each, in order, stopping at the first that fails. The spec is checked as by cfdot validate before it is sent",
 	RunE:  createDesiredLRP,
 }
 
 func init() {
 	AddBBSAndTimeoutFlags(createDesiredLRPCmd)
+	AddSpecFormatFlag(createDesiredLRPCmd)
 	RootCmd.AddCommand(createDesiredLRPCmd)
 }
 
@@ -32,7 +31,7 @@ func createDesiredLRP(cmd *cobra.Command, args []string) error {
 		return NewCFDotValidationError(cmd, fmt.Errorf("missing spec argument"))
 	}
 
-	spec, err := ValidateCreateDesiredLRPArguments(args)
+	specs, err := ValidateCreateDesiredLRPArguments(args, cmd.InOrStdin(), specFormatFlag)
 	if err != nil {
 		return NewCFDotValidationError(cmd, err)
 	}
@@ -42,40 +41,23 @@ func createDesiredLRP(cmd *cobra.Command, args []string) error {
 		return NewCFDotError(cmd, err)
 	}
 
-	err = CreateDesiredLRP(cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, spec)
-	if err != nil {
-		return NewCFDotError(cmd, err)
-	}
+	ctx, cancel := commandContext()
+	defer cancel()
 
-	return nil
+	return createFromSpecs(cmd, SpecKindDesiredLRP, specs, func(spec []byte) error {
+		return CreateDesiredLRP(ctx, cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, spec)
+	})
 }
 
-func ValidateCreateDesiredLRPArguments(args []string) ([]byte, error) {
-	var desiredLRP *models.DesiredLRP
-	var err error
-	var spec []byte
-	argValue := args[0]
-	if strings.HasPrefix(argValue, "@") {
-		_, err := os.Stat(argValue[1:])
-		if err != nil {
-			return nil, err
-		}
-		spec, err = ioutil.ReadFile(argValue[1:])
-		if err != nil {
-			return nil, err
-		}
-
-	} else {
-		spec = []byte(argValue)
-	}
-	err = json.Unmarshal([]byte(spec), &desiredLRP)
+func ValidateCreateDesiredLRPArguments(args []string, stdin io.Reader, format string) ([][]byte, error) {
+	specs, err := ReadSpecs(args[0], stdin, format, specValue(SpecKindDesiredLRP))
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("Invalid JSON: %s", err.Error()))
+		return nil, err
 	}
-	return spec, nil
+	return specs, checkSpecs(SpecKindDesiredLRP, specs)
 }
 
-func CreateDesiredLRP(stdout, stderr io.Writer, bbsClient bbs.Client, spec []byte) error {
+func CreateDesiredLRP(ctx context.Context, stdout, stderr io.Writer, bbsClient bbs.Client, spec []byte) error {
 	logger := globalLogger.Session("create-desired-lrp")
 
 	var desiredLRP *models.DesiredLRP
@@ -83,10 +65,7 @@ func CreateDesiredLRP(stdout, stderr io.Writer, bbsClient bbs.Client, spec []byt
 	if err != nil {
 		return err
 	}
-	err = bbsClient.DesireLRP(logger, desiredLRP)
-	if err != nil {
-		return err
-	}
 
-	return nil
+	printSpecWarnings(stderr, diego.CheckDesiredLRP(desiredLRP))
+	return diego.DesireLRP(ctx, logger, bbsClient, desiredLRP)
 }
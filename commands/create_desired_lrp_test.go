@@ -1,12 +1,14 @@
 package commands_test
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"os"
 
 	"code.cloudfoundry.org/bbs/fake_bbs"
 	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/bbs/models/test/model_helpers"
 	"code.cloudfoundry.org/cfdot/commands"
 
 	. "github.com/onsi/ginkgo"
@@ -36,8 +38,9 @@ var _ = Describe("CreateDesiredLRP", func() {
 	})
 
 	It("creates the desired lrp", func() {
-		err := commands.CreateDesiredLRP(stdout, stderr, fakeBBSClient, spec)
+		err := commands.CreateDesiredLRP(context.Background(), stdout, stderr, fakeBBSClient, spec)
 		Expect(err).NotTo(HaveOccurred())
+		Expect(stderr).To(gbytes.Say("Warning: check_definition: has no health check"))
 
 		Expect(fakeBBSClient.DesireLRPCallCount()).To(Equal(1))
 		_, lrp := fakeBBSClient.DesireLRPArgsForCall(0)
@@ -48,6 +51,10 @@ var _ = Describe("CreateDesiredLRP", func() {
 		var filename string
 
 		BeforeEach(func() {
+			var err error
+			spec, err = json.Marshal(model_helpers.NewValidDesiredLRP("some-desired-lrp"))
+			Expect(err).NotTo(HaveOccurred())
+
 			f, err := ioutil.TempFile(os.TempDir(), "spec_file")
 			Expect(err).NotTo(HaveOccurred())
 			defer f.Close()
@@ -58,9 +65,16 @@ var _ = Describe("CreateDesiredLRP", func() {
 
 		It("validates the input file successfully", func() {
 			args := []string{"@" + filename}
-			actualSpec, err := commands.ValidateCreateDesiredLRPArguments(args)
+			actualSpec, err := commands.ValidateCreateDesiredLRPArguments(args, nil, commands.SpecFormatAuto)
 			Expect(err).NotTo(HaveOccurred())
-			Expect(actualSpec).To(Equal(spec))
+			Expect(actualSpec).To(Equal([][]byte{spec}))
+		})
+
+		It("rejects a spec the BBS would reject", func() {
+			args := []string{`{"process_guid":"some-desired-lrp"}`}
+			_, err := commands.ValidateCreateDesiredLRPArguments(args, nil, commands.SpecFormatAuto)
+			Expect(err).To(MatchError(ContainSubstring("Invalid desired-lrp spec: ")))
+			Expect(err).To(MatchError(ContainSubstring("domain: is invalid")))
 		})
 
 	})
@@ -71,7 +85,7 @@ var _ = Describe("CreateDesiredLRP", func() {
 		})
 
 		It("fails with a relevant error", func() {
-			err := commands.CreateDesiredLRP(stdout, stderr, fakeBBSClient, []byte("{}"))
+			err := commands.CreateDesiredLRP(context.Background(), stdout, stderr, fakeBBSClient, []byte("{}"))
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(Equal(models.ErrUnknownError))
 		})
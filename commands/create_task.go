@@ -1,29 +1,28 @@
 package commands
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
-	"os"
-	"strings"
 
 	"code.cloudfoundry.org/bbs"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"github.com/spf13/cobra"
 )
 
 var createTaskCmd = &cobra.Command{
-	Use:   "create-task (SPEC|@FILE)",
+	Use:   "create-task (SPEC|@FILE|-)",
 	Short: "Create a Task",
-	Long:  "Create a Task from the given spec. Spec can either be json encoded task, e.g. '{\"task_guid\":\"some-guid\"}' or a file containing json encoded task, e.g. @/path/to/spec/file",
+	Long:  "Create a Task from the given spec. Spec can either be json or yaml encoded task, e.g. '{\"task_guid\":\"some-guid\"}', a file containing json or yaml encoded task, e.g. @/path/to/spec/file, or - to read it from stdin. A yaml spec with several documents separated by --- creates a Task for each, in order, stopping at the first that fails. The spec is checked as by cfdot validate before it is sent",
 	RunE:  createTask,
 }
 
 func init() {
 	AddBBSAndTimeoutFlags(createTaskCmd)
+	AddSpecFormatFlag(createTaskCmd)
 	RootCmd.AddCommand(createTaskCmd)
 }
 
@@ -32,7 +31,7 @@ func createTask(cmd *cobra.Command, args []string) error {
 		return NewCFDotValidationError(cmd, fmt.Errorf("missing spec argument"))
 	}
 
-	spec, err := ValidateCreateTaskArguments(args)
+	specs, err := ValidateCreateTaskArguments(args, cmd.InOrStdin(), specFormatFlag)
 	if err != nil {
 		return NewCFDotValidationError(cmd, err)
 	}
@@ -42,41 +41,23 @@ func createTask(cmd *cobra.Command, args []string) error {
 		return NewCFDotError(cmd, err)
 	}
 
-	err = CreateTask(cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, spec)
-	if err != nil {
-		return NewCFDotError(cmd, err)
-	}
+	ctx, cancel := commandContext()
+	defer cancel()
 
-	return nil
+	return createFromSpecs(cmd, SpecKindTask, specs, func(spec []byte) error {
+		return CreateTask(ctx, cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, spec)
+	})
 }
 
-func ValidateCreateTaskArguments(args []string) ([]byte, error) {
-	var spec []byte
-	var err error
-	var task *models.Task
-
-	argValue := args[0]
-	if strings.HasPrefix(argValue, "@") {
-		_, err := os.Stat(argValue[1:])
-		if err != nil {
-			return nil, err
-		}
-		spec, err = ioutil.ReadFile(argValue[1:])
-		if err != nil {
-			return nil, err
-		}
-
-	} else {
-		spec = []byte(argValue)
-	}
-	err = json.Unmarshal([]byte(spec), &task)
+func ValidateCreateTaskArguments(args []string, stdin io.Reader, format string) ([][]byte, error) {
+	specs, err := ReadSpecs(args[0], stdin, format, specValue(SpecKindTask))
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("Invalid JSON: %s", err.Error()))
+		return nil, err
 	}
-	return spec, nil
+	return specs, checkSpecs(SpecKindTask, specs)
 }
 
-func CreateTask(stdout, stderr io.Writer, bbsClient bbs.Client, spec []byte) error {
+func CreateTask(ctx context.Context, stdout, stderr io.Writer, bbsClient bbs.Client, spec []byte) error {
 	logger := globalLogger.Session("create-task")
 
 	var task *models.Task
@@ -85,10 +66,6 @@ func CreateTask(stdout, stderr io.Writer, bbsClient bbs.Client, spec []byte) err
 		return err
 	}
 
-	err = bbsClient.DesireTask(logger, task.TaskGuid, task.Domain, task.TaskDefinition)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	printSpecWarnings(stderr, diego.CheckTask(task))
+	return diego.DesireTask(ctx, logger, bbsClient, task)
 }
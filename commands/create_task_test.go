@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"os"
@@ -40,7 +41,7 @@ var _ = Describe("CreateTask", func() {
 	})
 
 	It("creates the task", func() {
-		err := commands.CreateTask(stdout, stderr, fakeBBSClient, spec)
+		err := commands.CreateTask(context.Background(), stdout, stderr, fakeBBSClient, spec)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeBBSClient.DesireTaskCallCount()).To(Equal(1))
@@ -64,9 +65,9 @@ var _ = Describe("CreateTask", func() {
 
 		It("validates the input file successfully", func() {
 			args := []string{"@" + filename}
-			actualSpec, err := commands.ValidateCreateTaskArguments(args)
+			actualSpec, err := commands.ValidateCreateTaskArguments(args, nil, commands.SpecFormatAuto)
 			Expect(err).NotTo(HaveOccurred())
-			Expect(actualSpec).To(Equal(spec))
+			Expect(actualSpec).To(Equal([][]byte{spec}))
 		})
 
 	})
@@ -77,7 +78,7 @@ var _ = Describe("CreateTask", func() {
 		})
 
 		It("fails with a relevant error", func() {
-			err := commands.CreateTask(stdout, stderr, fakeBBSClient, []byte("{}"))
+			err := commands.CreateTask(context.Background(), stdout, stderr, fakeBBSClient, []byte("{}"))
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(Equal(models.ErrUnknownError))
 		})
@@ -1,10 +1,12 @@
 package commands
 
 import (
+	"context"
 	"io"
 
 	"code.cloudfoundry.org/bbs"
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"github.com/spf13/cobra"
 )
 
@@ -31,7 +33,10 @@ func deleteDesiredLRP(cmd *cobra.Command, args []string) error {
 		return NewCFDotError(cmd, err)
 	}
 
-	err = DeleteDesiredLRP(cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, processGuid)
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	err = DeleteDesiredLRP(ctx, cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, processGuid)
 	if err != nil {
 		return NewCFDotError(cmd, err)
 	}
@@ -55,13 +60,8 @@ func ValidateDeleteDesiredLRPArguments(args []string) (string, error) {
 	return args[0], nil
 }
 
-func DeleteDesiredLRP(stdout, stderr io.Writer, bbsClient bbs.Client, processGuid string) error {
+func DeleteDesiredLRP(ctx context.Context, stdout, stderr io.Writer, bbsClient bbs.Client, processGuid string) error {
 	logger := globalLogger.Session("delete-desired-lrp")
 
-	err := bbsClient.RemoveDesiredLRP(logger, processGuid)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return diego.RemoveDesiredLRP(ctx, logger, bbsClient, processGuid)
 }
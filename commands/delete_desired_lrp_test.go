@@ -1,6 +1,8 @@
 package commands_test
 
 import (
+	"context"
+
 	"code.cloudfoundry.org/bbs/fake_bbs"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/cfdot/commands"
@@ -27,7 +29,7 @@ var _ = Describe("DeleteDesiredLRP", func() {
 	})
 
 	It("deletes the desired lrp", func() {
-		err := commands.DeleteDesiredLRP(stdout, stderr, fakeBBSClient, processGuid)
+		err := commands.DeleteDesiredLRP(context.Background(), stdout, stderr, fakeBBSClient, processGuid)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeBBSClient.RemoveDesiredLRPCallCount()).To(Equal(1))
@@ -41,7 +43,7 @@ var _ = Describe("DeleteDesiredLRP", func() {
 		})
 
 		It("fails with a relevant error", func() {
-			err := commands.DeleteDesiredLRP(stdout, stderr, fakeBBSClient, "the-process-guid")
+			err := commands.DeleteDesiredLRP(context.Background(), stdout, stderr, fakeBBSClient, "the-process-guid")
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(Equal(models.ErrUnknownError))
 		})
@@ -1,10 +1,12 @@
 package commands
 
 import (
+	"context"
 	"io"
 
 	"code.cloudfoundry.org/bbs"
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"github.com/spf13/cobra"
 )
 
@@ -31,7 +33,10 @@ func deleteTask(cmd *cobra.Command, args []string) error {
 		return NewCFDotError(cmd, err)
 	}
 
-	err = DeleteTask(cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, taskGuid)
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	err = DeleteTask(ctx, cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, taskGuid)
 	if err != nil {
 		return NewCFDotError(cmd, err)
 	}
@@ -55,16 +60,8 @@ func ValidateDeleteTaskArguments(args []string) (string, error) {
 	return args[0], nil
 }
 
-func DeleteTask(stdout, stderr io.Writer, bbsClient bbs.Client, taskGuid string) error {
+func DeleteTask(ctx context.Context, stdout, stderr io.Writer, bbsClient bbs.Client, taskGuid string) error {
 	logger := globalLogger.Session("delete-task")
-	err := bbsClient.ResolvingTask(logger, taskGuid)
-	if err != nil {
-		return err
-	}
-	err = bbsClient.DeleteTask(logger, taskGuid)
-	if err != nil {
-		return err
-	}
 
-	return nil
+	return diego.DeleteTask(ctx, logger, bbsClient, taskGuid)
 }
@@ -1,6 +1,8 @@
 package commands_test
 
 import (
+	"context"
+
 	"code.cloudfoundry.org/bbs/fake_bbs"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/cfdot/commands"
@@ -27,7 +29,7 @@ var _ = Describe("DeleteTask", func() {
 	})
 
 	It("deletes the task", func() {
-		err := commands.DeleteTask(stdout, stderr, fakeBBSClient, taskGuid)
+		err := commands.DeleteTask(context.Background(), stdout, stderr, fakeBBSClient, taskGuid)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeBBSClient.ResolvingTaskCallCount()).To(Equal(1))
@@ -45,7 +47,7 @@ var _ = Describe("DeleteTask", func() {
 		})
 
 		It("fails with a relevant error", func() {
-			err := commands.DeleteTask(stdout, stderr, fakeBBSClient, "the-task-guid")
+			err := commands.DeleteTask(context.Background(), stdout, stderr, fakeBBSClient, "the-task-guid")
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(Equal(models.ErrUnknownError))
 		})
@@ -0,0 +1,345 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"code.cloudfoundry.org/rep"
+	"github.com/spf13/cobra"
+)
+
+var describeCmd = &cobra.Command{
+	Use:   "describe KIND ID",
+	Short: "Describe an LRP, a task or a cell",
+	Long:  "Show a human-readable description joining the BBS and rep views of an LRP, a task or a cell. 'describe lrp PROCESS_GUID' shows the desired spec, every actual instance and the free capacity of their cells, 'describe task TASK_GUID' the task and the free capacity of its cell, and 'describe cell CELL_ID' the cell, its free capacity and the instances and tasks placed on it",
+	RunE:  describe,
+}
+
+// Kinds of the resources shown by describe.
+const (
+	DescribeLRP  = "lrp"
+	DescribeTask = "task"
+	DescribeCell = "cell"
+)
+
+var describeKinds = []string{DescribeLRP, DescribeTask, DescribeCell}
+
+func init() {
+	AddBBSAndTimeoutFlags(describeCmd)
+	AddRepTimeoutFlag(describeCmd)
+	RootCmd.AddCommand(describeCmd)
+}
+
+func describe(cmd *cobra.Command, args []string) error {
+	err := ValidateDescribeArguments(args)
+	if err != nil {
+		return NewCFDotValidationError(cmd, err)
+	}
+
+	bbsClient, err := helpers.NewBBSClient(cmd, Config)
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+
+	repClientFactory, err := helpers.NewRepClientFactory(cmd, Config)
+	if err != nil {
+		return NewCFDotRepError(cmd, fmt.Errorf("Failed creating rep client factory: %s", err), "")
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	err = Describe(ctx, cmd.OutOrStdout(), bbsClient, repClientFactory, args[0], args[1])
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+	return nil
+}
+
+func ValidateDescribeArguments(args []string) error {
+	switch {
+	case len(args) < 2:
+		return errMissingArguments
+	case len(args) > 2:
+		return errExtraArguments
+	}
+
+	for _, kind := range describeKinds {
+		if args[0] == kind {
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown kind '%s', expected one of lrp, task or cell", args[0])
+}
+
+// Describe writes the description of the LRP, task or cell id of kind to w.
+func Describe(ctx context.Context, w io.Writer, bbsClient bbs.Client, clientFactory rep.ClientFactory, kind, id string) error {
+	logger := globalLogger.Session("describe")
+
+	switch kind {
+	case DescribeLRP:
+		description, err := diego.DescribeLRP(ctx, logger, bbsClient, clientFactory, id)
+		if err != nil {
+			return err
+		}
+		return RenderLRPDescription(w, description, time.Now())
+	case DescribeTask:
+		description, err := diego.DescribeTask(ctx, logger, bbsClient, clientFactory, id)
+		if err != nil {
+			return err
+		}
+		return RenderTaskDescription(w, description, time.Now())
+	default:
+		description, err := diego.DescribeCell(ctx, logger, bbsClient, clientFactory, id)
+		if err != nil {
+			return err
+		}
+		return RenderCellDescription(w, description, time.Now())
+	}
+}
+
+// RenderLRPDescription writes the desired spec of the LRP, its instances and
+// the capacity of their cells to w, with ages relative to now.
+func RenderLRPDescription(w io.Writer, description diego.LRPDescription, now time.Time) error {
+	lrp := description.DesiredLRP
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	zones := map[string]string{}
+	for _, cell := range description.Cells {
+		zones[cell.CellID] = cell.Zone
+	}
+
+	running := 0
+	for _, actualLRP := range description.ActualLRPs {
+		if actualLRP.State == models.ActualLRPStateRunning && actualLRP.Presence == models.ActualLRP_Ordinary && actualLRP.Index < lrp.Instances {
+			running++
+		}
+	}
+
+	fmt.Fprintf(tw, "Process guid:\t%s\n", lrp.ProcessGuid)
+	fmt.Fprintf(tw, "Domain:\t%s\n", lrp.Domain)
+	fmt.Fprintf(tw, "Instances:\t%d desired, %d running\n", lrp.Instances, running)
+	fmt.Fprintf(tw, "Memory:\t%d MB\n", lrp.MemoryMb)
+	fmt.Fprintf(tw, "Disk:\t%d MB\n", lrp.DiskMb)
+	fmt.Fprintf(tw, "Root FS:\t%s\n", lrp.RootFs)
+	fmt.Fprintf(tw, "Placement tags:\t%s\n", describeList(lrp.PlacementTags))
+	for i, route := range describeRoutes(lrp) {
+		label := ""
+		if i == 0 {
+			label = "Routes:"
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", label, route)
+	}
+
+	fmt.Fprintf(tw, "\nInstances:\n")
+	fmt.Fprintf(tw, "INDEX\tSTATE\tCELL\tZONE\tADDRESS\tPORTS\tCRASHES\tSINCE\tREASON\n")
+	for _, actualLRP := range description.ActualLRPs {
+		state := actualLRP.State
+		if actualLRP.Presence != models.ActualLRP_Ordinary {
+			state += " (" + strings.ToLower(actualLRP.Presence.String()) + ")"
+		}
+		reason := actualLRP.CrashReason
+		if actualLRP.PlacementError != "" {
+			reason = actualLRP.PlacementError
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			actualLRP.Index,
+			state,
+			describeValue(actualLRP.CellId),
+			describeValue(zones[actualLRP.CellId]),
+			describeValue(actualLRP.Address),
+			describePorts(actualLRP.Ports),
+			actualLRP.CrashCount,
+			describeAge(actualLRP.Since, now),
+			reason,
+		)
+	}
+
+	renderCapacities(tw, description.Cells)
+	return tw.Flush()
+}
+
+// RenderTaskDescription writes the task and the capacity of its cell to w,
+// with ages relative to now.
+func RenderTaskDescription(w io.Writer, description diego.TaskDescription, now time.Time) error {
+	task := description.Task
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Task guid:\t%s\n", task.TaskGuid)
+	fmt.Fprintf(tw, "Domain:\t%s\n", task.Domain)
+	fmt.Fprintf(tw, "State:\t%s\n", task.State)
+	fmt.Fprintf(tw, "Cell:\t%s\n", describeValue(task.CellId))
+	if task.TaskDefinition != nil {
+		fmt.Fprintf(tw, "Memory:\t%d MB\n", task.MemoryMb)
+		fmt.Fprintf(tw, "Disk:\t%d MB\n", task.DiskMb)
+		fmt.Fprintf(tw, "Root FS:\t%s\n", task.RootFs)
+		fmt.Fprintf(tw, "Placement tags:\t%s\n", describeList(task.PlacementTags))
+	}
+	fmt.Fprintf(tw, "Created:\t%s\n", describeTimeAgo(task.CreatedAt, now))
+	fmt.Fprintf(tw, "Updated:\t%s\n", describeTimeAgo(task.UpdatedAt, now))
+	if task.Failed {
+		fmt.Fprintf(tw, "Failure reason:\t%s\n", task.FailureReason)
+	} else if task.Result != "" {
+		fmt.Fprintf(tw, "Result:\t%s\n", task.Result)
+	}
+
+	if description.Cell != nil {
+		renderCapacities(tw, []diego.CellCapacity{*description.Cell})
+	}
+	return tw.Flush()
+}
+
+// RenderCellDescription writes the cell, its capacity and the instances and
+// tasks placed on it to w, with ages relative to now.
+func RenderCellDescription(w io.Writer, description diego.CellDescription, now time.Time) error {
+	presence := description.Presence
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	repURL := presence.RepUrl
+	if repURL == "" {
+		repURL = presence.RepAddress
+	}
+	var providers []string
+	for _, provider := range presence.RootfsProviders {
+		providers = append(providers, provider.Name)
+	}
+
+	fmt.Fprintf(tw, "Cell id:\t%s\n", presence.CellId)
+	fmt.Fprintf(tw, "Zone:\t%s\n", describeValue(presence.Zone))
+	fmt.Fprintf(tw, "Rep:\t%s\n", repURL)
+	fmt.Fprintf(tw, "Placement tags:\t%s\n", describeList(presence.PlacementTags))
+	fmt.Fprintf(tw, "Optional placement tags:\t%s\n", describeList(presence.OptionalPlacementTags))
+	fmt.Fprintf(tw, "Root FS providers:\t%s\n", describeList(providers))
+
+	renderCapacities(tw, []diego.CellCapacity{description.Capacity})
+
+	fmt.Fprintf(tw, "\nInstances:\n")
+	fmt.Fprintf(tw, "PROCESS GUID\tINDEX\tSTATE\tCRASHES\tSINCE\n")
+	for _, actualLRP := range description.ActualLRPs {
+		state := actualLRP.State
+		if actualLRP.Presence != models.ActualLRP_Ordinary {
+			state += " (" + strings.ToLower(actualLRP.Presence.String()) + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%d\t%s\n", actualLRP.ProcessGuid, actualLRP.Index, state, actualLRP.CrashCount, describeAge(actualLRP.Since, now))
+	}
+
+	fmt.Fprintf(tw, "\nTasks:\n")
+	fmt.Fprintf(tw, "TASK GUID\tSTATE\tUPDATED\n")
+	for _, task := range description.Tasks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", task.TaskGuid, task.State, describeAge(task.UpdatedAt, now))
+	}
+
+	return tw.Flush()
+}
+
+func renderCapacities(w io.Writer, cells []diego.CellCapacity) {
+	fmt.Fprintf(w, "\nCells:\n")
+	fmt.Fprintf(w, "CELL\tZONE\tFREE MEMORY\tFREE DISK\tFREE CONTAINERS\n")
+	for _, cell := range cells {
+		if cell.Error != "" {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", cell.CellID, describeValue(cell.Zone), cell.Error)
+			continue
+		}
+
+		line := fmt.Sprintf("%s\t%s\t%d of %d MB\t%d of %d MB\t%d of %d",
+			cell.CellID,
+			describeValue(cell.Zone),
+			cell.Available.MemoryMB, cell.Total.MemoryMB,
+			cell.Available.DiskMB, cell.Total.DiskMB,
+			cell.Available.Containers, cell.Total.Containers,
+		)
+		if cell.Evacuating {
+			line += "\tevacuating"
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// describeRoutes lists the cf-router routes of lrp as HOSTNAME:PORT followed
+// by the routes of any other router as ROUTER: JSON.
+func describeRoutes(lrp *models.DesiredLRP) []string {
+	if lrp.Routes == nil || len(*lrp.Routes) == 0 {
+		return []string{"-"}
+	}
+
+	var routes []string
+	var routers []string
+	for router := range *lrp.Routes {
+		routers = append(routers, router)
+	}
+	sort.Strings(routers)
+
+	for _, router := range routers {
+		raw := (*lrp.Routes)[router]
+		if raw == nil {
+			continue
+		}
+
+		if router == diego.CFRouter {
+			cfRoutes, err := diego.CFRoutes(lrp)
+			if err == nil {
+				for _, route := range cfRoutes {
+					routes = append(routes, fmt.Sprintf("%s:%d", route.Hostname, route.Port))
+				}
+				continue
+			}
+		}
+		routes = append(routes, fmt.Sprintf("%s: %s", router, *raw))
+	}
+
+	if len(routes) == 0 {
+		return []string{"-"}
+	}
+	return routes
+}
+
+func describePorts(ports []*models.PortMapping) string {
+	var mappings []string
+	for _, port := range ports {
+		mappings = append(mappings, fmt.Sprintf("%d->%d", port.HostPort, port.ContainerPort))
+	}
+	return describeList(mappings)
+}
+
+// describeAge returns how long ago the time given in nanoseconds since the
+// epoch was, or - when it is not set.
+func describeAge(nanos int64, now time.Time) string {
+	if nanos == 0 {
+		return "-"
+	}
+	return now.Sub(time.Unix(0, nanos)).Round(time.Second).String()
+}
+
+// describeTimeAgo is like describeAge for a point in time rather than the age
+// of a state.
+func describeTimeAgo(nanos int64, now time.Time) string {
+	if nanos == 0 {
+		return "-"
+	}
+	return describeAge(nanos, now) + " ago"
+}
+
+// describeList joins values, or returns - when there are none.
+func describeList(values []string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	return strings.Join(values, ", ")
+}
+
+// describeValue returns value, or - when it is empty.
+func describeValue(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
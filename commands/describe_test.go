@@ -0,0 +1,146 @@
+package commands_test
+
+import (
+	"encoding/json"
+	"time"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/commands"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"code.cloudfoundry.org/rep"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Describe", func() {
+	var (
+		now    time.Time
+		stdout *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		now = time.Unix(1600000000, 0)
+		stdout = gbytes.NewBuffer()
+	})
+
+	Context("ValidateDescribeArguments", func() {
+		It("accepts a kind and an id", func() {
+			Expect(commands.ValidateDescribeArguments([]string{"lrp", "process-guid"})).To(Succeed())
+			Expect(commands.ValidateDescribeArguments([]string{"task", "task-guid"})).To(Succeed())
+			Expect(commands.ValidateDescribeArguments([]string{"cell", "cell-id"})).To(Succeed())
+		})
+
+		It("rejects missing and extra arguments", func() {
+			Expect(commands.ValidateDescribeArguments([]string{"lrp"})).To(MatchError("Missing arguments"))
+			Expect(commands.ValidateDescribeArguments([]string{"lrp", "a", "b"})).To(MatchError("Too many arguments specified"))
+		})
+
+		It("rejects unknown kinds", func() {
+			Expect(commands.ValidateDescribeArguments([]string{"app", "guid"})).To(MatchError("Unknown kind 'app', expected one of lrp, task or cell"))
+		})
+	})
+
+	Context("RenderLRPDescription", func() {
+		It("shows the spec, the instances and the capacity of their cells", func() {
+			cf := json.RawMessage(`[{"hostnames":["app.example.com"],"port":8080}]`)
+			description := diego.LRPDescription{
+				DesiredLRP: &models.DesiredLRP{
+					ProcessGuid:   "process-guid",
+					Domain:        "cf-apps",
+					Instances:     2,
+					MemoryMb:      256,
+					DiskMb:        1024,
+					RootFs:        "preloaded:cflinuxfs3",
+					PlacementTags: []string{"isolated"},
+					Routes:        &models.Routes{diego.CFRouter: &cf},
+				},
+				ActualLRPs: []*models.ActualLRP{
+					{
+						ActualLRPKey:         models.NewActualLRPKey("process-guid", 0, "cf-apps"),
+						ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-guid", "cell-1"),
+						ActualLRPNetInfo:     models.ActualLRPNetInfo{Address: "10.0.0.1", Ports: []*models.PortMapping{{HostPort: 61001, ContainerPort: 8080}}},
+						State:                models.ActualLRPStateRunning,
+						Since:                now.Add(-5 * time.Minute).UnixNano(),
+					},
+					{
+						ActualLRPKey:         models.NewActualLRPKey("process-guid", 1, "cf-apps"),
+						ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-guid", "cell-2"),
+						State:                models.ActualLRPStateCrashed,
+						CrashCount:           3,
+						CrashReason:          "exit status 1",
+						Since:                now.Add(-time.Minute).UnixNano(),
+					},
+				},
+				Cells: []diego.CellCapacity{
+					{
+						CellID:    "cell-1",
+						Zone:      "z1",
+						Available: rep.Resources{MemoryMB: 1024, DiskMB: 2048, Containers: 10},
+						Total:     rep.Resources{MemoryMB: 4096, DiskMB: 8192, Containers: 250},
+					},
+					{CellID: "cell-2", Zone: "z2", Error: "failed to get cell state for cell cell-2: boom"},
+				},
+			}
+
+			Expect(commands.RenderLRPDescription(stdout, description, now)).To(Succeed())
+			Expect(stdout).To(gbytes.Say(`Process guid: +process-guid\n`))
+			Expect(stdout).To(gbytes.Say(`Instances: +2 desired, 1 running\n`))
+			Expect(stdout).To(gbytes.Say(`Memory: +256 MB\n`))
+			Expect(stdout).To(gbytes.Say(`Placement tags: +isolated\n`))
+			Expect(stdout).To(gbytes.Say(`Routes: +app.example.com:8080\n`))
+			Expect(stdout).To(gbytes.Say(`0 +RUNNING +cell-1 +z1 +10.0.0.1 +61001->8080 +0 +5m0s`))
+			Expect(stdout).To(gbytes.Say(`1 +CRASHED +cell-2 +z2 +- +- +3 +1m0s +exit status 1\n`))
+			Expect(stdout).To(gbytes.Say(`cell-1 +z1 +1024 of 4096 MB +2048 of 8192 MB +10 of 250\n`))
+			Expect(stdout).To(gbytes.Say(`cell-2 +z2 +failed to get cell state for cell cell-2: boom\n`))
+		})
+	})
+
+	Context("RenderTaskDescription", func() {
+		It("shows the task and why it failed", func() {
+			description := diego.TaskDescription{
+				Task: &models.Task{
+					TaskGuid:       "task-guid",
+					Domain:         "cfdot",
+					State:          models.Task_Completed,
+					CellId:         "cell-1",
+					TaskDefinition: &models.TaskDefinition{MemoryMb: 128, RootFs: "docker:///busybox"},
+					CreatedAt:      now.Add(-time.Hour).UnixNano(),
+					Failed:         true,
+					FailureReason:  "exit status 2",
+				},
+			}
+
+			Expect(commands.RenderTaskDescription(stdout, description, now)).To(Succeed())
+			Expect(stdout).To(gbytes.Say(`State: +Completed\n`))
+			Expect(stdout).To(gbytes.Say(`Cell: +cell-1\n`))
+			Expect(stdout).To(gbytes.Say(`Created: +1h0m0s ago\n`))
+			Expect(stdout).To(gbytes.Say(`Updated: +-\n`))
+			Expect(stdout).To(gbytes.Say(`Failure reason: +exit status 2\n`))
+			Expect(stdout).NotTo(gbytes.Say(`Cells:`))
+		})
+	})
+
+	Context("RenderCellDescription", func() {
+		It("shows the cell, its capacity, instances and tasks", func() {
+			description := diego.CellDescription{
+				Presence: &models.CellPresence{CellId: "cell-1", Zone: "z1", RepUrl: "https://cell-1.cell.service.cf.internal:1801"},
+				Capacity: diego.CellCapacity{CellID: "cell-1", Zone: "z1", Evacuating: true},
+				ActualLRPs: []*models.ActualLRP{{
+					ActualLRPKey: models.NewActualLRPKey("process-guid", 0, "cf-apps"),
+					State:        models.ActualLRPStateRunning,
+					Presence:     models.ActualLRP_Evacuating,
+				}},
+				Tasks: []*models.Task{{TaskGuid: "task-guid", State: models.Task_Running}},
+			}
+
+			Expect(commands.RenderCellDescription(stdout, description, now)).To(Succeed())
+			Expect(stdout).To(gbytes.Say(`Cell id: +cell-1\n`))
+			Expect(stdout).To(gbytes.Say(`Rep: +https://cell-1.cell.service.cf.internal:1801\n`))
+			Expect(stdout).To(gbytes.Say(`cell-1 +z1 +0 of 0 MB +0 of 0 MB +0 of 0 +evacuating\n`))
+			Expect(stdout).To(gbytes.Say(`process-guid +0 +RUNNING \(evacuating\)`))
+			Expect(stdout).To(gbytes.Say(`task-guid +Running +-\n`))
+		})
+	})
+})
@@ -1,10 +1,11 @@
 package commands
 
 import (
-	"encoding/json"
+	"context"
 	"io"
 
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 
 	"code.cloudfoundry.org/bbs"
 	"github.com/spf13/cobra"
@@ -33,7 +34,10 @@ func desiredLRP(cmd *cobra.Command, args []string) error {
 		return NewCFDotError(cmd, err)
 	}
 
-	err = DesiredLRP(cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, processGuid)
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	err = DesiredLRP(ctx, newPrinter(cmd), cmd.OutOrStderr(), bbsClient, processGuid)
 	if err != nil {
 		return NewCFDotError(cmd, err)
 	}
@@ -57,14 +61,13 @@ func ValidateDesiredLRPArguments(args []string) (string, error) {
 	return args[0], nil
 }
 
-func DesiredLRP(stdout, stderr io.Writer, bbsClient bbs.Client, processGuid string) error {
+func DesiredLRP(ctx context.Context, printer Printer, stderr io.Writer, bbsClient bbs.Client, processGuid string) error {
 	logger := globalLogger.Session("desired-lrp")
 
-	desiredLRP, err := bbsClient.DesiredLRPByProcessGuid(logger, processGuid)
+	desiredLRP, err := diego.DesiredLRP(ctx, logger, bbsClient, processGuid)
 	if err != nil {
 		return err
 	}
 
-	encoder := json.NewEncoder(stdout)
-	return encoder.Encode(desiredLRP)
+	return printer.Print(desiredLRP)
 }
@@ -1,12 +1,13 @@
 package commands
 
 import (
-	"encoding/json"
+	"context"
 	"io"
 
 	"code.cloudfoundry.org/bbs"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"github.com/spf13/cobra"
 )
 
@@ -44,7 +45,10 @@ func desiredLRPSchedulingInfos(cmd *cobra.Command, args []string) error {
 		return NewCFDotError(cmd, err)
 	}
 
-	err = DesiredLRPSchedulingInfos(cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, desiredLRPSchedulingInfosDomainFlag)
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	err = DesiredLRPSchedulingInfos(ctx, newPrinter(cmd), cmd.OutOrStderr(), bbsClient, desiredLRPSchedulingInfosDomainFlag)
 	if err != nil {
 		return NewCFDotError(cmd, err)
 	}
@@ -59,21 +63,20 @@ func ValidateDesiredLRPSchedulingInfosArguments(args []string) error {
 	return nil
 }
 
-func DesiredLRPSchedulingInfos(stdout, stderr io.Writer, bbsClient bbs.Client, domain string) error {
+func DesiredLRPSchedulingInfos(ctx context.Context, printer Printer, stderr io.Writer, bbsClient bbs.Client, domain string) error {
 	logger := globalLogger.Session("desired-lrp-scheduling-infos")
 
-	encoder := json.NewEncoder(stdout)
 	desiredLRPFilter := models.DesiredLRPFilter{
 		Domain: domain,
 	}
 
-	desiredLRPSchedulingInfos, err := bbsClient.DesiredLRPSchedulingInfos(logger, desiredLRPFilter)
+	desiredLRPSchedulingInfos, err := diego.DesiredLRPSchedulingInfos(ctx, logger, bbsClient, desiredLRPFilter)
 	if err != nil {
 		return err
 	}
 
 	for _, info := range desiredLRPSchedulingInfos {
-		err = encoder.Encode(info)
+		err = printer.Print(info)
 		if err != nil {
 			logger.Error("failed-to-marshal", err)
 		}
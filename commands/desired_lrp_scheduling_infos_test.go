@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"context"
 	"encoding/json"
 
 	"code.cloudfoundry.org/bbs/fake_bbs"
@@ -33,7 +34,7 @@ var _ = Describe("DesiredLRPSchedulingInfos", func() {
 	})
 
 	It("prints a json stream of all the desired lrp scheduling infos", func() {
-		err := commands.DesiredLRPSchedulingInfos(stdout, stderr, fakeBBSClient, "domain")
+		err := commands.DesiredLRPSchedulingInfos(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "domain")
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeBBSClient.DesiredLRPSchedulingInfosCallCount()).To(Equal(1))
@@ -55,7 +56,7 @@ var _ = Describe("DesiredLRPSchedulingInfos", func() {
 		})
 
 		It("fails with a relevant error", func() {
-			err := commands.DesiredLRPSchedulingInfos(stdout, stderr, fakeBBSClient, "")
+			err := commands.DesiredLRPSchedulingInfos(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "")
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(Equal(models.ErrUnknownError))
 		})
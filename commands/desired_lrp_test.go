@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 
@@ -35,7 +36,7 @@ var _ = Describe("DesiredLRP Command", func() {
 		})
 
 		It("writes the json representation of the desired LRP to stdout", func() {
-			err := commands.DesiredLRP(stdout, stderr, fakeBBSClient, "test-guid")
+			err := commands.DesiredLRP(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "test-guid")
 			Expect(err).NotTo(HaveOccurred())
 
 			jsonData, err := json.Marshal(desiredLRP)
@@ -50,7 +51,7 @@ var _ = Describe("DesiredLRP Command", func() {
 			})
 
 			It("returns the error", func() {
-				err := commands.DesiredLRP(stdout, stderr, fakeBBSClient, "test-guid")
+				err := commands.DesiredLRP(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "test-guid")
 				Expect(err).To(HaveOccurred())
 			})
 		})
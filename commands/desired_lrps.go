@@ -1,12 +1,13 @@
 package commands
 
 import (
-	"encoding/json"
+	"context"
 	"io"
 
 	"code.cloudfoundry.org/bbs"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"github.com/spf13/cobra"
 )
 
@@ -44,7 +45,10 @@ func desiredLRPs(cmd *cobra.Command, args []string) error {
 		return NewCFDotError(cmd, err)
 	}
 
-	err = DesiredLRPs(cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, desiredLRPsDomainFlag)
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	err = DesiredLRPs(ctx, newPrinter(cmd), cmd.OutOrStderr(), bbsClient, desiredLRPsDomainFlag)
 	if err != nil {
 		return NewCFDotError(cmd, err)
 	}
@@ -59,19 +63,18 @@ func ValidateDesiredLRPsArguments(args []string) error {
 	return nil
 }
 
-func DesiredLRPs(stdout, stderr io.Writer, bbsClient bbs.Client, domain string) error {
+func DesiredLRPs(ctx context.Context, printer Printer, stderr io.Writer, bbsClient bbs.Client, domain string) error {
 	logger := globalLogger.Session("desiredLRPs")
 
 	desiredLRPFilter := models.DesiredLRPFilter{Domain: domain}
 
-	desiredLRPs, err := bbsClient.DesiredLRPs(logger, desiredLRPFilter)
+	desiredLRPs, err := diego.DesiredLRPs(ctx, logger, bbsClient, desiredLRPFilter)
 	if err != nil {
 		return err
 	}
 
-	encoder := json.NewEncoder(stdout)
 	for _, lrp := range desiredLRPs {
-		err = encoder.Encode(lrp)
+		err = printer.Print(lrp)
 		if err != nil {
 			logger.Error("failed-to-marshal", err)
 		}
@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"context"
 	"encoding/json"
 
 	"code.cloudfoundry.org/bbs/fake_bbs"
@@ -34,7 +35,7 @@ var _ = Describe("DesiredLRPs", func() {
 	})
 
 	It("prints a json stream of all the desired lrps", func() {
-		err := commands.DesiredLRPs(stdout, stderr, fakeBBSClient, "domain")
+		err := commands.DesiredLRPs(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "domain")
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeBBSClient.DesiredLRPsCallCount()).To(Equal(1))
@@ -57,7 +58,7 @@ var _ = Describe("DesiredLRPs", func() {
 		})
 
 		It("fails with a relevant error", func() {
-			err := commands.DesiredLRPs(stdout, stderr, fakeBBSClient, "domain")
+			err := commands.DesiredLRPs(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "domain")
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(Equal(models.ErrUnknownError))
 		})
@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose connectivity to Diego components",
+	Long:  "Check the client and CA certificates, then DNS resolution, TCP reachability, the TLS handshake and the certificates of the BBS, Locket and a sample of reps, and ping the BBS. Each check is printed with a hint on how to fix it if it fails",
+	RunE:  doctor,
+	// The certificates are checked by doctor, which tells what is wrong with
+	// them instead of failing before any check runs.
+	Annotations: map[string]string{skipCertificateValidation: "true"},
+}
+
+type Check = diego.Check
+
+var doctorRepSample int
+
+// errors
+var (
+	errNegativeRepSample = errors.New("--reps must not be negative")
+)
+
+func init() {
+	AddBBSAndTimeoutFlags(doctorCmd)
+	doctorCmd.Flags().StringVar(&locketApiLocation, "locketAPILocation", "", "Hostname:Port of Locket server to check, Locket is not checked if unset [environment variable equivalent: LOCKET_API_LOCATION]")
+	doctorCmd.Flags().IntVar(&doctorRepSample, "reps", 3, "number of registered cells whose rep to check")
+	RootCmd.AddCommand(doctorCmd)
+}
+
+func doctor(cmd *cobra.Command, args []string) error {
+	err := ValidateDoctorArguments(args, doctorRepSample)
+	if err != nil {
+		return NewCFDotValidationError(cmd, err)
+	}
+
+	if locketApiLocation == "" {
+		locketApiLocation = os.Getenv("LOCKET_API_LOCATION")
+	}
+	Config.LocketApiLocation = locketApiLocation
+
+	_, bbsClients, err := helpers.NewBBSEndpointClients(cmd, Config)
+	if err != nil {
+		// The credentials the clients could not be created from are reported
+		// by the checks, which skip the BBS pings without clients.
+		bbsClients = nil
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	err = Doctor(ctx, newPrinter(cmd), cmd.OutOrStderr(), Config, bbsClients, doctorRepSample)
+	if err != nil {
+		return NewCFDotComponentError(cmd, err)
+	}
+
+	return nil
+}
+
+func ValidateDoctorArguments(args []string, repSample int) error {
+	switch {
+	case len(args) > 0:
+		return errExtraArguments
+	case repSample < 0:
+		return errNegativeRepSample
+	default:
+		return nil
+	}
+}
+
+// Doctor prints the result of each diagnostic check and returns an error when
+// any of them failed.
+func Doctor(ctx context.Context, printer Printer, stderr io.Writer, config helpers.TLSConfig, bbsClients []bbs.Client, repSample int) error {
+	logger := globalLogger.Session("doctor")
+
+	var total, failed int
+	var printErr error
+	err := diego.Diagnose(ctx, logger, config, bbsClients, repSample, func(check diego.Check) {
+		total++
+		if check.Status == diego.CheckFailed {
+			failed++
+		}
+		if printErr == nil {
+			printErr = printer.Print(check)
+		}
+	})
+	if printErr != nil {
+		logger.Error("failed-to-marshal", printErr)
+		return printErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, total)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package commands_test
+
+import (
+	"context"
+	"encoding/json"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/cfdot/commands"
+	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Doctor", func() {
+	var (
+		stdout, stderr *gbytes.Buffer
+		config         helpers.TLSConfig
+		bbsClient      *fake_bbs.FakeClient
+	)
+
+	BeforeEach(func() {
+		stdout = gbytes.NewBuffer()
+		stderr = gbytes.NewBuffer()
+
+		config = helpers.TLSConfig{
+			BBSUrl:         "https://127.0.0.1:1",
+			SkipCertVerify: true,
+		}
+		bbsClient = &fake_bbs.FakeClient{}
+	})
+
+	Context("ValidateDoctorArguments", func() {
+		It("rejects extra arguments", func() {
+			err := commands.ValidateDoctorArguments([]string{"extra-arg"}, 3)
+			Expect(err).To(MatchError("Too many arguments specified"))
+		})
+
+		It("rejects a negative number of reps", func() {
+			err := commands.ValidateDoctorArguments([]string{}, -1)
+			Expect(err).To(MatchError("--reps must not be negative"))
+		})
+	})
+
+	It("prints every check and returns an error counting the failed ones", func() {
+		err := commands.Doctor(context.Background(), commands.NewJSONPrinter(stdout), stderr, config, []bbs.Client{bbsClient}, 0)
+		Expect(err).To(MatchError("2 of 6 checks failed"))
+
+		decoder := json.NewDecoder(stdout)
+		var checks []commands.Check
+		for decoder.More() {
+			var check commands.Check
+			Expect(decoder.Decode(&check)).To(Succeed())
+			checks = append(checks, check)
+		}
+
+		Expect(checks).To(HaveLen(6))
+		Expect(checks[1].Name).To(Equal(diego.CheckClientCertificate))
+		Expect(checks[1].Status).To(Equal(diego.CheckFailed))
+		Expect(checks[1].Hint).To(ContainSubstring("--clientCertFile"))
+		Expect(checks[3].Name).To(Equal(diego.CheckTCP))
+		Expect(checks[3].Status).To(Equal(diego.CheckFailed))
+		Expect(bbsClient.PingCallCount()).To(Equal(0))
+	})
+})
@@ -1,12 +1,13 @@
 package commands
 
 import (
-	"encoding/json"
+	"context"
 	"io"
 
 	"code.cloudfoundry.org/bbs"
 
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"github.com/spf13/cobra"
 )
 
@@ -33,7 +34,10 @@ func domains(cmd *cobra.Command, args []string) error {
 		return NewCFDotError(cmd, err)
 	}
 
-	err = Domains(cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient)
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	err = Domains(ctx, newPrinter(cmd), cmd.OutOrStderr(), bbsClient)
 	if err != nil {
 		return NewCFDotError(cmd, err)
 	}
@@ -48,17 +52,16 @@ func ValidateDomainsArguments(args []string) error {
 	return nil
 }
 
-func Domains(stdout, stderr io.Writer, bbsClient bbs.Client) error {
+func Domains(ctx context.Context, printer Printer, stderr io.Writer, bbsClient bbs.Client) error {
 	logger := globalLogger.Session("domains")
 
-	encoder := json.NewEncoder(stdout)
-	domains, err := bbsClient.Domains(logger)
+	domains, err := diego.Domains(ctx, logger, bbsClient)
 	if err != nil {
 		return err
 	}
 
 	for _, domain := range domains {
-		err = encoder.Encode(domain)
+		err = printer.Print(domain)
 		if err != nil {
 			logger.Error("failed-to-marshal", err)
 		}
@@ -1,6 +1,8 @@
 package commands_test
 
 import (
+	"context"
+
 	"code.cloudfoundry.org/bbs/fake_bbs"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/cfdot/commands"
@@ -28,7 +30,7 @@ var _ = Describe("Domains", func() {
 		})
 
 		It("prints a json stream of all the domains", func() {
-			err := commands.Domains(stdout, stderr, fakeBBSClient)
+			err := commands.Domains(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(stdout).To(gbytes.Say(`"domain-1"\n"domain-2"\n`))
 		})
@@ -40,7 +42,7 @@ var _ = Describe("Domains", func() {
 		})
 
 		It("returns an empty response", func() {
-			err := commands.Domains(stdout, stderr, fakeBBSClient)
+			err := commands.Domains(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(stdout.Contents()).To(BeEmpty())
 		})
@@ -52,7 +54,7 @@ var _ = Describe("Domains", func() {
 		})
 
 		It("fails with a relevant error", func() {
-			err := commands.Domains(stdout, stderr, fakeBBSClient)
+			err := commands.Domains(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient)
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(Equal(models.ErrUnknownError))
 		})
@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	ErrorFormatText = "text"
+	ErrorFormatJSON = "json"
+)
+
+var (
+	errorFormat string
+	jsonErrors  bool
+)
+
+func init() {
+	RootCmd.PersistentFlags().StringVar(&errorFormat, "error-format", "", "format of the errors written to stderr, text or json [environment variable equivalent: CFDOT_ERROR_FORMAT]")
+	RootCmd.PersistentPreRunE = errorFormatPreHook
+	RootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		setErrorFormat(cmd)
+		return NewCFDotValidationError(cmd, err)
+	})
+
+	// Apply the environment variable straight away, so that errors raised
+	// before any flag is parsed, like an unknown command, are formatted too.
+	setErrorFormat(RootCmd)
+}
+
+func errorFormatPreHook(cmd *cobra.Command, args []string) error {
+	return setErrorFormat(cmd)
+}
+
+// setErrorFormat switches between cobra printing errors as text and
+// ReportError printing them as JSON, according to --error-format or
+// CFDOT_ERROR_FORMAT.
+func setErrorFormat(cmd *cobra.Command) error {
+	format := errorFormat
+	if format == "" {
+		format = os.Getenv("CFDOT_ERROR_FORMAT")
+	}
+
+	var err error
+	switch format {
+	case "", ErrorFormatText:
+		jsonErrors = false
+	case ErrorFormatJSON:
+		jsonErrors = true
+	default:
+		jsonErrors = false
+		err = NewCFDotValidationError(
+			cmd,
+			fmt.Errorf("The value '%s' is not a valid error format. Please specify text or json.", format),
+		)
+	}
+
+	RootCmd.SilenceErrors = jsonErrors
+	RootCmd.SilenceUsage = jsonErrors
+	return err
+}
+
+// ReportError writes err to w as a single line of JSON when errors are
+// formatted as JSON. In text mode cobra has already printed err.
+func ReportError(w io.Writer, err error) {
+	if !jsonErrors {
+		return
+	}
+
+	cfDotErr, ok := err.(CFDotError)
+	if !ok {
+		cfDotErr = CFDotError{err: err, exitCode: ExitCode(err)}
+	}
+
+	json.NewEncoder(w).Encode(cfDotErr)
+}
+
+// ExitCode returns the status cfdot exits with after failing with err, one of
+// the ExitCode constants.
+func ExitCode(err error) int {
+	if cfDotErr, ok := err.(CFDotError); ok {
+		return cfDotErr.ExitCode()
+	}
+
+	return ExitCodeError
+}
@@ -0,0 +1,95 @@
+package commands_test
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/cfdot/commands"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/spf13/cobra"
+)
+
+var _ = Describe("ErrorFormat", func() {
+	var (
+		output *gbytes.Buffer
+		err    error
+	)
+
+	setErrorFormat := func(format string) error {
+		Expect(commands.RootCmd.PersistentFlags().Set("error-format", format)).To(Succeed())
+		return commands.RootCmd.PersistentPreRunE(commands.RootCmd, []string{})
+	}
+
+	BeforeEach(func() {
+		output = gbytes.NewBuffer()
+		err = commands.NewCFDotValidationError(&cobra.Command{}, errors.New("Missing arguments"))
+	})
+
+	AfterEach(func() {
+		Expect(setErrorFormat("text")).To(Succeed())
+	})
+
+	Context("when errors are formatted as text", func() {
+		BeforeEach(func() {
+			Expect(setErrorFormat("text")).To(Succeed())
+		})
+
+		It("leaves printing the error to cobra", func() {
+			commands.ReportError(output, err)
+			Expect(output.Contents()).To(BeEmpty())
+			Expect(commands.RootCmd.SilenceErrors).To(BeFalse())
+		})
+	})
+
+	Context("when errors are formatted as JSON", func() {
+		BeforeEach(func() {
+			Expect(setErrorFormat("json")).To(Succeed())
+		})
+
+		It("silences cobra's error and usage messages", func() {
+			Expect(commands.RootCmd.SilenceErrors).To(BeTrue())
+			Expect(commands.RootCmd.SilenceUsage).To(BeTrue())
+		})
+
+		It("writes the error as a line of JSON", func() {
+			commands.ReportError(output, err)
+			Expect(output.Contents()).To(MatchJSON(`{"component":"validation","message":"Missing arguments","exit_code":3}`))
+		})
+
+		It("writes errors raised by cobra itself", func() {
+			commands.ReportError(output, errors.New(`unknown command "x" for "cfdot"`))
+			Expect(output.Contents()).To(MatchJSON(`{"message":"unknown command \"x\" for \"cfdot\"","exit_code":1}`))
+		})
+
+		It("writes invalid flag values as validation errors", func() {
+			err := commands.RootCmd.FlagErrorFunc()(commands.RootCmd, errors.New(`invalid argument "x" for "--timeout" flag`))
+			commands.ReportError(output, err)
+			Expect(output.Contents()).To(MatchJSON(`{"component":"validation","message":"invalid argument \"x\" for \"--timeout\" flag","exit_code":3}`))
+		})
+	})
+
+	Context("when the error format is not valid", func() {
+		It("returns a validation error", func() {
+			err := setErrorFormat("xml")
+			Expect(err).To(MatchError("The value 'xml' is not a valid error format. Please specify text or json."))
+			Expect(commands.ExitCode(err)).To(Equal(3))
+		})
+	})
+
+	Describe("ExitCode", func() {
+		It("returns the exit code of a CFDotError", func() {
+			Expect(commands.ExitCode(err)).To(Equal(3))
+		})
+
+		It("returns 3 for invalid flag values", func() {
+			err := commands.RootCmd.FlagErrorFunc()(commands.RootCmd, errors.New(`invalid argument "x" for "--timeout" flag`))
+			Expect(commands.ExitCode(err)).To(Equal(3))
+		})
+
+		It("returns 1 for other errors", func() {
+			Expect(commands.ExitCode(errors.New("unknown command"))).To(Equal(1))
+		})
+	})
+})
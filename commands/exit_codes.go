@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"strings"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Exit codes, one per class of error, so that scripts can tell for example a
+// task that does not exist from a BBS that is down.
+const (
+	// ExitCodeError is returned for errors raised by cobra itself, such as an
+	// unknown command.
+	ExitCodeError = 1
+	// ExitCodeValidation is returned for invalid arguments, flags or
+	// environment variables, and for requests the component rejected as
+	// invalid.
+	ExitCodeValidation = 3
+	// ExitCodeComponentError is returned when a component failed in a way not
+	// covered by a more specific exit code.
+	ExitCodeComponentError = 4
+	// ExitCodeFailure is returned when cfdot itself failed, for example to
+	// print a response, or was interrupted.
+	ExitCodeFailure = 5
+	// ExitCodeNotFound is returned when the requested resource does not exist.
+	ExitCodeNotFound = 6
+	// ExitCodeConflict is returned when the resource already exists or is not
+	// in a state that allows the requested change.
+	ExitCodeConflict = 7
+	// ExitCodeUnauthorized is returned when the TLS handshake failed or the
+	// component refused the client certificate.
+	ExitCodeUnauthorized = 8
+	// ExitCodeTimeout is returned when the command or a request timed out.
+	ExitCodeTimeout = 9
+	// ExitCodeUnreachable is returned when no connection to the component
+	// could be established.
+	ExitCodeUnreachable = 10
+	// ExitCodePartialFailure is returned when part of the output was printed
+	// but some of the requests it needed failed, like some cells in
+	// cell-states.
+	ExitCodePartialFailure = 11
+	// ExitCodeTaskFailed is returned by run-task when the task completed but
+	// failed.
+	ExitCodeTaskFailed = 12
+	// ExitCodeInstanceCrashed is returned by run-lrp --wait when an instance
+	// of the LRP crashed before all of them were running.
+	ExitCodeInstanceCrashed = 13
+)
+
+// exitCodeFor returns the exit code for the class of err or of any error it
+// wraps, or defaultCode when none of them belongs to a class.
+func exitCodeFor(err error, defaultCode int) int {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if code, ok := classifyError(err); ok {
+			return code
+		}
+	}
+	return defaultCode
+}
+
+func classifyError(err error) (int, bool) {
+	switch err {
+	case context.DeadlineExceeded:
+		return ExitCodeTimeout, true
+	case context.Canceled:
+		return ExitCodeFailure, true
+	case diego.ErrCellNotFound:
+		return ExitCodeNotFound, true
+	}
+
+	switch err := err.(type) {
+	case *models.Error:
+		return bbsErrorExitCode(err.Type)
+	case *TaskFailedError:
+		return ExitCodeTaskFailed, true
+	case *diego.InstanceCrashedError:
+		return ExitCodeInstanceCrashed, true
+	case *diego.UnreachableError, *net.DNSError:
+		return ExitCodeUnreachable, true
+	case x509.UnknownAuthorityError, x509.CertificateInvalidError, x509.HostnameError, tls.RecordHeaderError:
+		return ExitCodeUnauthorized, true
+	case *net.OpError:
+		switch {
+		case err.Timeout():
+			return ExitCodeTimeout, true
+		case err.Op == "dial":
+			return ExitCodeUnreachable, true
+		case err.Op == "remote error":
+			// The component sent a TLS alert, typically because it refused the
+			// client certificate.
+			return ExitCodeUnauthorized, true
+		}
+	case net.Error:
+		if err.Timeout() {
+			return ExitCodeTimeout, true
+		}
+	}
+
+	if s, ok := status.FromError(err); ok && s.Code() != codes.OK && s.Code() != codes.Unknown {
+		return grpcExitCode(s)
+	}
+
+	return 0, false
+}
+
+func bbsErrorExitCode(errType models.Error_Type) (int, bool) {
+	switch errType {
+	case models.Error_ResourceNotFound:
+		return ExitCodeNotFound, true
+	case models.Error_ResourceConflict,
+		models.Error_ResourceExists,
+		models.Error_InvalidStateTransition,
+		models.Error_ActualLRPCannotBeClaimed,
+		models.Error_ActualLRPCannotBeStarted,
+		models.Error_ActualLRPCannotBeCrashed,
+		models.Error_ActualLRPCannotBeFailed,
+		models.Error_ActualLRPCannotBeRemoved,
+		models.Error_ActualLRPCannotBeUnclaimed,
+		models.Error_RunningOnDifferentCell,
+		models.Error_LockCollision:
+		return ExitCodeConflict, true
+	case models.Error_InvalidDomain,
+		models.Error_InvalidRecord,
+		models.Error_InvalidRequest,
+		models.Error_InvalidJSON:
+		return ExitCodeValidation, true
+	case models.Error_Timeout:
+		return ExitCodeTimeout, true
+	case models.Error_RouterError:
+		return ExitCodeUnreachable, true
+	default:
+		return 0, false
+	}
+}
+
+func grpcExitCode(s *status.Status) (int, bool) {
+	switch s.Code() {
+	case codes.NotFound:
+		return ExitCodeNotFound, true
+	case codes.AlreadyExists, codes.Aborted, codes.FailedPrecondition:
+		return ExitCodeConflict, true
+	case codes.InvalidArgument, codes.OutOfRange:
+		return ExitCodeValidation, true
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return ExitCodeUnauthorized, true
+	case codes.DeadlineExceeded:
+		return ExitCodeTimeout, true
+	case codes.Unavailable:
+		// gRPC reports a failed TLS handshake as the server being unavailable.
+		if strings.Contains(s.Message(), "authentication handshake failed") {
+			return ExitCodeUnauthorized, true
+		}
+		return ExitCodeUnreachable, true
+	case codes.Canceled:
+		return ExitCodeFailure, true
+	default:
+		return 0, false
+	}
+}
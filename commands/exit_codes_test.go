@@ -0,0 +1,130 @@
+package commands_test
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/commands"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	locketmodels "code.cloudfoundry.org/locket/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+)
+
+var _ = Describe("ExitCodes", func() {
+	var cmd *cobra.Command
+
+	BeforeEach(func() {
+		cmd = &cobra.Command{}
+	})
+
+	exitCode := func(err error) int {
+		return commands.NewCFDotError(cmd, err).ExitCode()
+	}
+
+	Context("when the BBS returns an error", func() {
+		It("maps a missing resource to not found", func() {
+			Expect(exitCode(models.ErrResourceNotFound)).To(Equal(commands.ExitCodeNotFound))
+		})
+
+		It("maps existing resources and invalid state transitions to conflict", func() {
+			Expect(exitCode(models.ErrResourceExists)).To(Equal(commands.ExitCodeConflict))
+			Expect(exitCode(models.ErrResourceConflict)).To(Equal(commands.ExitCodeConflict))
+			Expect(exitCode(models.NewError(models.Error_InvalidStateTransition, "cannot cancel"))).To(Equal(commands.ExitCodeConflict))
+		})
+
+		It("maps invalid requests to validation", func() {
+			Expect(exitCode(models.ErrBadRequest)).To(Equal(commands.ExitCodeValidation))
+			Expect(exitCode(models.NewError(models.Error_InvalidRecord, "bad record"))).To(Equal(commands.ExitCodeValidation))
+		})
+
+		It("maps timeouts to timeout", func() {
+			Expect(exitCode(models.NewError(models.Error_Timeout, "timed out"))).To(Equal(commands.ExitCodeTimeout))
+		})
+
+		It("maps other errors to component error", func() {
+			Expect(exitCode(models.ErrUnknownError)).To(Equal(commands.ExitCodeComponentError))
+			Expect(exitCode(models.ErrDeadlock)).To(Equal(commands.ExitCodeComponentError))
+		})
+	})
+
+	Context("when Locket returns an error", func() {
+		It("maps a missing lock to not found", func() {
+			Expect(exitCode(locketmodels.ErrResourceNotFound)).To(Equal(commands.ExitCodeNotFound))
+		})
+
+		It("maps a lock collision to conflict", func() {
+			Expect(exitCode(locketmodels.ErrLockCollision)).To(Equal(commands.ExitCodeConflict))
+		})
+
+		It("maps an unavailable server to unreachable", func() {
+			Expect(exitCode(status.Error(codes.Unavailable, "connection refused"))).To(Equal(commands.ExitCodeUnreachable))
+		})
+
+		It("maps a failed TLS handshake to unauthorized", func() {
+			err := status.Error(codes.Unavailable, "connection error: desc = \"transport: authentication handshake failed: remote error: tls: bad certificate\"")
+			Expect(exitCode(err)).To(Equal(commands.ExitCodeUnauthorized))
+		})
+
+		It("maps an expired deadline to timeout", func() {
+			Expect(exitCode(status.Error(codes.DeadlineExceeded, "context deadline exceeded"))).To(Equal(commands.ExitCodeTimeout))
+		})
+
+		It("maps a failed connection to unreachable", func() {
+			err := &diego.UnreachableError{Address: "locket.service.cf.internal:8891", Err: context.DeadlineExceeded}
+			Expect(exitCode(err)).To(Equal(commands.ExitCodeUnreachable))
+		})
+	})
+
+	Context("when the connection fails", func() {
+		It("maps a refused connection to unreachable", func() {
+			err := &url.Error{Op: "Post", URL: "https://bbs.service.cf.internal:8889", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}
+			Expect(exitCode(err)).To(Equal(commands.ExitCodeUnreachable))
+		})
+
+		It("maps a failed DNS lookup to unreachable", func() {
+			err := &url.Error{Op: "Post", URL: "https://bbs.invalid:8889", Err: &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "bbs.invalid"}}}
+			Expect(exitCode(err)).To(Equal(commands.ExitCodeUnreachable))
+		})
+
+		It("maps an untrusted certificate to unauthorized", func() {
+			err := &url.Error{Op: "Post", URL: "https://bbs.service.cf.internal:8889", Err: x509.UnknownAuthorityError{}}
+			Expect(exitCode(err)).To(Equal(commands.ExitCodeUnauthorized))
+		})
+
+		It("maps a refused client certificate to unauthorized", func() {
+			err := &url.Error{Op: "Post", URL: "https://bbs.service.cf.internal:8889", Err: &net.OpError{Op: "remote error", Err: errors.New("tls: bad certificate")}}
+			Expect(exitCode(err)).To(Equal(commands.ExitCodeUnauthorized))
+		})
+	})
+
+	It("maps a missing cell to not found", func() {
+		Expect(exitCode(diego.ErrCellNotFound)).To(Equal(commands.ExitCodeNotFound))
+	})
+
+	It("maps a failed task to task failed", func() {
+		Expect(exitCode(&commands.TaskFailedError{TaskGuid: "task-guid", FailureReason: "exit status 1"})).To(Equal(commands.ExitCodeTaskFailed))
+	})
+
+	It("maps a crashed instance to instance crashed", func() {
+		Expect(exitCode(&diego.InstanceCrashedError{ProcessGuid: "process-guid", Index: 1, CrashReason: "exit status 1"})).To(Equal(commands.ExitCodeInstanceCrashed))
+	})
+
+	It("classifies wrapped errors by their cause", func() {
+		err := fmt.Errorf("Rep error: Failed to get cell state for cell cell-1: %w", context.DeadlineExceeded)
+		Expect(exitCode(err)).To(Equal(commands.ExitCodeTimeout))
+	})
+
+	It("maps other errors to failure", func() {
+		Expect(exitCode(errors.New("boom"))).To(Equal(commands.ExitCodeFailure))
+	})
+})
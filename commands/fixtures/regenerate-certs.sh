@@ -0,0 +1,19 @@
+#!/bin/bash
+
+set -e
+
+this_dir="$(cd $(dirname $0) && pwd)"
+
+pushd "$this_dir"
+
+openssl req -x509 -newkey rsa:2048 -nodes -days 36500 \
+  -subj "/CN=bbsCA" -keyout bbsCACert.key -out bbsCACert.crt
+
+openssl req -newkey rsa:2048 -nodes \
+  -subj "/CN=bbsClient" -keyout bbsClient.key -out bbsClient.csr
+openssl x509 -req -days 36500 -in bbsClient.csr \
+  -CA bbsCACert.crt -CAkey bbsCACert.key -CAcreateserial -out bbsClient.crt
+
+rm -f bbsCACert.key bbsCACert.srl bbsClient.csr
+
+popd
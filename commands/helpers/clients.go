@@ -1,109 +1,105 @@
 package helpers
 
 import (
-	"strings"
-	"time"
+	"fmt"
+	"sync"
 
 	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"code.cloudfoundry.org/lager"
-	"code.cloudfoundry.org/locket"
 	locketmodels "code.cloudfoundry.org/locket/models"
 	"code.cloudfoundry.org/rep"
 	"github.com/spf13/cobra"
 )
 
-const (
-	clientSessionCacheSize int = -1
-	maxIdleConnsPerHost    int = -1
-)
+type TLSConfig = diego.ClientConfig
+
+// cache holds the clients built for each configuration once
+// EnableClientCache has been called.
+var cache *clientCache
 
-type TLSConfig struct {
-	BBSUrl            string
-	LocketApiLocation string
-	CACertFile        string
-	CertFile          string
-	KeyFile           string
-	SkipCertVerify    bool
-	Timeout           int
+type clientCache struct {
+	mu                 sync.Mutex
+	bbsClients         map[TLSConfig]bbs.Client
+	repClientFactories map[TLSConfig]rep.ClientFactory
+	locketClients      map[TLSConfig]locketmodels.LocketClient
 }
 
-func NewBBSClient(cmd *cobra.Command, bbsClientConfig TLSConfig) (bbs.Client, error) {
-	var err error
-	var client bbs.Client
+// EnableClientCache makes NewBBSClient, NewRepClientFactory and
+// NewLocketClient return the client built by an earlier call with the same
+// configuration, so that the commands run from one cfdot shell share their
+// connections instead of reading the certificates and handshaking every time.
+func EnableClientCache() {
+	cache = &clientCache{
+		bbsClients:         map[TLSConfig]bbs.Client{},
+		repClientFactories: map[TLSConfig]rep.ClientFactory{},
+		locketClients:      map[TLSConfig]locketmodels.LocketClient{},
+	}
+}
 
-	if !strings.HasPrefix(bbsClientConfig.BBSUrl, "https") {
-		client, err = bbs.NewClientWithConfig(bbs.ClientConfig{
-			URL:            bbsClientConfig.BBSUrl,
-			Retries:        1,
-			RequestTimeout: time.Duration(bbsClientConfig.Timeout) * time.Second,
-		})
-	} else {
-		client, err = bbs.NewClientWithConfig(
-			bbs.ClientConfig{
-				URL:                    bbsClientConfig.BBSUrl,
-				IsTLS:                  true,
-				InsecureSkipVerify:     bbsClientConfig.SkipCertVerify,
-				CAFile:                 bbsClientConfig.CACertFile,
-				CertFile:               bbsClientConfig.CertFile,
-				KeyFile:                bbsClientConfig.KeyFile,
-				ClientSessionCacheSize: clientSessionCacheSize,
-				MaxIdleConnsPerHost:    maxIdleConnsPerHost,
-				Retries:                1,
-				RequestTimeout:         time.Duration(bbsClientConfig.Timeout) * time.Second,
-			},
-		)
+func NewBBSClient(cmd *cobra.Command, bbsClientConfig TLSConfig) (bbs.Client, error) {
+	if cache != nil {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		if client, ok := cache.bbsClients[bbsClientConfig]; ok {
+			return client, nil
+		}
 	}
 
+	client, err := diego.NewBBSClientWithFailover(bbsClientConfig, func(failedURL string, err error, nextURL string) {
+		fmt.Fprintf(cmd.OutOrStderr(), "BBS at %s is unreachable (%s), trying %s\n", failedURL, err, nextURL)
+	})
+	if err == nil && cache != nil {
+		cache.bbsClients[bbsClientConfig] = client
+	}
 	return client, err
 }
 
+func NewBBSEndpointClients(cmd *cobra.Command, bbsClientConfig TLSConfig) ([]string, []bbs.Client, error) {
+	urls := bbsClientConfig.BBSURLs()
+	clients := make([]bbs.Client, 0, len(urls))
+	for _, url := range urls {
+		client, err := diego.NewBBSEndpointClient(bbsClientConfig, url)
+		if err != nil {
+			return nil, nil, err
+		}
+		clients = append(clients, client)
+	}
+	return urls, clients, nil
+}
+
 func NewRepClient(clientFactory rep.ClientFactory, address, url string) (rep.Client, error) {
 	return clientFactory.CreateClient(address, url)
 }
 
-func NewLocketClient(logger lager.Logger, cmd *cobra.Command, locketClientConfig TLSConfig) (locketmodels.LocketClient, error) {
-	var err error
-	var client locketmodels.LocketClient
-	config := locket.ClientLocketConfig{
-		LocketAddress:        locketClientConfig.LocketApiLocation,
-		LocketCACertFile:     locketClientConfig.CACertFile,
-		LocketClientCertFile: locketClientConfig.CertFile,
-		LocketClientKeyFile:  locketClientConfig.KeyFile,
+func NewRepClientFactory(cmd *cobra.Command, repClientConfig TLSConfig) (rep.ClientFactory, error) {
+	if cache != nil {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		if factory, ok := cache.repClientFactories[repClientConfig]; ok {
+			return factory, nil
+		}
 	}
 
-	if locketClientConfig.SkipCertVerify {
-		client, err = locket.NewClientSkipCertVerify(
-			logger,
-			config,
-		)
-	} else {
-		client, err = locket.NewClient(
-			logger,
-			config,
-		)
+	factory, err := diego.NewRepClientFactory(repClientConfig)
+	if err == nil && cache != nil {
+		cache.repClientFactories[repClientConfig] = factory
 	}
-
-	return client, err
+	return factory, err
 }
 
-func (config *TLSConfig) Merge(newConfig TLSConfig) {
-	if newConfig.BBSUrl != "" {
-		config.BBSUrl = newConfig.BBSUrl
-	}
-	if newConfig.LocketApiLocation != "" {
-		config.LocketApiLocation = newConfig.LocketApiLocation
-	}
-	if newConfig.Timeout != 0 {
-		config.Timeout = newConfig.Timeout
-	}
-	if newConfig.KeyFile != "" {
-		config.KeyFile = newConfig.KeyFile
-	}
-	if newConfig.CACertFile != "" {
-		config.CACertFile = newConfig.CACertFile
+func NewLocketClient(logger lager.Logger, cmd *cobra.Command, locketClientConfig TLSConfig) (locketmodels.LocketClient, error) {
+	if cache != nil {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		if client, ok := cache.locketClients[locketClientConfig]; ok {
+			return client, nil
+		}
 	}
-	if newConfig.CertFile != "" {
-		config.CertFile = newConfig.CertFile
+
+	client, err := diego.NewLocketClient(logger, locketClientConfig)
+	if err == nil && cache != nil {
+		cache.locketClients[locketClientConfig] = client
 	}
-	config.SkipCertVerify = config.SkipCertVerify || newConfig.SkipCertVerify
+	return client, err
 }
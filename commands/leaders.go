@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"code.cloudfoundry.org/locket/models"
+
+	"github.com/spf13/cobra"
+)
+
+// flags
+var (
+	leadersWatchFlag       bool
+	leadersIntervalFlag    time.Duration
+	leadersHistoryFileFlag string
+)
+
+var leadersCmd = &cobra.Command{
+	Use:   "leaders",
+	Short: "Show the owners of well-known Locket locks",
+	Long:  "Show which instance currently holds each Locket lock, naming the Diego and CF components behind well-known lock keys and listing the well-known locks nobody holds as absent. Locket does not record when a lock was acquired, so the time each owner was first observed is only reported with --watch",
+	RunE:  leaders,
+}
+
+type Leader = diego.Leader
+type LeaderChange = diego.LeaderChange
+
+func init() {
+	AddLocketFlags(leadersCmd)
+	leadersCmd.Flags().BoolVarP(&leadersWatchFlag, "watch", "w", false, "keep polling Locket and print leadership changes as they happen")
+	leadersCmd.Flags().DurationVarP(&leadersIntervalFlag, "interval", "i", 5*time.Second, "polling interval used with --watch")
+	leadersCmd.Flags().StringVar(&leadersHistoryFileFlag, "history-file", "", "file to append leadership changes to when used with --watch")
+	RootCmd.AddCommand(leadersCmd)
+}
+
+func leaders(cmd *cobra.Command, args []string) error {
+	err := ValidateLeadersArguments(args, leadersWatchFlag, leadersIntervalFlag, leadersHistoryFileFlag)
+	if err != nil {
+		return NewCFDotValidationError(cmd, err)
+	}
+
+	logger := globalLogger.Session("locket-client")
+	locketClient, err := helpers.NewLocketClient(logger, cmd, Config)
+	if err != nil {
+		return NewCFDotComponentError(cmd, err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if !leadersWatchFlag {
+		err = Leaders(ctx, newPrinter(cmd), cmd.OutOrStderr(), locketClient)
+		if err != nil {
+			return NewCFDotComponentError(cmd, err)
+		}
+		return nil
+	}
+
+	var history io.Writer
+	if leadersHistoryFileFlag != "" {
+		historyFile, err := os.OpenFile(leadersHistoryFileFlag, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return NewCFDotValidationError(cmd, err)
+		}
+		defer historyFile.Close()
+		history = historyFile
+	}
+
+	err = WatchLeaders(ctx, newPrinter(cmd), cmd.OutOrStderr(), history, locketClient, leadersIntervalFlag)
+	if err != nil {
+		return NewCFDotComponentError(cmd, err)
+	}
+
+	return nil
+}
+
+func ValidateLeadersArguments(args []string, watch bool, interval time.Duration, historyFile string) error {
+	if len(args) > 0 {
+		return errExtraArguments
+	}
+
+	if watch && interval <= 0 {
+		return errors.New("interval should be a duration greater than zero")
+	}
+
+	if !watch && historyFile != "" {
+		return errors.New("--history-file can only be used with --watch")
+	}
+
+	return nil
+}
+
+func Leaders(ctx context.Context, printer Printer, stderr io.Writer, locketClient models.LocketClient) error {
+	logger := globalLogger.Session("leaders")
+
+	current, err := diego.Leaders(ctx, locketClient)
+	if err != nil {
+		return err
+	}
+
+	for _, leader := range current {
+		err = printer.Print(leader)
+		if err != nil {
+			logger.Error("failed-to-marshal", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WatchLeaders prints the current leaders and then polls Locket every
+// interval, printing a LeaderChange whenever a lock changes owner. Changes are
+// also appended to history when it is not nil. It returns nil once ctx is
+// cancelled.
+func WatchLeaders(ctx context.Context, printer Printer, stderr, history io.Writer, locketClient models.LocketClient, interval time.Duration) error {
+	logger := globalLogger.Session("watch-leaders")
+
+	var historyEncoder *json.Encoder
+	if history != nil {
+		historyEncoder = json.NewEncoder(history)
+	}
+
+	current, err := diego.Leaders(ctx, locketClient)
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	leaderHistory := diego.NewLeaderHistory(current, time.Now())
+	for _, leader := range current {
+		err = printer.Print(leader)
+		if err != nil {
+			logger.Error("failed-to-marshal", err)
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		latest, err := diego.Leaders(ctx, locketClient)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			logger.Error("failed-to-fetch-locks", err)
+			continue
+		}
+
+		for _, change := range leaderHistory.Observe(latest, time.Now()) {
+			err = printer.Print(change)
+			if err != nil {
+				logger.Error("failed-to-marshal", err)
+				return err
+			}
+
+			if historyEncoder != nil {
+				err = historyEncoder.Encode(change)
+				if err != nil {
+					logger.Error("failed-to-write-history", err)
+					return err
+				}
+			}
+		}
+	}
+}
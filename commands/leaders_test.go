@@ -0,0 +1,167 @@
+package commands_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/cfdot/commands"
+	"code.cloudfoundry.org/locket/models"
+	"code.cloudfoundry.org/locket/models/modelsfakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Leaders", func() {
+	var (
+		fakeLocketClient *modelsfakes.FakeLocketClient
+		stdout, stderr   *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		stdout = gbytes.NewBuffer()
+		stderr = gbytes.NewBuffer()
+		fakeLocketClient = &modelsfakes.FakeLocketClient{}
+		fakeLocketClient.FetchAllReturns(&models.FetchAllResponse{
+			Resources: []*models.Resource{
+				{Key: "some-lock", Owner: "some-owner", Value: "plain-value", TypeCode: models.LOCK},
+				{Key: "bbs", Owner: "bbs-instance-guid", Value: `{"address":"10.0.0.1"}`, TypeCode: models.LOCK},
+			},
+		}, nil)
+	})
+
+	Context("ValidateLeadersArguments", func() {
+		It("accepts no arguments", func() {
+			Expect(commands.ValidateLeadersArguments([]string{}, false, 0, "")).To(Succeed())
+		})
+
+		It("rejects extra arguments", func() {
+			err := commands.ValidateLeadersArguments([]string{"extra-arg"}, false, 0, "")
+			Expect(err).To(MatchError("Too many arguments specified"))
+		})
+
+		It("rejects a non-positive interval when watching", func() {
+			err := commands.ValidateLeadersArguments([]string{}, true, 0, "")
+			Expect(err).To(MatchError("interval should be a duration greater than zero"))
+		})
+
+		It("rejects a history file when not watching", func() {
+			err := commands.ValidateLeadersArguments([]string{}, false, time.Second, "history")
+			Expect(err).To(MatchError("--history-file can only be used with --watch"))
+		})
+	})
+
+	It("prints the owner of each lock and the absent well-known locks, sorted by key", func() {
+		err := commands.Leaders(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeLocketClient)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, req, _ := fakeLocketClient.FetchAllArgsForCall(0)
+		Expect(req).To(Equal(&models.FetchAllRequest{TypeCode: models.LOCK}))
+
+		var leaders []commands.Leader
+		decoder := json.NewDecoder(stdout)
+		for decoder.More() {
+			var leader commands.Leader
+			Expect(decoder.Decode(&leader)).To(Succeed())
+			leaders = append(leaders, leader)
+		}
+		Expect(leaders).To(HaveLen(7))
+
+		Expect(leaders[0].Key).To(Equal("auctioneer"))
+		Expect(leaders[0].Owner).To(BeEmpty())
+		Expect(leaders[0].Absent).To(BeTrue())
+
+		Expect(leaders[1].Key).To(Equal("bbs"))
+		Expect(leaders[1].Component).To(Equal("bbs"))
+		Expect(leaders[1].Owner).To(Equal("bbs-instance-guid"))
+		Expect(leaders[1].Absent).To(BeFalse())
+		Expect(leaders[1].Value).To(Equal(map[string]interface{}{"address": "10.0.0.1"}))
+		Expect(leaders[1].DefaultTTLInSeconds).To(BeEquivalentTo(15))
+		Expect(leaders[1].ObservedSince).To(BeNil())
+
+		Expect(leaders[5].Key).To(Equal("some-lock"))
+		Expect(leaders[5].Component).To(Equal("some-lock"))
+		Expect(leaders[5].Value).To(Equal("plain-value"))
+		Expect(leaders[5].DefaultTTLInSeconds).To(BeZero())
+	})
+
+	Context("when the locket errors", func() {
+		BeforeEach(func() {
+			fakeLocketClient.FetchAllReturns(nil, errors.New("boom"))
+		})
+
+		It("fails with a relevant error", func() {
+			err := commands.Leaders(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeLocketClient)
+			Expect(err).To(MatchError("boom"))
+		})
+	})
+
+	Context("WatchLeaders", func() {
+		var (
+			history *gbytes.Buffer
+			ctx     context.Context
+			cancel  context.CancelFunc
+			errCh   chan error
+		)
+
+		BeforeEach(func() {
+			history = gbytes.NewBuffer()
+			ctx, cancel = context.WithCancel(context.Background())
+			errCh = make(chan error, 1)
+
+			fakeLocketClient.FetchAllReturnsOnCall(0, &models.FetchAllResponse{
+				Resources: []*models.Resource{
+					{Key: "bbs", Owner: "bbs-1", TypeCode: models.LOCK},
+					{Key: "auctioneer", Owner: "auctioneer-1", TypeCode: models.LOCK},
+				},
+			}, nil)
+			fakeLocketClient.FetchAllReturns(&models.FetchAllResponse{
+				Resources: []*models.Resource{
+					{Key: "bbs", Owner: "bbs-2", TypeCode: models.LOCK},
+				},
+			}, nil)
+		})
+
+		JustBeforeEach(func() {
+			go func() {
+				errCh <- commands.WatchLeaders(ctx, commands.NewJSONPrinter(stdout), stderr, history, fakeLocketClient, 10*time.Millisecond)
+			}()
+		})
+
+		AfterEach(func() {
+			cancel()
+			Eventually(errCh).Should(Receive(BeNil()))
+		})
+
+		It("prints the initial leaders with the time they were first observed", func() {
+			Eventually(stdout).Should(gbytes.Say(`"key":"auctioneer","component":"auctioneer","owner":"auctioneer-1","default_ttl_in_seconds":15,"observed_since":`))
+			Eventually(stdout).Should(gbytes.Say(`"key":"bbs","component":"bbs","owner":"bbs-1","default_ttl_in_seconds":15,"observed_since":`))
+		})
+
+		It("prints and records leadership changes", func() {
+			Eventually(stdout).Should(gbytes.Say(`"key":"auctioneer","component":"auctioneer","previous_owner":"auctioneer-1","owner":""`))
+			Eventually(stdout).Should(gbytes.Say(`"key":"bbs","component":"bbs","previous_owner":"bbs-1","owner":"bbs-2"`))
+
+			Eventually(history).Should(gbytes.Say(`"key":"auctioneer","component":"auctioneer","previous_owner":"auctioneer-1","owner":""`))
+			Eventually(history).Should(gbytes.Say(`"key":"bbs","component":"bbs","previous_owner":"bbs-1","owner":"bbs-2"`))
+		})
+
+		It("only reports a change once", func() {
+			Eventually(fakeLocketClient.FetchAllCallCount).Should(BeNumerically(">", 3))
+
+			var changes []commands.LeaderChange
+			decoder := json.NewDecoder(history)
+			for {
+				var change commands.LeaderChange
+				if decoder.Decode(&change) != nil {
+					break
+				}
+				changes = append(changes, change)
+			}
+			Expect(changes).To(HaveLen(2))
+		})
+	})
+})
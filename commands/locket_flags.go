@@ -9,6 +9,7 @@ import (
 
 var (
 	locketApiLocation string
+	locketTimeout     int
 )
 
 // errors
@@ -19,6 +20,7 @@ var (
 func AddLocketFlags(cmd *cobra.Command) {
 	AddTLSFlags(cmd)
 	cmd.Flags().StringVar(&locketApiLocation, "locketAPILocation", "", "Hostname:Port of Locket server to target [environment variable equivalent: LOCKET_API_LOCATION]")
+	cmd.Flags().IntVar(&locketTimeout, "locket-timeout", 0, "timeout for Locket requests in seconds [environment variable equivalent: CFDOT_LOCKET_TIMEOUT]")
 	cmd.PreRunE = LocketPrehook
 }
 
@@ -38,5 +40,12 @@ func setLocketFlags(cmd *cobra.Command, args []string) error {
 	if Config.LocketApiLocation == "" {
 		return NewCFDotValidationError(cmd, errMissingLocketUrl)
 	}
+
+	timeout, err := timeoutFromEnv(cmd, "locket-timeout", locketTimeout, "CFDOT_LOCKET_TIMEOUT")
+	if err != nil {
+		return err
+	}
+
+	Config.LocketTimeout = timeout
 	return nil
 }
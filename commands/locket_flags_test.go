@@ -89,4 +89,49 @@ var _ = Describe("Locket Flags", func() {
 			})
 		})
 	})
+
+	Describe("locket-timeout", func() {
+		Context("when the --locket-timeout flag is given", func() {
+			BeforeEach(func() {
+				validTLSFlags["--locket-timeout"] = "7"
+				parseFlagsErr := dummyCmd.ParseFlags(buildArgList(validTLSFlags))
+				Expect(parseFlagsErr).NotTo(HaveOccurred())
+			})
+
+			It("sets the timeout in the configuration", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(commands.Config.LocketTimeout).To(Equal(7))
+			})
+		})
+
+		Context("when a negative --locket-timeout flag is given", func() {
+			BeforeEach(func() {
+				validTLSFlags["--locket-timeout"] = "-7"
+				parseFlagsErr := dummyCmd.ParseFlags(buildArgList(validTLSFlags))
+				Expect(parseFlagsErr).NotTo(HaveOccurred())
+			})
+
+			It("returns a validation error", func() {
+				Expect(err).To(MatchError("--locket-timeout must be a non-negative number of seconds"))
+				Expect(err.(commands.CFDotError).ExitCode()).To(Equal(3))
+			})
+		})
+
+		Context("when a CFDOT_LOCKET_TIMEOUT environment variable is specified", func() {
+			BeforeEach(func() {
+				parseFlagsErr := dummyCmd.ParseFlags(buildArgList(validTLSFlags))
+				Expect(parseFlagsErr).NotTo(HaveOccurred())
+				os.Setenv("CFDOT_LOCKET_TIMEOUT", "9")
+			})
+
+			AfterEach(func() {
+				os.Unsetenv("CFDOT_LOCKET_TIMEOUT")
+			})
+
+			It("sets the timeout in the configuration", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(commands.Config.LocketTimeout).To(Equal(9))
+			})
+		})
+	})
 })
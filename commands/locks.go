@@ -2,10 +2,10 @@ package commands
 
 import (
 	"context"
-	"encoding/json"
 	"io"
 
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"code.cloudfoundry.org/locket/models"
 
 	"github.com/spf13/cobra"
@@ -35,8 +35,12 @@ func locks(cmd *cobra.Command, args []string) error {
 		return NewCFDotComponentError(cmd, err)
 	}
 
+	ctx, cancel := commandContext()
+	defer cancel()
+
 	err = Locks(
-		cmd.OutOrStdout(),
+		ctx,
+		newPrinter(cmd),
 		cmd.OutOrStderr(),
 		locketClient,
 	)
@@ -54,19 +58,16 @@ func ValidateLocksArguments(args []string) error {
 	return nil
 }
 
-func Locks(stdout, stderr io.Writer, locketClient models.LocketClient) error {
+func Locks(ctx context.Context, printer Printer, stderr io.Writer, locketClient models.LocketClient) error {
 	logger := globalLogger.Session("locks")
 
-	encoder := json.NewEncoder(stdout)
-
-	req := &models.FetchAllRequest{TypeCode: models.LOCK}
-	resp, err := locketClient.FetchAll(context.Background(), req)
+	locks, err := diego.Locks(ctx, locketClient)
 	if err != nil {
 		return err
 	}
 
-	for _, lock := range resp.Resources {
-		err = encoder.Encode(lock)
+	for _, lock := range locks {
+		err = printer.Print(lock)
 		if err != nil {
 			logger.Error("failed-to-marshal", err)
 			return err
@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 
@@ -44,7 +45,7 @@ var _ = Describe("Locks", func() {
 		})
 
 		It("prints a json stream of all the locks", func() {
-			err := commands.Locks(stdout, stderr, fakeLocketClient)
+			err := commands.Locks(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeLocketClient)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeLocketClient.FetchAllCallCount()).To(Equal(1))
@@ -66,7 +67,7 @@ var _ = Describe("Locks", func() {
 		})
 
 		It("fails with a relevant error", func() {
-			err := commands.Locks(stdout, stderr, fakeLocketClient)
+			err := commands.Locks(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeLocketClient)
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(Equal(errors.New("boom")))
 		})
@@ -1,14 +1,13 @@
 package commands
 
 import (
-	"encoding/json"
+	"context"
 	"io"
 
 	"code.cloudfoundry.org/bbs"
-	"code.cloudfoundry.org/bbs/events"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/cfdot/commands/helpers"
-	multierror "github.com/hashicorp/go-multierror"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"github.com/spf13/cobra"
 )
 
@@ -56,8 +55,11 @@ func lrpEvents(cmd *cobra.Command, args []string) error {
 		return NewCFDotError(cmd, err)
 	}
 
-	err = LRPEvents(cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, lrpEventsCellIdFlag, lrpEventsExcludeActualLRPGroups)
-	if err != nil {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	err = LRPEvents(ctx, newPrinter(cmd), cmd.OutOrStderr(), bbsClient, lrpEventsCellIdFlag, lrpEventsExcludeActualLRPGroups)
+	if err != nil && !isInterrupted(err) {
 		return NewCFDotError(cmd, err)
 	}
 	return nil
@@ -70,88 +72,19 @@ func validateLRPEventsArguments(args []string) error {
 	return nil
 }
 
-func LRPEvents(stdout, stderr io.Writer, bbsClient bbs.Client, cellID string, excludeActualLRPGroups bool) error {
+func LRPEvents(ctx context.Context, printer Printer, stderr io.Writer, bbsClient bbs.Client, cellID string, excludeActualLRPGroups bool) error {
 	logger := globalLogger.Session("lrp-events")
 
-	oldEventStream := make(chan models.Event)
-	newEventStream := make(chan models.Event)
-	oldErrChan := make(chan error)
-	newErrChan := make(chan error)
-
-	readEvent := func(es events.EventSource, eventStreamChan chan models.Event, errChan chan error) {
-		for {
-			event, err := es.Next()
-			if err != nil {
-				errChan <- err
-				return
-			}
-			eventStreamChan <- event
-		}
-	}
-
-	encoder := json.NewEncoder(stdout)
-	eventStreamCount := 1
-
-	if !excludeActualLRPGroups {
-		oldES, err := bbsClient.SubscribeToEventsByCellID(logger, cellID)
-		if err != nil {
-			return models.ConvertError(err)
-		}
-		defer oldES.Close()
-
-		eventStreamCount += 1
-
-		go readEvent(oldES, oldEventStream, oldErrChan)
-	}
-
-	instanceES, err := bbsClient.SubscribeToInstanceEventsByCellID(logger, cellID)
-	if err != nil {
-		return models.ConvertError(err)
-	}
-	defer instanceES.Close()
-
-	go readEvent(instanceES, newEventStream, newErrChan)
-
-	ret := &multierror.Error{}
-	var event models.Event
 	var lrpEvent LRPEvent
-	for {
-		var err error
-		select {
-		case e := <-oldEventStream:
-			switch e.EventType() {
-			case models.EventTypeActualLRPCreated, models.EventTypeActualLRPChanged, models.EventTypeActualLRPRemoved:
-				event = e
-			default:
-				continue
-			}
-		case event = <-newEventStream:
-		case err = <-oldErrChan:
-			multierror.Append(ret, err)
-		case err = <-newErrChan:
-			multierror.Append(ret, err)
-		}
-
-		if len(ret.Errors) >= eventStreamCount {
-			for _, err := range ret.Errors {
-				if err != io.EOF {
-					return ret.ErrorOrNil()
-				}
-			}
-			return nil
-		}
-
-		if err != nil {
-			continue
-		}
-
+	return diego.LRPEvents(ctx, logger, bbsClient, cellID, !excludeActualLRPGroups, func(event models.Event) error {
 		lrpEvent.Type = event.EventType()
 		lrpEvent.Data = event
-		err = encoder.Encode(lrpEvent)
+		err := printer.Print(lrpEvent)
 		if err != nil {
 			logger.Error("failed-to-marshal", err)
 		}
-	}
+		return nil
+	})
 }
 
 func printLRPGroupEventsWarning(stderr io.Writer) error {
@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
@@ -82,7 +83,7 @@ var _ = Describe("LRP Events", func() {
 			eventString(models.NewActualLRPInstanceRemovedEvent(actualLRP)),
 		}
 
-		err := commands.LRPEvents(stdout, stderr, fakeBBSClient, "", false)
+		err := commands.LRPEvents(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "", false)
 		Expect(err).NotTo(HaveOccurred())
 
 		stdoutData := strings.TrimSpace(string(stdout.Contents()))
@@ -99,7 +100,7 @@ var _ = Describe("LRP Events", func() {
 				eventString(models.NewActualLRPInstanceRemovedEvent(actualLRP)),
 			}
 
-			err := commands.LRPEvents(stdout, stderr, fakeBBSClient, "", true)
+			err := commands.LRPEvents(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "", true)
 			Expect(err).NotTo(HaveOccurred())
 
 			stdoutData := strings.TrimSpace(string(stdout.Contents()))
@@ -126,7 +127,7 @@ var _ = Describe("LRP Events", func() {
 			})
 
 			It("dedups them in the output", func() {
-				err := commands.LRPEvents(stdout, stderr, fakeBBSClient, "", false)
+				err := commands.LRPEvents(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "", false)
 				Expect(err).NotTo(HaveOccurred())
 
 				desiredLRPEvent := commands.LRPEvent{
@@ -148,7 +149,7 @@ var _ = Describe("LRP Events", func() {
 			})
 
 			It("dedups them in the output", func() {
-				err := commands.LRPEvents(stdout, stderr, fakeBBSClient, "", false)
+				err := commands.LRPEvents(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "", false)
 				Expect(err).NotTo(HaveOccurred())
 
 				desiredLRPEvent := commands.LRPEvent{
@@ -170,7 +171,7 @@ var _ = Describe("LRP Events", func() {
 			})
 
 			It("dedups them in the output", func() {
-				err := commands.LRPEvents(stdout, stderr, fakeBBSClient, "", false)
+				err := commands.LRPEvents(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "", false)
 				Expect(err).NotTo(HaveOccurred())
 
 				desiredLRPEvent := commands.LRPEvent{
@@ -188,7 +189,7 @@ var _ = Describe("LRP Events", func() {
 	})
 
 	It("closes the event streams", func() {
-		err := commands.LRPEvents(stdout, stderr, fakeBBSClient, "", false)
+		err := commands.LRPEvents(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "", false)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeEventSource.CloseCallCount()).To(Equal(1))
@@ -201,7 +202,7 @@ var _ = Describe("LRP Events", func() {
 		})
 
 		It("returns an error", func() {
-			err := commands.LRPEvents(stdout, stderr, fakeBBSClient, "", false)
+			err := commands.LRPEvents(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "", false)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("failed to connect"))
 		})
@@ -214,7 +215,7 @@ var _ = Describe("LRP Events", func() {
 		})
 
 		It("returns an error", func() {
-			err := commands.LRPEvents(stdout, stderr, fakeBBSClient, "", false)
+			err := commands.LRPEvents(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "", false)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("boom"))
 		})
@@ -0,0 +1,187 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	locketmodels "code.cloudfoundry.org/locket/models"
+	"github.com/spf13/cobra"
+)
+
+var orphansCmd = &cobra.Command{
+	Use:   "orphans",
+	Short: "List instances and tasks on vanished cells",
+	Long:  "List the actual LRPs and the running tasks placed on a cell that is no longer registered in the BBS, or holds no Locket presence when --locketAPILocation is set, along with how long they have been there. With --retire the orphaned instances are retired and with --fail the orphaned tasks are cancelled, which fails them, after confirmation unless --yes is given",
+	RunE:  orphans,
+}
+
+// OrphansOptions selects the remediation applied to the orphans found.
+type OrphansOptions struct {
+	Retire bool
+	Fail   bool
+	Yes    bool
+}
+
+// flags
+var (
+	orphansLocketAPILocationFlag string
+	orphansDomainFlag            string
+	orphansRetireFlag            bool
+	orphansFailFlag              bool
+	orphansYesFlag               bool
+)
+
+func init() {
+	AddBBSAndTimeoutFlags(orphansCmd)
+	orphansCmd.Flags().StringVar(&orphansLocketAPILocationFlag, "locketAPILocation", "", "Hostname:Port of Locket server whose presences a cell must hold to be live, Locket is not checked if unset [environment variable equivalent: LOCKET_API_LOCATION]")
+	orphansCmd.Flags().StringVarP(&orphansDomainFlag, "domain", "d", "", "only look for the actual lrps and tasks of the given domain")
+	orphansCmd.Flags().BoolVar(&orphansRetireFlag, "retire", false, "retire the orphaned instances")
+	orphansCmd.Flags().BoolVar(&orphansFailFlag, "fail", false, "fail the orphaned tasks by cancelling them")
+	orphansCmd.Flags().BoolVarP(&orphansYesFlag, "yes", "y", false, "do not ask for confirmation before --retire or --fail")
+	RootCmd.AddCommand(orphansCmd)
+}
+
+func orphans(cmd *cobra.Command, args []string) error {
+	err := ValidateOrphansArguments(args)
+	if err != nil {
+		return NewCFDotValidationError(cmd, err)
+	}
+
+	bbsClient, err := helpers.NewBBSClient(cmd, Config)
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+
+	// The Locket location is optional here, so it is kept out of the global
+	// Config, which the Locket commands run later in a shell rely on.
+	locketConfig := Config
+	locketConfig.LocketApiLocation = orphansLocketAPILocationFlag
+	if locketConfig.LocketApiLocation == "" {
+		locketConfig.LocketApiLocation = os.Getenv("LOCKET_API_LOCATION")
+	}
+
+	var locketClient locketmodels.LocketClient
+	if locketConfig.LocketApiLocation != "" {
+		locketClient, err = helpers.NewLocketClient(globalLogger.Session("locket-client"), cmd, locketConfig)
+		if err != nil {
+			return NewCFDotComponentError(cmd, err)
+		}
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	options := OrphansOptions{Retire: orphansRetireFlag, Fail: orphansFailFlag, Yes: orphansYesFlag}
+	err = Orphans(ctx, newPrinter(cmd), cmd.InOrStdin(), cmd.OutOrStderr(), bbsClient, locketClient, orphansDomainFlag, options)
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+	return nil
+}
+
+func ValidateOrphansArguments(args []string) error {
+	if len(args) > 0 {
+		return errExtraArguments
+	}
+	return nil
+}
+
+// Orphans prints the diego.Orphans of domain, or of every domain if empty,
+// checking the cells against Locket unless locketClient is nil. It then
+// applies the remediation selected by options, asking for confirmation on
+// stdin unless options.Yes is set, and writes its progress to stderr.
+// Evacuating instances are left to the BBS, as retiring their key would
+// retire the ordinary instance replacing them.
+func Orphans(ctx context.Context, printer Printer, stdin io.Reader, stderr io.Writer, bbsClient bbs.Client, locketClient locketmodels.LocketClient, domain string, options OrphansOptions) error {
+	logger := globalLogger.Session("orphans")
+
+	liveCells, err := diego.LiveCells(ctx, logger, bbsClient, locketClient)
+	if err != nil {
+		return err
+	}
+	actualLRPs, err := diego.ActualLRPs(ctx, logger, bbsClient, models.ActualLRPFilter{Domain: domain})
+	if err != nil {
+		return err
+	}
+	tasks, err := diego.Tasks(ctx, logger, bbsClient, models.TaskFilter{Domain: domain})
+	if err != nil {
+		return err
+	}
+
+	found := diego.FindOrphans(actualLRPs, tasks, liveCells, time.Now())
+	err = printer.Print(found)
+	if err != nil {
+		return err
+	}
+
+	var instances []diego.OrphanedInstance
+	if options.Retire {
+		for _, instance := range found.Instances {
+			if !instance.Evacuating {
+				instances = append(instances, instance)
+			}
+		}
+	}
+	var orphanedTasks []diego.OrphanedTask
+	if options.Fail {
+		orphanedTasks = found.Tasks
+	}
+	if len(instances) == 0 && len(orphanedTasks) == 0 {
+		return nil
+	}
+
+	if !options.Yes {
+		prompt := fmt.Sprintf("Retire %d orphaned instances and fail %d orphaned tasks? [y/N] ", len(instances), len(orphanedTasks))
+		if !confirm(bufio.NewReader(stdin), stderr, prompt) {
+			fmt.Fprintln(stderr, "Nothing was changed")
+			return nil
+		}
+	}
+
+	failed := 0
+	for _, instance := range instances {
+		err := diego.RetireActualLRP(ctx, logger, bbsClient, instance.ProcessGuid, instance.Index)
+		if err != nil {
+			failed++
+			fmt.Fprintf(stderr, "Failed to retire instance %d of %s on cell %s: %s\n", instance.Index, instance.ProcessGuid, instance.CellID, err)
+			continue
+		}
+		fmt.Fprintf(stderr, "Retired instance %d of %s on cell %s\n", instance.Index, instance.ProcessGuid, instance.CellID)
+	}
+	for _, task := range orphanedTasks {
+		err := diego.CancelTask(ctx, logger, bbsClient, task.TaskGuid)
+		if err != nil {
+			failed++
+			fmt.Fprintf(stderr, "Failed to fail task %s on cell %s: %s\n", task.TaskGuid, task.CellID, err)
+			continue
+		}
+		fmt.Fprintf(stderr, "Failed task %s on cell %s\n", task.TaskGuid, task.CellID)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d orphans could not be cleaned up", failed, len(instances)+len(orphanedTasks))
+	}
+	return nil
+}
+
+// confirm writes prompt to out and reports whether the line read from in is
+// yes.
+func confirm(in *bufio.Reader, out io.Writer, prompt string) bool {
+	fmt.Fprint(out, prompt)
+	answer, _ := in.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
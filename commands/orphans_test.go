@@ -0,0 +1,143 @@
+package commands_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/commands"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Orphans", func() {
+	Context("ValidateOrphansArguments", func() {
+		It("accepts no arguments", func() {
+			Expect(commands.ValidateOrphansArguments([]string{})).To(Succeed())
+		})
+
+		It("rejects arguments", func() {
+			Expect(commands.ValidateOrphansArguments([]string{"foo"})).To(MatchError("Too many arguments specified"))
+		})
+	})
+
+	Context("Orphans", func() {
+		var (
+			fakeBBSClient  *fake_bbs.FakeClient
+			stdout, stderr *gbytes.Buffer
+		)
+
+		BeforeEach(func() {
+			fakeBBSClient = &fake_bbs.FakeClient{}
+			stdout = gbytes.NewBuffer()
+			stderr = gbytes.NewBuffer()
+
+			now := time.Now()
+			evacuating := &models.ActualLRP{
+				ActualLRPKey:         models.NewActualLRPKey("guid-2", 0, "cf-apps"),
+				ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-guid-3", "cell-gone"),
+				State:                models.ActualLRPStateRunning,
+				Presence:             models.ActualLRP_Evacuating,
+				Since:                now.UnixNano(),
+			}
+			fakeBBSClient.CellsReturns([]*models.CellPresence{{CellId: "cell-1"}}, nil)
+			fakeBBSClient.ActualLRPsReturns([]*models.ActualLRP{
+				{
+					ActualLRPKey:         models.NewActualLRPKey("guid-1", 0, "cf-apps"),
+					ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-guid-1", "cell-1"),
+					State:                models.ActualLRPStateRunning,
+					Since:                now.UnixNano(),
+				},
+				{
+					ActualLRPKey:         models.NewActualLRPKey("guid-1", 1, "cf-apps"),
+					ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-guid-2", "cell-gone"),
+					State:                models.ActualLRPStateRunning,
+					Since:                now.UnixNano(),
+				},
+				evacuating,
+			}, nil)
+			fakeBBSClient.TasksWithFilterReturns([]*models.Task{
+				{TaskGuid: "task-1", Domain: "cf-apps", CellId: "cell-gone", State: models.Task_Running, UpdatedAt: now.UnixNano()},
+			}, nil)
+			fakeBBSClient.DesiredLRPByProcessGuidReturns(&models.DesiredLRP{ProcessGuid: "guid-1", Domain: "cf-apps"}, nil)
+		})
+
+		It("prints the orphans of the domain without changing anything", func() {
+			err := commands.Orphans(context.Background(), commands.NewJSONPrinter(stdout), strings.NewReader(""), stderr, fakeBBSClient, nil, "cf-apps", commands.OrphansOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, filter := fakeBBSClient.ActualLRPsArgsForCall(0)
+			Expect(filter).To(Equal(models.ActualLRPFilter{Domain: "cf-apps"}))
+			_, taskFilter := fakeBBSClient.TasksWithFilterArgsForCall(0)
+			Expect(taskFilter).To(Equal(models.TaskFilter{Domain: "cf-apps"}))
+
+			var orphans diego.Orphans
+			Expect(json.Unmarshal(stdout.Contents(), &orphans)).To(Succeed())
+			Expect(orphans.LiveCells).To(Equal(1))
+			Expect(orphans.Instances).To(HaveLen(2))
+			Expect(orphans.Tasks).To(HaveLen(1))
+
+			Expect(fakeBBSClient.RetireActualLRPCallCount()).To(Equal(0))
+			Expect(fakeBBSClient.CancelTaskCallCount()).To(Equal(0))
+		})
+
+		It("retires the ordinary orphaned instances and fails the orphaned tasks once confirmed", func() {
+			options := commands.OrphansOptions{Retire: true, Fail: true}
+			err := commands.Orphans(context.Background(), commands.NewJSONPrinter(stdout), strings.NewReader("y\n"), stderr, fakeBBSClient, nil, "", options)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stderr).To(gbytes.Say(`Retire 1 orphaned instances and fail 1 orphaned tasks\? \[y/N\]`))
+			Expect(fakeBBSClient.RetireActualLRPCallCount()).To(Equal(1))
+			_, key := fakeBBSClient.RetireActualLRPArgsForCall(0)
+			Expect(key).To(Equal(&models.ActualLRPKey{ProcessGuid: "guid-1", Index: 1, Domain: "cf-apps"}))
+			Expect(fakeBBSClient.CancelTaskCallCount()).To(Equal(1))
+			_, taskGuid := fakeBBSClient.CancelTaskArgsForCall(0)
+			Expect(taskGuid).To(Equal("task-1"))
+
+			Expect(stderr).To(gbytes.Say("Retired instance 1 of guid-1 on cell cell-gone"))
+			Expect(stderr).To(gbytes.Say("Failed task task-1 on cell cell-gone"))
+		})
+
+		It("changes nothing when not confirmed", func() {
+			options := commands.OrphansOptions{Retire: true, Fail: true}
+			err := commands.Orphans(context.Background(), commands.NewJSONPrinter(stdout), strings.NewReader("n\n"), stderr, fakeBBSClient, nil, "", options)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stderr).To(gbytes.Say("Nothing was changed"))
+			Expect(fakeBBSClient.RetireActualLRPCallCount()).To(Equal(0))
+			Expect(fakeBBSClient.CancelTaskCallCount()).To(Equal(0))
+		})
+
+		It("does not ask for confirmation with Yes", func() {
+			options := commands.OrphansOptions{Fail: true, Yes: true}
+			err := commands.Orphans(context.Background(), commands.NewJSONPrinter(stdout), strings.NewReader(""), stderr, fakeBBSClient, nil, "", options)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stderr).NotTo(gbytes.Say(`\[y/N\]`))
+			Expect(fakeBBSClient.RetireActualLRPCallCount()).To(Equal(0))
+			Expect(fakeBBSClient.CancelTaskCallCount()).To(Equal(1))
+		})
+
+		It("returns an error when some orphans could not be cleaned up", func() {
+			fakeBBSClient.CancelTaskReturns(models.ErrUnknownError)
+
+			options := commands.OrphansOptions{Retire: true, Fail: true, Yes: true}
+			err := commands.Orphans(context.Background(), commands.NewJSONPrinter(stdout), strings.NewReader(""), stderr, fakeBBSClient, nil, "", options)
+			Expect(err).To(MatchError("1 of 2 orphans could not be cleaned up"))
+			Expect(stderr).To(gbytes.Say("Failed to fail task task-1 on cell cell-gone"))
+		})
+
+		It("returns the BBS errors", func() {
+			fakeBBSClient.CellsReturns(nil, models.ErrUnknownError)
+
+			err := commands.Orphans(context.Background(), commands.NewJSONPrinter(stdout), strings.NewReader(""), stderr, fakeBBSClient, nil, "", commands.OrphansOptions{})
+			Expect(err).To(Equal(models.ErrUnknownError))
+		})
+	})
+})
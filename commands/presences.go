@@ -2,10 +2,10 @@ package commands
 
 import (
 	"context"
-	"encoding/json"
 	"io"
 
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"code.cloudfoundry.org/locket/models"
 
 	"github.com/spf13/cobra"
@@ -35,8 +35,12 @@ func presences(cmd *cobra.Command, args []string) error {
 		return NewCFDotComponentError(cmd, err)
 	}
 
+	ctx, cancel := commandContext()
+	defer cancel()
+
 	err = Presences(
-		cmd.OutOrStdout(),
+		ctx,
+		newPrinter(cmd),
 		cmd.OutOrStderr(),
 		locketClient,
 	)
@@ -54,19 +58,16 @@ func ValidatePresencesArguments(args []string) error {
 	return nil
 }
 
-func Presences(stdout, stderr io.Writer, locketClient models.LocketClient) error {
+func Presences(ctx context.Context, printer Printer, stderr io.Writer, locketClient models.LocketClient) error {
 	logger := globalLogger.Session("presences")
 
-	encoder := json.NewEncoder(stdout)
-
-	req := &models.FetchAllRequest{TypeCode: models.PRESENCE}
-	resp, err := locketClient.FetchAll(context.Background(), req)
+	presences, err := diego.Presences(ctx, locketClient)
 	if err != nil {
 		return err
 	}
 
-	for _, presence := range resp.Resources {
-		err = encoder.Encode(presence)
+	for _, presence := range presences {
+		err = printer.Print(presence)
 		if err != nil {
 			logger.Error("failed-to-marshal", err)
 			return err
@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 
@@ -44,7 +45,7 @@ var _ = Describe("Presences", func() {
 		})
 
 		It("prints a json stream of all the locks", func() {
-			err := commands.Presences(stdout, stderr, fakeLocketClient)
+			err := commands.Presences(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeLocketClient)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeLocketClient.FetchAllCallCount()).To(Equal(1))
@@ -66,7 +67,7 @@ var _ = Describe("Presences", func() {
 		})
 
 		It("fails with a relevant error", func() {
-			err := commands.Presences(stdout, stderr, fakeLocketClient)
+			err := commands.Presences(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeLocketClient)
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(Equal(errors.New("boom")))
 		})
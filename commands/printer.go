@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+// Printer receives the typed values produced by cfdot commands, such as
+// *models.Task or rep.CellState. Tools embedding this package can provide
+// their own implementation to consume results directly instead of parsing
+// the JSON written by the default JSONPrinter.
+type Printer interface {
+	Print(value interface{}) error
+}
+
+// PrinterFunc adapts an ordinary function to the Printer interface.
+type PrinterFunc func(value interface{}) error
+
+func (f PrinterFunc) Print(value interface{}) error {
+	return f(value)
+}
+
+// JSONPrinter writes every value it receives as a single line of JSON. It is
+// the Printer used by the cfdot CLI.
+type JSONPrinter struct {
+	encoder *json.Encoder
+}
+
+func NewJSONPrinter(w io.Writer) *JSONPrinter {
+	return &JSONPrinter{encoder: json.NewEncoder(w)}
+}
+
+func (p *JSONPrinter) Print(value interface{}) error {
+	return p.encoder.Encode(value)
+}
+
+func newPrinter(cmd *cobra.Command) Printer {
+	return NewJSONPrinter(cmd.OutOrStdout())
+}
@@ -0,0 +1,65 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/commands"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Printer", func() {
+	Context("JSONPrinter", func() {
+		It("writes each value as a line of json", func() {
+			stdout := gbytes.NewBuffer()
+			printer := commands.NewJSONPrinter(stdout)
+
+			Expect(printer.Print(&models.Task{TaskGuid: "task-1"})).To(Succeed())
+			Expect(printer.Print("some-domain")).To(Succeed())
+
+			Expect(stdout).To(gbytes.Say(`{"task_guid":"task-1".*}\n`))
+			Expect(stdout).To(gbytes.Say(`"some-domain"\n`))
+		})
+	})
+
+	Context("PrinterFunc", func() {
+		var fakeBBSClient *fake_bbs.FakeClient
+
+		BeforeEach(func() {
+			fakeBBSClient = &fake_bbs.FakeClient{}
+			fakeBBSClient.TasksWithFilterReturns([]*models.Task{
+				{TaskGuid: "task-1"},
+				{TaskGuid: "task-2"},
+			}, nil)
+		})
+
+		It("receives the typed values produced by a command", func() {
+			tasks := []*models.Task{}
+			printer := commands.PrinterFunc(func(value interface{}) error {
+				tasks = append(tasks, value.(*models.Task))
+				return nil
+			})
+
+			err := commands.Tasks(context.Background(), printer, gbytes.NewBuffer(), fakeBBSClient, "", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(Equal([]*models.Task{
+				{TaskGuid: "task-1"},
+				{TaskGuid: "task-2"},
+			}))
+		})
+
+		It("surfaces errors returned by the printer", func() {
+			printer := commands.PrinterFunc(func(interface{}) error {
+				return errors.New("boom")
+			})
+
+			err := commands.Tasks(context.Background(), printer, gbytes.NewBuffer(), fakeBBSClient, "", "")
+			Expect(err).To(MatchError("boom"))
+		})
+	})
+})
@@ -6,6 +6,7 @@ import (
 	"io"
 
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"code.cloudfoundry.org/locket/models"
 	"github.com/spf13/cobra"
 )
@@ -36,7 +37,11 @@ func releaseLock(cmd *cobra.Command, args []string) error {
 		return NewCFDotComponentError(cmd, err)
 	}
 
+	ctx, cancel := commandContext()
+	defer cancel()
+
 	err = ReleaseLock(
+		ctx,
 		cmd.OutOrStdout(),
 		cmd.OutOrStderr(),
 		locketClient,
@@ -79,19 +84,14 @@ func ValidateReleaseLocksArguments(cmd *cobra.Command, args []string, lockKey, l
 }
 
 func ReleaseLock(
+	ctx context.Context,
 	stdout, stderr io.Writer,
 	locketClient models.LocketClient,
 	lockKey, lockOwner string,
 ) error {
 	logger := globalLogger.Session("release-lock")
 
-	req := &models.ReleaseRequest{
-		Resource: &models.Resource{
-			Key:   lockKey,
-			Owner: lockOwner,
-		},
-	}
-	_, err := locketClient.Release(context.Background(), req)
+	err := diego.ReleaseLock(ctx, locketClient, lockKey, lockOwner)
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"context"
 	"errors"
 
 	"code.cloudfoundry.org/cfdot/commands"
@@ -32,6 +33,7 @@ var _ = Describe("ReleaseLock", func() {
 
 		It("should release the lock successfully", func() {
 			err := commands.ReleaseLock(
+				context.Background(),
 				stdout, stderr, fakeLocketClient, "key", "owner")
 			Expect(err).NotTo(HaveOccurred())
 
@@ -54,6 +56,7 @@ var _ = Describe("ReleaseLock", func() {
 
 		It("should return an error", func() {
 			err := commands.ReleaseLock(
+				context.Background(),
 				stdout, stderr, fakeLocketClient, "key", "owner")
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(Equal(errors.New("random-error")))
@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	repTimeout int
+)
+
+// AddRepTimeoutFlag adds --rep-timeout to a command that talks to the cells'
+// rep servers. It must be called after the command's other flags are added,
+// since it runs after their PreRunE.
+func AddRepTimeoutFlag(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&repTimeout, "rep-timeout", 0, "timeout for rep state requests in seconds, 10 if unset [environment variable equivalent: CFDOT_REP_TIMEOUT]")
+
+	preRunE := cmd.PreRunE
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if preRunE != nil {
+			if err := preRunE(cmd, args); err != nil {
+				return err
+			}
+		}
+		return setRepTimeoutFlag(cmd, args)
+	}
+}
+
+func setRepTimeoutFlag(cmd *cobra.Command, args []string) error {
+	timeout, err := timeoutFromEnv(cmd, "rep-timeout", repTimeout, "CFDOT_REP_TIMEOUT")
+	if err != nil {
+		return err
+	}
+
+	Config.RepTimeout = timeout
+	return nil
+}
@@ -0,0 +1,81 @@
+package commands_test
+
+import (
+	"os"
+
+	"code.cloudfoundry.org/cfdot/commands"
+	"github.com/spf13/cobra"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rep Timeout Flag", func() {
+	var (
+		validFlags map[string]string
+		dummyCmd   *cobra.Command
+		err        error
+	)
+
+	BeforeEach(func() {
+		dummyCmd = &cobra.Command{
+			Use: "dummy",
+			Run: func(cmd *cobra.Command, args []string) {},
+		}
+		commands.AddBBSAndTimeoutFlags(dummyCmd)
+		commands.AddRepTimeoutFlag(dummyCmd)
+
+		validFlags = map[string]string{
+			"--bbsURL":         "https://example.com",
+			"--skipCertVerify": "false",
+			"--caCertFile":     "fixtures/bbsCACert.crt",
+			"--clientCertFile": "fixtures/bbsClient.crt",
+			"--clientKeyFile":  "fixtures/bbsClient.key",
+		}
+	})
+
+	JustBeforeEach(func() {
+		err = dummyCmd.PreRunE(dummyCmd, dummyCmd.Flags().Args())
+	})
+
+	Context("when --rep-timeout is passed in as an argument", func() {
+		BeforeEach(func() {
+			validFlags["--rep-timeout"] = "30"
+			parseFlagsErr := dummyCmd.ParseFlags(buildArgList(validFlags))
+			Expect(parseFlagsErr).NotTo(HaveOccurred())
+		})
+
+		It("sets the timeout in the configuration", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(commands.Config.RepTimeout).To(Equal(30))
+		})
+	})
+
+	Context("when CFDOT_REP_TIMEOUT is set", func() {
+		BeforeEach(func() {
+			os.Setenv("CFDOT_REP_TIMEOUT", "20")
+			parseFlagsErr := dummyCmd.ParseFlags(buildArgList(validFlags))
+			Expect(parseFlagsErr).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.Unsetenv("CFDOT_REP_TIMEOUT")
+		})
+
+		It("sets the timeout in the configuration", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(commands.Config.RepTimeout).To(Equal(20))
+		})
+
+		Context("and is not a number", func() {
+			BeforeEach(func() {
+				os.Setenv("CFDOT_REP_TIMEOUT", "soon")
+			})
+
+			It("returns a validation error", func() {
+				Expect(err).To(MatchError("The value 'soon' is not a valid value for CFDOT_REP_TIMEOUT. Please specify a non-negative number of seconds."))
+				Expect(err.(commands.CFDotError).ExitCode()).To(Equal(3))
+			})
+		})
+	})
+})
@@ -1,12 +1,13 @@
 package commands
 
 import (
+	"context"
 	"io"
 	"strconv"
 
 	"code.cloudfoundry.org/bbs"
-	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"github.com/spf13/cobra"
 )
 
@@ -33,7 +34,10 @@ func retireActualLRP(cmd *cobra.Command, args []string) error {
 		return NewCFDotError(cmd, err)
 	}
 
-	err = RetireActualLRP(cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, processGuid, int32(index))
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	err = RetireActualLRP(ctx, cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, processGuid, int32(index))
 	if err != nil {
 		return NewCFDotError(cmd, err)
 	}
@@ -62,19 +66,8 @@ func ValidateRetireActualLRPArgs(args []string) (string, int, error) {
 	return args[0], index, nil
 }
 
-func RetireActualLRP(stdout, stderr io.Writer, bbsClient bbs.Client, processGuid string, index int32) error {
+func RetireActualLRP(ctx context.Context, stdout, stderr io.Writer, bbsClient bbs.Client, processGuid string, index int32) error {
 	logger := globalLogger.Session("retire-actual-lrp")
 
-	desiredLRP, err := bbsClient.DesiredLRPByProcessGuid(logger, processGuid)
-	if err != nil {
-		return err
-	}
-
-	actualLRPKey := models.ActualLRPKey{ProcessGuid: processGuid, Index: index, Domain: desiredLRP.Domain}
-	err = bbsClient.RetireActualLRP(logger, &actualLRPKey)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return diego.RetireActualLRP(ctx, logger, bbsClient, processGuid, index)
 }
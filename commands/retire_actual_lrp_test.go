@@ -1,6 +1,8 @@
 package commands_test
 
 import (
+	"context"
+
 	"code.cloudfoundry.org/bbs/fake_bbs"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/cfdot/commands"
@@ -72,7 +74,7 @@ var _ = Describe("RetireActualLRP", func() {
 		})
 
 		It("retires the actual lrp", func() {
-			err := commands.RetireActualLRP(stdout, stderr, fakeBBSClient, "process-guid", 1)
+			err := commands.RetireActualLRP(context.Background(), stdout, stderr, fakeBBSClient, "process-guid", 1)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeBBSClient.DesiredLRPByProcessGuidCallCount()).To(Equal(1))
@@ -94,7 +96,7 @@ var _ = Describe("RetireActualLRP", func() {
 			})
 
 			It("fails with a relevant error ", func() {
-				err := commands.RetireActualLRP(stdout, stderr, fakeBBSClient, "process-guid", 2)
+				err := commands.RetireActualLRP(context.Background(), stdout, stderr, fakeBBSClient, "process-guid", 2)
 				Expect(err).To(HaveOccurred())
 				Expect(err).To(Equal(models.ErrUnknownError))
 			})
@@ -106,7 +108,7 @@ var _ = Describe("RetireActualLRP", func() {
 			})
 
 			It("fails with a relevant error ", func() {
-				err := commands.RetireActualLRP(stdout, stderr, fakeBBSClient, "process-guid", 2)
+				err := commands.RetireActualLRP(context.Background(), stdout, stderr, fakeBBSClient, "process-guid", 2)
 				Expect(err).To(HaveOccurred())
 				Expect(err).To(Equal(models.ErrUnknownError))
 
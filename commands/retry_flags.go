@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"github.com/spf13/cobra"
+)
+
+var (
+	retryAttempts   int
+	retryBackoff    time.Duration
+	retryMaxBackoff time.Duration
+	retryMutations  bool
+)
+
+func addRetryFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&retryAttempts, "retry-attempts", diego.DefaultRetryPolicy.MaxAttempts, "maximum number of attempts for a BBS request failing with a transient error, 1 disables retries [environment variable equivalent: CFDOT_RETRY_ATTEMPTS]")
+	cmd.Flags().DurationVar(&retryBackoff, "retry-backoff", diego.DefaultRetryPolicy.InitialBackoff, "wait before the first retry of a BBS request, doubled for every later retry [environment variable equivalent: CFDOT_RETRY_BACKOFF]")
+	cmd.Flags().DurationVar(&retryMaxBackoff, "retry-max-backoff", diego.DefaultRetryPolicy.MaxBackoff, "longest wait between retries of a BBS request [environment variable equivalent: CFDOT_RETRY_MAX_BACKOFF]")
+	cmd.Flags().BoolVar(&retryMutations, "retry-mutations", false, "also retry BBS requests that change state, which may apply them more than once [environment variable equivalent: CFDOT_RETRY_MUTATIONS]")
+}
+
+func setRetryFlags(cmd *cobra.Command, args []string) error {
+	var err error
+
+	// Only look at the environment variables if the flags have not been set.
+	if !cmd.Flags().Lookup("retry-attempts").Changed && os.Getenv("CFDOT_RETRY_ATTEMPTS") != "" {
+		retryAttempts, err = strconv.Atoi(os.Getenv("CFDOT_RETRY_ATTEMPTS"))
+		if err != nil {
+			return invalidRetryEnvError(cmd, "CFDOT_RETRY_ATTEMPTS", "a positive number of attempts")
+		}
+	}
+
+	if !cmd.Flags().Lookup("retry-backoff").Changed && os.Getenv("CFDOT_RETRY_BACKOFF") != "" {
+		retryBackoff, err = time.ParseDuration(os.Getenv("CFDOT_RETRY_BACKOFF"))
+		if err != nil {
+			return invalidRetryEnvError(cmd, "CFDOT_RETRY_BACKOFF", "a duration such as 500ms")
+		}
+	}
+
+	if !cmd.Flags().Lookup("retry-max-backoff").Changed && os.Getenv("CFDOT_RETRY_MAX_BACKOFF") != "" {
+		retryMaxBackoff, err = time.ParseDuration(os.Getenv("CFDOT_RETRY_MAX_BACKOFF"))
+		if err != nil {
+			return invalidRetryEnvError(cmd, "CFDOT_RETRY_MAX_BACKOFF", "a duration such as 5s")
+		}
+	}
+
+	if !cmd.Flags().Lookup("retry-mutations").Changed && os.Getenv("CFDOT_RETRY_MUTATIONS") != "" {
+		retryMutations, err = strconv.ParseBool(os.Getenv("CFDOT_RETRY_MUTATIONS"))
+		if err != nil {
+			return invalidRetryEnvError(cmd, "CFDOT_RETRY_MUTATIONS", "a boolean such as true or false")
+		}
+	}
+
+	switch {
+	case retryAttempts < 1:
+		return NewCFDotValidationError(cmd, errors.New("--retry-attempts must be at least 1"))
+	case retryBackoff < 0:
+		return NewCFDotValidationError(cmd, errors.New("--retry-backoff must not be negative"))
+	case retryMaxBackoff < retryBackoff:
+		return NewCFDotValidationError(cmd, errors.New("--retry-max-backoff must not be less than --retry-backoff"))
+	}
+
+	Config.Retry = diego.RetryPolicy{
+		MaxAttempts:    retryAttempts,
+		InitialBackoff: retryBackoff,
+		MaxBackoff:     retryMaxBackoff,
+		RetryMutations: retryMutations,
+	}
+	return nil
+}
+
+func invalidRetryEnvError(cmd *cobra.Command, envVar, expected string) error {
+	return NewCFDotValidationError(
+		cmd,
+		fmt.Errorf("The value '%s' is not a valid value for %s. Please specify %s.", os.Getenv(envVar), envVar, expected),
+	)
+}
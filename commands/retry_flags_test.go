@@ -0,0 +1,127 @@
+package commands_test
+
+import (
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/cfdot/commands"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"github.com/spf13/cobra"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Retry Flags", func() {
+	var (
+		validFlags map[string]string
+		dummyCmd   *cobra.Command
+		err        error
+	)
+
+	BeforeEach(func() {
+		dummyCmd = &cobra.Command{
+			Use: "dummy",
+			Run: func(cmd *cobra.Command, args []string) {},
+		}
+		commands.AddBBSFlags(dummyCmd)
+
+		validFlags = map[string]string{
+			"--bbsURL":         "https://example.com",
+			"--skipCertVerify": "false",
+			"--caCertFile":     "fixtures/bbsCACert.crt",
+			"--clientCertFile": "fixtures/bbsClient.crt",
+			"--clientKeyFile":  "fixtures/bbsClient.key",
+		}
+	})
+
+	JustBeforeEach(func() {
+		Expect(dummyCmd.ParseFlags(buildArgList(validFlags))).To(Succeed())
+		err = dummyCmd.PreRunE(dummyCmd, dummyCmd.Flags().Args())
+	})
+
+	It("uses the default policy for reads", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(commands.Config.Retry).To(Equal(diego.DefaultRetryPolicy))
+	})
+
+	Context("when flags are passed in as arguments", func() {
+		BeforeEach(func() {
+			validFlags["--retry-attempts"] = "5"
+			validFlags["--retry-backoff"] = "1s"
+			validFlags["--retry-max-backoff"] = "10s"
+			validFlags["--retry-mutations"] = "true"
+		})
+
+		It("sets the policy in the configuration", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(commands.Config.Retry).To(Equal(diego.RetryPolicy{
+				MaxAttempts:    5,
+				InitialBackoff: time.Second,
+				MaxBackoff:     10 * time.Second,
+				RetryMutations: true,
+			}))
+		})
+	})
+
+	Context("when env vars are set", func() {
+		BeforeEach(func() {
+			os.Setenv("CFDOT_RETRY_ATTEMPTS", "4")
+			os.Setenv("CFDOT_RETRY_MUTATIONS", "true")
+		})
+
+		AfterEach(func() {
+			os.Unsetenv("CFDOT_RETRY_ATTEMPTS")
+			os.Unsetenv("CFDOT_RETRY_MUTATIONS")
+		})
+
+		It("sets the policy in the configuration", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(commands.Config.Retry.MaxAttempts).To(Equal(4))
+			Expect(commands.Config.Retry.RetryMutations).To(BeTrue())
+		})
+
+		Context("when the flag is also given", func() {
+			BeforeEach(func() {
+				validFlags["--retry-attempts"] = "2"
+			})
+
+			It("uses the value from the flag instead of the environment variable", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(commands.Config.Retry.MaxAttempts).To(Equal(2))
+			})
+		})
+
+		Context("when an env var is invalid", func() {
+			BeforeEach(func() {
+				os.Setenv("CFDOT_RETRY_ATTEMPTS", "many")
+			})
+
+			It("returns a validation error", func() {
+				Expect(err).To(MatchError("The value 'many' is not a valid value for CFDOT_RETRY_ATTEMPTS. Please specify a positive number of attempts."))
+				Expect(err.(commands.CFDotError).ExitCode()).To(Equal(3))
+			})
+		})
+	})
+
+	Context("when --retry-attempts is less than 1", func() {
+		BeforeEach(func() {
+			validFlags["--retry-attempts"] = "0"
+		})
+
+		It("returns a validation error", func() {
+			Expect(err).To(MatchError("--retry-attempts must be at least 1"))
+		})
+	})
+
+	Context("when --retry-max-backoff is less than --retry-backoff", func() {
+		BeforeEach(func() {
+			validFlags["--retry-backoff"] = "2s"
+			validFlags["--retry-max-backoff"] = "1s"
+		})
+
+		It("returns a validation error", func() {
+			Expect(err).To(MatchError("--retry-max-backoff must not be less than --retry-backoff"))
+		})
+	})
+})
@@ -0,0 +1,266 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"github.com/spf13/cobra"
+)
+
+var runLRPCmd = &cobra.Command{
+	Use:   "run-lrp [flags] -- PATH [ARGS...]",
+	Short: "Run a desired LRP built from flags",
+	Long:  "Build a desired LRP running PATH with ARGS in a Docker image or a rootfs from the given flags and create it. The LRP listens on --port, given to it as $PORT, which is health checked, and each --route HOST[:PORT] is routed to it by the cf-router. With --wait the command waits for all the instances to be running, at most --timeout seconds if given and 5 minutes otherwise, and fails with exit code 13 as soon as one of them crashes",
+	RunE:  runLRP,
+}
+
+// runLRPPollInterval is how often run-lrp --wait checks the instances.
+const runLRPPollInterval = time.Second
+
+// runLRPDefaultWaitTimeout bounds run-lrp --wait when --timeout is not set,
+// so that instances which are never placed do not hang the command.
+const runLRPDefaultWaitTimeout = 5 * time.Minute
+
+// RunLRPOptions are the flags of run-lrp that make up the desired LRP.
+type RunLRPOptions struct {
+	ProcessGuid string
+	Domain      string
+	DockerImage string
+	RootFS      string
+	User        string
+	Instances   int32
+	MemoryMB    int32
+	DiskMB      int32
+	Port        uint32
+	Routes      []string
+	Env         []string
+}
+
+// flags
+var (
+	runLRPFlags    RunLRPOptions
+	runLRPWaitFlag bool
+)
+
+// errors
+var (
+	errMissingLRPCommand    = errors.New("Missing command to run, expected -- PATH [ARGS...]")
+	errMissingLRPDomain     = errors.New("--domain must not be empty")
+	errMissingLRPImage      = errors.New("Missing --docker-image or --rootfs")
+	errLRPImageAndRootFS    = errors.New("Pass either --docker-image or --rootfs, not both")
+	errNegativeLRPInstances = errors.New("--instances must not be negative")
+	errNegativeLRPResources = errors.New("--memory-mb and --disk-mb must not be negative")
+	errInvalidLRPPort       = errors.New("--port must be between 1 and 65535")
+	errLRPCommandBeforeDash = errors.New("The command to run must follow --")
+)
+
+func init() {
+	AddBBSAndTimeoutFlags(runLRPCmd)
+	runLRPCmd.Flags().StringVar(&runLRPFlags.ProcessGuid, "process-guid", "", "process guid of the desired LRP, generated if not given")
+	runLRPCmd.Flags().StringVar(&runLRPFlags.Domain, "domain", "cfdot", "domain of the desired LRP")
+	runLRPCmd.Flags().StringVar(&runLRPFlags.DockerImage, "docker-image", "", "Docker image to run, e.g. nginx or busybox:1.32")
+	runLRPCmd.Flags().StringVar(&runLRPFlags.RootFS, "rootfs", "", "rootfs to run in instead of a Docker image, e.g. preloaded:cflinuxfs3")
+	runLRPCmd.Flags().StringVar(&runLRPFlags.User, "user", "", "user running the command, root for a Docker image and vcap otherwise if not given")
+	runLRPCmd.Flags().Int32Var(&runLRPFlags.Instances, "instances", 1, "number of instances")
+	runLRPCmd.Flags().Int32Var(&runLRPFlags.MemoryMB, "memory-mb", 256, "memory limit of each instance in MB")
+	runLRPCmd.Flags().Int32Var(&runLRPFlags.DiskMB, "disk-mb", 1024, "disk limit of each instance in MB")
+	runLRPCmd.Flags().Uint32Var(&runLRPFlags.Port, "port", 8080, "port the command listens on")
+	runLRPCmd.Flags().StringArrayVar(&runLRPFlags.Routes, "route", nil, "HOST[:PORT] route of the cf-router to the LRP, PORT defaults to --port, can be repeated")
+	runLRPCmd.Flags().StringArrayVar(&runLRPFlags.Env, "env", nil, "NAME=VALUE environment variable of the command, can be repeated")
+	runLRPCmd.Flags().BoolVar(&runLRPWaitFlag, "wait", false, "wait for all the instances to be running")
+	RootCmd.AddCommand(runLRPCmd)
+}
+
+func runLRP(cmd *cobra.Command, args []string) error {
+	if cmd.ArgsLenAtDash() > 0 {
+		return NewCFDotValidationError(cmd, errLRPCommandBeforeDash)
+	}
+
+	lrp, err := ValidateRunLRPArguments(args, runLRPFlags)
+	if err != nil {
+		return NewCFDotValidationError(cmd, err)
+	}
+
+	bbsClient, err := helpers.NewBBSClient(cmd, Config)
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	if runLRPWaitFlag && Config.Timeout <= 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, runLRPDefaultWaitTimeout)
+		defer cancelTimeout()
+	}
+
+	err = RunLRP(ctx, newPrinter(cmd), cmd.OutOrStderr(), bbsClient, lrp, runLRPWaitFlag)
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+
+	return nil
+}
+
+// ValidateRunLRPArguments builds the desired LRP running the command in args
+// with options, generating its process guid unless one is given.
+func ValidateRunLRPArguments(args []string, options RunLRPOptions) (*models.DesiredLRP, error) {
+	if len(args) == 0 || args[0] == "" {
+		return nil, errMissingLRPCommand
+	}
+	if options.Domain == "" {
+		return nil, errMissingLRPDomain
+	}
+	if options.DockerImage == "" && options.RootFS == "" {
+		return nil, errMissingLRPImage
+	}
+	if options.DockerImage != "" && options.RootFS != "" {
+		return nil, errLRPImageAndRootFS
+	}
+	if options.Instances < 0 {
+		return nil, errNegativeLRPInstances
+	}
+	if options.MemoryMB < 0 || options.DiskMB < 0 {
+		return nil, errNegativeLRPResources
+	}
+	if options.Port == 0 || options.Port > 65535 {
+		return nil, errInvalidLRPPort
+	}
+
+	env, err := parseEnv(options.Env)
+	if err != nil {
+		return nil, err
+	}
+	env = append(env, &models.EnvironmentVariable{Name: "PORT", Value: strconv.Itoa(int(options.Port))})
+
+	rootFS, user := options.RootFS, options.User
+	if options.DockerImage != "" {
+		rootFS = "docker:///" + options.DockerImage
+		if user == "" {
+			user = "root"
+		}
+	}
+	if user == "" {
+		user = "vcap"
+	}
+
+	processGuid := options.ProcessGuid
+	if processGuid == "" {
+		processGuid, err = newGuid("cfdot-run-lrp")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	lrp := &models.DesiredLRP{
+		ProcessGuid: processGuid,
+		Domain:      options.Domain,
+		LogGuid:     processGuid,
+		RootFs:      rootFS,
+		Instances:   options.Instances,
+		MemoryMb:    options.MemoryMB,
+		DiskMb:      options.DiskMB,
+		Ports:       []uint32{options.Port},
+		Action: models.WrapAction(&models.RunAction{
+			Path: args[0],
+			Args: args[1:],
+			User: user,
+			Env:  env,
+		}),
+		CheckDefinition: &models.CheckDefinition{
+			Checks: []*models.Check{
+				{TcpCheck: &models.TCPCheck{Port: options.Port}},
+			},
+		},
+		StartTimeoutMs: 60000,
+	}
+
+	var routes []diego.CFRoute
+	for _, route := range options.Routes {
+		cfRoute, err := parseRunLRPRoute(route, options.Port)
+		if err != nil {
+			return nil, err
+		}
+		if !containsPort(lrp.Ports, cfRoute.Port) {
+			lrp.Ports = append(lrp.Ports, cfRoute.Port)
+		}
+		routes = append(routes, cfRoute)
+	}
+	if len(routes) > 0 {
+		lrp.Routes, err = diego.EditCFRoutes(lrp, routes, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	spec, err := json.Marshal(lrp)
+	if err != nil {
+		return nil, err
+	}
+	return lrp, checkSpecs(SpecKindDesiredLRP, [][]byte{spec})
+}
+
+// RunLRP creates lrp and prints it. With wait, it then waits for all of its
+// instances to be running, writing their progress to stderr, and fails with
+// a *diego.InstanceCrashedError if one of them crashes.
+func RunLRP(ctx context.Context, printer Printer, stderr io.Writer, bbsClient bbs.Client, lrp *models.DesiredLRP, wait bool) error {
+	logger := globalLogger.Session("run-lrp")
+
+	printSpecWarnings(stderr, diego.CheckDesiredLRP(lrp))
+
+	err := diego.DesireLRP(ctx, logger, bbsClient, lrp)
+	if err != nil {
+		return err
+	}
+
+	err = printer.Print(lrp)
+	if err != nil {
+		return err
+	}
+
+	if !wait {
+		return nil
+	}
+
+	last := -1
+	return diego.WaitForRunningInstances(ctx, logger, bbsClient, lrp.ProcessGuid, int(lrp.Instances), runLRPPollInterval, func(running int) {
+		if running != last {
+			fmt.Fprintf(stderr, "%d of %d instances of %s running\n", running, lrp.Instances, lrp.ProcessGuid)
+			last = running
+		}
+	})
+}
+
+// parseRunLRPRoute parses a route given as HOST[:PORT], where PORT defaults to
+// defaultPort.
+func parseRunLRPRoute(route string, defaultPort uint32) (diego.CFRoute, error) {
+	host, port := route, strconv.Itoa(int(defaultPort))
+	if i := strings.LastIndex(route, ":"); i >= 0 {
+		host, port = route[:i], route[i+1:]
+	}
+
+	cfRoute, err := diego.ParseCFRoute(diego.CFRouter + ":" + host + ":" + port)
+	if err != nil {
+		return diego.CFRoute{}, fmt.Errorf("Invalid route '%s', expected HOST[:PORT]", route)
+	}
+	return cfRoute, nil
+}
+
+func containsPort(ports []uint32, port uint32) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
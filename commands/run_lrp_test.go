@@ -0,0 +1,175 @@
+package commands_test
+
+import (
+	"context"
+
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/commands"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("RunLRP", func() {
+	var options commands.RunLRPOptions
+
+	BeforeEach(func() {
+		options = commands.RunLRPOptions{
+			ProcessGuid: "process-guid",
+			Domain:      "test",
+			DockerImage: "nginx",
+			Instances:   2,
+			MemoryMB:    256,
+			DiskMB:      1024,
+			Port:        8080,
+		}
+	})
+
+	Context("ValidateRunLRPArguments", func() {
+		It("builds a desired LRP running the command in the image", func() {
+			options.Env = []string{"A=1"}
+
+			lrp, err := commands.ValidateRunLRPArguments([]string{"nginx", "-g", "daemon off;"}, options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lrp.ProcessGuid).To(Equal("process-guid"))
+			Expect(lrp.Domain).To(Equal("test"))
+			Expect(lrp.RootFs).To(Equal("docker:///nginx"))
+			Expect(lrp.Instances).To(Equal(int32(2)))
+			Expect(lrp.MemoryMb).To(Equal(int32(256)))
+			Expect(lrp.DiskMb).To(Equal(int32(1024)))
+			Expect(lrp.Ports).To(Equal([]uint32{8080}))
+			Expect(lrp.Routes).To(BeNil())
+			Expect(lrp.Action.RunAction).To(Equal(&models.RunAction{
+				Path: "nginx",
+				Args: []string{"-g", "daemon off;"},
+				User: "root",
+				Env: []*models.EnvironmentVariable{
+					{Name: "A", Value: "1"},
+					{Name: "PORT", Value: "8080"},
+				},
+			}))
+			Expect(lrp.CheckDefinition.Checks).To(Equal([]*models.Check{
+				{TcpCheck: &models.TCPCheck{Port: 8080}},
+			}))
+		})
+
+		It("runs as vcap in a rootfs", func() {
+			options.DockerImage = ""
+			options.RootFS = "preloaded:cflinuxfs3"
+
+			lrp, err := commands.ValidateRunLRPArguments([]string{"ls"}, options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lrp.RootFs).To(Equal("preloaded:cflinuxfs3"))
+			Expect(lrp.Action.RunAction.User).To(Equal("vcap"))
+		})
+
+		It("routes the hosts to their ports", func() {
+			options.Routes = []string{"a.example.com", "b.example.com:8080", "c.example.com:9090"}
+
+			lrp, err := commands.ValidateRunLRPArguments([]string{"ls"}, options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lrp.Ports).To(Equal([]uint32{8080, 9090}))
+			Expect(string(*(*lrp.Routes)["cf-router"])).To(MatchJSON(`[
+				{"hostnames":["a.example.com","b.example.com"],"port":8080},
+				{"hostnames":["c.example.com"],"port":9090}
+			]`))
+		})
+
+		It("generates a process guid", func() {
+			options.ProcessGuid = ""
+
+			lrp, err := commands.ValidateRunLRPArguments([]string{"ls"}, options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lrp.ProcessGuid).To(MatchRegexp(`^cfdot-run-lrp-[0-9a-f]{16}$`))
+			Expect(lrp.LogGuid).To(Equal(lrp.ProcessGuid))
+		})
+
+		It("requires a command", func() {
+			_, err := commands.ValidateRunLRPArguments([]string{}, options)
+			Expect(err).To(MatchError("Missing command to run, expected -- PATH [ARGS...]"))
+		})
+
+		It("requires exactly one of an image and a rootfs", func() {
+			options.RootFS = "preloaded:cflinuxfs3"
+			_, err := commands.ValidateRunLRPArguments([]string{"ls"}, options)
+			Expect(err).To(MatchError("Pass either --docker-image or --rootfs, not both"))
+
+			options.DockerImage, options.RootFS = "", ""
+			_, err = commands.ValidateRunLRPArguments([]string{"ls"}, options)
+			Expect(err).To(MatchError("Missing --docker-image or --rootfs"))
+		})
+
+		It("rejects malformed routes", func() {
+			options.Routes = []string{"a.example.com:http"}
+			_, err := commands.ValidateRunLRPArguments([]string{"ls"}, options)
+			Expect(err).To(MatchError("Invalid route 'a.example.com:http', expected HOST[:PORT]"))
+		})
+	})
+
+	Context("RunLRP", func() {
+		var (
+			fakeBBSClient  *fake_bbs.FakeClient
+			stdout, stderr *gbytes.Buffer
+			lrp            *models.DesiredLRP
+		)
+
+		BeforeEach(func() {
+			fakeBBSClient = &fake_bbs.FakeClient{}
+			stdout = gbytes.NewBuffer()
+			stderr = gbytes.NewBuffer()
+
+			var err error
+			lrp, err = commands.ValidateRunLRPArguments([]string{"nginx"}, options)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("creates the desired LRP and prints it", func() {
+			err := commands.RunLRP(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, lrp, false)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeBBSClient.DesireLRPCallCount()).To(Equal(1))
+			_, desired := fakeBBSClient.DesireLRPArgsForCall(0)
+			Expect(desired).To(Equal(lrp))
+			Expect(stdout).To(gbytes.Say(`"process_guid":"process-guid"`))
+			Expect(fakeBBSClient.ActualLRPsCallCount()).To(Equal(0))
+		})
+
+		It("waits for the instances to be running", func() {
+			running := func(index int32) *models.ActualLRP {
+				return &models.ActualLRP{
+					ActualLRPKey: models.NewActualLRPKey("process-guid", index, "test"),
+					State:        models.ActualLRPStateRunning,
+				}
+			}
+			fakeBBSClient.ActualLRPsReturnsOnCall(0, []*models.ActualLRP{running(0)}, nil)
+			fakeBBSClient.ActualLRPsReturnsOnCall(1, []*models.ActualLRP{running(0), running(1)}, nil)
+
+			err := commands.RunLRP(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, lrp, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stderr).To(gbytes.Say("1 of 2 instances of process-guid running\n"))
+			Expect(stderr).To(gbytes.Say("2 of 2 instances of process-guid running\n"))
+		})
+
+		It("fails once an instance crashes", func() {
+			fakeBBSClient.ActualLRPsReturns([]*models.ActualLRP{{
+				ActualLRPKey: models.NewActualLRPKey("process-guid", 0, "test"),
+				State:        models.ActualLRPStateCrashed,
+				CrashReason:  "exit status 1",
+			}}, nil)
+
+			err := commands.RunLRP(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, lrp, true)
+			Expect(err).To(MatchError("Instance 0 of process-guid crashed: exit status 1"))
+			Expect(fakeBBSClient.ActualLRPsCallCount()).To(Equal(1))
+		})
+
+		It("does not wait when the desired LRP cannot be created", func() {
+			fakeBBSClient.DesireLRPReturns(models.ErrResourceExists)
+
+			err := commands.RunLRP(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, lrp, true)
+			Expect(err).To(Equal(models.ErrResourceExists))
+			Expect(fakeBBSClient.ActualLRPsCallCount()).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,246 @@
+package commands
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"code.cloudfoundry.org/lager"
+	"github.com/spf13/cobra"
+)
+
+var runTaskCmd = &cobra.Command{
+	Use:   "run-task [flags] -- PATH [ARGS...]",
+	Short: "Run a one-off Task and wait for it to complete",
+	Long:  "Build a Task running PATH with ARGS from the given flags, create it and wait for it to complete. State changes are written to stderr and the completed task to stdout, after which it is deleted; the command fails with exit code 12 if the task failed. The contents of --result-file, if given, are returned as the result of the task. If cfdot is interrupted or --timeout is exceeded, the task is cancelled",
+	RunE:  runTask,
+}
+
+// runTaskCleanupTimeout bounds the requests cancelling the task of an
+// interrupted run-task or deleting the completed task.
+const runTaskCleanupTimeout = 10 * time.Second
+
+// RunTaskOptions are the flags of run-task that make up the Task.
+type RunTaskOptions struct {
+	TaskGuid   string
+	Domain     string
+	RootFS     string
+	User       string
+	MemoryMB   int32
+	DiskMB     int32
+	Env        []string
+	ResultFile string
+}
+
+// TaskFailedError is returned by RunTask when the task completed but failed.
+type TaskFailedError struct {
+	TaskGuid      string
+	FailureReason string
+}
+
+func (e *TaskFailedError) Error() string {
+	return fmt.Sprintf("Task %s failed: %s", e.TaskGuid, e.FailureReason)
+}
+
+// flags
+var runTaskFlags RunTaskOptions
+
+// errors
+var (
+	errMissingTaskCommand    = errors.New("Missing command to run, expected -- PATH [ARGS...]")
+	errMissingTaskDomain     = errors.New("--domain must not be empty")
+	errMissingTaskRootFS     = errors.New("Missing --rootfs")
+	errNegativeTaskMemory    = errors.New("--memory-mb must not be negative")
+	errNegativeTaskDisk      = errors.New("--disk-mb must not be negative")
+	errTaskCommandBeforeDash = errors.New("The command to run must follow --")
+)
+
+func init() {
+	AddBBSAndTimeoutFlags(runTaskCmd)
+	runTaskCmd.Flags().StringVar(&runTaskFlags.TaskGuid, "task-guid", "", "guid of the task, generated if not given")
+	runTaskCmd.Flags().StringVar(&runTaskFlags.Domain, "domain", "cfdot", "domain of the task")
+	runTaskCmd.Flags().StringVar(&runTaskFlags.RootFS, "rootfs", "", "rootfs of the task, e.g. preloaded:cflinuxfs3 or docker:///busybox")
+	runTaskCmd.Flags().StringVar(&runTaskFlags.User, "user", "vcap", "user running the command")
+	runTaskCmd.Flags().Int32Var(&runTaskFlags.MemoryMB, "memory-mb", 256, "memory limit of the task in MB")
+	runTaskCmd.Flags().Int32Var(&runTaskFlags.DiskMB, "disk-mb", 1024, "disk limit of the task in MB")
+	runTaskCmd.Flags().StringArrayVar(&runTaskFlags.Env, "env", nil, "NAME=VALUE environment variable of the command, can be repeated")
+	runTaskCmd.Flags().StringVar(&runTaskFlags.ResultFile, "result-file", "", "file in the container whose contents are the result of the task")
+	RootCmd.AddCommand(runTaskCmd)
+}
+
+func runTask(cmd *cobra.Command, args []string) error {
+	if cmd.ArgsLenAtDash() > 0 {
+		return NewCFDotValidationError(cmd, errTaskCommandBeforeDash)
+	}
+
+	task, err := ValidateRunTaskArguments(args, runTaskFlags)
+	if err != nil {
+		return NewCFDotValidationError(cmd, err)
+	}
+
+	bbsClient, err := helpers.NewBBSClient(cmd, Config)
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	err = RunTask(ctx, newPrinter(cmd), cmd.OutOrStderr(), bbsClient, task)
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+
+	return nil
+}
+
+// ValidateRunTaskArguments builds the Task running the command in args with
+// options, generating its guid unless one is given.
+func ValidateRunTaskArguments(args []string, options RunTaskOptions) (*models.Task, error) {
+	if len(args) == 0 || args[0] == "" {
+		return nil, errMissingTaskCommand
+	}
+	if options.Domain == "" {
+		return nil, errMissingTaskDomain
+	}
+	if options.RootFS == "" {
+		return nil, errMissingTaskRootFS
+	}
+	if options.MemoryMB < 0 {
+		return nil, errNegativeTaskMemory
+	}
+	if options.DiskMB < 0 {
+		return nil, errNegativeTaskDisk
+	}
+
+	env, err := parseEnv(options.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	taskGuid := options.TaskGuid
+	if taskGuid == "" {
+		taskGuid, err = newGuid("cfdot-run-task")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	task := &models.Task{
+		TaskGuid: taskGuid,
+		Domain:   options.Domain,
+		TaskDefinition: &models.TaskDefinition{
+			RootFs:     options.RootFS,
+			MemoryMb:   options.MemoryMB,
+			DiskMb:     options.DiskMB,
+			ResultFile: options.ResultFile,
+			Action: models.WrapAction(&models.RunAction{
+				Path: args[0],
+				Args: args[1:],
+				User: options.User,
+				Env:  env,
+			}),
+		},
+	}
+
+	spec, err := json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+	return task, checkSpecs(SpecKindTask, [][]byte{spec})
+}
+
+// RunTask creates task and waits for it to complete, writing its state
+// changes to stderr, printing the completed task and deleting it. It returns
+// a *TaskFailedError if the task failed. The task is cancelled if ctx is done
+// after it was created but before it completed.
+func RunTask(ctx context.Context, printer Printer, stderr io.Writer, bbsClient bbs.Client, task *models.Task) error {
+	logger := globalLogger.Session("run-task")
+
+	printSpecWarnings(stderr, diego.CheckTask(task))
+
+	completed, created, err := diego.RunTask(ctx, logger, bbsClient, task, func(current *models.Task) {
+		if current.CellId != "" && current.State == models.Task_Running {
+			fmt.Fprintf(stderr, "Task %s is %s on cell %s\n", current.TaskGuid, current.State, current.CellId)
+		} else {
+			fmt.Fprintf(stderr, "Task %s is %s\n", current.TaskGuid, current.State)
+		}
+	})
+	if err != nil {
+		if created && isContextError(ctx.Err()) {
+			cancelRunningTask(logger, stderr, bbsClient, task.TaskGuid)
+		}
+		return err
+	}
+
+	err = printer.Print(completed)
+	deleteCompletedTask(logger, stderr, bbsClient, completed.TaskGuid)
+	if err != nil {
+		return err
+	}
+
+	if completed.Failed {
+		return &TaskFailedError{TaskGuid: completed.TaskGuid, FailureReason: completed.FailureReason}
+	}
+	return nil
+}
+
+// deleteCompletedTask resolves and deletes the task run by run-task once it
+// completed, so that it does not linger in the BBS until it expires, with a
+// context of its own since that of the command may be done.
+func deleteCompletedTask(logger lager.Logger, stderr io.Writer, bbsClient bbs.Client, taskGuid string) {
+	ctx, cancel := context.WithTimeout(context.Background(), runTaskCleanupTimeout)
+	defer cancel()
+
+	err := diego.DeleteTask(ctx, logger, bbsClient, taskGuid)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to delete task %s: %s\n", taskGuid, err)
+	}
+}
+
+// cancelRunningTask cancels the task left behind by an interrupted run-task,
+// with a context of its own since that of the command is already done.
+func cancelRunningTask(logger lager.Logger, stderr io.Writer, bbsClient bbs.Client, taskGuid string) {
+	ctx, cancel := context.WithTimeout(context.Background(), runTaskCleanupTimeout)
+	defer cancel()
+
+	err := diego.CancelTask(ctx, logger, bbsClient, taskGuid)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to cancel task %s: %s\n", taskGuid, err)
+		return
+	}
+	fmt.Fprintf(stderr, "Cancelled task %s\n", taskGuid)
+}
+
+// parseEnv parses environment variables given as NAME=VALUE.
+func parseEnv(variables []string) ([]*models.EnvironmentVariable, error) {
+	var env []*models.EnvironmentVariable
+	for _, variable := range variables {
+		parts := strings.SplitN(variable, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Invalid environment variable '%s', expected NAME=VALUE", variable)
+		}
+		env = append(env, &models.EnvironmentVariable{Name: parts[0], Value: parts[1]})
+	}
+	return env, nil
+}
+
+// newGuid returns a random guid starting with prefix.
+func newGuid(prefix string) (string, error) {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return prefix + "-" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,222 @@
+package commands_test
+
+import (
+	"context"
+	"io"
+
+	"code.cloudfoundry.org/bbs/events"
+	"code.cloudfoundry.org/bbs/events/eventfakes"
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/commands"
+	"code.cloudfoundry.org/lager"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("RunTask", func() {
+	var options commands.RunTaskOptions
+
+	BeforeEach(func() {
+		options = commands.RunTaskOptions{
+			TaskGuid: "task-guid",
+			Domain:   "cfdot",
+			RootFS:   "preloaded:cflinuxfs3",
+			User:     "vcap",
+			MemoryMB: 256,
+			DiskMB:   1024,
+		}
+	})
+
+	Context("ValidateRunTaskArguments", func() {
+		It("builds a task running the command", func() {
+			options.Env = []string{"A=1", "B=x=y"}
+			options.ResultFile = "/tmp/result"
+
+			task, err := commands.ValidateRunTaskArguments([]string{"/bin/sh", "-c", "echo hi"}, options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(task.TaskGuid).To(Equal("task-guid"))
+			Expect(task.Domain).To(Equal("cfdot"))
+			Expect(task.RootFs).To(Equal("preloaded:cflinuxfs3"))
+			Expect(task.MemoryMb).To(Equal(int32(256)))
+			Expect(task.DiskMb).To(Equal(int32(1024)))
+			Expect(task.ResultFile).To(Equal("/tmp/result"))
+			Expect(task.Action.RunAction).To(Equal(&models.RunAction{
+				Path: "/bin/sh",
+				Args: []string{"-c", "echo hi"},
+				User: "vcap",
+				Env: []*models.EnvironmentVariable{
+					{Name: "A", Value: "1"},
+					{Name: "B", Value: "x=y"},
+				},
+			}))
+		})
+
+		It("generates a task guid", func() {
+			options.TaskGuid = ""
+
+			task, err := commands.ValidateRunTaskArguments([]string{"ls"}, options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(task.TaskGuid).To(MatchRegexp(`^cfdot-run-task-[0-9a-f]{16}$`))
+
+			other, err := commands.ValidateRunTaskArguments([]string{"ls"}, options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(other.TaskGuid).NotTo(Equal(task.TaskGuid))
+		})
+
+		It("requires a command", func() {
+			_, err := commands.ValidateRunTaskArguments([]string{}, options)
+			Expect(err).To(MatchError("Missing command to run, expected -- PATH [ARGS...]"))
+		})
+
+		It("requires a rootfs", func() {
+			options.RootFS = ""
+			_, err := commands.ValidateRunTaskArguments([]string{"ls"}, options)
+			Expect(err).To(MatchError("Missing --rootfs"))
+		})
+
+		It("rejects negative resources", func() {
+			options.MemoryMB = -1
+			_, err := commands.ValidateRunTaskArguments([]string{"ls"}, options)
+			Expect(err).To(MatchError("--memory-mb must not be negative"))
+		})
+
+		It("rejects malformed environment variables", func() {
+			options.Env = []string{"A"}
+			_, err := commands.ValidateRunTaskArguments([]string{"ls"}, options)
+			Expect(err).To(MatchError("Invalid environment variable 'A', expected NAME=VALUE"))
+		})
+
+		It("rejects tasks the BBS would reject", func() {
+			options.TaskGuid = "invalid/guid"
+			_, err := commands.ValidateRunTaskArguments([]string{"ls"}, options)
+			Expect(err).To(MatchError(ContainSubstring("Invalid task spec: task_guid: is invalid")))
+		})
+	})
+
+	Context("RunTask", func() {
+		var (
+			fakeBBSClient   *fake_bbs.FakeClient
+			fakeEventSource *eventfakes.FakeEventSource
+			stdout, stderr  *gbytes.Buffer
+			task            *models.Task
+			completed       *models.Task
+		)
+
+		BeforeEach(func() {
+			fakeBBSClient = &fake_bbs.FakeClient{}
+			fakeEventSource = &eventfakes.FakeEventSource{}
+			fakeBBSClient.SubscribeToTaskEventsReturns(fakeEventSource, nil)
+			stdout = gbytes.NewBuffer()
+			stderr = gbytes.NewBuffer()
+
+			var err error
+			task, err = commands.ValidateRunTaskArguments([]string{"ls"}, options)
+			Expect(err).NotTo(HaveOccurred())
+
+			running := &models.Task{TaskGuid: "task-guid", State: models.Task_Running, CellId: "cell-1"}
+			completed = &models.Task{TaskGuid: "task-guid", State: models.Task_Completed, Result: "some-result"}
+			events := []models.Event{
+				models.NewTaskChangedEvent(&models.Task{TaskGuid: "task-guid"}, running),
+				models.NewTaskChangedEvent(running, completed),
+			}
+			fakeEventSource.NextStub = func() (models.Event, error) {
+				if len(events) == 0 {
+					return nil, io.EOF
+				}
+				event := events[0]
+				events = events[1:]
+				return event, nil
+			}
+		})
+
+		It("creates the task and prints it once completed", func() {
+			err := commands.RunTask(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, task)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeBBSClient.DesireTaskCallCount()).To(Equal(1))
+			_, guid, domain, definition := fakeBBSClient.DesireTaskArgsForCall(0)
+			Expect(guid).To(Equal("task-guid"))
+			Expect(domain).To(Equal("cfdot"))
+			Expect(definition).To(Equal(task.TaskDefinition))
+
+			Expect(stderr).To(gbytes.Say("Task task-guid is Running on cell cell-1\n"))
+			Expect(stderr).To(gbytes.Say("Task task-guid is Completed\n"))
+			Expect(stdout).To(gbytes.Say(`"result":"some-result"`))
+		})
+
+		It("resolves and deletes the completed task", func() {
+			err := commands.RunTask(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, task)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeBBSClient.ResolvingTaskCallCount()).To(Equal(1))
+			_, guid := fakeBBSClient.ResolvingTaskArgsForCall(0)
+			Expect(guid).To(Equal("task-guid"))
+			Expect(fakeBBSClient.DeleteTaskCallCount()).To(Equal(1))
+			_, guid = fakeBBSClient.DeleteTaskArgsForCall(0)
+			Expect(guid).To(Equal("task-guid"))
+		})
+
+		It("reports a task it fails to delete", func() {
+			fakeBBSClient.ResolvingTaskReturns(models.ErrResourceConflict)
+
+			err := commands.RunTask(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, task)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdout).To(gbytes.Say(`"result":"some-result"`))
+			Expect(stderr).To(gbytes.Say("Failed to delete task task-guid"))
+			Expect(fakeBBSClient.DeleteTaskCallCount()).To(Equal(0))
+		})
+
+		It("fails with the failure reason of a failed task", func() {
+			completed.Failed = true
+			completed.FailureReason = "exit status 1"
+
+			err := commands.RunTask(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, task)
+			Expect(err).To(Equal(&commands.TaskFailedError{TaskGuid: "task-guid", FailureReason: "exit status 1"}))
+			Expect(err).To(MatchError("Task task-guid failed: exit status 1"))
+			Expect(stdout).To(gbytes.Say(`"failure_reason":"exit status 1"`))
+			Expect(fakeBBSClient.DeleteTaskCallCount()).To(Equal(1))
+		})
+
+		Context("when interrupted before the task completes", func() {
+			var ctx context.Context
+
+			BeforeEach(func() {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(context.Background())
+				fakeEventSource.NextStub = func() (models.Event, error) {
+					cancel()
+					return nil, io.ErrClosedPipe
+				}
+			})
+
+			It("cancels the task", func() {
+				err := commands.RunTask(ctx, commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, task)
+				Expect(err).To(Equal(context.Canceled))
+
+				Expect(fakeBBSClient.CancelTaskCallCount()).To(Equal(1))
+				_, guid := fakeBBSClient.CancelTaskArgsForCall(0)
+				Expect(guid).To(Equal("task-guid"))
+				Expect(stderr).To(gbytes.Say("Cancelled task task-guid"))
+			})
+		})
+
+		Context("when interrupted before the task is created", func() {
+			It("does not cancel the task", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				fakeBBSClient.SubscribeToTaskEventsStub = func(lager.Logger) (events.EventSource, error) {
+					cancel()
+					return fakeEventSource, nil
+				}
+
+				err := commands.RunTask(ctx, commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, task)
+				Expect(err).To(Equal(context.Canceled))
+
+				Expect(fakeBBSClient.DesireTaskCallCount()).To(Equal(0))
+				Expect(fakeBBSClient.CancelTaskCallCount()).To(Equal(0))
+			})
+		})
+	})
+})
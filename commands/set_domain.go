@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"errors"
 	"io"
 	"time"
@@ -8,6 +9,7 @@ import (
 	"code.cloudfoundry.org/bbs"
 
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"github.com/spf13/cobra"
 )
 
@@ -48,7 +50,10 @@ func setDomain(cmd *cobra.Command, args []string) error {
 		return NewCFDotError(cmd, err)
 	}
 
-	err = SetDomain(cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, domain, setDomainTTLFlag)
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	err = SetDomain(ctx, cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, domain, setDomainTTLFlag)
 	if err != nil {
 		return NewCFDotError(cmd, err)
 	}
@@ -72,13 +77,8 @@ func ValidateSetDomainArgs(args []string) (string, error) {
 	return args[0], nil
 }
 
-func SetDomain(stdout, stderr io.Writer, bbsClient bbs.Client, domain string, ttlDuration time.Duration) error {
+func SetDomain(ctx context.Context, stdout, stderr io.Writer, bbsClient bbs.Client, domain string, ttlDuration time.Duration) error {
 	logger := globalLogger.Session("set-domain")
 
-	err := bbsClient.UpsertDomain(logger, domain, ttlDuration)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return diego.UpsertDomain(ctx, logger, bbsClient, domain, ttlDuration)
 }
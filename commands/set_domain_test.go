@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"context"
 	"time"
 
 	"code.cloudfoundry.org/bbs/fake_bbs"
@@ -59,7 +60,7 @@ var _ = Describe("Set Domain", func() {
 		})
 
 		It("prints a success message when a domain is given", func() {
-			err := commands.SetDomain(stdout, stderr, fakeBBSClient, "anything", 5*time.Second)
+			err := commands.SetDomain(context.Background(), stdout, stderr, fakeBBSClient, "anything", 5*time.Second)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeBBSClient.UpsertDomainCallCount()).To(Equal(1))
@@ -74,7 +75,7 @@ var _ = Describe("Set Domain", func() {
 			})
 
 			It("fails with a relevant error", func() {
-				err := commands.SetDomain(stdout, stderr, fakeBBSClient, "anything", 0)
+				err := commands.SetDomain(context.Background(), stdout, stderr, fakeBBSClient, "anything", 0)
 				Expect(err).To(HaveOccurred())
 				Expect(err).To(Equal(models.ErrUnknownError))
 			})
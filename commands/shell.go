@@ -0,0 +1,539 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"code.cloudfoundry.org/lager"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Run cfdot commands interactively",
+	Long:  "Run cfdot commands interactively over a single BBS client, Locket client and rep client factory, with history and tab completion of command names, process guids, task guids and cell ids. Flags given to shell apply to every command run from it. Type exit or press Ctrl-D to leave the shell",
+	RunE:  shell,
+}
+
+const (
+	shellPrompt = "cfdot> "
+
+	// shellListingTimeout bounds the requests made to complete an argument,
+	// so that pressing tab never hangs the shell for long.
+	shellListingTimeout = 5 * time.Second
+	shellListingTTL     = time.Minute
+)
+
+// Kinds of argument completed from listings of the BBS, by cfdot shell and
+// by the completion scripts. completionKinds and describeArgKinds name the
+// arguments of each kind.
+const (
+	ShellProcessGuid = "PROCESS_GUID"
+	ShellTaskGuid    = "TASK_GUID"
+	ShellCellID      = "CELL_ID"
+)
+
+// shellFlagKinds maps the flags taking a process guid, task guid or cell id
+// to the kind of their value.
+var shellFlagKinds = map[string]string{
+	"cell-id": ShellCellID,
+}
+
+var shellBuiltins = []string{"exit", "quit"}
+
+// errors
+var (
+	errUnterminatedQuote = errors.New("Unterminated quote or escape")
+	errNestedShell       = errors.New("Already running cfdot shell")
+)
+
+func init() {
+	AddBBSAndTimeoutFlags(shellCmd)
+	shellCmd.Flags().StringVar(&locketApiLocation, "locketAPILocation", "", "Hostname:Port of Locket server for the Locket commands [environment variable equivalent: LOCKET_API_LOCATION]")
+	AddRepTimeoutFlag(shellCmd)
+	RootCmd.AddCommand(shellCmd)
+}
+
+func shell(cmd *cobra.Command, args []string) error {
+	err := ValidateShellArguments(args)
+	if err != nil {
+		return NewCFDotValidationError(cmd, err)
+	}
+
+	if locketApiLocation == "" {
+		locketApiLocation = os.Getenv("LOCKET_API_LOCATION")
+	}
+	Config.LocketApiLocation = locketApiLocation
+
+	// Match the configuration the Locket commands end up with, so that they
+	// are given the client built here.
+	Config.LocketTimeout, err = timeoutFromEnv(cmd, "locket-timeout", 0, "CFDOT_LOCKET_TIMEOUT")
+	if err != nil {
+		return err
+	}
+
+	helpers.EnableClientCache()
+	validatedCertificates = map[certificateFiles]bool{}
+
+	bbsClient, err := helpers.NewBBSClient(cmd, Config)
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+
+	_, err = helpers.NewRepClientFactory(cmd, Config)
+	if err != nil {
+		return NewCFDotRepError(cmd, fmt.Errorf("Failed creating rep client factory: %s", err), "")
+	}
+
+	if Config.LocketApiLocation != "" {
+		_, err = helpers.NewLocketClient(globalLogger.Session("locket-client"), cmd, Config)
+		if err != nil {
+			cfDotErr := NewCFDotComponentError(cmd, err)
+			cfDotErr.component, cfDotErr.endpoint = ComponentLocket, Config.LocketApiLocation
+			return cfDotErr
+		}
+	}
+
+	session := newShellSession(cmd, bbsClient)
+	err = session.run(cmd.InOrStdin(), cmd.OutOrStdout())
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+
+	return nil
+}
+
+func ValidateShellArguments(args []string) error {
+	if len(args) > 0 {
+		return errExtraArguments
+	}
+	return nil
+}
+
+// shellSession runs the commands read by cfdot shell. Every command starts
+// from the flags and configuration the shell was started with.
+type shellSession struct {
+	stderr        io.Writer
+	flags         map[string]string
+	config        helpers.TLSConfig
+	timeoutConfig helpers.TLSConfig
+	completer     ShellCompleter
+}
+
+func newShellSession(cmd *cobra.Command, bbsClient bbs.Client) *shellSession {
+	flags := map[string]string{}
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		flags[flag.Name] = flag.Value.String()
+	})
+
+	listings := &shellListings{
+		logger:    globalLogger.Session("shell-listings"),
+		bbsClient: bbsClient,
+		cached:    map[string]shellListing{},
+	}
+
+	return &shellSession{
+		stderr:        cmd.OutOrStderr(),
+		flags:         flags,
+		config:        Config,
+		timeoutConfig: timeoutConfig,
+		completer:     ShellCompleter{Root: RootCmd, List: listings.list},
+	}
+}
+
+// run reads commands from in until it is exhausted or exit is entered. When
+// in is a terminal the line is edited in raw mode, with history and tab
+// completion; otherwise commands are read line by line, as from a script.
+func (s *shellSession) run(in io.Reader, out io.Writer) error {
+	if file, ok := in.(*os.File); ok && terminal.IsTerminal(int(file.Fd())) {
+		return s.runTerminal(file, out)
+	}
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if !s.execute(scanner.Text()) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *shellSession) runTerminal(in *os.File, out io.Writer) error {
+	fd := int(in.Fd())
+
+	term := terminal.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{in, out}, shellPrompt)
+	term.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+
+		newLine, newPos, candidates := s.completer.Complete(line, pos)
+		if newLine == line && len(candidates) > 1 {
+			fmt.Fprintln(term, strings.Join(candidates, "  "))
+		}
+		return newLine, newPos, true
+	}
+
+	for {
+		state, err := terminal.MakeRaw(fd)
+		if err != nil {
+			return err
+		}
+		line, err := term.ReadLine()
+		// Commands run with the terminal restored, so that Ctrl-C interrupts
+		// them as usual.
+		terminal.Restore(fd, state)
+
+		if err == io.EOF {
+			fmt.Fprintln(out)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !s.execute(line) {
+			return nil
+		}
+	}
+}
+
+// execute runs the command on line and returns false when the shell should
+// exit.
+func (s *shellSession) execute(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return true
+	}
+
+	args, err := ParseShellLine(line)
+	if err != nil {
+		fmt.Fprintf(s.stderr, "Error: %s\n", err)
+		return true
+	}
+
+	for _, builtin := range shellBuiltins {
+		if args[0] == builtin {
+			return false
+		}
+	}
+
+	cmd, _, err := RootCmd.Find(args)
+	if err == nil {
+		if cmd == shellCmd {
+			fmt.Fprintf(s.stderr, "Error: %s\n", errNestedShell)
+			return true
+		}
+		s.reset(cmd)
+	}
+
+	RootCmd.SetArgs(args)
+	if err := RootCmd.Execute(); err != nil {
+		ReportError(s.stderr, err)
+	}
+	return true
+}
+
+// reset restores the flags of cmd to the values given to cfdot shell, or to
+// their defaults, and the configuration to the one the shell started with, so
+// that flags given to one command do not carry over to the next.
+func (s *shellSession) reset(cmd *cobra.Command) {
+	Config = s.config
+	timeoutConfig = s.timeoutConfig
+
+	reset := func(flag *pflag.Flag) {
+		value, ok := s.flags[flag.Name]
+		if !ok {
+			value = flag.DefValue
+		}
+		if slice, isSlice := flag.Value.(pflag.SliceValue); isSlice && !ok {
+			slice.Replace(nil)
+		} else {
+			flag.Value.Set(value)
+		}
+		flag.Changed = ok
+	}
+	RootCmd.PersistentFlags().VisitAll(reset)
+	cmd.Flags().VisitAll(reset)
+	cmd.SilenceUsage = false
+}
+
+// ParseShellLine splits a line of cfdot shell input into arguments at
+// whitespace. Single quotes keep everything up to the closing quote, such as
+// a JSON spec, as is; elsewhere a backslash escapes the next character.
+func ParseShellLine(line string) ([]string, error) {
+	var (
+		args    []string
+		arg     strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			arg.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inArg = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, errUnterminatedQuote
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// ShellCompleter completes cfdot shell input: command names in the first
+// word, flag names, and the process guids, task guids and cell ids returned
+// by List for the arguments and flags that take them.
+type ShellCompleter struct {
+	Root *cobra.Command
+	List func(kind string) []string
+}
+
+// Complete completes the word ending at pos in line. It returns the new line
+// and position, and every candidate the word could be completed to. A single
+// candidate is completed in full, several only up to their common prefix.
+func (c ShellCompleter) Complete(line string, pos int) (string, int, []string) {
+	start := strings.LastIndexFunc(line[:pos], unicode.IsSpace) + 1
+	word := line[start:pos]
+
+	var candidates []string
+	for _, candidate := range c.candidates(strings.Fields(line[:start]), word) {
+		if strings.HasPrefix(candidate, word) {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	var completion string
+	switch len(candidates) {
+	case 0:
+		return line, pos, nil
+	case 1:
+		completion = candidates[0] + " "
+	default:
+		completion = commonPrefix(candidates)
+	}
+
+	return line[:start] + completion + line[pos:], start + len(completion), candidates
+}
+
+func (c ShellCompleter) candidates(previous []string, word string) []string {
+	cmd, positional := c.command(previous)
+	if cmd == nil {
+		if len(previous) == 0 && !strings.HasPrefix(word, "-") {
+			return c.commandNames()
+		}
+		return nil
+	}
+
+	if strings.HasPrefix(word, "-") {
+		return flagNames(cmd)
+	}
+
+	if last := previous[len(previous)-1]; !strings.Contains(last, "=") {
+		if flag := lookupFlag(cmd.Flags(), last); flag != nil && flag.Value.Type() != "bool" {
+			if kind, ok := shellFlagKinds[flag.Name]; ok {
+				return c.List(kind)
+			}
+			return nil
+		}
+	}
+
+	values, kind := completionArg(cmd, positional)
+	if kind != "" {
+		return c.List(kind)
+	}
+	return values
+}
+
+// command returns the command named in words, skipping flags and their
+// values, and the positional arguments after its name.
+func (c ShellCompleter) command(words []string) (*cobra.Command, []string) {
+	var cmd *cobra.Command
+	var positional []string
+
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		if strings.HasPrefix(word, "-") {
+			flags := c.Root.PersistentFlags()
+			if cmd != nil {
+				flags = cmd.Flags()
+			}
+			if flag := lookupFlag(flags, word); flag != nil && flag.Value.Type() != "bool" && !strings.Contains(word, "=") {
+				i++
+			}
+			continue
+		}
+
+		if cmd == nil {
+			for _, sub := range c.Root.Commands() {
+				if sub.Name() == word {
+					cmd = sub
+				}
+			}
+			if cmd == nil {
+				return nil, nil
+			}
+			continue
+		}
+		positional = append(positional, word)
+	}
+
+	return cmd, positional
+}
+
+func (c ShellCompleter) commandNames() []string {
+	names := append([]string{}, shellBuiltins...)
+	for _, cmd := range c.Root.Commands() {
+		if cmd.IsAvailableCommand() {
+			names = append(names, cmd.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func flagNames(cmd *cobra.Command) []string {
+	var names []string
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if !flag.Hidden {
+			names = append(names, "--"+flag.Name)
+		}
+	})
+	sort.Strings(names)
+	return names
+}
+
+// lookupFlag returns the flag named by word, such as --cell-id or -c.
+func lookupFlag(flags *pflag.FlagSet, word string) *pflag.Flag {
+	switch {
+	case strings.HasPrefix(word, "--"):
+		return flags.Lookup(strings.SplitN(word[2:], "=", 2)[0])
+	case strings.HasPrefix(word, "-") && len(word) == 2:
+		return flags.ShorthandLookup(word[1:])
+	default:
+		return nil
+	}
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// shellListings caches the process guids, task guids and cell ids used to
+// complete arguments, refetching each at most once every shellListingTTL.
+type shellListings struct {
+	logger    lager.Logger
+	bbsClient bbs.Client
+	cached    map[string]shellListing
+}
+
+type shellListing struct {
+	values    []string
+	fetchedAt time.Time
+}
+
+func (l *shellListings) list(kind string) []string {
+	if listing, ok := l.cached[kind]; ok && time.Since(listing.fetchedAt) < shellListingTTL {
+		return listing.values
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shellListingTimeout)
+	defer cancel()
+
+	values, err := fetchShellListing(ctx, l.logger, l.bbsClient, kind)
+	if err != nil {
+		l.logger.Error("failed-to-fetch-listing", err, lager.Data{"kind": kind})
+		return nil
+	}
+
+	sort.Strings(values)
+	l.cached[kind] = shellListing{values: values, fetchedAt: time.Now()}
+	return values
+}
+
+func fetchShellListing(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, kind string) ([]string, error) {
+	var values []string
+
+	switch kind {
+	case ShellProcessGuid:
+		infos, err := diego.DesiredLRPSchedulingInfos(ctx, logger, bbsClient, models.DesiredLRPFilter{})
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			values = append(values, info.ProcessGuid)
+		}
+	case ShellTaskGuid:
+		tasks, err := diego.Tasks(ctx, logger, bbsClient, models.TaskFilter{})
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range tasks {
+			values = append(values, task.TaskGuid)
+		}
+	case ShellCellID:
+		cells, err := diego.Cells(ctx, logger, bbsClient)
+		if err != nil {
+			return nil, err
+		}
+		for _, cell := range cells {
+			values = append(values, cell.CellId)
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,158 @@
+package commands_test
+
+import (
+	"code.cloudfoundry.org/cfdot/commands"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Shell", func() {
+	Context("ValidateShellArguments", func() {
+		It("validates there are no arguments", func() {
+			Expect(commands.ValidateShellArguments([]string{})).To(Succeed())
+		})
+
+		It("returns an extra arguments error", func() {
+			err := commands.ValidateShellArguments([]string{"extra-arg"})
+			Expect(err).To(MatchError("Too many arguments specified"))
+		})
+	})
+
+	Describe("ParseShellLine", func() {
+		It("splits the line at whitespace", func() {
+			Expect(commands.ParseShellLine("  actual-lrps   --domain cf-apps ")).To(Equal([]string{"actual-lrps", "--domain", "cf-apps"}))
+		})
+
+		It("keeps single quoted arguments as is", func() {
+			Expect(commands.ParseShellLine(`create-task '{"task_guid": "some guid"}'`)).To(Equal([]string{"create-task", `{"task_guid": "some guid"}`}))
+		})
+
+		It("supports double quotes and backslash escapes", func() {
+			Expect(commands.ParseShellLine(`set-domain "my domain" my\ other\ domain ""`)).To(Equal([]string{"set-domain", "my domain", "my other domain", ""}))
+		})
+
+		It("returns an error for an unterminated quote", func() {
+			_, err := commands.ParseShellLine(`create-task '{"task_guid"`)
+			Expect(err).To(MatchError("Unterminated quote or escape"))
+		})
+	})
+
+	Describe("ShellCompleter", func() {
+		var (
+			completer commands.ShellCompleter
+			listed    []string
+		)
+
+		BeforeEach(func() {
+			listed = nil
+			completer = commands.ShellCompleter{
+				Root: commands.RootCmd,
+				List: func(kind string) []string {
+					listed = append(listed, kind)
+					switch kind {
+					case commands.ShellTaskGuid:
+						return []string{"task-guid-1", "task-guid-2"}
+					case commands.ShellCellID:
+						return []string{"cell-1", "diego-cell-2"}
+					case commands.ShellProcessGuid:
+						return []string{"process-guid-1"}
+					default:
+						return nil
+					}
+				},
+			}
+		})
+
+		complete := func(line string) (string, []string) {
+			newLine, newPos, candidates := completer.Complete(line, len(line))
+			Expect(newPos).To(Equal(len(newLine)))
+			return newLine, candidates
+		}
+
+		It("completes a unique command name", func() {
+			line, candidates := complete("desired-lrp-sch")
+			Expect(line).To(Equal("desired-lrp-scheduling-infos "))
+			Expect(candidates).To(Equal([]string{"desired-lrp-scheduling-infos"}))
+		})
+
+		It("completes command names up to their common prefix", func() {
+			line, candidates := complete("ta")
+			Expect(line).To(Equal("task"))
+			Expect(candidates).To(Equal([]string{"task", "task-events", "task-stats", "tasks"}))
+		})
+
+		It("completes the shell's own commands", func() {
+			line, _ := complete("ex")
+			Expect(line).To(Equal("exit "))
+		})
+
+		It("completes task guids", func() {
+			line, candidates := complete("task ")
+			Expect(line).To(Equal("task task-guid-"))
+			Expect(candidates).To(Equal([]string{"task-guid-1", "task-guid-2"}))
+			Expect(listed).To(Equal([]string{commands.ShellTaskGuid}))
+		})
+
+		It("completes process guids", func() {
+			line, _ := complete("desired-lrp proc")
+			Expect(line).To(Equal("desired-lrp process-guid-1 "))
+		})
+
+		It("completes cell ids", func() {
+			line, _ := complete("cell-state di")
+			Expect(line).To(Equal("cell-state diego-cell-2 "))
+		})
+
+		It("completes the arguments of commands regardless of their usage", func() {
+			line, _ := complete("update-desired-lrp proc")
+			Expect(line).To(Equal("update-desired-lrp process-guid-1 "))
+		})
+
+		It("completes the kind and then the id of describe", func() {
+			line, candidates := complete("describe ")
+			Expect(line).To(Equal("describe "))
+			Expect(candidates).To(Equal([]string{"lrp", "task", "cell"}))
+
+			line, _ = complete("describe ta")
+			Expect(line).To(Equal("describe task "))
+
+			line, _ = complete("describe task task-guid-2")
+			Expect(line).To(Equal("describe task task-guid-2 "))
+
+			line, _ = complete("describe cell di")
+			Expect(line).To(Equal("describe cell diego-cell-2 "))
+		})
+
+		It("completes the values of flags taking a cell id", func() {
+			line, _ := complete("lrp-events --cell-id c")
+			Expect(line).To(Equal("lrp-events --cell-id cell-1 "))
+
+			line, _ = complete("lrp-events -x -c c")
+			Expect(line).To(Equal("lrp-events -x -c cell-1 "))
+		})
+
+		It("completes flag names", func() {
+			line, _ := complete("domains --ti")
+			Expect(line).To(Equal("domains --timeout "))
+		})
+
+		It("skips flags when counting arguments", func() {
+			line, _ := complete("retire-actual-lrp --timeout 5 proc")
+			Expect(line).To(Equal("retire-actual-lrp --timeout 5 process-guid-1 "))
+		})
+
+		It("does not complete arguments that are not listed", func() {
+			line, candidates := complete("retire-actual-lrp process-guid-1 ")
+			Expect(line).To(Equal("retire-actual-lrp process-guid-1 "))
+			Expect(candidates).To(BeEmpty())
+			Expect(listed).To(BeEmpty())
+		})
+
+		It("completes the word at the cursor", func() {
+			newLine, newPos, _ := completer.Complete("cell-state di --timeout 5", len("cell-state di"))
+			Expect(newLine).To(Equal("cell-state diego-cell-2  --timeout 5"))
+			Expect(newPos).To(Equal(len("cell-state diego-cell-2 ")))
+		})
+	})
+})
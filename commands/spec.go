@@ -0,0 +1,288 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of the specs given to the commands creating or updating resources.
+const (
+	SpecFormatAuto = "auto"
+	SpecFormatJSON = "json"
+	SpecFormatYAML = "yaml"
+)
+
+var specFormatFlag string
+
+// errors
+var (
+	errEmptySpec = errors.New("The spec is empty")
+)
+
+// AddSpecFormatFlag adds --format to a command reading a spec with ReadSpecs.
+func AddSpecFormatFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&specFormatFlag, "format", SpecFormatAuto, "format of the spec, json or yaml, or auto to detect it from the spec")
+}
+
+// ReadSpecs reads the spec argument of a command: the spec itself, @FILE to
+// read it from a file, or - or @- to read it from stdin. The spec is JSON or
+// YAML, as given by format, and may hold several documents, separated by ---
+// in YAML. It returns every document as JSON, once it decoded into the value
+// returned by newValue. Errors point to the line, and the field if known,
+// that failed to decode.
+func ReadSpecs(arg string, stdin io.Reader, format string, newValue func() interface{}) ([][]byte, error) {
+	var data []byte
+	var err error
+
+	switch {
+	case arg == "-" || arg == "@-":
+		data, err = ioutil.ReadAll(stdin)
+	case strings.HasPrefix(arg, "@"):
+		data, err = ioutil.ReadFile(arg[1:])
+	default:
+		data = []byte(arg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case SpecFormatAuto:
+		if isYAMLSpec(data) {
+			return readYAMLSpecs(data, newValue)
+		}
+		return readJSONSpecs(data, newValue)
+	case SpecFormatJSON:
+		return readJSONSpecs(data, newValue)
+	case SpecFormatYAML:
+		return readYAMLSpecs(data, newValue)
+	default:
+		return nil, fmt.Errorf("The value '%s' is not a valid spec format. Please specify json, yaml or auto.", format)
+	}
+}
+
+// isYAMLSpec tells whether a spec given in no particular format is YAML: it
+// does not start like JSON, and its first document is a mapping or fails to
+// parse. Anything else, such as a plain word, is read as JSON, so that it is
+// reported as invalid JSON as it was before YAML specs were accepted.
+func isYAMLSpec(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return false
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if err != nil {
+			return true
+		}
+		if len(document.Content) == 0 {
+			continue
+		}
+
+		node := document.Content[0]
+		if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+			// An empty document, such as after a leading ---
+			continue
+		}
+		return node.Kind == yaml.MappingNode || node.Kind == yaml.AliasNode
+	}
+}
+
+func readJSONSpecs(data []byte, newValue func() interface{}) ([][]byte, error) {
+	var specs [][]byte
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var spec json.RawMessage
+		err := decoder.Decode(&spec)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if syntaxErr, ok := err.(*json.SyntaxError); ok {
+				line, column := position(data, syntaxErr.Offset-1)
+				return nil, fmt.Errorf("Invalid JSON: line %d, column %d: %s", line, column, err)
+			}
+			return nil, fmt.Errorf("Invalid JSON: %s", err)
+		}
+
+		err = json.Unmarshal(spec, newValue())
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+			start := decoder.InputOffset() - int64(len(spec))
+			line, _ := position(data, start+typeErr.Offset)
+			return nil, fmt.Errorf("Invalid JSON: line %d%s", line, describeTypeError(typeErr))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid JSON: %s", err)
+		}
+
+		specs = append(specs, spec)
+	}
+
+	if len(specs) == 0 {
+		return nil, errEmptySpec
+	}
+	return specs, nil
+}
+
+func readYAMLSpecs(data []byte, newValue func() interface{}) ([][]byte, error) {
+	var specs [][]byte
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid YAML: %s", strings.TrimPrefix(err.Error(), "yaml: "))
+		}
+		if len(document.Content) == 0 {
+			continue
+		}
+
+		value, err := yamlValue(&document)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid YAML: %s", err)
+		}
+		if value == nil {
+			// An empty document, such as after a trailing ---
+			continue
+		}
+		spec, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid YAML: line %d: %s", document.Line, err)
+		}
+
+		err = json.Unmarshal(spec, newValue())
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+			return nil, fmt.Errorf("Invalid YAML: line %d%s", yamlFieldLine(&document, typeErr.Field), describeTypeError(typeErr))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid YAML: line %d: %s", document.Line, err)
+		}
+
+		specs = append(specs, spec)
+	}
+
+	if len(specs) == 0 {
+		return nil, errEmptySpec
+	}
+	return specs, nil
+}
+
+// yamlValue converts a YAML node to the value it encodes as JSON.
+func yamlValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		return yamlValue(node.Content[0])
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+	case yaml.MappingNode:
+		mapping := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: keys must be strings", key.Line)
+			}
+			value, err := yamlValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			mapping[key.Value] = value
+		}
+		return mapping, nil
+	case yaml.SequenceNode:
+		sequence := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			value, err := yamlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			sequence = append(sequence, value)
+		}
+		return sequence, nil
+	default:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return nil, fmt.Errorf("line %d: %s", node.Line, err)
+		}
+		return value, nil
+	}
+}
+
+// yamlFieldLine returns the line of field, a path of keys separated by dots,
+// in a YAML document, where list items are numbered. When the path cannot be
+// followed it returns the line of the last key found.
+func yamlFieldLine(node *yaml.Node, field string) int {
+	line := node.Line
+	if field == "" {
+		return line
+	}
+
+	for _, name := range strings.Split(field, ".") {
+		for node.Kind == yaml.DocumentNode || node.Kind == yaml.AliasNode {
+			if node.Kind == yaml.AliasNode {
+				node = node.Alias
+			} else {
+				node = node.Content[0]
+			}
+		}
+
+		var value *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == name {
+					line, value = node.Content[i].Line, node.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if index, err := strconv.Atoi(name); err == nil && index >= 0 && index < len(node.Content) {
+				line, value = node.Content[index].Line, node.Content[index]
+			}
+		}
+		if value == nil {
+			break
+		}
+		node = value
+	}
+
+	return line
+}
+
+func describeTypeError(err *json.UnmarshalTypeError) string {
+	if err.Field == "" {
+		return fmt.Sprintf(": cannot unmarshal %s into %s", err.Value, err.Type)
+	}
+	return fmt.Sprintf(", field %s: cannot unmarshal %s into %s", err.Field, err.Value, err.Type)
+}
+
+// position returns the line and column of the byte at offset in data, both
+// counted from one.
+func position(data []byte, offset int64) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
@@ -0,0 +1,116 @@
+package commands_test
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/commands"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReadSpecs", func() {
+	newDesiredLRP := func() interface{} { return &models.DesiredLRP{} }
+
+	readSpecs := func(arg, format string) ([]string, error) {
+		specs, err := commands.ReadSpecs(arg, strings.NewReader(""), format, newDesiredLRP)
+		var docs []string
+		for _, spec := range specs {
+			docs = append(docs, string(spec))
+		}
+		return docs, err
+	}
+
+	It("returns a json spec as is", func() {
+		specs, err := readSpecs(`{"process_guid":"guid-1","instances":2}`, commands.SpecFormatAuto)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(specs).To(Equal([]string{`{"process_guid":"guid-1","instances":2}`}))
+	})
+
+	It("converts a yaml spec to json", func() {
+		specs, err := readSpecs("process_guid: guid-1\ninstances: 2\nports: [8080]\n", commands.SpecFormatAuto)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(specs).To(HaveLen(1))
+		Expect(specs[0]).To(MatchJSON(`{"process_guid":"guid-1","instances":2,"ports":[8080]}`))
+	})
+
+	It("returns every document of a multi-document spec", func() {
+		specs, err := readSpecs("process_guid: guid-1\n---\nprocess_guid: guid-2\n---\n", commands.SpecFormatAuto)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(specs).To(HaveLen(2))
+		Expect(specs[1]).To(MatchJSON(`{"process_guid":"guid-2"}`))
+
+		specs, err = readSpecs("{\"process_guid\":\"guid-1\"}\n{\"process_guid\":\"guid-2\"}\n", commands.SpecFormatAuto)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(specs).To(HaveLen(2))
+	})
+
+	It("reads the spec from stdin", func() {
+		for _, arg := range []string{"-", "@-"} {
+			specs, err := commands.ReadSpecs(arg, strings.NewReader("process_guid: guid-1\n"), commands.SpecFormatAuto, newDesiredLRP)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(specs).To(HaveLen(1))
+			Expect(string(specs[0])).To(MatchJSON(`{"process_guid":"guid-1"}`))
+		}
+	})
+
+	It("reads the spec from a file", func() {
+		f, err := ioutil.TempFile(os.TempDir(), "spec_file")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.Remove(f.Name())
+		_, err = f.WriteString("process_guid: guid-1\n")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.Close()).To(Succeed())
+
+		specs, err := readSpecs("@"+f.Name(), commands.SpecFormatAuto)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(specs).To(HaveLen(1))
+	})
+
+	It("reads the spec in the given format", func() {
+		_, err := readSpecs("{process_guid: guid-1}", commands.SpecFormatYAML)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = readSpecs("process_guid: guid-1", commands.SpecFormatJSON)
+		Expect(err).To(MatchError(HavePrefix("Invalid JSON: line 1, column 1")))
+
+		_, err = readSpecs("process_guid: guid-1", "toml")
+		Expect(err).To(MatchError("The value 'toml' is not a valid spec format. Please specify json, yaml or auto."))
+	})
+
+	It("reads a spec that is neither json nor a yaml mapping as json", func() {
+		_, err := readSpecs("foo", commands.SpecFormatAuto)
+		Expect(err).To(MatchError(HavePrefix("Invalid JSON: line 1, column 2")))
+
+		_, err = readSpecs("- process_guid: guid-1\n", commands.SpecFormatAuto)
+		Expect(err).To(MatchError(HavePrefix("Invalid JSON: line 1, column 2")))
+	})
+
+	It("points to the line and column of a json syntax error", func() {
+		_, err := readSpecs("{\n  \"process_guid\": \"guid-1\",\n}", commands.SpecFormatAuto)
+		Expect(err).To(MatchError(HavePrefix("Invalid JSON: line 3, column 1")))
+	})
+
+	It("points to the line and field of a json type error", func() {
+		_, err := readSpecs("{\n  \"process_guid\": \"guid-1\",\n  \"instances\": \"two\"\n}", commands.SpecFormatAuto)
+		Expect(err).To(MatchError("Invalid JSON: line 3, field instances: cannot unmarshal string into int32"))
+	})
+
+	It("points to the line of a yaml syntax error", func() {
+		_, err := readSpecs("process_guid: guid-1\n  ports: 8080\n", commands.SpecFormatAuto)
+		Expect(err).To(MatchError("Invalid YAML: line 2: mapping values are not allowed in this context"))
+	})
+
+	It("points to the line and field of a yaml type error", func() {
+		_, err := readSpecs("---\nprocess_guid: guid-1\n---\nprocess_guid: guid-2\n\ninstances: two\n", commands.SpecFormatAuto)
+		Expect(err).To(MatchError("Invalid YAML: line 6, field instances: cannot unmarshal string into int32"))
+	})
+
+	It("rejects an empty spec", func() {
+		_, err := readSpecs("# nothing here\n", commands.SpecFormatAuto)
+		Expect(err).To(MatchError("The spec is empty"))
+	})
+})
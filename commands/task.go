@@ -1,11 +1,12 @@
 package commands
 
 import (
-	"encoding/json"
+	"context"
 	"io"
 
 	"code.cloudfoundry.org/bbs"
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"github.com/spf13/cobra"
 )
 
@@ -32,23 +33,25 @@ func task(cmd *cobra.Command, args []string) error {
 		return NewCFDotError(cmd, err)
 	}
 
-	if err := TaskByGuid(cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, guid); err != nil {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if err := TaskByGuid(ctx, newPrinter(cmd), cmd.OutOrStderr(), bbsClient, guid); err != nil {
 		return NewCFDotError(cmd, err)
 	}
 
 	return nil
 }
 
-func TaskByGuid(stdout, _ io.Writer, bbsClient bbs.Client, taskGuid string) error {
+func TaskByGuid(ctx context.Context, printer Printer, _ io.Writer, bbsClient bbs.Client, taskGuid string) error {
 	logger := globalLogger.Session("task-by-guid")
 
-	task, err := bbsClient.TaskByGuid(logger, taskGuid)
+	task, err := diego.TaskByGuid(ctx, logger, bbsClient, taskGuid)
 	if err != nil {
 		return err
 	}
 
-	encoder := json.NewEncoder(stdout)
-	err = encoder.Encode(task)
+	err = printer.Print(task)
 	if err != nil {
 		logger.Error("failed-to-marshal", err)
 	}
@@ -1,12 +1,13 @@
 package commands
 
 import (
-	"encoding/json"
+	"context"
 	"io"
 
 	"code.cloudfoundry.org/bbs"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"github.com/spf13/cobra"
 )
 
@@ -42,39 +43,27 @@ func taskEvents(cmd *cobra.Command, args []string) error {
 		return NewCFDotError(cmd, err)
 	}
 
-	err = TaskEvents(cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, taskEventsCellIdFlag)
-	if err != nil {
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	err = TaskEvents(ctx, newPrinter(cmd), cmd.OutOrStderr(), bbsClient, taskEventsCellIdFlag)
+	if err != nil && !isInterrupted(err) {
 		return NewCFDotError(cmd, err)
 	}
 	return nil
 }
 
-func TaskEvents(stdout, stderr io.Writer, bbsClient bbs.Client, cellID string) error {
+func TaskEvents(ctx context.Context, printer Printer, stderr io.Writer, bbsClient bbs.Client, cellID string) error {
 	logger := globalLogger.Session("lrp-events")
 
-	es, err := bbsClient.SubscribeToTaskEvents(logger)
-	if err != nil {
-		return models.ConvertError(err)
-	}
-	defer es.Close()
-	encoder := json.NewEncoder(stdout)
-
 	var taskEvents LRPEvent
-	for {
-		event, err := es.Next()
-		switch err {
-		case nil:
-			taskEvents.Type = event.EventType()
-			taskEvents.Data = event
-			err = encoder.Encode(taskEvents)
-			if err != nil {
-				logger.Error("failed-to-marshal", err)
-			}
-		case io.EOF:
-			return nil
-		default:
-			return err
+	return diego.TaskEvents(ctx, logger, bbsClient, func(event models.Event) error {
+		taskEvents.Type = event.EventType()
+		taskEvents.Data = event
+		err := printer.Print(taskEvents)
+		if err != nil {
+			logger.Error("failed-to-marshal", err)
 		}
-	}
-	return nil
+		return nil
+	})
 }
@@ -2,9 +2,11 @@ package commands_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
+	"sync"
 
 	"code.cloudfoundry.org/bbs/events/eventfakes"
 	"code.cloudfoundry.org/bbs/fake_bbs"
@@ -55,7 +57,7 @@ var _ = Describe("Task Events", func() {
 
 		expectedLines := []string{string(data), string(data)}
 
-		err = commands.TaskEvents(stdout, stderr, fakeBBSClient, "")
+		err = commands.TaskEvents(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "")
 		Expect(err).NotTo(HaveOccurred())
 
 		stdoutData := stdout.Contents()
@@ -68,7 +70,7 @@ var _ = Describe("Task Events", func() {
 	})
 
 	It("closes the event stream", func() {
-		err := commands.TaskEvents(stdout, stderr, fakeBBSClient, "")
+		err := commands.TaskEvents(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "")
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeEventSource.CloseCallCount()).To(Equal(1))
@@ -80,7 +82,7 @@ var _ = Describe("Task Events", func() {
 		})
 
 		It("returns an error", func() {
-			err := commands.TaskEvents(stdout, stderr, fakeBBSClient, "")
+			err := commands.TaskEvents(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("failed to connect"))
 		})
@@ -93,9 +95,38 @@ var _ = Describe("Task Events", func() {
 		})
 
 		It("returns an error", func() {
-			err := commands.TaskEvents(stdout, stderr, fakeBBSClient, "")
+			err := commands.TaskEvents(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("boom"))
 		})
 	})
+
+	Context("when the context is cancelled", func() {
+		BeforeEach(func() {
+			closed := make(chan struct{})
+			var once sync.Once
+			fakeEventSource.CloseStub = func() error {
+				once.Do(func() { close(closed) })
+				return nil
+			}
+			fakeEventSource.NextStub = func() (models.Event, error) {
+				<-closed
+				return nil, errors.New("stream closed")
+			}
+		})
+
+		It("closes the event stream and returns the context error", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- commands.TaskEvents(ctx, commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "")
+			}()
+
+			Consistently(errCh).ShouldNot(Receive())
+			cancel()
+
+			Eventually(errCh).Should(Receive(Equal(context.Canceled)))
+			Expect(fakeEventSource.CloseCallCount()).To(BeNumerically(">=", 1))
+		})
+	})
 })
@@ -0,0 +1,174 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"github.com/spf13/cobra"
+)
+
+var taskStatsCmd = &cobra.Command{
+	Use:   "task-stats",
+	Short: "Show statistics of the tasks in BBS",
+	Long:  "Show the tasks counted by state, domain and cell, the age of the oldest pending task, the ages of the running tasks, the most common failure reasons and the completed tasks not yet resolved, as JSON or as a table",
+	RunE:  taskStats,
+}
+
+// Outputs of task-stats.
+const (
+	TaskStatsOutputJSON  = "json"
+	TaskStatsOutputTable = "table"
+)
+
+// flags
+var (
+	taskStatsDomainFlag, taskStatsCellIdFlag string
+	taskStatsOutputFlag                      string
+)
+
+func init() {
+	AddBBSAndTimeoutFlags(taskStatsCmd)
+	taskStatsCmd.Flags().StringVarP(&taskStatsDomainFlag, "domain", "d", "", "only count the tasks of the given domain")
+	taskStatsCmd.Flags().StringVarP(&taskStatsCellIdFlag, "cell-id", "c", "", "only count the tasks of the given cell-id")
+	taskStatsCmd.Flags().StringVar(&taskStatsOutputFlag, "output", TaskStatsOutputJSON, "output format, json or table")
+	RootCmd.AddCommand(taskStatsCmd)
+}
+
+func taskStats(cmd *cobra.Command, args []string) error {
+	err := ValidateTaskStatsArguments(args, taskStatsOutputFlag)
+	if err != nil {
+		return NewCFDotValidationError(cmd, err)
+	}
+
+	bbsClient, err := helpers.NewBBSClient(cmd, Config)
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	printer := newPrinter(cmd)
+	if taskStatsOutputFlag == TaskStatsOutputTable {
+		printer = PrinterFunc(func(value interface{}) error {
+			return RenderTaskStats(cmd.OutOrStdout(), value.(diego.TaskStats))
+		})
+	}
+
+	err = TaskStats(ctx, printer, bbsClient, models.TaskFilter{Domain: taskStatsDomainFlag, CellID: taskStatsCellIdFlag})
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+	return nil
+}
+
+func ValidateTaskStatsArguments(args []string, output string) error {
+	if len(args) > 0 {
+		return errExtraArguments
+	}
+	if output != TaskStatsOutputJSON && output != TaskStatsOutputTable {
+		return fmt.Errorf("The value '%s' is not a valid output. Please specify json or table.", output)
+	}
+	return nil
+}
+
+// TaskStats prints the diego.TaskStats of the tasks matching filter.
+func TaskStats(ctx context.Context, printer Printer, bbsClient bbs.Client, filter models.TaskFilter) error {
+	logger := globalLogger.Session("task-stats")
+
+	tasks, err := diego.Tasks(ctx, logger, bbsClient, filter)
+	if err != nil {
+		return err
+	}
+
+	return printer.Print(diego.AnalyzeTasks(tasks, time.Now()))
+}
+
+// RenderTaskStats writes stats to w as tables.
+func RenderTaskStats(w io.Writer, stats diego.TaskStats) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	states := []string{
+		models.Task_Pending.String(),
+		models.Task_Running.String(),
+		models.Task_Completed.String(),
+		models.Task_Resolving.String(),
+	}
+
+	fmt.Fprintf(tw, "Tasks:\t%d\n", stats.Total)
+	fmt.Fprintf(tw, "Oldest pending:\t%s\n", describeTaskAge(stats.OldestPending))
+	fmt.Fprintf(tw, "Completed, not resolved:\t%d\n", stats.CompletedUnresolved)
+	fmt.Fprintf(tw, "Oldest not resolved:\t%s\n", describeTaskAge(stats.OldestUnresolved))
+
+	fmt.Fprintf(tw, "\nDOMAIN\tTOTAL")
+	for _, state := range states {
+		fmt.Fprintf(tw, "\t%s", state)
+	}
+	fmt.Fprintln(tw)
+	for _, domain := range sortedCountKeys(stats.ByDomain) {
+		total := 0
+		for _, count := range stats.ByDomain[domain] {
+			total += count
+		}
+		fmt.Fprintf(tw, "%s\t%d", describeValue(domain), total)
+		for _, state := range states {
+			fmt.Fprintf(tw, "\t%d", stats.ByDomain[domain][state])
+		}
+		fmt.Fprintln(tw)
+	}
+	fmt.Fprintf(tw, "all domains\t%d", stats.Total)
+	for _, state := range states {
+		fmt.Fprintf(tw, "\t%d", stats.ByState[state])
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintf(tw, "\nRUNNING TASKS CREATED\tTASKS\n")
+	for _, bucket := range stats.RunningAges {
+		ages := bucket.Min + " - " + bucket.Max + " ago"
+		if bucket.Max == "" {
+			ages = bucket.Min + " ago or more"
+		}
+		fmt.Fprintf(tw, "%s\t%d\n", ages, bucket.Tasks)
+	}
+
+	fmt.Fprintf(tw, "\nCELL\tTASKS\n")
+	cells := make([]string, 0, len(stats.ByCell))
+	for cellID := range stats.ByCell {
+		cells = append(cells, cellID)
+	}
+	sort.Strings(cells)
+	for _, cellID := range cells {
+		fmt.Fprintf(tw, "%s\t%d\n", cellID, stats.ByCell[cellID])
+	}
+
+	fmt.Fprintf(tw, "\nFAILED TASKS\tFAILURE REASON\n")
+	for _, reason := range stats.FailureReasons {
+		fmt.Fprintf(tw, "%d\t%s\n", reason.Tasks, reason.Reason)
+	}
+
+	return tw.Flush()
+}
+
+func describeTaskAge(task *diego.TaskAge) string {
+	if task == nil {
+		return "-"
+	}
+	age := (time.Duration(task.AgeMs) * time.Millisecond).Round(time.Second)
+	return fmt.Sprintf("%s ago, task %s of domain %s", age, task.TaskGuid, describeValue(task.Domain))
+}
+
+func sortedCountKeys(counts map[string]map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
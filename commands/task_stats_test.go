@@ -0,0 +1,89 @@
+package commands_test
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/commands"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("TaskStats", func() {
+	Context("ValidateTaskStatsArguments", func() {
+		It("accepts the json and table outputs", func() {
+			Expect(commands.ValidateTaskStatsArguments([]string{}, "json")).To(Succeed())
+			Expect(commands.ValidateTaskStatsArguments([]string{}, "table")).To(Succeed())
+		})
+
+		It("rejects arguments", func() {
+			Expect(commands.ValidateTaskStatsArguments([]string{"foo"}, "json")).To(MatchError("Too many arguments specified"))
+		})
+
+		It("rejects other outputs", func() {
+			Expect(commands.ValidateTaskStatsArguments([]string{}, "yaml")).To(MatchError("The value 'yaml' is not a valid output. Please specify json or table."))
+		})
+	})
+
+	Context("TaskStats", func() {
+		var (
+			fakeBBSClient *fake_bbs.FakeClient
+			stdout        *gbytes.Buffer
+		)
+
+		BeforeEach(func() {
+			fakeBBSClient = &fake_bbs.FakeClient{}
+			stdout = gbytes.NewBuffer()
+			fakeBBSClient.TasksWithFilterReturns([]*models.Task{
+				{TaskGuid: "task-1", Domain: "cf-tasks", State: models.Task_Pending, CreatedAt: time.Now().UnixNano()},
+			}, nil)
+		})
+
+		It("prints the stats of the tasks matching the filter", func() {
+			filter := models.TaskFilter{Domain: "cf-tasks", CellID: "cell-1"}
+			err := commands.TaskStats(context.Background(), commands.NewJSONPrinter(stdout), fakeBBSClient, filter)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, actualFilter := fakeBBSClient.TasksWithFilterArgsForCall(0)
+			Expect(actualFilter).To(Equal(filter))
+
+			var stats diego.TaskStats
+			Expect(json.Unmarshal(stdout.Contents(), &stats)).To(Succeed())
+			Expect(stats.Total).To(Equal(1))
+			Expect(stats.OldestPending.TaskGuid).To(Equal("task-1"))
+		})
+
+		It("returns the BBS errors", func() {
+			fakeBBSClient.TasksWithFilterReturns(nil, models.ErrUnknownError)
+
+			err := commands.TaskStats(context.Background(), commands.NewJSONPrinter(stdout), fakeBBSClient, models.TaskFilter{})
+			Expect(err).To(Equal(models.ErrUnknownError))
+		})
+	})
+
+	Context("RenderTaskStats", func() {
+		It("writes the stats as tables", func() {
+			stats := diego.AnalyzeTasks([]*models.Task{
+				{TaskGuid: "task-1", Domain: "cf-tasks", State: models.Task_Pending, CreatedAt: time.Now().Add(-time.Hour).UnixNano()},
+				{TaskGuid: "task-2", Domain: "cf-tasks", CellId: "cell-1", State: models.Task_Completed, Failed: true, FailureReason: "exit status 1", CreatedAt: time.Now().UnixNano()},
+			}, time.Now())
+
+			Expect(commands.RenderTaskStats(stdout, stats)).To(Succeed())
+			Expect(stdout).To(gbytes.Say(`Tasks: +2\n`))
+			Expect(stdout).To(gbytes.Say(`Oldest pending: +1h0m0s ago, task task-1 of domain cf-tasks\n`))
+			Expect(stdout).To(gbytes.Say(`Completed, not resolved: +1\n`))
+			Expect(stdout).To(gbytes.Say(`DOMAIN +TOTAL +Pending +Running +Completed +Resolving\n`))
+			Expect(stdout).To(gbytes.Say(`cf-tasks +2 +1 +0 +1 +0\n`))
+			Expect(stdout).To(gbytes.Say(`all domains +2 +1 +0 +1 +0\n`))
+			Expect(stdout).To(gbytes.Say(`24h0m0s ago or more +0\n`))
+			Expect(stdout).To(gbytes.Say(`cell-1 +1\n`))
+			Expect(stdout).To(gbytes.Say(`1 +exit status 1\n`))
+		})
+	})
+})
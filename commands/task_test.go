@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"context"
 	"encoding/json"
 
 	"code.cloudfoundry.org/bbs/fake_bbs"
@@ -34,7 +35,7 @@ var _ = Describe("Task", func() {
 
 				fakeBBSClient.TaskByGuidReturns(task, nil)
 
-				err := commands.TaskByGuid(stdout, stderr, fakeBBSClient, taskGuid)
+				err := commands.TaskByGuid(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, taskGuid)
 				Expect(err).NotTo(HaveOccurred())
 
 				taskJSON, err := json.Marshal(task)
@@ -50,7 +51,7 @@ var _ = Describe("Task", func() {
 			It("returns an error back", func() {
 				fakeBBSClient.TaskByGuidReturns(nil, models.ErrResourceNotFound)
 
-				err := commands.TaskByGuid(stdout, stderr, fakeBBSClient, "broken")
+				err := commands.TaskByGuid(context.Background(), commands.NewJSONPrinter(stdout), stderr, fakeBBSClient, "broken")
 				Expect(err).To(HaveOccurred())
 				Expect(err).To(Equal(models.ErrResourceNotFound))
 			})
@@ -1,12 +1,13 @@
 package commands
 
 import (
-	"encoding/json"
+	"context"
 	"io"
 
 	"code.cloudfoundry.org/bbs"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"github.com/spf13/cobra"
 )
 
@@ -48,7 +49,10 @@ func tasks(cmd *cobra.Command, args []string) error {
 		return NewCFDotError(cmd, err)
 	}
 
-	err = Tasks(cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, tasksDomainFlag, tasksCellIdFlag)
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	err = Tasks(ctx, newPrinter(cmd), cmd.OutOrStderr(), bbsClient, tasksDomainFlag, tasksCellIdFlag)
 	if err != nil {
 		return NewCFDotError(cmd, err)
 	}
@@ -56,18 +60,14 @@ func tasks(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func Tasks(stdout, _ io.Writer, bbsClient bbs.Client, domain, cellID string) error {
-	var tasks []*models.Task
-	var err error
-
-	tasks, err = bbsClient.TasksWithFilter(globalLogger, models.TaskFilter{Domain: domain, CellID: cellID})
+func Tasks(ctx context.Context, printer Printer, _ io.Writer, bbsClient bbs.Client, domain, cellID string) error {
+	tasks, err := diego.Tasks(ctx, globalLogger, bbsClient, models.TaskFilter{Domain: domain, CellID: cellID})
 	if err != nil {
 		return err
 	}
 
-	encoder := json.NewEncoder(stdout)
 	for _, task := range tasks {
-		err = encoder.Encode(task)
+		err = printer.Print(task)
 		if err != nil {
 			return err
 		}
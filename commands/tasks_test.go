@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 
@@ -37,7 +38,7 @@ var _ = Describe("tasks", func() {
 		})
 
 		It("fetches tasks from BBS", func() {
-			err := commands.Tasks(stdout, nil, bbsClient, "", "")
+			err := commands.Tasks(context.Background(), commands.NewJSONPrinter(stdout), nil, bbsClient, "", "")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(bbsClient.TasksWithFilterCallCount()).To(Equal(1))
 		})
@@ -45,7 +46,7 @@ var _ = Describe("tasks", func() {
 		It("outputs some JSON tasks", func() {
 			bbsClient.TasksReturns(testData, nil)
 
-			err := commands.Tasks(stdout, nil, bbsClient, "", "")
+			err := commands.Tasks(context.Background(), commands.NewJSONPrinter(stdout), nil, bbsClient, "", "")
 			Expect(err).NotTo(HaveOccurred())
 
 			expectedOutput1, err := json.Marshal(&testTask1)
@@ -59,7 +60,7 @@ var _ = Describe("tasks", func() {
 		Context("when there are task filters", func() {
 			Context("when there is the domain filter", func() {
 				It("should filter by domain", func() {
-					err := commands.Tasks(stdout, nil, bbsClient, "domain", "")
+					err := commands.Tasks(context.Background(), commands.NewJSONPrinter(stdout), nil, bbsClient, "domain", "")
 					Expect(err).NotTo(HaveOccurred())
 
 					_, filter := bbsClient.TasksWithFilterArgsForCall(0)
@@ -74,7 +75,7 @@ var _ = Describe("tasks", func() {
 
 			Context("when there is the cellID filter", func() {
 				It("should filter by cellID", func() {
-					err := commands.Tasks(stdout, nil, bbsClient, "", "cell-id")
+					err := commands.Tasks(context.Background(), commands.NewJSONPrinter(stdout), nil, bbsClient, "", "cell-id")
 					Expect(err).NotTo(HaveOccurred())
 
 					_, filter := bbsClient.TasksWithFilterArgsForCall(0)
@@ -89,7 +90,7 @@ var _ = Describe("tasks", func() {
 
 			Context("when there is the cellID filter", func() {
 				It("should filter by cellID", func() {
-					err := commands.Tasks(stdout, nil, bbsClient, "domain", "cell-id")
+					err := commands.Tasks(context.Background(), commands.NewJSONPrinter(stdout), nil, bbsClient, "domain", "cell-id")
 					Expect(err).NotTo(HaveOccurred())
 
 					_, filter := bbsClient.TasksWithFilterArgsForCall(0)
@@ -109,7 +110,7 @@ var _ = Describe("tasks", func() {
 			})
 
 			It("outputs nothing", func() {
-				err := commands.Tasks(stdout, nil, bbsClient, "", "")
+				err := commands.Tasks(context.Background(), commands.NewJSONPrinter(stdout), nil, bbsClient, "", "")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(stdout.Contents()).To(BeEmpty())
 			})
@@ -119,7 +120,7 @@ var _ = Describe("tasks", func() {
 			It("should return the error", func() {
 				testError := errors.New("barf")
 				bbsClient.TasksWithFilterReturns(nil, testError)
-				err := commands.Tasks(stdout, nil, bbsClient, "", "")
+				err := commands.Tasks(context.Background(), commands.NewJSONPrinter(stdout), nil, bbsClient, "", "")
 				Expect(err).To(Equal(testError))
 			})
 		})
@@ -128,7 +129,7 @@ var _ = Describe("tasks", func() {
 			It("should return the error", func() {
 				err := stdout.Close()
 				Expect(err).NotTo(HaveOccurred())
-				err = commands.Tasks(stdout, nil, bbsClient, "", "")
+				err = commands.Tasks(context.Background(), commands.NewJSONPrinter(stdout), nil, bbsClient, "", "")
 				Expect(err).To(HaveOccurred())
 			})
 		})
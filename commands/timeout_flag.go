@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 
@@ -15,7 +16,8 @@ var (
 
 func AddBBSAndTimeoutFlags(cmd *cobra.Command) {
 	AddBBSFlags(cmd)
-	cmd.Flags().IntVar(&timeoutConfig.Timeout, "timeout", 0, "timeout for BBS requests in seconds [environment variable equivalent: CFDOT_TIMEOUT]")
+	cmd.Flags().IntVar(&timeoutConfig.Timeout, "timeout", 0, "timeout for the whole command in seconds, also used for BBS requests unless --bbs-timeout is set [environment variable equivalent: CFDOT_TIMEOUT]")
+	cmd.Flags().IntVar(&timeoutConfig.BBSTimeout, "bbs-timeout", 0, "timeout for BBS requests in seconds [environment variable equivalent: CFDOT_BBS_TIMEOUT]")
 	timeoutPreHooks = append(timeoutPreHooks, cmd.PreRunE)
 	cmd.PreRunE = TimeoutPrehook
 }
@@ -43,13 +45,44 @@ func TimeoutPrehook(cmd *cobra.Command, args []string) error {
 }
 
 func setTimeoutFlag(cmd *cobra.Command, args []string) error {
-	if timeoutConfig.Timeout == 0 && os.Getenv("CFDOT_TIMEOUT") != "" {
-		timeout, err := strconv.ParseInt(os.Getenv("CFDOT_TIMEOUT"), 10, 16)
-		if err != nil {
-			return err
-		}
-		timeoutConfig.Timeout = int(timeout)
+	var err error
+
+	timeoutConfig.Timeout, err = timeoutFromEnv(cmd, "timeout", timeoutConfig.Timeout, "CFDOT_TIMEOUT")
+	if err != nil {
+		return err
+	}
+
+	timeoutConfig.BBSTimeout, err = timeoutFromEnv(cmd, "bbs-timeout", timeoutConfig.BBSTimeout, "CFDOT_BBS_TIMEOUT")
+	if err != nil {
+		return err
 	}
 
 	return nil
 }
+
+// timeoutFromEnv validates the value of the timeout flag named flagName,
+// falling back to the environment variable envVar when the flag is not set.
+func timeoutFromEnv(cmd *cobra.Command, flagName string, value int, envVar string) (int, error) {
+	if value < 0 {
+		return 0, NewCFDotValidationError(
+			cmd,
+			fmt.Errorf("--%s must be a non-negative number of seconds", flagName),
+		)
+	}
+
+	if value != 0 || os.Getenv(envVar) == "" {
+		return value, nil
+	}
+
+	timeout, err := strconv.ParseInt(os.Getenv(envVar), 10, 16)
+	if err != nil || timeout < 0 {
+		return 0, NewCFDotValidationError(
+			cmd,
+			fmt.Errorf(
+				"The value '%s' is not a valid value for %s. Please specify a non-negative number of seconds.",
+				os.Getenv(envVar), envVar),
+		)
+	}
+
+	return int(timeout), nil
+}
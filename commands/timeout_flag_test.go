@@ -83,5 +83,59 @@ var _ = Describe("Timeout Flag", func() {
 				Expect(commands.Config.Timeout).To(Equal(0))
 			})
 		})
+
+		Context("when the bbs timeout is set through env var", func() {
+			BeforeEach(func() {
+				os.Setenv("CFDOT_BBS_TIMEOUT", "5")
+			})
+
+			AfterEach(func() {
+				os.Unsetenv("CFDOT_BBS_TIMEOUT")
+			})
+
+			It("should set the bbs timeout in the configuration", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(commands.Config.BBSTimeout).To(Equal(5))
+			})
+		})
+
+		Context("when a negative timeout is set through env var", func() {
+			BeforeEach(func() {
+				os.Setenv("CFDOT_TIMEOUT", "-1")
+			})
+
+			It("returns a validation error", func() {
+				Expect(err).To(MatchError("The value '-1' is not a valid value for CFDOT_TIMEOUT. Please specify a non-negative number of seconds."))
+				Expect(err.(commands.CFDotError).ExitCode()).To(Equal(3))
+			})
+		})
+	})
+
+	Context("when --bbs-timeout is passed in as an argument", func() {
+		BeforeEach(func() {
+			validFlags["--timeout"] = "10"
+			validFlags["--bbs-timeout"] = "3"
+			parseFlagsErr := dummyCmd.ParseFlags(buildArgList(validFlags))
+			Expect(parseFlagsErr).NotTo(HaveOccurred())
+		})
+
+		It("sets both timeouts in the configuration", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(commands.Config.Timeout).To(Equal(10))
+			Expect(commands.Config.BBSTimeout).To(Equal(3))
+		})
+	})
+
+	Context("when a negative --bbs-timeout is passed in as an argument", func() {
+		BeforeEach(func() {
+			validFlags["--bbs-timeout"] = "-3"
+			parseFlagsErr := dummyCmd.ParseFlags(buildArgList(validFlags))
+			Expect(parseFlagsErr).NotTo(HaveOccurred())
+		})
+
+		It("returns a validation error", func() {
+			Expect(err).To(MatchError("--bbs-timeout must be a non-negative number of seconds"))
+			Expect(err.(commands.CFDotError).ExitCode()).To(Equal(3))
+		})
 	})
 })
@@ -1,10 +1,15 @@
 package commands
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strconv"
+	"time"
 
 	"code.cloudfoundry.org/cfdot/commands/helpers"
 
@@ -18,14 +23,43 @@ var (
 )
 
 var (
-	Config helpers.TLSConfig
+	Config            helpers.TLSConfig
+	certExpiryWarning time.Duration
+
+	// validatedCertificates records the certificates already validated when
+	// cfdot shell runs the TLS pre-hook for each of its commands, so that they
+	// are read, checked and warned about once per shell. It is nil otherwise.
+	validatedCertificates map[certificateFiles]bool
 )
 
+// certificateFiles identifies a set of certificates validated together.
+type certificateFiles struct {
+	CACertFile, CertFile, KeyFile string
+	SkipCertVerify                bool
+}
+
+func currentCertificateFiles() certificateFiles {
+	return certificateFiles{
+		CACertFile:     Config.CACertFile,
+		CertFile:       Config.CertFile,
+		KeyFile:        Config.KeyFile,
+		SkipCertVerify: Config.SkipCertVerify,
+	}
+}
+
+const defaultCertExpiryWarning = 30 * 24 * time.Hour
+
+// skipCertificateValidation is the annotation of the commands whose
+// certificates are not validated before they run, such as doctor, which
+// diagnoses them itself.
+const skipCertificateValidation = "cfdot.skip-certificate-validation"
+
 func AddTLSFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&Config.SkipCertVerify, "skipCertVerify", false, "when set to true, skips all SSL/TLS certificate verification [environment variable equivalent: SKIP_CERT_VERIFY]")
 	cmd.Flags().StringVar(&Config.CACertFile, "caCertFile", "", "path the Certificate Authority (CA) file to use when verifying TLS keypairs [environment variable equivalent: CA_CERT_FILE]")
 	cmd.Flags().StringVar(&Config.CertFile, "clientCertFile", "", "path to the TLS client certificate to use during mutual-auth TLS [environment variable equivalent: CLIENT_CERT_FILE]")
 	cmd.Flags().StringVar(&Config.KeyFile, "clientKeyFile", "", "path to the TLS client private key file to use during mutual-auth TLS [environment variable equivalent: CLIENT_KEY_FILE]")
+	cmd.Flags().DurationVar(&certExpiryWarning, "cert-expiry-warning", defaultCertExpiryWarning, "warn when the client or CA certificate expires within this duration, such as 720h, 0 disables the warning [environment variable equivalent: CFDOT_CERT_EXPIRY_WARNING]")
 
 	cmd.PreRunE = tlsPreHook
 }
@@ -92,5 +126,181 @@ func tlsPreHook(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if !cmd.Flags().Lookup("cert-expiry-warning").Changed && os.Getenv("CFDOT_CERT_EXPIRY_WARNING") != "" {
+		certExpiryWarning, err = time.ParseDuration(os.Getenv("CFDOT_CERT_EXPIRY_WARNING"))
+		if err != nil {
+			return NewCFDotValidationError(
+				cmd,
+				fmt.Errorf(
+					"The value '%s' is not a valid value for CFDOT_CERT_EXPIRY_WARNING. Please specify a duration such as 720h.",
+					os.Getenv("CFDOT_CERT_EXPIRY_WARNING")),
+			)
+		}
+	}
+
+	if certExpiryWarning < 0 {
+		return NewCFDotValidationError(cmd, errors.New("--cert-expiry-warning must not be negative"))
+	}
+
+	if _, ok := cmd.Annotations[skipCertificateValidation]; ok {
+		return nil
+	}
+
+	files := currentCertificateFiles()
+	if validatedCertificates[files] {
+		return nil
+	}
+
+	err = validateCertificates(cmd)
+	if err == nil && validatedCertificates != nil {
+		validatedCertificates[files] = true
+	}
+	return err
+}
+
+// validateCertificates parses the client certificate and key and, unless
+// certificate verification is skipped, the CA certificates. It fails when the
+// key does not match the certificate, the client certificate or every CA
+// certificate has expired, or the client certificate no longer verifies
+// against the CA certificates, and warns on stderr about the other expired
+// CA certificates and those expiring within the --cert-expiry-warning
+// window.
+func validateCertificates(cmd *cobra.Command) error {
+	now := time.Now()
+
+	clientCerts, err := parseCertificates(Config.CertFile)
+	if err != nil {
+		return NewCFDotValidationError(
+			cmd,
+			fmt.Errorf("cert file '%s' is not a valid certificate: %s", Config.CertFile, err.Error()),
+		)
+	}
+
+	_, err = tls.LoadX509KeyPair(Config.CertFile, Config.KeyFile)
+	if err != nil {
+		return NewCFDotValidationError(
+			cmd,
+			fmt.Errorf("key file '%s' is not a valid key for cert file '%s': %s", Config.KeyFile, Config.CertFile, err.Error()),
+		)
+	}
+
+	err = validateCertificateExpiry(cmd, clientCerts[0], Config.CertFile, "cert", now)
+	if err != nil {
+		return err
+	}
+
+	if Config.SkipCertVerify {
+		return nil
+	}
+
+	caCerts, err := parseCertificates(Config.CACertFile)
+	if err != nil {
+		return NewCFDotValidationError(
+			cmd,
+			fmt.Errorf("CA cert file '%s' is not a valid certificate: %s", Config.CACertFile, err.Error()),
+		)
+	}
+
+	// CA bundles keep the old CA next to the new one while it is rotated, so
+	// an expired CA only matters once none is left or it issued the client
+	// certificate.
+	pool := x509.NewCertPool()
+	expired := 0
+	for _, caCert := range caCerts {
+		pool.AddCert(caCert)
+		if now.After(caCert.NotAfter) {
+			expired++
+		}
+	}
+
+	if expired == len(caCerts) {
+		return validateCertificateExpiry(cmd, caCerts[len(caCerts)-1], Config.CACertFile, "CA cert", now)
+	}
+	for _, caCert := range caCerts {
+		if now.After(caCert.NotAfter) {
+			fmt.Fprintf(cmd.OutOrStderr(), "Warning: CA cert file '%s' holds a certificate for %s that expired on %s\n", Config.CACertFile, caCert.Subject.CommonName, caCert.NotAfter.UTC().Format(time.RFC3339))
+			continue
+		}
+		err = validateCertificateExpiry(cmd, caCert, Config.CACertFile, "CA cert", now)
+		if err != nil {
+			return err
+		}
+	}
+
+	if issuedByExpiredCA(clientCerts[0], pool, now) {
+		return NewCFDotValidationError(
+			cmd,
+			fmt.Errorf("cert file '%s' no longer verifies against CA cert file '%s': the CA that issued it has expired", Config.CertFile, Config.CACertFile),
+		)
+	}
+
+	return nil
+}
+
+// issuedByExpiredCA reports whether cert was issued by a CA in pool but no
+// longer verifies against it at now. Client certificates issued by a CA
+// outside of pool, which the servers may trust, are not reported.
+func issuedByExpiredCA(cert *x509.Certificate, pool *x509.CertPool, now time.Time) bool {
+	opts := x509.VerifyOptions{
+		Roots:       pool,
+		CurrentTime: now,
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	if _, err := cert.Verify(opts); err == nil {
+		return false
+	}
+
+	opts.CurrentTime = cert.NotBefore
+	_, err := cert.Verify(opts)
+	return err == nil
+}
+
+func validateCertificateExpiry(cmd *cobra.Command, cert *x509.Certificate, filename, filetype string, now time.Time) error {
+	expiry := cert.NotAfter.UTC().Format(time.RFC3339)
+
+	if now.After(cert.NotAfter) {
+		return NewCFDotValidationError(
+			cmd,
+			fmt.Errorf("%s file '%s' expired on %s", filetype, filename, expiry),
+		)
+	}
+
+	if now.Add(certExpiryWarning).After(cert.NotAfter) {
+		fmt.Fprintf(cmd.OutOrStderr(), "Warning: %s file '%s' expires on %s\n", filetype, filename, expiry)
+	}
+
 	return nil
 }
+
+// parseCertificates returns the PEM encoded certificates in filename, in the
+// order they appear.
+func parseCertificates(filename string) ([]*x509.Certificate, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+
+	return certs, nil
+}
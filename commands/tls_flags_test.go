@@ -1,7 +1,17 @@
 package commands_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
 	"os"
+	"path/filepath"
+	"time"
 
 	"code.cloudfoundry.org/cfdot/commands"
 	. "github.com/onsi/ginkgo"
@@ -359,6 +369,228 @@ var _ = Describe("TLSFlags", func() {
 				})
 			})
 
+			Describe("certificates", func() {
+				var tmpDir string
+
+				BeforeEach(func() {
+					var err error
+					tmpDir, err = ioutil.TempDir("", "tls-flags")
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				AfterEach(func() {
+					os.RemoveAll(tmpDir)
+				})
+
+				Context("when all certificates are valid beyond the warning window", func() {
+					BeforeEach(func() {
+						parseFlagsErr := dummyCmd.ParseFlags(buildArgList(validTLSFlags))
+						Expect(parseFlagsErr).NotTo(HaveOccurred())
+					})
+
+					It("does not warn", func() {
+						Expect(err).NotTo(HaveOccurred())
+						Expect(output.Contents()).To(BeEmpty())
+					})
+				})
+
+				Context("when the cert file does not hold a certificate", func() {
+					BeforeEach(func() {
+						parseFlagsErr := dummyCmd.ParseFlags(replaceFlagValue(validTLSFlags, "--clientCertFile", "fixtures/bbsClient.key"))
+						Expect(parseFlagsErr).NotTo(HaveOccurred())
+					})
+
+					It("returns a validation error", func() {
+						Expect(err).To(MatchError("cert file 'fixtures/bbsClient.key' is not a valid certificate: no PEM encoded certificate found"))
+						cfdotError, ok := err.(commands.CFDotError)
+						Expect(ok).To(BeTrue())
+						Expect(cfdotError.ExitCode()).To(Equal(3))
+					})
+				})
+
+				Context("when the key does not match the certificate", func() {
+					BeforeEach(func() {
+						_, keyFile := writeSelfSignedCertificate(tmpDir, time.Now().Add(365*24*time.Hour))
+						parseFlagsErr := dummyCmd.ParseFlags(replaceFlagValue(validTLSFlags, "--clientKeyFile", keyFile))
+						Expect(parseFlagsErr).NotTo(HaveOccurred())
+					})
+
+					It("returns a validation error", func() {
+						Expect(err).To(MatchError(MatchRegexp("^key file '.*' is not a valid key for cert file 'fixtures/bbsClient.crt': .*")))
+						cfdotError, ok := err.(commands.CFDotError)
+						Expect(ok).To(BeTrue())
+						Expect(cfdotError.ExitCode()).To(Equal(3))
+					})
+				})
+
+				Context("when the client certificate has expired", func() {
+					var certFile string
+
+					BeforeEach(func() {
+						var keyFile string
+						certFile, keyFile = writeSelfSignedCertificate(tmpDir, time.Now().Add(-time.Hour))
+						parseFlagsErr := dummyCmd.ParseFlags(mergeFlags(validTLSFlags, map[string]string{
+							"--clientCertFile": certFile,
+							"--clientKeyFile":  keyFile,
+						}))
+						Expect(parseFlagsErr).NotTo(HaveOccurred())
+					})
+
+					It("returns a validation error", func() {
+						Expect(err).To(MatchError(MatchRegexp("^cert file '" + certFile + "' expired on .*")))
+						cfdotError, ok := err.(commands.CFDotError)
+						Expect(ok).To(BeTrue())
+						Expect(cfdotError.ExitCode()).To(Equal(3))
+					})
+				})
+
+				Context("when the CA certificate has expired", func() {
+					var caFile string
+
+					BeforeEach(func() {
+						caFile, _ = writeSelfSignedCertificate(tmpDir, time.Now().Add(-time.Hour))
+						parseFlagsErr := dummyCmd.ParseFlags(replaceFlagValue(validTLSFlags, "--caCertFile", caFile))
+						Expect(parseFlagsErr).NotTo(HaveOccurred())
+					})
+
+					It("returns a validation error", func() {
+						Expect(err).To(MatchError(MatchRegexp("^CA cert file '" + caFile + "' expired on .*")))
+					})
+				})
+
+				Context("when the CA bundle holds an expired CA next to a valid one", func() {
+					var caFile string
+
+					BeforeEach(func() {
+						expiredFile, _ := writeSelfSignedCertificate(tmpDir, time.Now().Add(-time.Hour))
+						caFile = writeBundle(tmpDir, expiredFile, "fixtures/bbsCACert.crt")
+						parseFlagsErr := dummyCmd.ParseFlags(replaceFlagValue(validTLSFlags, "--caCertFile", caFile))
+						Expect(parseFlagsErr).NotTo(HaveOccurred())
+					})
+
+					It("only warns about the expired CA", func() {
+						Expect(err).NotTo(HaveOccurred())
+						Expect(output).To(gbytes.Say("Warning: CA cert file '" + caFile + "' holds a certificate for cfdot that expired on "))
+					})
+				})
+
+				Context("when the CA that issued the client certificate has expired", func() {
+					var certFile, caFile string
+
+					BeforeEach(func() {
+						var keyFile string
+						certFile, keyFile, caFile = writeCertificateIssuedByExpiredCA(tmpDir)
+						caFile = writeBundle(tmpDir, caFile, "fixtures/bbsCACert.crt")
+						parseFlagsErr := dummyCmd.ParseFlags(mergeFlags(validTLSFlags, map[string]string{
+							"--clientCertFile": certFile,
+							"--clientKeyFile":  keyFile,
+							"--caCertFile":     caFile,
+						}))
+						Expect(parseFlagsErr).NotTo(HaveOccurred())
+					})
+
+					It("returns a validation error", func() {
+						Expect(err).To(MatchError("cert file '" + certFile + "' no longer verifies against CA cert file '" + caFile + "': the CA that issued it has expired"))
+					})
+				})
+
+				Context("when the command checks the certificates itself", func() {
+					BeforeEach(func() {
+						dummyCmd.Annotations = map[string]string{"cfdot.skip-certificate-validation": "true"}
+						certFile, keyFile := writeSelfSignedCertificate(tmpDir, time.Now().Add(-time.Hour))
+						parseFlagsErr := dummyCmd.ParseFlags(mergeFlags(validTLSFlags, map[string]string{
+							"--clientCertFile": certFile,
+							"--clientKeyFile":  keyFile,
+						}))
+						Expect(parseFlagsErr).NotTo(HaveOccurred())
+					})
+
+					It("does not validate them", func() {
+						Expect(err).NotTo(HaveOccurred())
+					})
+				})
+
+				Context("when the client certificate expires within the warning window", func() {
+					var certFile string
+
+					BeforeEach(func() {
+						var keyFile string
+						certFile, keyFile = writeSelfSignedCertificate(tmpDir, time.Now().Add(24*time.Hour))
+						mergeFlags(validTLSFlags, map[string]string{
+							"--clientCertFile": certFile,
+							"--clientKeyFile":  keyFile,
+						})
+					})
+
+					Context("with the default window", func() {
+						BeforeEach(func() {
+							parseFlagsErr := dummyCmd.ParseFlags(buildArgList(validTLSFlags))
+							Expect(parseFlagsErr).NotTo(HaveOccurred())
+						})
+
+						It("warns on stderr", func() {
+							Expect(err).NotTo(HaveOccurred())
+							Expect(output).To(gbytes.Say("Warning: cert file '" + certFile + "' expires on "))
+						})
+					})
+
+					Context("when the window is shorter", func() {
+						BeforeEach(func() {
+							parseFlagsErr := dummyCmd.ParseFlags(mergeFlags(validTLSFlags, map[string]string{"--cert-expiry-warning": "1h"}))
+							Expect(parseFlagsErr).NotTo(HaveOccurred())
+						})
+
+						It("does not warn", func() {
+							Expect(err).NotTo(HaveOccurred())
+							Expect(output.Contents()).To(BeEmpty())
+						})
+					})
+
+					Context("when CFDOT_CERT_EXPIRY_WARNING is set", func() {
+						AfterEach(func() {
+							os.Unsetenv("CFDOT_CERT_EXPIRY_WARNING")
+						})
+
+						BeforeEach(func() {
+							os.Setenv("CFDOT_CERT_EXPIRY_WARNING", "0")
+							parseFlagsErr := dummyCmd.ParseFlags(buildArgList(validTLSFlags))
+							Expect(parseFlagsErr).NotTo(HaveOccurred())
+						})
+
+						It("uses the window from the environment variable", func() {
+							Expect(err).NotTo(HaveOccurred())
+							Expect(output.Contents()).To(BeEmpty())
+						})
+					})
+				})
+
+				Context("when CFDOT_CERT_EXPIRY_WARNING is not a duration", func() {
+					AfterEach(func() {
+						os.Unsetenv("CFDOT_CERT_EXPIRY_WARNING")
+					})
+
+					BeforeEach(func() {
+						os.Setenv("CFDOT_CERT_EXPIRY_WARNING", "a month")
+						parseFlagsErr := dummyCmd.ParseFlags(buildArgList(validTLSFlags))
+						Expect(parseFlagsErr).NotTo(HaveOccurred())
+					})
+
+					It("returns a validation error", func() {
+						Expect(err).To(MatchError("The value 'a month' is not a valid value for CFDOT_CERT_EXPIRY_WARNING. Please specify a duration such as 720h."))
+					})
+				})
+
+				Context("when --cert-expiry-warning is negative", func() {
+					BeforeEach(func() {
+						parseFlagsErr := dummyCmd.ParseFlags(mergeFlags(validTLSFlags, map[string]string{"--cert-expiry-warning": "-1h"}))
+						Expect(parseFlagsErr).NotTo(HaveOccurred())
+					})
+
+					It("returns a validation error", func() {
+						Expect(err).To(MatchError("--cert-expiry-warning must not be negative"))
+					})
+				})
+			})
 		})
 	})
 
@@ -687,3 +919,86 @@ var _ = Describe("TLSFlags", func() {
 		})
 	})
 })
+
+// writeSelfSignedCertificate writes a certificate expiring at notAfter and
+// its key into dir, and returns the paths of both files.
+func writeSelfSignedCertificate(dir string, notAfter time.Time) (string, string) {
+	certFile, keyFile, _, _ := writeCertificate(dir, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "cfdot"},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}, nil, nil)
+	return certFile, keyFile
+}
+
+// writeCertificateIssuedByExpiredCA writes a client certificate that is still
+// valid, its key and the CA that issued it, which has expired, into dir, and
+// returns the paths of the three files.
+func writeCertificateIssuedByExpiredCA(dir string) (string, string, string) {
+	notAfter := time.Now().Add(-time.Hour)
+	caFile, _, ca, caKey := writeCertificate(dir, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "expired-ca"},
+		NotBefore:             notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+
+	certFile, keyFile, _, _ := writeCertificate(dir, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "cfdot"},
+		NotBefore:    notAfter.Add(-24 * time.Hour),
+		NotAfter:     time.Now().Add(365 * 24 * time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	return certFile, keyFile, caFile
+}
+
+// writeBundle concatenates the certificates in files into a new file in dir
+// and returns its path.
+func writeBundle(dir string, files ...string) string {
+	bundle, err := ioutil.TempFile(dir, "bundle")
+	Expect(err).NotTo(HaveOccurred())
+	defer bundle.Close()
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = bundle.Write(data)
+		Expect(err).NotTo(HaveOccurred())
+	}
+	return bundle.Name()
+}
+
+// writeCertificate writes the certificate made from template, issued by
+// parent with parentKey or self-signed when parent is nil, and its new key
+// into dir. It returns the paths of both files, the certificate and its key.
+func writeCertificate(dir string, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (string, string, *x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	Expect(err).NotTo(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+
+	certFile, err := ioutil.TempFile(dir, "cert")
+	Expect(err).NotTo(HaveOccurred())
+	defer certFile.Close()
+	Expect(pem.Encode(certFile, &pem.Block{Type: "CERTIFICATE", Bytes: der})).To(Succeed())
+
+	keyFile := filepath.Join(dir, filepath.Base(certFile.Name())+".key")
+	Expect(ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)).To(Succeed())
+
+	return certFile.Name(), keyFile, cert, key
+}
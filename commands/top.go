@@ -0,0 +1,307 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"code.cloudfoundry.org/rep"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Show a live dashboard of cells, LRPs and events",
+	Long:  "Show a full-screen dashboard of the cells and their utilization, the LRPs and the state of their instances, and a log of the latest LRP and task events. Press d to filter by domain, p to filter by process guid, c to clear the filters and q to quit",
+	RunE:  top,
+}
+
+const (
+	topMaxEvents = 500
+
+	// topRedrawInterval bounds how often the screen is drawn, so that a burst
+	// of events does not flood the terminal.
+	topRedrawInterval = 250 * time.Millisecond
+
+	escape          = "\x1b"
+	enterAltScreen  = escape + "[?1049h"
+	leaveAltScreen  = escape + "[?1049l"
+	hideCursor      = escape + "[?25l"
+	showCursor      = escape + "[?25h"
+	clearScreen     = escape + "[H" + escape + "[2J"
+	keyCtrlC        = 3
+	keyCtrlD        = 4
+	keyBackspace    = 8
+	keyEnter        = '\r'
+	keyEscape       = 27
+	keyDelete       = 127
+	topFilterDomain = "domain"
+	topFilterGuid   = "process guid"
+)
+
+var topRefreshIntervalFlag time.Duration
+
+// errors
+var (
+	errNotATerminal           = errors.New("top must be run in a terminal")
+	errInvalidRefreshInterval = errors.New("--refresh-interval must be positive")
+)
+
+func init() {
+	AddBBSFlags(topCmd)
+	topCmd.Flags().DurationVar(&topRefreshIntervalFlag, "refresh-interval", 30*time.Second, "how often the cells, their utilization and the LRPs are fetched again")
+	AddRepTimeoutFlag(topCmd)
+	RootCmd.AddCommand(topCmd)
+}
+
+func top(cmd *cobra.Command, args []string) error {
+	err := ValidateTopArguments(args, topRefreshIntervalFlag)
+	if err != nil {
+		return NewCFDotValidationError(cmd, err)
+	}
+
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) || !terminal.IsTerminal(int(os.Stdout.Fd())) {
+		return NewCFDotValidationError(cmd, errNotATerminal)
+	}
+
+	bbsClient, err := helpers.NewBBSClient(cmd, Config)
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+
+	repClientFactory, err := helpers.NewRepClientFactory(cmd, Config)
+	if err != nil {
+		return NewCFDotRepError(cmd, fmt.Errorf("Failed creating rep client factory: %s", err), "")
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	err = Top(ctx, os.Stdin, os.Stdout, bbsClient, repClientFactory, topRefreshIntervalFlag)
+	if err != nil && !isInterrupted(err) {
+		return NewCFDotComponentError(cmd, err)
+	}
+	return nil
+}
+
+func ValidateTopArguments(args []string, refreshInterval time.Duration) error {
+	switch {
+	case len(args) > 0:
+		return errExtraArguments
+	case refreshInterval <= 0:
+		return errInvalidRefreshInterval
+	default:
+		return nil
+	}
+}
+
+// Top draws the dashboard on the terminal out until q is pressed on the
+// terminal in, ctx is done or the event streams fail. The terminal is put in
+// raw mode and switched to its alternate screen for as long as Top runs.
+func Top(ctx context.Context, in, out *os.File, bbsClient bbs.Client, clientFactory rep.ClientFactory, refreshInterval time.Duration) error {
+	logger := globalLogger.Session("top")
+
+	state, err := terminal.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return err
+	}
+	defer terminal.Restore(int(in.Fd()), state)
+
+	io.WriteString(out, enterAltScreen+hideCursor)
+	defer io.WriteString(out, showCursor+leaveAltScreen)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	dashboard := diego.NewDashboard(topMaxEvents)
+
+	changed := make(chan struct{}, 1)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- diego.WatchDashboard(ctx, logger, bbsClient, clientFactory, dashboard, refreshInterval, func() {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		})
+	}()
+
+	// The reading goroutine is left blocked on the terminal when Top returns;
+	// cfdot exits right after.
+	keys := make(chan byte)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := in.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			for _, key := range buf[:n] {
+				keys <- key
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(topRedrawInterval)
+	defer ticker.Stop()
+
+	ui := &topUI{status: "Loading..."}
+	dirty := true
+	for {
+		select {
+		case <-changed:
+			ui.status = ""
+			dirty = true
+		case key, ok := <-keys:
+			if !ok || ui.handleKey(key) {
+				return nil
+			}
+			dirty = true
+		case err := <-watchErr:
+			return err
+		case <-ticker.C:
+			if !dirty {
+				continue
+			}
+			width, height, err := terminal.GetSize(int(out.Fd()))
+			if err != nil {
+				return err
+			}
+			lines := RenderDashboard(dashboard.View(ui.filter), ui.filter, ui.prompt(), width, height)
+			io.WriteString(out, clearScreen+strings.Join(lines, "\r\n"))
+			dirty = false
+		}
+	}
+}
+
+// topUI holds the filter of the dashboard and the filter being typed in.
+type topUI struct {
+	filter  diego.DashboardFilter
+	editing string
+	input   []byte
+	status  string
+}
+
+// handleKey applies a key pressed on the dashboard and reports whether it
+// quits.
+func (ui *topUI) handleKey(key byte) bool {
+	if ui.editing == "" {
+		switch key {
+		case 'q', keyCtrlC, keyCtrlD:
+			return true
+		case 'd':
+			ui.editing, ui.input = topFilterDomain, []byte(ui.filter.Domain)
+		case 'p':
+			ui.editing, ui.input = topFilterGuid, []byte(ui.filter.ProcessGuid)
+		case 'c':
+			ui.filter = diego.DashboardFilter{}
+		}
+		return false
+	}
+
+	switch {
+	case key == keyEnter || key == '\n':
+		if ui.editing == topFilterDomain {
+			ui.filter.Domain = string(ui.input)
+		} else {
+			ui.filter.ProcessGuid = string(ui.input)
+		}
+		ui.editing = ""
+	case key == keyEscape || key == keyCtrlC:
+		ui.editing = ""
+	case key == keyBackspace || key == keyDelete:
+		if len(ui.input) > 0 {
+			ui.input = ui.input[:len(ui.input)-1]
+		}
+	case key >= ' ' && key < keyDelete:
+		ui.input = append(ui.input, key)
+	}
+	return false
+}
+
+func (ui *topUI) prompt() string {
+	if ui.editing != "" {
+		return fmt.Sprintf("Filter by %s (enter to apply, esc to cancel): %s_", ui.editing, ui.input)
+	}
+	return ui.status
+}
+
+// RenderDashboard lays out view in lines no wider than width, fitting the
+// cells, the LRPs and the latest events in height lines. The second line
+// shows prompt, or the keys of the dashboard when prompt is empty.
+func RenderDashboard(view diego.DashboardView, filter diego.DashboardFilter, prompt string, width, height int) []string {
+	header := fmt.Sprintf("cfdot top - %d cells, %d LRPs", len(view.Cells), len(view.LRPs))
+	if filter.Domain != "" {
+		header += fmt.Sprintf(", domain %s", filter.Domain)
+	}
+	if filter.ProcessGuid != "" {
+		header += fmt.Sprintf(", process guid matching %s", filter.ProcessGuid)
+	}
+	if prompt == "" {
+		prompt = "q quit  d filter by domain  p filter by process guid  c clear filters"
+	}
+	lines := []string{header, prompt}
+
+	// Each section takes a blank line and a heading besides its rows; the
+	// cells and the LRPs get up to a third of the remaining rows each and the
+	// events whatever is left.
+	rows := height - len(lines) - 3*2
+	if rows < 0 {
+		rows = 0
+	}
+
+	cellRows := fitRows(len(view.Cells), rows/3)
+	lines = append(lines, "", fmt.Sprintf("%-36s %-10s %7s %7s %11s %5s", "CELL", "ZONE", "MEMORY", "DISK", "CONTAINERS", "LRPS"))
+	for _, cell := range view.Cells[:cellRows] {
+		line := fmt.Sprintf("%-36s %-10s %7s %7s %11s %5d", cell.CellID, cell.Zone, percent(cell.MemoryUsage), percent(cell.DiskUsage), percent(cell.ContainerUsage), cell.LRPs)
+		if cell.Error != "" {
+			line = fmt.Sprintf("%-36s %-10s %s", cell.CellID, cell.Zone, cell.Error)
+		} else if cell.Evacuating {
+			line += " evacuating"
+		}
+		lines = append(lines, line)
+	}
+
+	lrpRows := fitRows(len(view.LRPs), rows/3)
+	lines = append(lines, "", fmt.Sprintf("%-36s %-16s %9s %7s %7s %9s %7s", "PROCESS GUID", "DOMAIN", "INSTANCES", "RUNNING", "CLAIMED", "UNCLAIMED", "CRASHED"))
+	for _, lrp := range view.LRPs[:lrpRows] {
+		lines = append(lines, fmt.Sprintf("%-36s %-16s %9d %7d %7d %9d %7d", lrp.ProcessGuid, lrp.Domain, lrp.Instances, lrp.Running, lrp.Claimed, lrp.Unclaimed, lrp.Crashed))
+	}
+
+	eventRows := fitRows(len(view.Events), rows-cellRows-lrpRows)
+	lines = append(lines, "", "EVENTS")
+	for _, event := range view.Events[len(view.Events)-eventRows:] {
+		lines = append(lines, fmt.Sprintf("%s %-28s %s", event.Time.Format("15:04:05"), event.Type, event.Message))
+	}
+
+	for i, line := range lines {
+		if len(line) > width {
+			lines[i] = line[:width]
+		}
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return lines
+}
+
+func percent(usage float64) string {
+	return fmt.Sprintf("%.0f%%", usage*100)
+}
+
+// fitRows returns how many of count entries fit in the given rows.
+func fitRows(count, rows int) int {
+	if count < rows {
+		return count
+	}
+	return rows
+}
@@ -0,0 +1,72 @@
+package commands_test
+
+import (
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cfdot/commands"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Top", func() {
+	Context("ValidateTopArguments", func() {
+		It("rejects extra arguments", func() {
+			err := commands.ValidateTopArguments([]string{"extra-arg"}, time.Second)
+			Expect(err).To(MatchError("Too many arguments specified"))
+		})
+
+		It("rejects a refresh interval that is not positive", func() {
+			err := commands.ValidateTopArguments([]string{}, 0)
+			Expect(err).To(MatchError("--refresh-interval must be positive"))
+		})
+	})
+
+	Context("RenderDashboard", func() {
+		var view diego.DashboardView
+
+		BeforeEach(func() {
+			view = diego.DashboardView{
+				Cells: []diego.DashboardCell{
+					{CellID: "cell-1", Zone: "z1", MemoryUsage: 0.5, DiskUsage: 0.25, ContainerUsage: 0.1, LRPs: 3},
+					{CellID: "cell-2", Zone: "z2", Error: "connection refused"},
+				},
+				LRPs: []diego.DashboardLRP{
+					{ProcessGuid: "guid-1", Domain: "cf-apps", Instances: 2, Running: 1, Crashed: 1},
+				},
+				Events: []diego.DashboardEvent{
+					{Time: time.Date(2020, 1, 1, 10, 0, 0, 0, time.Local), Type: "actual_lrp_instance_created", Message: "guid-1/0 created"},
+					{Time: time.Date(2020, 1, 1, 10, 0, 1, 0, time.Local), Type: "actual_lrp_instance_changed", Message: "guid-1/0 CLAIMED -> RUNNING"},
+				},
+			}
+		})
+
+		It("shows the cells, the LRPs and the events", func() {
+			screen := strings.Join(commands.RenderDashboard(view, diego.DashboardFilter{}, "", 200, 40), "\n")
+
+			Expect(screen).To(ContainSubstring("cfdot top - 2 cells, 1 LRPs"))
+			Expect(screen).To(ContainSubstring("q quit"))
+			Expect(screen).To(MatchRegexp(`cell-1\s+z1\s+50%\s+25%\s+10%\s+3`))
+			Expect(screen).To(MatchRegexp(`cell-2\s+z2\s+connection refused`))
+			Expect(screen).To(MatchRegexp(`guid-1\s+cf-apps\s+2\s+1\s+0\s+0\s+1`))
+			Expect(screen).To(ContainSubstring("10:00:01 actual_lrp_instance_changed"))
+		})
+
+		It("shows the filter and the prompt", func() {
+			lines := commands.RenderDashboard(view, diego.DashboardFilter{Domain: "cf-apps"}, "Filter by domain: cf_", 200, 40)
+			Expect(lines[0]).To(ContainSubstring("domain cf-apps"))
+			Expect(lines[1]).To(Equal("Filter by domain: cf_"))
+		})
+
+		It("fits the screen, keeping the latest events", func() {
+			lines := commands.RenderDashboard(view, diego.DashboardFilter{}, "", 20, 10)
+			Expect(lines).To(HaveLen(10))
+			for _, line := range lines {
+				Expect(len(line)).To(BeNumerically("<=", 20))
+			}
+			Expect(lines[len(lines)-1]).To(HavePrefix("10:00:01"))
+		})
+	})
+})
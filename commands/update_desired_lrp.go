@@ -1,38 +1,79 @@
 package commands
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
-	"os"
-	"strings"
 
 	"code.cloudfoundry.org/bbs"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/cfdot/commands/helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 	"github.com/spf13/cobra"
 )
 
 var updateDesiredLRPCmd = &cobra.Command{
-	Use:   "update-desired-lrp process-guid (SPEC|@FILE)",
+	Use:   "update-desired-lrp process-guid [(SPEC|@FILE|-)]",
 	Short: "Update a desired LRP",
-	Long:  "Update a desired LRP for a process-guid with the given spec. Spec can either be json encoded update to a desired-lrp, e.g. '{\"instances\":\"4\"}', or a file containing json encoded update to a desired-lrp, e.g. @/path/to/spec/file",
+	Long:  "Update a desired LRP for a process-guid with the given spec, or with the --instances, --annotation, --add-route and --remove-route flags. Spec can either be json or yaml encoded update to a desired-lrp, e.g. '{\"instances\":\"4\"}', a file containing json or yaml encoded update to a desired-lrp, e.g. @/path/to/spec/file, or - to read it from stdin. Routes are given as cf-router:HOSTNAME:PORT and are merged into the current routes of the desired LRP, keeping those of other routers",
 	RunE:  updateDesiredLRP,
 }
 
+// flags
+var (
+	updateDesiredLRPInstancesFlag    int32
+	updateDesiredLRPAnnotationFlag   string
+	updateDesiredLRPAddRoutesFlag    []string
+	updateDesiredLRPRemoveRoutesFlag []string
+)
+
+// DesiredLRPEdit is a change to a desired LRP given with the flags of
+// update-desired-lrp. Nil fields are left unchanged.
+type DesiredLRPEdit struct {
+	Instances    *int32
+	Annotation   *string
+	AddRoutes    []diego.CFRoute
+	RemoveRoutes []diego.CFRoute
+}
+
+// errors
+var (
+	errMultipleUpdateSpecs = errors.New("The spec holds several documents, but a desired LRP is updated with a single one")
+	errSpecAndUpdateFlags  = errors.New("Pass either a spec or update flags, not both")
+	errNegativeInstances   = errors.New("--instances must not be negative")
+)
+
 func init() {
 	AddBBSAndTimeoutFlags(updateDesiredLRPCmd)
+	updateDesiredLRPCmd.Flags().Int32Var(&updateDesiredLRPInstancesFlag, "instances", 0, "set the number of instances")
+	updateDesiredLRPCmd.Flags().StringVar(&updateDesiredLRPAnnotationFlag, "annotation", "", "set the annotation")
+	updateDesiredLRPCmd.Flags().StringArrayVar(&updateDesiredLRPAddRoutesFlag, "add-route", nil, "add a cf-router:HOSTNAME:PORT route, can be repeated")
+	updateDesiredLRPCmd.Flags().StringArrayVar(&updateDesiredLRPRemoveRoutesFlag, "remove-route", nil, "remove a cf-router:HOSTNAME:PORT route, can be repeated")
+	AddSpecFormatFlag(updateDesiredLRPCmd)
 	RootCmd.AddCommand(updateDesiredLRPCmd)
 }
 
 func updateDesiredLRP(cmd *cobra.Command, args []string) error {
+	var instances *int32
+	if cmd.Flags().Changed("instances") {
+		instances = &updateDesiredLRPInstancesFlag
+	}
+	var annotation *string
+	if cmd.Flags().Changed("annotation") {
+		annotation = &updateDesiredLRPAnnotationFlag
+	}
+
+	if instances != nil || annotation != nil || len(updateDesiredLRPAddRoutesFlag) > 0 || len(updateDesiredLRPRemoveRoutesFlag) > 0 {
+		return editDesiredLRP(cmd, args, instances, annotation)
+	}
+
 	if len(args) != 2 {
 		return NewCFDotValidationError(cmd, fmt.Errorf("Missing arguments"))
 	}
 
-	processGuid, spec, err := ValidateUpdateDesiredLRPArguments(args)
+	processGuid, spec, err := ValidateUpdateDesiredLRPArguments(args, cmd.InOrStdin(), specFormatFlag)
 	if err != nil {
 		return NewCFDotValidationError(cmd, err)
 	}
@@ -42,7 +83,10 @@ func updateDesiredLRP(cmd *cobra.Command, args []string) error {
 		return NewCFDotError(cmd, err)
 	}
 
-	err = UpdateDesiredLRP(cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, processGuid, spec)
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	err = UpdateDesiredLRP(ctx, cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, processGuid, spec)
 	if err != nil {
 		return NewCFDotError(cmd, err)
 	}
@@ -50,34 +94,100 @@ func updateDesiredLRP(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func ValidateUpdateDesiredLRPArguments(args []string) (string, []byte, error) {
-	var desiredLRP *models.DesiredLRPUpdate
-	var err error
-	var spec []byte
+func editDesiredLRP(cmd *cobra.Command, args []string, instances *int32, annotation *string) error {
+	processGuid, edit, err := ValidateUpdateDesiredLRPFlags(args, instances, annotation, updateDesiredLRPAddRoutesFlag, updateDesiredLRPRemoveRoutesFlag)
+	if err != nil {
+		return NewCFDotValidationError(cmd, err)
+	}
+
+	bbsClient, err := helpers.NewBBSClient(cmd, Config)
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	err = EditDesiredLRP(ctx, cmd.OutOrStdout(), cmd.OutOrStderr(), bbsClient, processGuid, edit)
+	if err != nil {
+		return NewCFDotError(cmd, err)
+	}
+
+	return nil
+}
+
+func ValidateUpdateDesiredLRPArguments(args []string, stdin io.Reader, format string) (string, []byte, error) {
 	processGuid := args[0]
-	argValue := args[1]
-	if strings.HasPrefix(argValue, "@") {
-		_, err := os.Stat(argValue[1:])
+	specs, err := ReadSpecs(args[1], stdin, format, func() interface{} { return &models.DesiredLRPUpdate{} })
+	if err != nil {
+		return "", nil, err
+	}
+	if len(specs) > 1 {
+		return "", nil, errMultipleUpdateSpecs
+	}
+	return processGuid, specs[0], nil
+}
+
+func ValidateUpdateDesiredLRPFlags(args []string, instances *int32, annotation *string, addRoutes, removeRoutes []string) (string, DesiredLRPEdit, error) {
+	if len(args) == 0 {
+		return "", DesiredLRPEdit{}, fmt.Errorf("Missing arguments")
+	}
+	if len(args) > 1 {
+		return "", DesiredLRPEdit{}, errSpecAndUpdateFlags
+	}
+	if instances != nil && *instances < 0 {
+		return "", DesiredLRPEdit{}, errNegativeInstances
+	}
+
+	edit := DesiredLRPEdit{Instances: instances, Annotation: annotation}
+	for _, route := range addRoutes {
+		cfRoute, err := diego.ParseCFRoute(route)
 		if err != nil {
-			println(err.Error())
-			return "", nil, err
+			return "", DesiredLRPEdit{}, err
 		}
-		spec, err = ioutil.ReadFile(argValue[1:])
+		edit.AddRoutes = append(edit.AddRoutes, cfRoute)
+	}
+	for _, route := range removeRoutes {
+		cfRoute, err := diego.ParseCFRoute(route)
 		if err != nil {
-			return "", nil, err
+			return "", DesiredLRPEdit{}, err
 		}
+		edit.RemoveRoutes = append(edit.RemoveRoutes, cfRoute)
+	}
 
-	} else {
-		spec = []byte(argValue)
+	return args[0], edit, nil
+}
+
+// EditDesiredLRP submits an update with only the fields changed by edit. Route
+// changes are merged into the routes the desired LRP has when it is read, so
+// a concurrent change to its routes between the read and the update is lost.
+func EditDesiredLRP(ctx context.Context, stdout, stderr io.Writer, bbsClient bbs.Client, processGuid string, edit DesiredLRPEdit) error {
+	logger := globalLogger.Session("update-desired-lrp")
+
+	update := &models.DesiredLRPUpdate{}
+	if edit.Instances != nil {
+		update.SetInstances(*edit.Instances)
 	}
-	err = json.Unmarshal([]byte(spec), &desiredLRP)
-	if err != nil {
-		return "", nil, errors.New(fmt.Sprintf("Invalid JSON: %s", err.Error()))
+	if edit.Annotation != nil {
+		update.SetAnnotation(*edit.Annotation)
+	}
+
+	if len(edit.AddRoutes) > 0 || len(edit.RemoveRoutes) > 0 {
+		lrp, err := diego.DesiredLRP(ctx, logger, bbsClient, processGuid)
+		if err != nil {
+			return err
+		}
+
+		update.Routes, err = diego.EditCFRoutes(lrp, edit.AddRoutes, edit.RemoveRoutes)
+		if err != nil {
+			return err
+		}
 	}
-	return processGuid, spec, nil
+
+	return diego.UpdateDesiredLRP(ctx, logger, bbsClient, processGuid, update)
 }
 
-func UpdateDesiredLRP(stdout, stderr io.Writer, bbsClient bbs.Client, processGuid string, spec []byte) error {
+func UpdateDesiredLRP(ctx context.Context, stdout, stderr io.Writer, bbsClient bbs.Client, processGuid string, spec []byte) error {
 	logger := globalLogger.Session("update-desired-lrp")
 
 	var desiredLRP *models.DesiredLRPUpdate
@@ -85,10 +195,6 @@ func UpdateDesiredLRP(stdout, stderr io.Writer, bbsClient bbs.Client, processGui
 	if err != nil {
 		return err
 	}
-	err = bbsClient.UpdateDesiredLRP(logger, processGuid, desiredLRP)
-	if err != nil {
-		return err
-	}
 
-	return nil
+	return diego.UpdateDesiredLRP(ctx, logger, bbsClient, processGuid, desiredLRP)
 }
@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"os"
@@ -8,6 +9,7 @@ import (
 	"code.cloudfoundry.org/bbs/fake_bbs"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/cfdot/commands"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -36,7 +38,7 @@ var _ = Describe("UpdateDesiredLRP", func() {
 		var err error
 		initialSpec, err := json.Marshal(initialDesiredLRP)
 		Expect(err).NotTo(HaveOccurred())
-		err = commands.CreateDesiredLRP(stdout, stderr, fakeBBSClient, initialSpec)
+		err = commands.CreateDesiredLRP(context.Background(), stdout, stderr, fakeBBSClient, initialSpec)
 		Expect(err).NotTo(HaveOccurred())
 
 		updatedInstanceCount := int32(4)
@@ -49,7 +51,7 @@ var _ = Describe("UpdateDesiredLRP", func() {
 	})
 
 	It("updates the desired lrp", func() {
-		err := commands.UpdateDesiredLRP(stdout, stderr, fakeBBSClient, processGuid, spec)
+		err := commands.UpdateDesiredLRP(context.Background(), stdout, stderr, fakeBBSClient, processGuid, spec)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeBBSClient.UpdateDesiredLRPCallCount()).To(Equal(1))
@@ -72,20 +74,108 @@ var _ = Describe("UpdateDesiredLRP", func() {
 
 		It("validates the input file successfully", func() {
 			args := []string{processGuid, "@" + filename}
-			actualProcessGuid, actualSpec, err := commands.ValidateUpdateDesiredLRPArguments(args)
+			actualProcessGuid, actualSpec, err := commands.ValidateUpdateDesiredLRPArguments(args, nil, commands.SpecFormatAuto)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(actualSpec).To(Equal(spec))
 			Expect(actualProcessGuid).To(Equal(processGuid))
 		})
 	})
 
+	Context("ValidateUpdateDesiredLRPFlags", func() {
+		It("requires a process guid", func() {
+			_, _, err := commands.ValidateUpdateDesiredLRPFlags([]string{}, nil, nil, []string{"cf-router:app.example.com:8080"}, nil)
+			Expect(err).To(MatchError("Missing arguments"))
+		})
+
+		It("rejects a spec together with update flags", func() {
+			instances := int32(2)
+			_, _, err := commands.ValidateUpdateDesiredLRPFlags([]string{processGuid, "{}"}, &instances, nil, nil, nil)
+			Expect(err).To(MatchError("Pass either a spec or update flags, not both"))
+		})
+
+		It("rejects negative instances", func() {
+			instances := int32(-1)
+			_, _, err := commands.ValidateUpdateDesiredLRPFlags([]string{processGuid}, &instances, nil, nil, nil)
+			Expect(err).To(MatchError("--instances must not be negative"))
+		})
+
+		It("rejects routes of other routers", func() {
+			_, _, err := commands.ValidateUpdateDesiredLRPFlags([]string{processGuid}, nil, nil, []string{"tcp-router:61000:8080"}, nil)
+			Expect(err).To(MatchError("Invalid route 'tcp-router:61000:8080', expected cf-router:HOSTNAME:PORT"))
+		})
+
+		It("returns the process guid and the edit", func() {
+			annotation := "some-annotation"
+			guid, edit, err := commands.ValidateUpdateDesiredLRPFlags([]string{processGuid}, nil, &annotation, []string{"cf-router:app.example.com:8080"}, []string{"cf-router:old.example.com:8080"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(guid).To(Equal(processGuid))
+			Expect(edit).To(Equal(commands.DesiredLRPEdit{
+				Annotation:   &annotation,
+				AddRoutes:    []diego.CFRoute{{Hostname: "app.example.com", Port: 8080}},
+				RemoveRoutes: []diego.CFRoute{{Hostname: "old.example.com", Port: 8080}},
+			}))
+		})
+	})
+
+	Context("EditDesiredLRP", func() {
+		var tcpRoutes json.RawMessage
+
+		BeforeEach(func() {
+			cfRoutes := json.RawMessage(`[{"hostnames":["old.example.com"],"port":8080}]`)
+			tcpRoutes = json.RawMessage(`[{"external_port":61000,"container_port":8080}]`)
+			fakeBBSClient.DesiredLRPByProcessGuidReturns(&models.DesiredLRP{
+				ProcessGuid: processGuid,
+				Ports:       []uint32{8080},
+				Routes:      &models.Routes{"cf-router": &cfRoutes, "tcp-router": &tcpRoutes},
+			}, nil)
+		})
+
+		It("only updates the scale without reading the desired lrp", func() {
+			instances := int32(3)
+			err := commands.EditDesiredLRP(context.Background(), stdout, stderr, fakeBBSClient, processGuid, commands.DesiredLRPEdit{Instances: &instances})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeBBSClient.DesiredLRPByProcessGuidCallCount()).To(Equal(0))
+			_, guid, update := fakeBBSClient.UpdateDesiredLRPArgsForCall(0)
+			Expect(guid).To(Equal(processGuid))
+			Expect(update.GetInstances()).To(Equal(int32(3)))
+			Expect(update.AnnotationExists()).To(BeFalse())
+			Expect(update.Routes).To(BeNil())
+		})
+
+		It("merges the routes into those of the desired lrp", func() {
+			err := commands.EditDesiredLRP(context.Background(), stdout, stderr, fakeBBSClient, processGuid, commands.DesiredLRPEdit{
+				AddRoutes:    []diego.CFRoute{{Hostname: "new.example.com", Port: 8080}},
+				RemoveRoutes: []diego.CFRoute{{Hostname: "old.example.com", Port: 8080}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, guid := fakeBBSClient.DesiredLRPByProcessGuidArgsForCall(0)
+			Expect(guid).To(Equal(processGuid))
+
+			_, _, update := fakeBBSClient.UpdateDesiredLRPArgsForCall(0)
+			Expect(update.InstancesExists()).To(BeFalse())
+			routes := *update.Routes
+			Expect(string(*routes["cf-router"])).To(MatchJSON(`[{"hostnames":["new.example.com"],"port":8080}]`))
+			Expect(routes["tcp-router"]).To(Equal(&tcpRoutes))
+		})
+
+		It("fails without updating when a route cannot be removed", func() {
+			err := commands.EditDesiredLRP(context.Background(), stdout, stderr, fakeBBSClient, processGuid, commands.DesiredLRPEdit{
+				RemoveRoutes: []diego.CFRoute{{Hostname: "missing.example.com", Port: 8080}},
+			})
+			Expect(err).To(MatchError("Cannot remove route cf-router:missing.example.com:8080: the desired LRP has no such route"))
+			Expect(fakeBBSClient.UpdateDesiredLRPCallCount()).To(Equal(0))
+		})
+	})
+
 	Context("when the bbs errors", func() {
 		BeforeEach(func() {
 			fakeBBSClient.UpdateDesiredLRPReturns(models.ErrUnknownError)
 		})
 
 		It("fails with a relevant error", func() {
-			err := commands.UpdateDesiredLRP(stdout, stderr, fakeBBSClient, processGuid, []byte("{}"))
+			err := commands.UpdateDesiredLRP(context.Background(), stdout, stderr, fakeBBSClient, processGuid, []byte("{}"))
 			Expect(err).To(MatchError(models.ErrUnknownError))
 		})
 	})
@@ -0,0 +1,204 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate (task|desired-lrp) FILE...",
+	Short: "Check task or desired LRP specs without sending them",
+	Long:  "Check json or yaml encoded task or desired LRP specs in the given files, or - for stdin, against the validation rules of the BBS, and for likely mistakes such as a missing health check, zero memory or disk, an unknown rootfs scheme, an action that runs nothing or malformed routes. Every problem is printed with its field; the command fails if any of them is an error",
+	RunE:  validateSpecs,
+}
+
+// Kinds of spec checked by cfdot validate.
+const (
+	SpecKindTask       = "task"
+	SpecKindDesiredLRP = "desired-lrp"
+)
+
+// SpecProblem is a problem found in a document of a spec file.
+type SpecProblem struct {
+	File     string `json:"file"`
+	Document int    `json:"document,omitempty"`
+	diego.SpecProblem
+}
+
+// specCheckers decode a spec of each kind, as returned by ReadSpecs, and
+// check it.
+var specCheckers = map[string]func(spec []byte) []diego.SpecProblem{
+	SpecKindTask: func(spec []byte) []diego.SpecProblem {
+		task := &models.Task{}
+		if err := json.Unmarshal(spec, task); err != nil {
+			return decodeProblems(err)
+		}
+		return diego.CheckTask(task)
+	},
+	SpecKindDesiredLRP: func(spec []byte) []diego.SpecProblem {
+		lrp := &models.DesiredLRP{}
+		if err := json.Unmarshal(spec, lrp); err != nil {
+			return decodeProblems(err)
+		}
+		return diego.CheckDesiredLRP(lrp)
+	},
+}
+
+// decodeProblems reports a spec that does not decode into its model, which
+// cannot be checked any further.
+func decodeProblems(err error) []diego.SpecProblem {
+	problem := diego.SpecProblem{Severity: diego.SpecError, Message: err.Error()}
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok && typeErr.Field != "" {
+		problem.Field = typeErr.Field
+		problem.Message = fmt.Sprintf("cannot unmarshal %s into %s", typeErr.Value, typeErr.Type)
+	}
+	return []diego.SpecProblem{problem}
+}
+
+// errors
+var (
+	errMissingSpecKind  = errors.New("Missing spec kind, expected task or desired-lrp")
+	errMissingSpecFiles = errors.New("Missing FILE argument")
+)
+
+func init() {
+	AddSpecFormatFlag(validateCmd)
+	RootCmd.AddCommand(validateCmd)
+}
+
+func validateSpecs(cmd *cobra.Command, args []string) error {
+	kind, files, err := ValidateValidateArguments(args)
+	if err != nil {
+		return NewCFDotValidationError(cmd, err)
+	}
+
+	err = Validate(newPrinter(cmd), cmd.InOrStdin(), kind, specFormatFlag, files)
+	if err != nil {
+		return NewCFDotValidationError(cmd, err)
+	}
+	return nil
+}
+
+func ValidateValidateArguments(args []string) (string, []string, error) {
+	if len(args) == 0 {
+		return "", nil, errMissingSpecKind
+	}
+	if _, ok := specCheckers[args[0]]; !ok {
+		return "", nil, fmt.Errorf("Unknown spec kind '%s', expected task or desired-lrp", args[0])
+	}
+	if len(args) == 1 {
+		return "", nil, errMissingSpecFiles
+	}
+	return args[0], args[1:], nil
+}
+
+// Validate prints every problem found in the specs of kind in files, where -
+// stands for stdin, and returns an error if any of them is an error.
+func Validate(printer Printer, stdin io.Reader, kind, format string, files []string) error {
+	check := specCheckers[kind]
+
+	var errorCount int
+	report := func(problem SpecProblem) error {
+		if problem.Severity == diego.SpecError {
+			errorCount++
+		}
+		return printer.Print(problem)
+	}
+
+	for _, file := range files {
+		arg := file
+		if arg != "-" {
+			arg = "@" + file
+		}
+
+		specs, err := ReadSpecs(arg, stdin, format, specValue(kind))
+		if err != nil {
+			err = report(SpecProblem{File: file, SpecProblem: diego.SpecProblem{Severity: diego.SpecError, Message: err.Error()}})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		for i, spec := range specs {
+			for _, problem := range check(spec) {
+				err := report(SpecProblem{File: file, Document: i + 1, SpecProblem: problem})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if errorCount > 0 {
+		return fmt.Errorf("Found %d errors in the specs", errorCount)
+	}
+	return nil
+}
+
+func specValue(kind string) func() interface{} {
+	if kind == SpecKindTask {
+		return func() interface{} { return &models.Task{} }
+	}
+	return func() interface{} { return &models.DesiredLRP{} }
+}
+
+// checkSpecs fails when any of specs of kind has errors, listing those of the
+// first such spec. Warnings are left to printSpecWarnings.
+func checkSpecs(kind string, specs [][]byte) error {
+	for i, spec := range specs {
+		var errs []string
+		for _, problem := range specCheckers[kind](spec) {
+			if problem.Severity == diego.SpecError {
+				errs = append(errs, problem.String())
+			}
+		}
+		if len(errs) == 0 {
+			continue
+		}
+
+		if len(specs) > 1 {
+			return fmt.Errorf("Invalid %s spec in document %d: %s", kind, i+1, strings.Join(errs, "; "))
+		}
+		return fmt.Errorf("Invalid %s spec: %s", kind, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// createFromSpecs calls create with each of specs of kind in turn, stopping
+// at the first error. When there are several, the document that failed is
+// named on stderr, since those before it have already been created.
+func createFromSpecs(cmd *cobra.Command, kind string, specs [][]byte, create func(spec []byte) error) error {
+	for i, spec := range specs {
+		err := create(spec)
+		if err == nil {
+			continue
+		}
+
+		if len(specs) > 1 {
+			created := "the documents before it were created"
+			if i == 0 {
+				created = "none of the documents were created"
+			}
+			fmt.Fprintf(cmd.OutOrStderr(), "Failed to create the %s in document %d of %d; %s\n", kind, i+1, len(specs), created)
+		}
+		return NewCFDotError(cmd, err)
+	}
+	return nil
+}
+
+// printSpecWarnings writes the warnings among problems to w.
+func printSpecWarnings(w io.Writer, problems []diego.SpecProblem) {
+	for _, problem := range problems {
+		if problem.Severity == diego.SpecWarning {
+			fmt.Fprintf(w, "Warning: %s\n", problem)
+		}
+	}
+}
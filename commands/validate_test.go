@@ -0,0 +1,102 @@
+package commands_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/bbs/models/test/model_helpers"
+	"code.cloudfoundry.org/cfdot/commands"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Validate", func() {
+	Context("ValidateValidateArguments", func() {
+		It("requires a spec kind", func() {
+			_, _, err := commands.ValidateValidateArguments([]string{})
+			Expect(err).To(MatchError("Missing spec kind, expected task or desired-lrp"))
+		})
+
+		It("rejects unknown spec kinds", func() {
+			_, _, err := commands.ValidateValidateArguments([]string{"actual-lrp", "spec.yml"})
+			Expect(err).To(MatchError("Unknown spec kind 'actual-lrp', expected task or desired-lrp"))
+		})
+
+		It("requires files", func() {
+			_, _, err := commands.ValidateValidateArguments([]string{"task"})
+			Expect(err).To(MatchError("Missing FILE argument"))
+		})
+
+		It("returns the kind and the files", func() {
+			kind, files, err := commands.ValidateValidateArguments([]string{"desired-lrp", "a.yml", "b.json"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kind).To(Equal(commands.SpecKindDesiredLRP))
+			Expect(files).To(Equal([]string{"a.yml", "b.json"}))
+		})
+	})
+
+	Context("Validate", func() {
+		var (
+			stdout   *gbytes.Buffer
+			filename string
+		)
+
+		writeSpec := func(spec string) {
+			os.Remove(filename)
+
+			f, err := ioutil.TempFile(os.TempDir(), "spec_file")
+			Expect(err).NotTo(HaveOccurred())
+			defer f.Close()
+			_, err = f.WriteString(spec)
+			Expect(err).NotTo(HaveOccurred())
+			filename = f.Name()
+		}
+
+		BeforeEach(func() {
+			stdout = gbytes.NewBuffer()
+
+			task := &models.Task{
+				TaskGuid:       "task-guid",
+				Domain:         "domain",
+				TaskDefinition: model_helpers.NewValidTaskDefinition(),
+			}
+			task.RootFs = "preloaded:cflinuxfs3"
+			task.MemoryMb = 0
+			spec, err := json.Marshal(task)
+			Expect(err).NotTo(HaveOccurred())
+
+			writeSpec(string(spec) + "\n{\"task_guid\":\"other-task-guid\"}\n")
+		})
+
+		AfterEach(func() {
+			os.Remove(filename)
+		})
+
+		It("prints every problem with its file, document and field", func() {
+			err := commands.Validate(commands.NewJSONPrinter(stdout), nil, commands.SpecKindTask, commands.SpecFormatAuto, []string{filename})
+			Expect(err).To(MatchError(MatchRegexp(`Found \d+ errors in the specs`)))
+
+			Expect(stdout).To(gbytes.Say(`{"file":"` + filename + `","document":1,"field":"memory_mb","severity":"warning","message":"is 0, so memory is not limited"}`))
+			Expect(stdout).To(gbytes.Say(`{"file":"` + filename + `","document":2,"field":"domain","severity":"error","message":"is invalid"}`))
+		})
+
+		It("only warns about specs without errors", func() {
+			err := commands.Validate(commands.NewJSONPrinter(stdout), strings.NewReader("task_guid: task-guid\ndomain: domain\nrootfs: preloaded:cflinuxfs3\naction:\n  run:\n    path: ls\n    user: vcap\n"), commands.SpecKindTask, commands.SpecFormatAuto, []string{"-"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdout).To(gbytes.Say(`"file":"-","document":1,"field":"memory_mb","severity":"warning"`))
+		})
+
+		It("reports specs that cannot be read", func() {
+			writeSpec("task_guid: a\n  domain: b\n")
+
+			err := commands.Validate(commands.NewJSONPrinter(stdout), nil, commands.SpecKindTask, commands.SpecFormatAuto, []string{filename})
+			Expect(err).To(MatchError("Found 1 errors in the specs"))
+			Expect(stdout).To(gbytes.Say(`{"file":"` + filename + `","severity":"error","message":"Invalid YAML: line 2: `))
+		})
+	})
+})
@@ -48,7 +48,7 @@ var _ = Describe("actual-lrp-groups-for-guid", func() {
 			})
 
 			It("returns the json encoding of the actual lrp", func() {
-				sess := RunCFDot("actual-lrp-groups-for-guid", "random-guid")
+				sess := RunCFDot("actual-lrp-groups-for-guid", "random-guid", "--retry-attempts", "1")
 				Eventually(sess).Should(gexec.Exit(0))
 				Expect(sess.Out).To(gbytes.Say(`"state":"running"`))
 			})
@@ -59,9 +59,9 @@ var _ = Describe("actual-lrp-groups-for-guid", func() {
 						serverTimeout = 2
 					})
 
-					It("exits with code 4 and a timeout message", func() {
+					It("exits with code 9 and a timeout message", func() {
 						sess := RunCFDot("actual-lrp-groups-for-guid", "random-guid", "--timeout", "1")
-						Eventually(sess, 2).Should(gexec.Exit(4))
+						Eventually(sess, 2).Should(gexec.Exit(9))
 						Expect(sess.Err).To(gbytes.Say(`Timeout exceeded`))
 					})
 				})
@@ -93,7 +93,7 @@ var _ = Describe("actual-lrp-groups-for-guid", func() {
 			})
 
 			It("exits with status code 4 and should print the type and message of the error", func() {
-				sess := RunCFDot("actual-lrp-groups-for-guid", "random-guid")
+				sess := RunCFDot("actual-lrp-groups-for-guid", "random-guid", "--retry-attempts", "1")
 				Eventually(sess).Should(gexec.Exit(4))
 				Expect(sess.Err).To(gbytes.Say("BBS error"))
 				Expect(sess.Err).To(gbytes.Say("Type 28: Deadlock"))
@@ -101,7 +101,7 @@ var _ = Describe("actual-lrp-groups-for-guid", func() {
 			})
 
 			It("should not print the usage", func() {
-				sess := RunCFDot("actual-lrp-groups-for-guid", "random-guid")
+				sess := RunCFDot("actual-lrp-groups-for-guid", "random-guid", "--retry-attempts", "1")
 				Expect(sess.Err).NotTo(gbytes.Say("Usage:"))
 			})
 		})
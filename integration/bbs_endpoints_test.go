@@ -0,0 +1,71 @@
+package integration_test
+
+import (
+	"code.cloudfoundry.org/bbs/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+const unreachableBBSURL = "https://127.0.0.1:1"
+
+var _ = Describe("bbs-endpoints", func() {
+	itValidatesBBSFlags("bbs-endpoints")
+	itHasNoArgs("bbs-endpoints", false)
+
+	Context("when one of the endpoints is the active BBS", func() {
+		BeforeEach(func() {
+			bbsServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/ping"),
+					ghttp.RespondWithProto(200, &models.PingResponse{Available: true}),
+				),
+			)
+		})
+
+		It("reports which endpoint is active", func() {
+			sess := RunCFDot("--bbsURL", unreachableBBSURL+","+bbsServer.URL(), "bbs-endpoints")
+			Eventually(sess).Should(gexec.Exit(0))
+			Expect(sess.Out).To(gbytes.Say(`"url":"https://127.0.0.1:1","active":false`))
+			Expect(sess.Out).To(gbytes.Say(`"url":"` + bbsServer.URL() + `","active":true`))
+		})
+	})
+
+	Context("when none of the endpoints is active", func() {
+		It("exits with status code 4", func() {
+			sess := RunCFDot("--bbsURL", unreachableBBSURL, "bbs-endpoints")
+			Eventually(sess).Should(gexec.Exit(4))
+			Expect(sess.Err).To(gbytes.Say("None of the BBS endpoints is active"))
+		})
+	})
+})
+
+var _ = Describe("BBS failover", func() {
+	BeforeEach(func() {
+		bbsServer.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/v1/domains/list"),
+				ghttp.RespondWithProto(200, &models.DomainsResponse{
+					Domains: []string{"domain-1"},
+				}),
+			),
+		)
+	})
+
+	It("sends requests to the next endpoint when one is unreachable", func() {
+		sess := RunCFDot("--bbsURL", unreachableBBSURL+","+bbsServer.URL(), "domains")
+		Eventually(sess).Should(gexec.Exit(0))
+		Expect(sess.Out).To(gbytes.Say(`"domain-1"`))
+		Expect(sess.Err).To(gbytes.Say("BBS at https://127.0.0.1:1 is unreachable"))
+		Expect(sess.Err).To(gbytes.Say("trying " + bbsServer.URL()))
+	})
+
+	It("rejects a list containing a URL without an https scheme", func() {
+		sess := RunCFDot("--bbsURL", bbsServer.URL()+",http://bbs.example.com", "domains")
+		Eventually(sess).Should(gexec.Exit(3))
+		Expect(sess.Err).To(gbytes.Say("The URL 'http://bbs.example.com' does not have an 'https' scheme"))
+	})
+})
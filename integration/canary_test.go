@@ -0,0 +1,44 @@
+package integration_test
+
+import (
+	"code.cloudfoundry.org/bbs/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("canary", func() {
+	itValidatesBBSFlags("canary")
+
+	Context("when arguments are passed", func() {
+		It("exits with status 3 and prints the usage", func() {
+			sess := RunCFDot("canary", "foo")
+			Eventually(sess).Should(gexec.Exit(3))
+			Expect(sess.Err).To(gbytes.Say("Too many arguments specified"))
+			Expect(sess.Err).To(gbytes.Say("cfdot canary \\[flags\\]"))
+		})
+	})
+
+	Context("when the targeted cell is not registered", func() {
+		BeforeEach(func() {
+			bbsServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/cells/list.r1"),
+					ghttp.RespondWithProto(200, &models.CellsResponse{
+						Cells: []*models.CellPresence{{CellId: "cell-1"}},
+					}),
+				),
+			)
+		})
+
+		It("exits with status 6 without desiring the canaries", func() {
+			sess := RunCFDot("canary", "--cell-id", "cell-2")
+			Eventually(sess).Should(gexec.Exit(6))
+			Expect(sess.Err).To(gbytes.Say("Cell not found"))
+			Expect(bbsServer.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
+})
@@ -49,9 +49,9 @@ var _ = Describe("cancel-task", func() {
 					serverTimeout = 2
 				})
 
-				It("exits with code 4 and a timeout message", func() {
+				It("exits with code 9 and a timeout message", func() {
 					sess := RunCFDot("cancel-task", "task-guid", "--timeout", "1")
-					Eventually(sess, 2).Should(gexec.Exit(4))
+					Eventually(sess, 2).Should(gexec.Exit(9))
 					Expect(sess.Err).To(gbytes.Say(`Timeout exceeded`))
 				})
 			})
@@ -133,8 +133,8 @@ var _ = Describe("cell-state", func() {
 					serverTimeout = 2
 				})
 
-				It("exits with code 4 and a timeout message", func() {
-					Eventually(sess, 2).Should(gexec.Exit(4))
+				It("exits with code 9 and a timeout message", func() {
+					Eventually(sess, 2).Should(gexec.Exit(9))
 					Expect(sess.Err).To(gbytes.Say(`Timeout exceeded`))
 				})
 
@@ -154,6 +154,26 @@ var _ = Describe("cell-state", func() {
 			})
 		})
 
+		Context("when the rep-timeout flag is present", func() {
+			BeforeEach(func() {
+				rep2Server.RouteToHandler("GET", "/state", func(resp http.ResponseWriter, req *http.Request) {
+					time.Sleep(2 * time.Second)
+				})
+			})
+
+			It("gives up on the rep after the timeout", func() {
+				sess := RunCFDot("cell-state", "cell-2", "--rep-timeout", "1")
+				Eventually(sess, 2).Should(gexec.Exit(9))
+				Expect(sess.Err).To(gbytes.Say(`Rep error: Failed to get cell state for cell cell-2`))
+			})
+
+			It("exits with code 3 when the timeout is negative", func() {
+				sess := RunCFDot("cell-state", "cell-2", "--rep-timeout", "-1")
+				Eventually(sess).Should(gexec.Exit(3))
+				Expect(sess.Err).To(gbytes.Say(`--rep-timeout must be a non-negative number of seconds`))
+			})
+		})
+
 		Context("when the rep has mutual TLS enabled", func() {
 			It("uses the correct TLS config", func() {
 				sess := RunCFDot("cell-state", "cell-1")
@@ -209,8 +229,8 @@ var _ = Describe("cell-state", func() {
 						serverTimeout = 2
 					})
 
-					It("exits with code 4 and a timeout message", func() {
-						Eventually(sess, 2).Should(gexec.Exit(4))
+					It("exits with code 9 and a timeout message", func() {
+						Eventually(sess, 2).Should(gexec.Exit(9))
 						Expect(sess.Err).To(gbytes.Say(`Timeout exceeded`))
 					})
 				})
@@ -238,9 +258,9 @@ var _ = Describe("cell-state", func() {
 		})
 
 		Context("when the cell does not exist", func() {
-			It("exits with status code of 5", func() {
+			It("exits with status code of 6", func() {
 				sess := RunCFDot("cell-state", "cell-id-dsafasdklfjasdlkf")
-				Eventually(sess).Should(gexec.Exit(5))
+				Eventually(sess).Should(gexec.Exit(6))
 			})
 		})
 
@@ -300,6 +320,21 @@ var _ = Describe("cell-state", func() {
 			})
 		})
 
+		Context("when some of the Rep requests fail", func() {
+			BeforeEach(func() {
+				rep1Server.RouteToHandler("GET", "/state", func(resp http.ResponseWriter, req *http.Request) {
+					resp.WriteHeader(503)
+				})
+			})
+
+			It("prints the other cell states and exits with status code of 11", func() {
+				sess := RunCFDot("cell-states")
+				Eventually(sess).Should(gexec.Exit(11))
+				Expect(sess.Out).To(gbytes.Say(`"cell_id":"cell-2"`))
+				Expect(sess.Err).To(gbytes.Say("Failed to get cell state for cell cell-1"))
+			})
+		})
+
 		Context("when cell command is called with extra arguments", func() {
 			It("exits with status code of 3", func() {
 				sess := RunCFDot("cell-state", "cell-id", "extra-argument")
@@ -55,9 +55,9 @@ var _ = Describe("cell", func() {
 		})
 
 		Context("when the cell does not exist", func() {
-			It("exits with status code of 5", func() {
+			It("exits with status code of 6", func() {
 				sess := RunCFDot("cell", "cell-id-dsafasdklfjasdlkf")
-				Eventually(sess).Should(gexec.Exit(5))
+				Eventually(sess).Should(gexec.Exit(6))
 			})
 		})
 
@@ -73,8 +73,8 @@ var _ = Describe("cell", func() {
 					serverTimeout = 2
 				})
 
-				It("exits with code 4 and a timeout message", func() {
-					Eventually(sess, 2).Should(gexec.Exit(4))
+				It("exits with code 9 and a timeout message", func() {
+					Eventually(sess, 2).Should(gexec.Exit(9))
 					Expect(sess.Err).To(gbytes.Say(`Timeout exceeded`))
 				})
 			})
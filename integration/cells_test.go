@@ -73,8 +73,8 @@ var _ = Describe("cells", func() {
 					serverTimeout = 2
 				})
 
-				It("exits with code 4 and a timeout message", func() {
-					Eventually(sess, 2).Should(gexec.Exit(4))
+				It("exits with code 9 and a timeout message", func() {
+					Eventually(sess, 2).Should(gexec.Exit(9))
 					Expect(sess.Err).To(gbytes.Say(`Timeout exceeded`))
 				})
 			})
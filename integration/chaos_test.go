@@ -0,0 +1,66 @@
+package integration_test
+
+import (
+	"code.cloudfoundry.org/bbs/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("chaos", func() {
+	itValidatesBBSFlags("chaos", "--domain", "game-day")
+
+	Context("when the domain is missing", func() {
+		It("exits with status 3 and prints the usage", func() {
+			sess := RunCFDot("chaos")
+			Eventually(sess).Should(gexec.Exit(3))
+			Expect(sess.Err).To(gbytes.Say("--domain is required"))
+			Expect(sess.Err).To(gbytes.Say("cfdot chaos --domain DOMAIN \\[flags\\]"))
+		})
+	})
+
+	Context("when the rate is invalid", func() {
+		It("exits with status 3", func() {
+			sess := RunCFDot("chaos", "--domain", "game-day", "--rate", "5m")
+			Eventually(sess).Should(gexec.Exit(3))
+			Expect(sess.Err).To(gbytes.Say("Invalid rate '5m', expected N/DURATION, e.g. 1/5m"))
+		})
+	})
+
+	Context("with --dry-run", func() {
+		BeforeEach(func() {
+			bbsServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/desired_lrp_scheduling_infos/list"),
+					ghttp.VerifyProtoRepresenting(&models.DesiredLRPsRequest{Domain: "game-day"}),
+					ghttp.RespondWithProto(200, &models.DesiredLRPSchedulingInfosResponse{
+						DesiredLrpSchedulingInfos: []*models.DesiredLRPSchedulingInfo{
+							{DesiredLRPKey: models.NewDesiredLRPKey("process-guid", "game-day", "log-guid"), Instances: 2},
+						},
+					}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/actual_lrps/list"),
+					ghttp.VerifyProtoRepresenting(&models.ActualLRPsRequest{Domain: "game-day"}),
+					ghttp.RespondWithProto(200, &models.ActualLRPsResponse{
+						ActualLrps: []*models.ActualLRP{
+							{ActualLRPKey: models.NewActualLRPKey("process-guid", 0, "game-day"), State: models.ActualLRPStateRunning},
+							{ActualLRPKey: models.NewActualLRPKey("process-guid", 1, "game-day"), State: models.ActualLRPStateRunning},
+						},
+					}),
+				),
+			)
+		})
+
+		It("prints the instance it would retire without retiring it", func() {
+			sess := RunCFDot("chaos", "--domain", "game-day", "--rate", "1/10ms", "--max-unavailable", "1", "--max-retirements", "1", "--dry-run")
+			Eventually(sess).Should(gexec.Exit(0))
+			Expect(sess.Out).To(gbytes.Say(`"action":"dry-run","instance":{"process_guid":"process-guid"`))
+			Expect(sess.Out).To(gbytes.Say(`"action":"stop"`))
+			Expect(bbsServer.ReceivedRequests()).To(HaveLen(2))
+		})
+	})
+})
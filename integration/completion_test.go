@@ -0,0 +1,100 @@
+package integration_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+
+	"code.cloudfoundry.org/bbs/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("completion", func() {
+	runCompletion := func(args ...string) *gexec.Session {
+		sess, err := gexec.Start(exec.Command(cfdotPath, append([]string{"completion"}, args...)...), GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		return sess
+	}
+
+	It("generates a bash completion script", func() {
+		sess := runCompletion("bash")
+		Eventually(sess).Should(gexec.Exit(0))
+		Expect(sess.Out).To(gbytes.Say("bash completion for cfdot"))
+	})
+
+	It("rejects an unsupported shell", func() {
+		sess := runCompletion("tcsh")
+		Eventually(sess).Should(gexec.Exit(3))
+		Expect(sess.Err).To(gbytes.Say("Unsupported shell 'tcsh'"))
+	})
+
+	It("suggests the kinds of resources to describe", func() {
+		sess, err := gexec.Start(exec.Command(cfdotPath, "__complete", "describe", ""), GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(sess).Should(gexec.Exit(0))
+		Expect(sess.Out).To(gbytes.Say("lrp\ntask\ncell\n"))
+	})
+
+	Context("when completing a process guid", func() {
+		var cacheDir string
+
+		complete := func(toComplete string) *gexec.Session {
+			cmd := exec.Command(cfdotPath, "__complete", "desired-lrp",
+				"--bbsURL", bbsServer.URL(),
+				"--caCertFile", locketCACertFile,
+				"--clientCertFile", locketClientCertFile,
+				"--clientKeyFile", locketClientKeyFile,
+				toComplete,
+			)
+			cmd.Env = append(os.Environ(), "XDG_CACHE_HOME="+cacheDir)
+
+			sess, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			return sess
+		}
+
+		BeforeEach(func() {
+			var err error
+			cacheDir, err = ioutil.TempDir("", "cfdot-completion")
+			Expect(err).NotTo(HaveOccurred())
+
+			bbsServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/desired_lrp_scheduling_infos/list"),
+					ghttp.RespondWithProto(200, &models.DesiredLRPSchedulingInfosResponse{
+						DesiredLrpSchedulingInfos: []*models.DesiredLRPSchedulingInfo{
+							{DesiredLRPKey: models.NewDesiredLRPKey("process-guid-1", "domain", "log-guid")},
+							{DesiredLRPKey: models.NewDesiredLRPKey("other-guid", "domain", "log-guid")},
+						},
+					}),
+				),
+			)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(cacheDir)
+		})
+
+		It("suggests the matching process guids from the BBS", func() {
+			sess := complete("proc")
+			Eventually(sess).Should(gexec.Exit(0))
+			Expect(sess.Out).To(gbytes.Say("process-guid-1\n"))
+			Expect(sess.Out).NotTo(gbytes.Say("other-guid"))
+		})
+
+		It("reuses the cached process guids", func() {
+			sess := complete("")
+			Eventually(sess).Should(gexec.Exit(0))
+
+			sess = complete("oth")
+			Eventually(sess).Should(gexec.Exit(0))
+			Expect(sess.Out).To(gbytes.Say("other-guid\n"))
+			Expect(bbsServer.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
+})
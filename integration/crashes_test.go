@@ -0,0 +1,51 @@
+package integration_test
+
+import (
+	"code.cloudfoundry.org/bbs/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("crashes", func() {
+	itValidatesBBSFlags("crashes")
+
+	Context("when --top is negative", func() {
+		It("exits with status 3 and prints the usage", func() {
+			sess := RunCFDot("crashes", "--top", "-1")
+			Eventually(sess).Should(gexec.Exit(3))
+			Expect(sess.Err).To(gbytes.Say("--top must not be negative"))
+			Expect(sess.Err).To(gbytes.Say("cfdot crashes \\[flags\\]"))
+		})
+	})
+
+	Context("when instances crashed", func() {
+		BeforeEach(func() {
+			bbsServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/actual_lrps/list"),
+					ghttp.VerifyProtoRepresenting(&models.ActualLRPsRequest{Domain: "cf-apps"}),
+					ghttp.RespondWithProto(200, &models.ActualLRPsResponse{
+						ActualLrps: []*models.ActualLRP{
+							{
+								ActualLRPKey: models.NewActualLRPKey("process-guid", 0, "cf-apps"),
+								State:        models.ActualLRPStateCrashed,
+								CrashCount:   3,
+								CrashReason:  "APP/PROC/WEB: Exited with status 1",
+							},
+						},
+					}),
+				),
+			)
+		})
+
+		It("prints the crash report", func() {
+			sess := RunCFDot("crashes", "--domain", "cf-apps")
+			Eventually(sess).Should(gexec.Exit(0))
+			Expect(sess.Out).To(gbytes.Say(`"process_guid":"process-guid","domain":"cf-apps","crashed_instances":1,"crashing_instances":1,"crash_count":3,"reasons":\[{"reason":"APP/PROC/WEB: Exited with status 1","instances":1}\]`))
+		})
+	})
+})
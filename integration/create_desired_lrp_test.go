@@ -5,12 +5,15 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"os/exec"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/bbs/models/test/model_helpers"
 	"github.com/onsi/gomega/gbytes"
 	"github.com/onsi/gomega/gexec"
 	"github.com/onsi/gomega/ghttp"
@@ -25,7 +28,7 @@ var _ = Describe("create-desired-lrp", func() {
 			sess := RunCFDot("create-desired-lrp")
 			Eventually(sess).Should(gexec.Exit(3))
 			Expect(sess.Err).To(gbytes.Say(`missing spec`))
-			Expect(sess.Err).To(gbytes.Say("cfdot create-desired-lrp \\(SPEC\\|@FILE\\) .*"))
+			Expect(sess.Err).To(gbytes.Say("cfdot create-desired-lrp \\(SPEC\\|@FILE\\|-\\) .*"))
 		})
 	})
 
@@ -36,9 +39,7 @@ var _ = Describe("create-desired-lrp", func() {
 		)
 
 		BeforeEach(func() {
-			lrp = &models.DesiredLRP{
-				ProcessGuid: "some-process-guid",
-			}
+			lrp = model_helpers.NewValidDesiredLRP("some-process-guid")
 			serverTimeout = 0
 		})
 
@@ -85,8 +86,8 @@ var _ = Describe("create-desired-lrp", func() {
 						serverTimeout = 2
 					})
 
-					It("exits with code 4 and a timeout message", func() {
-						Eventually(sess, 2).Should(gexec.Exit(4))
+					It("exits with code 9 and a timeout message", func() {
+						Eventually(sess, 2).Should(gexec.Exit(9))
 						Expect(sess.Err).To(gbytes.Say(`Timeout exceeded`))
 					})
 				})
@@ -117,6 +118,33 @@ var _ = Describe("create-desired-lrp", func() {
 			})
 		})
 
+		Context("as yaml from stdin", func() {
+			It("exits with status code 0", func() {
+				cmd := exec.Command(cfdotPath,
+					"--bbsURL", bbsServer.URL(),
+					"--caCertFile", locketCACertFile,
+					"--clientCertFile", locketClientCertFile,
+					"--clientKeyFile", locketClientKeyFile,
+					"create-desired-lrp", "--format", "yaml", "-",
+				)
+				spec, err := json.Marshal(lrp)
+				Expect(err).NotTo(HaveOccurred())
+				cmd.Stdin = strings.NewReader("# some lrp\n" + string(spec))
+
+				sess, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(sess).Should(gexec.Exit(0))
+			})
+		})
+
+		Context("invalid yaml spec", func() {
+			It("exits with status code of 3 and prints the line of the error", func() {
+				sess := RunCFDot("create-desired-lrp", "process_guid: some-process-guid\ninstances: many\n")
+				Eventually(sess).Should(gexec.Exit(3))
+				Expect(sess.Err).To(gbytes.Say("Invalid YAML: line 2, field instances"))
+			})
+		})
+
 		Context("empty spec", func() {
 			It("exits with status code of 3", func() {
 				sess := RunCFDot("create-desired-lrp")
@@ -162,4 +190,30 @@ var _ = Describe("create-desired-lrp", func() {
 			Expect(sess.Err).To(gbytes.Say("deadlock"))
 		})
 	})
+	Context("when the desired LRP of a later document fails to be created", func() {
+		BeforeEach(func() {
+			bbsServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/desired_lrp/desire.r2"),
+					ghttp.RespondWithProto(200, &models.DesiredLRPLifecycleResponse{}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/desired_lrp/desire.r2"),
+					ghttp.RespondWithProto(500, &models.DesiredLRPLifecycleResponse{
+						Error: &models.Error{
+							Type:    models.Error_Deadlock,
+							Message: "deadlock detected",
+						},
+					}),
+				),
+			)
+		})
+
+		It("names the document that failed and exits with status code 4", func() {
+			sess := RunCFDot("create-desired-lrp", "--format", "yaml", "process_guid: guid-1\n---\nprocess_guid: guid-2\n")
+			Eventually(sess).Should(gexec.Exit(4))
+			Expect(sess.Err).To(gbytes.Say("Failed to create the desired-lrp in document 2 of 2; the documents before it were created"))
+			Expect(sess.Err).To(gbytes.Say("deadlock"))
+		})
+	})
 })
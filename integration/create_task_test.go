@@ -11,6 +11,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/bbs/models/test/model_helpers"
 	"github.com/onsi/gomega/gbytes"
 	"github.com/onsi/gomega/gexec"
 	"github.com/onsi/gomega/ghttp"
@@ -25,7 +26,7 @@ var _ = Describe("create-task", func() {
 			sess := RunCFDot("create-task")
 			Eventually(sess).Should(gexec.Exit(3))
 			Expect(sess.Err).To(gbytes.Say(`missing spec`))
-			Expect(sess.Err).To(gbytes.Say("cfdot create-task \\(SPEC\\|@FILE\\) .*"))
+			Expect(sess.Err).To(gbytes.Say("cfdot create-task \\(SPEC\\|@FILE\\|-\\) .*"))
 		})
 	})
 
@@ -37,7 +38,9 @@ var _ = Describe("create-task", func() {
 
 		BeforeEach(func() {
 			task = &models.Task{
-				TaskGuid: "some-task-guid",
+				TaskGuid:       "some-task-guid",
+				Domain:         "some-domain",
+				TaskDefinition: model_helpers.NewValidTaskDefinition(),
 			}
 			serverTimeout = 0
 		})
@@ -87,8 +90,8 @@ var _ = Describe("create-task", func() {
 						serverTimeout = 2
 					})
 
-					It("exits with code 4 and a timeout message", func() {
-						Eventually(sess, 2).Should(gexec.Exit(4))
+					It("exits with code 9 and a timeout message", func() {
+						Eventually(sess, 2).Should(gexec.Exit(9))
 						Expect(sess.Err).To(gbytes.Say(`Timeout exceeded`))
 					})
 				})
@@ -134,6 +137,15 @@ var _ = Describe("create-task", func() {
 			})
 		})
 
+		Context("spec rejected by the BBS validation", func() {
+			It("exits with status code of 3 without sending it", func() {
+				sess := RunCFDot("create-task", `{"task_guid":"some-task-guid"}`)
+				Eventually(sess).Should(gexec.Exit(3))
+				Expect(sess.Err).To(gbytes.Say("Invalid task spec: .*domain: is invalid"))
+				Expect(bbsServer.ReceivedRequests()).To(BeEmpty())
+			})
+		})
+
 		Context("non-existing spec file", func() {
 			It("exits with status 3 and prints the error", func() {
 				sess := RunCFDot("create-task", "@/path/to/non/existing/file")
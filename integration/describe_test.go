@@ -0,0 +1,73 @@
+package integration_test
+
+import (
+	"code.cloudfoundry.org/bbs/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("describe", func() {
+	itValidatesBBSFlags("describe", "task", "task-guid")
+
+	Context("when the id is missing", func() {
+		It("exits with status 3 and prints the usage", func() {
+			sess := RunCFDot("describe", "lrp")
+			Eventually(sess).Should(gexec.Exit(3))
+			Expect(sess.Err).To(gbytes.Say("Missing arguments"))
+			Expect(sess.Err).To(gbytes.Say("cfdot describe KIND ID \\[flags\\]"))
+		})
+	})
+
+	Context("when the kind is unknown", func() {
+		It("exits with status 3", func() {
+			sess := RunCFDot("describe", "app", "guid")
+			Eventually(sess).Should(gexec.Exit(3))
+			Expect(sess.Err).To(gbytes.Say("Unknown kind 'app', expected one of lrp, task or cell"))
+		})
+	})
+
+	Context("describe task", func() {
+		BeforeEach(func() {
+			bbsServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/tasks/get_by_task_guid.r3"),
+					ghttp.VerifyProtoRepresenting(&models.TaskByGuidRequest{TaskGuid: "task-guid"}),
+					ghttp.RespondWithProto(200, &models.TaskResponse{
+						Task: &models.Task{TaskGuid: "task-guid", Domain: "cfdot", State: models.Task_Pending},
+					}),
+				),
+			)
+		})
+
+		It("prints a description of the task", func() {
+			sess := RunCFDot("describe", "task", "task-guid")
+			Eventually(sess).Should(gexec.Exit(0))
+			Expect(sess.Out).To(gbytes.Say(`Task guid: +task-guid`))
+			Expect(sess.Out).To(gbytes.Say(`State: +Pending`))
+			Expect(sess.Out).To(gbytes.Say(`Cell: +-`))
+		})
+	})
+
+	Context("describe cell", func() {
+		BeforeEach(func() {
+			bbsServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/cells/list.r1"),
+					ghttp.RespondWithProto(200, &models.CellsResponse{
+						Cells: []*models.CellPresence{{CellId: "cell-1"}},
+					}),
+				),
+			)
+		})
+
+		It("exits with status 6 when the cell is not registered", func() {
+			sess := RunCFDot("describe", "cell", "cell-2")
+			Eventually(sess).Should(gexec.Exit(6))
+			Expect(sess.Err).To(gbytes.Say("Cell not found"))
+		})
+	})
+})
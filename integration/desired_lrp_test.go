@@ -119,9 +119,9 @@ var _ = Describe("desired-lrp", func() {
 						serverTimeout = 2
 					})
 
-					It("exits with code 4 and a timeout message", func() {
+					It("exits with code 9 and a timeout message", func() {
 						sess := RunCFDot("desired-lrp", "--timeout", "1", "test-guid")
-						Eventually(sess, 2).Should(gexec.Exit(4))
+						Eventually(sess, 2).Should(gexec.Exit(9))
 						Expect(sess.Err).To(gbytes.Say(`Timeout exceeded`))
 					})
 				})
@@ -154,7 +154,7 @@ var _ = Describe("desired-lrp", func() {
 				})
 
 				It("exits with status 4 and prints the error", func() {
-					sess := RunCFDot("desired-lrp", "test-guid")
+					sess := RunCFDot("desired-lrp", "test-guid", "--retry-attempts", "1")
 					Eventually(sess).Should(gexec.Exit(4))
 					Expect(sess.Err).To(gbytes.Say("deadlock"))
 				})
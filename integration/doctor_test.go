@@ -0,0 +1,93 @@
+package integration_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("doctor", func() {
+	itValidatesBBSFlags("doctor")
+	itHasNoArgs("doctor", false)
+
+	Context("when the BBS is unreachable", func() {
+		It("reports the failing check with a hint and exits with status code 4", func() {
+			sess := RunCFDot("--bbsURL", unreachableBBSURL, "doctor", "--locketAPILocation", locketAPILocation)
+			Eventually(sess).Should(gexec.Exit(4))
+
+			Expect(sess.Out).To(gbytes.Say(`"component":"bbs","address":"https://127.0.0.1:1","check":"tcp","status":"fail"`))
+			Expect(sess.Out).To(gbytes.Say(`"hint":"Check that the BBS is running`))
+			Expect(sess.Out).To(gbytes.Say(`"component":"locket","address":"` + locketAPILocation + `","check":"tcp","status":"pass"`))
+			Expect(sess.Err).To(gbytes.Say("checks failed"))
+		})
+	})
+
+	Context("when the client certificate has expired", func() {
+		var tmpDir, certFile, keyFile string
+
+		BeforeEach(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "doctor")
+			Expect(err).NotTo(HaveOccurred())
+			certFile, keyFile = writeExpiredCertificate(tmpDir)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tmpDir)
+		})
+
+		It("diagnoses the certificate instead of refusing to run and exits with status code 4", func() {
+			sess := RunCFDot("doctor", "--clientCertFile", certFile, "--clientKeyFile", keyFile)
+			Eventually(sess).Should(gexec.Exit(4))
+
+			Expect(sess.Out).To(gbytes.Say(`"check":"client-certificate","status":"fail","detail":"expired on `))
+			Expect(sess.Err).To(gbytes.Say("checks failed"))
+		})
+	})
+
+	Context("when --reps is negative", func() {
+		It("exits with status code 3", func() {
+			sess := RunCFDot("doctor", "--reps", "-1")
+			Eventually(sess).Should(gexec.Exit(3))
+			Expect(sess.Err).To(gbytes.Say("--reps must not be negative"))
+		})
+	})
+})
+
+// writeExpiredCertificate writes a self-signed client certificate that expired
+// an hour ago and its key into dir.
+func writeExpiredCertificate(dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "cfdot"},
+		NotBefore:    time.Now().Add(-72 * time.Hour),
+		NotAfter:     time.Now().Add(-time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+
+	certFile := filepath.Join(dir, "expired.crt")
+	keyFile := filepath.Join(dir, "expired.key")
+	Expect(ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)).To(Succeed())
+	Expect(ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)).To(Succeed())
+	return certFile, keyFile
+}
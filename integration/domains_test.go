@@ -1,7 +1,10 @@
 package integration_test
 
 import (
+	"encoding/json"
 	"net/http"
+	"os"
+	"os/exec"
 	"time"
 
 	"code.cloudfoundry.org/bbs/models"
@@ -53,9 +56,9 @@ var _ = Describe("domains", func() {
 					serverTimeout = 2
 				})
 
-				It("exits with code 4 and a timeout message", func() {
+				It("exits with code 9 and a timeout message", func() {
 					sess := RunCFDot("domains", "--timeout", "1")
-					Eventually(sess, 2).Should(gexec.Exit(4))
+					Eventually(sess, 2).Should(gexec.Exit(9))
 					Expect(sess.Err).To(gbytes.Say(`Timeout exceeded`))
 				})
 			})
@@ -77,12 +80,38 @@ var _ = Describe("domains", func() {
 		})
 
 		It("domains fails with a relevant error message", func() {
-			sess := RunCFDot("domains")
+			sess := RunCFDot("domains", "--retry-attempts", "1")
 			Eventually(sess, 2*time.Second).Should(gexec.Exit(4))
 			Expect(sess.Err).To(gbytes.Say("Invalid Response with status code: 500"))
 		})
 	})
 
+	Context("when the server fails transiently", func() {
+		BeforeEach(func() {
+			bbsServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/domains/list"),
+					ghttp.RespondWithProto(200, &models.DomainsResponse{
+						Error: models.ErrDeadlock,
+					}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/domains/list"),
+					ghttp.RespondWithProto(200, &models.DomainsResponse{
+						Domains: []string{"domain-1"},
+					}),
+				),
+			)
+		})
+
+		It("retries the request", func() {
+			sess := RunCFDot("domains", "--retry-backoff", "10ms")
+			Eventually(sess).Should(gexec.Exit(0))
+			Expect(sess.Out).To(gbytes.Say(`"domain-1"`))
+			Expect(bbsServer.ReceivedRequests()).To(HaveLen(2))
+		})
+	})
+
 	Context("when the server returns an error", func() {
 		BeforeEach(func() {
 			bbsServer.AppendHandlers(
@@ -100,7 +129,7 @@ var _ = Describe("domains", func() {
 		})
 
 		It("exits with status code 4 and should print the type and message of the error", func() {
-			sess := RunCFDot("domains")
+			sess := RunCFDot("domains", "--retry-attempts", "1")
 			Eventually(sess).Should(gexec.Exit(4))
 			Expect(sess.Err).To(gbytes.Say("BBS error"))
 			Expect(sess.Err).To(gbytes.Say("Type 28: Deadlock"))
@@ -108,9 +137,53 @@ var _ = Describe("domains", func() {
 		})
 
 		It("should not print the usage", func() {
-			sess := RunCFDot("domains")
+			sess := RunCFDot("domains", "--retry-attempts", "1")
 			Expect(sess.Err).NotTo(gbytes.Say("Usage:"))
 		})
+
+		Context("when errors are formatted as JSON", func() {
+			It("prints the error as a JSON object", func() {
+				sess := RunCFDot("domains", "--retry-attempts", "1", "--error-format", "json")
+				Eventually(sess).Should(gexec.Exit(4))
+
+				var cfdotErr map[string]interface{}
+				Expect(json.Unmarshal(sess.Err.Contents(), &cfdotErr)).To(Succeed())
+				Expect(cfdotErr).To(Equal(map[string]interface{}{
+					"component": "bbs",
+					"type":      "Deadlock",
+					"message":   "the request failed due to deadlock",
+					"exit_code": float64(4),
+					"endpoint":  bbsServer.URL(),
+				}))
+			})
+		})
+	})
+
+	Context("when errors are formatted as JSON and a flag is invalid", func() {
+		It("prints a validation error as a JSON object without the usage", func() {
+			sess := RunCFDot("domains", "--retry-attempts", "0", "--error-format", "json")
+			Eventually(sess).Should(gexec.Exit(3))
+
+			var cfdotErr map[string]interface{}
+			Expect(json.Unmarshal(sess.Err.Contents(), &cfdotErr)).To(Succeed())
+			Expect(cfdotErr).To(Equal(map[string]interface{}{
+				"component": "validation",
+				"message":   "--retry-attempts must be at least 1",
+				"exit_code": float64(3),
+			}))
+		})
+	})
+
+	Context("when CFDOT_ERROR_FORMAT is invalid", func() {
+		It("exits with status code 3", func() {
+			cmd := exec.Command(cfdotPath, "--bbsURL", bbsServer.URL(), "domains")
+			cmd.Env = append(os.Environ(), "CFDOT_ERROR_FORMAT=xml")
+			sess, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess).Should(gexec.Exit(3))
+			Expect(sess.Err).To(gbytes.Say("The value 'xml' is not a valid error format. Please specify text or json."))
+		})
 	})
 
 	Describe("flag parsing for bbsURL", func() {
@@ -6,23 +6,21 @@ this_dir="$(cd $(dirname $0) && pwd)"
 
 pushd "$this_dir"
 
-rm -rf out
-certstrap init --common-name "locketCA" --passphrase ""
-
-certstrap request-cert --common-name "locketServer" --ip "127.0.0.1" --domain "localhost" --passphrase ""
-certstrap sign locketServer --CA "locketCA"
-
-certstrap request-cert --common-name "locketClient" --ip "127.0.0.1" --passphrase ""
-certstrap sign locketClient --CA "locketCA"
-
-mv -f out/locketServer.crt ./locketServer.crt
-mv -f out/locketServer.key ./locketServer.key
-mv -f out/locketClient.crt ./locketClient.crt
-mv -f out/locketClient.key ./locketClient.key
-mv -f out/locketCA.crt ./locketCA.crt
-
-rm -rf out
-
-
+openssl req -x509 -newkey rsa:2048 -nodes -days 36500 \
+  -subj "/CN=locketCA" -keyout locketCA.key -out locketCA.crt
+
+openssl req -newkey rsa:2048 -nodes \
+  -subj "/CN=locketServer" -keyout locketServer.key -out locketServer.csr
+openssl x509 -req -days 36500 -in locketServer.csr \
+  -extfile <(printf "subjectAltName=IP:127.0.0.1,DNS:localhost") \
+  -CA locketCA.crt -CAkey locketCA.key -CAcreateserial -out locketServer.crt
+
+openssl req -newkey rsa:2048 -nodes \
+  -subj "/CN=locketClient" -keyout locketClient.key -out locketClient.csr
+openssl x509 -req -days 36500 -in locketClient.csr \
+  -extfile <(printf "subjectAltName=IP:127.0.0.1") \
+  -CA locketCA.crt -CAkey locketCA.key -CAcreateserial -out locketClient.crt
+
+rm -f locketCA.key locketCA.srl locketServer.csr locketClient.csr
 
 popd
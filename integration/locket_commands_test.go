@@ -123,7 +123,7 @@ var _ = Describe("Locket commands", func() {
 				sess, err := gexec.Start(cfdotCmd, GinkgoWriter, GinkgoWriter)
 				Expect(err).NotTo(HaveOccurred())
 
-				Eventually(sess, 11*time.Second).Should(gexec.Exit(4))
+				Eventually(sess, 11*time.Second).Should(gexec.Exit(10))
 				Expect(sess.Err).To(gbytes.Say("context deadline exceeded"))
 			})
 		})
@@ -171,9 +171,52 @@ var _ = Describe("Locket commands", func() {
 				sess, err := gexec.Start(cfdotCmd, GinkgoWriter, GinkgoWriter)
 				Expect(err).NotTo(HaveOccurred())
 
-				Eventually(sess, 11*time.Second).Should(gexec.Exit(4))
+				Eventually(sess, 11*time.Second).Should(gexec.Exit(10))
 				Expect(sess.Err).To(gbytes.Say("context deadline exceeded"))
 			})
 		})
 	})
+
+	Describe("leaders", func() {
+		itValidatesLocketFlags("leaders")
+		itHasNoArgs("leaders", true)
+
+		Context("when a well-known lock is held", func() {
+			BeforeEach(func() {
+				req := &models.LockRequest{
+					Resource: &models.Resource{
+						Key:   "bbs",
+						Owner: "bbs-instance-guid",
+						Type:  "lock",
+					},
+					TtlInSeconds: 10,
+				}
+
+				_, err := locketClient.Lock(context.Background(), req)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("prints the component holding the lock", func() {
+				cfdotCmd := exec.Command(cfdotPath, "--locketAPILocation", locketAPILocation, "leaders")
+
+				sess, err := gexec.Start(cfdotCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(0))
+				Expect(sess.Out).To(gbytes.Say(`"key":"bbs","component":"bbs","owner":"bbs-instance-guid","default_ttl_in_seconds":15`))
+				Expect(sess.Out).To(gbytes.Say(`"key":"cc-deployment-updater","component":"cc-deployment-updater","owner":"","absent":true`))
+			})
+
+			It("keeps printing when watching", func() {
+				cfdotCmd := exec.Command(cfdotPath, "--locketAPILocation", locketAPILocation, "leaders", "--watch", "--interval", "100ms")
+
+				sess, err := gexec.Start(cfdotCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Out).Should(gbytes.Say(`"owner":"bbs-instance-guid"`))
+				Consistently(sess, 500*time.Millisecond).ShouldNot(gexec.Exit())
+				sess.Kill()
+			})
+		})
+	})
 })
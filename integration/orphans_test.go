@@ -0,0 +1,76 @@
+package integration_test
+
+import (
+	"code.cloudfoundry.org/bbs/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("orphans", func() {
+	itValidatesBBSFlags("orphans")
+
+	Context("when work is placed on a vanished cell", func() {
+		BeforeEach(func() {
+			bbsServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/cells/list.r1"),
+					ghttp.RespondWithProto(200, &models.CellsResponse{
+						Cells: []*models.CellPresence{{CellId: "cell-1"}},
+					}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/actual_lrps/list"),
+					ghttp.RespondWithProto(200, &models.ActualLRPsResponse{
+						ActualLrps: []*models.ActualLRP{
+							{
+								ActualLRPKey:         models.NewActualLRPKey("process-guid", 0, "cf-apps"),
+								ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-guid", "cell-1"),
+								State:                models.ActualLRPStateRunning,
+							},
+							{
+								ActualLRPKey:         models.NewActualLRPKey("process-guid", 1, "cf-apps"),
+								ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-guid", "cell-gone"),
+								State:                models.ActualLRPStateRunning,
+							},
+						},
+					}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/tasks/list.r3"),
+					ghttp.RespondWithProto(200, &models.TasksResponse{
+						Tasks: []*models.Task{
+							{TaskGuid: "task-guid", Domain: "cf-tasks", CellId: "cell-gone", State: models.Task_Running},
+						},
+					}),
+				),
+			)
+		})
+
+		It("prints the orphaned instances and tasks", func() {
+			sess := RunCFDot("orphans")
+			Eventually(sess).Should(gexec.Exit(0))
+			Expect(sess.Out).To(gbytes.Say(`"instances":\[{"process_guid":"process-guid","index":1,"domain":"cf-apps","cell_id":"cell-gone","state":"RUNNING"`))
+			Expect(sess.Out).To(gbytes.Say(`"tasks":\[{"task_guid":"task-guid","domain":"cf-tasks","cell_id":"cell-gone"`))
+			Expect(bbsServer.ReceivedRequests()).To(HaveLen(3))
+		})
+
+		It("fails the orphaned tasks with --fail --yes", func() {
+			bbsServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/tasks/cancel"),
+					ghttp.VerifyProtoRepresenting(&models.TaskGuidRequest{TaskGuid: "task-guid"}),
+					ghttp.RespondWithProto(200, &models.TaskLifecycleResponse{}),
+				),
+			)
+
+			sess := RunCFDot("orphans", "--fail", "--yes")
+			Eventually(sess).Should(gexec.Exit(0))
+			Expect(sess.Err).To(gbytes.Say("Failed task task-guid on cell cell-gone"))
+			Expect(bbsServer.ReceivedRequests()).To(HaveLen(4))
+		})
+	})
+})
@@ -86,7 +86,7 @@ var _ = Describe("Presences", func() {
 			sess, err := gexec.Start(cfdotCmd, GinkgoWriter, GinkgoWriter)
 			Expect(err).NotTo(HaveOccurred())
 
-			Eventually(sess, 11*time.Second).Should(gexec.Exit(4))
+			Eventually(sess, 11*time.Second).Should(gexec.Exit(10))
 			Expect(sess.Err).To(gbytes.Say("context deadline exceeded"))
 		})
 	})
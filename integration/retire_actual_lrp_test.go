@@ -46,7 +46,7 @@ var _ = Describe("retire-actual-lrp", func() {
 		})
 
 		It("exits with exit code 0", func() {
-			session := RunCFDot("retire-actual-lrp", "test-process-guid", "1")
+			session := RunCFDot("retire-actual-lrp", "test-process-guid", "1", "--retry-attempts", "1")
 			Eventually(session).Should(gexec.Exit(0))
 		})
 
@@ -56,9 +56,9 @@ var _ = Describe("retire-actual-lrp", func() {
 					serverTimeout = 2
 				})
 
-				It("exits with code 4 and a timeout message", func() {
+				It("exits with code 9 and a timeout message", func() {
 					session := RunCFDot("retire-actual-lrp", "--timeout", "1", "test-process-guid", "1")
-					Eventually(session, 2).Should(gexec.Exit(4))
+					Eventually(session, 2).Should(gexec.Exit(9))
 					Expect(session.Err).To(gbytes.Say(`Timeout exceeded`))
 				})
 			})
@@ -88,7 +88,7 @@ var _ = Describe("retire-actual-lrp", func() {
 		})
 
 		It("exits with exit code 4", func() {
-			session := RunCFDot("retire-actual-lrp", "test-process-guid", "1")
+			session := RunCFDot("retire-actual-lrp", "test-process-guid", "1", "--retry-attempts", "1")
 			Eventually(session).Should(gexec.Exit(4))
 		})
 	})
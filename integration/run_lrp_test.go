@@ -0,0 +1,103 @@
+package integration_test
+
+import (
+	"io/ioutil"
+	"net/http"
+
+	"code.cloudfoundry.org/bbs/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("run-lrp", func() {
+	itValidatesBBSFlags("run-lrp", "--docker-image", "nginx", "--", "nginx")
+
+	Context("when neither an image nor a rootfs is given", func() {
+		It("exits with status 3 and prints the usage", func() {
+			sess := RunCFDot("run-lrp", "--", "nginx")
+			Eventually(sess).Should(gexec.Exit(3))
+			Expect(sess.Err).To(gbytes.Say("Missing --docker-image or --rootfs"))
+			Expect(sess.Err).To(gbytes.Say("cfdot run-lrp \\[flags\\] -- PATH \\[ARGS...\\]"))
+		})
+	})
+
+	Context("when the desired LRP is created", func() {
+		JustBeforeEach(func() {
+			bbsServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/desired_lrp/desire.r2"),
+					func(w http.ResponseWriter, req *http.Request) {
+						request := &models.DesireLRPRequest{}
+						body, err := ioutil.ReadAll(req.Body)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(request.Unmarshal(body)).To(Succeed())
+
+						lrp := request.DesiredLrp
+						Expect(lrp.ProcessGuid).To(Equal("process-guid"))
+						Expect(lrp.Domain).To(Equal("test"))
+						Expect(lrp.RootFs).To(Equal("docker:///nginx"))
+						Expect(lrp.Instances).To(Equal(int32(2)))
+						Expect(lrp.Action.RunAction.Path).To(Equal("nginx"))
+						Expect(string(*(*lrp.Routes)["cf-router"])).To(MatchJSON(`[{"hostnames":["host.example.com"],"port":8080}]`))
+					},
+					ghttp.RespondWithProto(200, &models.DesiredLRPLifecycleResponse{}),
+				),
+			)
+		})
+
+		It("prints the desired LRP and exits with status 0", func() {
+			sess := RunCFDot("run-lrp", "--process-guid", "process-guid", "--docker-image", "nginx", "--instances", "2", "--route", "host.example.com:8080", "--domain", "test", "--", "nginx")
+			Eventually(sess).Should(gexec.Exit(0))
+			Expect(sess.Out).To(gbytes.Say(`"process_guid":"process-guid"`))
+		})
+
+		Context("with --wait", func() {
+			JustBeforeEach(func() {
+				bbsServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/v1/actual_lrps/list"),
+						ghttp.VerifyProtoRepresenting(&models.ActualLRPsRequest{ProcessGuid: "process-guid"}),
+						ghttp.RespondWithProto(200, &models.ActualLRPsResponse{
+							ActualLrps: []*models.ActualLRP{
+								{ActualLRPKey: models.NewActualLRPKey("process-guid", 0, "test"), State: models.ActualLRPStateRunning},
+								{ActualLRPKey: models.NewActualLRPKey("process-guid", 1, "test"), State: models.ActualLRPStateRunning},
+							},
+						}),
+					),
+				)
+			})
+
+			It("waits for the instances to be running", func() {
+				sess := RunCFDot("run-lrp", "--process-guid", "process-guid", "--docker-image", "nginx", "--instances", "2", "--route", "host.example.com:8080", "--domain", "test", "--wait", "--", "nginx")
+				Eventually(sess).Should(gexec.Exit(0))
+				Expect(sess.Err).To(gbytes.Say("2 of 2 instances of process-guid running"))
+			})
+		})
+
+		Context("with --wait when an instance crashes", func() {
+			JustBeforeEach(func() {
+				bbsServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/v1/actual_lrps/list"),
+						ghttp.RespondWithProto(200, &models.ActualLRPsResponse{
+							ActualLrps: []*models.ActualLRP{
+								{ActualLRPKey: models.NewActualLRPKey("process-guid", 0, "test"), State: models.ActualLRPStateRunning},
+								{ActualLRPKey: models.NewActualLRPKey("process-guid", 1, "test"), State: models.ActualLRPStateCrashed, CrashReason: "exit status 1"},
+							},
+						}),
+					),
+				)
+			})
+
+			It("exits with status code 13", func() {
+				sess := RunCFDot("run-lrp", "--process-guid", "process-guid", "--docker-image", "nginx", "--instances", "2", "--route", "host.example.com:8080", "--domain", "test", "--wait", "--", "nginx")
+				Eventually(sess).Should(gexec.Exit(13))
+				Expect(sess.Err).To(gbytes.Say("Instance 1 of process-guid crashed: exit status 1"))
+			})
+		})
+	})
+})
@@ -0,0 +1,96 @@
+package integration_test
+
+import (
+	"net/http"
+
+	"code.cloudfoundry.org/bbs/events"
+	"code.cloudfoundry.org/bbs/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("run-task", func() {
+	itValidatesBBSFlags("run-task", "--rootfs", "preloaded:cflinuxfs3", "--", "ls")
+
+	Context("when the command to run is missing", func() {
+		It("exits with status 3 and prints the usage", func() {
+			sess := RunCFDot("run-task", "--rootfs", "preloaded:cflinuxfs3")
+			Eventually(sess).Should(gexec.Exit(3))
+			Expect(sess.Err).To(gbytes.Say("Missing command to run"))
+			Expect(sess.Err).To(gbytes.Say("cfdot run-task \\[flags\\] -- PATH \\[ARGS...\\]"))
+		})
+	})
+
+	Context("when the task runs", func() {
+		var completed *models.Task
+
+		BeforeEach(func() {
+			completed = &models.Task{TaskGuid: "task-guid", State: models.Task_Completed, Result: "some-result"}
+		})
+
+		JustBeforeEach(func() {
+			event, err := events.NewEventFromModelEvent(1, models.NewTaskChangedEvent(&models.Task{TaskGuid: "task-guid"}, completed))
+			Expect(err).NotTo(HaveOccurred())
+
+			bbsServer.RouteToHandler("POST", "/v1/events/tasks.r1", func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(200)
+				w.(http.Flusher).Flush()
+				w.Write(event.Encode())
+				w.(http.Flusher).Flush()
+				<-req.Context().Done()
+			})
+			bbsServer.RouteToHandler("POST", "/v1/tasks/desire.r2", ghttp.CombineHandlers(
+				ghttp.VerifyProtoRepresenting(&models.DesireTaskRequest{
+					TaskGuid: "task-guid",
+					Domain:   "cfdot",
+					TaskDefinition: &models.TaskDefinition{
+						RootFs:   "preloaded:cflinuxfs3",
+						MemoryMb: 256,
+						DiskMb:   1024,
+						Action: models.WrapAction(&models.RunAction{
+							Path: "/bin/echo",
+							Args: []string{"hello", "--world"},
+							User: "vcap",
+						}),
+					},
+				}),
+				ghttp.RespondWithProto(200, &models.TaskLifecycleResponse{}),
+			))
+			bbsServer.RouteToHandler("POST", "/v1/tasks/resolving", ghttp.CombineHandlers(
+				ghttp.VerifyProtoRepresenting(&models.TaskGuidRequest{TaskGuid: "task-guid"}),
+				ghttp.RespondWithProto(200, &models.TaskLifecycleResponse{}),
+			))
+			bbsServer.RouteToHandler("POST", "/v1/tasks/delete", ghttp.CombineHandlers(
+				ghttp.VerifyProtoRepresenting(&models.TaskGuidRequest{TaskGuid: "task-guid"}),
+				ghttp.RespondWithProto(200, &models.TaskLifecycleResponse{}),
+			))
+		})
+
+		It("prints the completed task, deletes it and exits with status 0", func() {
+			sess := RunCFDot("run-task", "--task-guid", "task-guid", "--rootfs", "preloaded:cflinuxfs3", "--", "/bin/echo", "hello", "--world")
+			Eventually(sess).Should(gexec.Exit(0))
+			Expect(sess.Err).To(gbytes.Say("Task task-guid is Completed"))
+			Expect(sess.Out).To(gbytes.Say(`"result":"some-result"`))
+			Expect(bbsServer.ReceivedRequests()).To(ContainElement(WithTransform(func(req *http.Request) string {
+				return req.URL.Path
+			}, Equal("/v1/tasks/delete"))))
+		})
+
+		Context("when the task fails", func() {
+			BeforeEach(func() {
+				completed.Failed = true
+				completed.FailureReason = "exit status 1"
+			})
+
+			It("exits with status 12 and prints the failure reason", func() {
+				sess := RunCFDot("run-task", "--task-guid", "task-guid", "--rootfs", "preloaded:cflinuxfs3", "--", "/bin/echo", "hello", "--world")
+				Eventually(sess).Should(gexec.Exit(12))
+				Expect(sess.Err).To(gbytes.Say("Task task-guid failed: exit status 1"))
+			})
+		})
+	})
+})
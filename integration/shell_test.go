@@ -0,0 +1,87 @@
+package integration_test
+
+import (
+	"os/exec"
+	"strings"
+
+	"code.cloudfoundry.org/bbs/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("shell", func() {
+	itValidatesBBSFlags("shell")
+	itHasNoArgs("shell", false)
+
+	runShell := func(input string) *gexec.Session {
+		cmd := exec.Command(cfdotPath,
+			"--bbsURL", bbsServer.URL(),
+			"--caCertFile", locketCACertFile,
+			"--clientCertFile", locketClientCertFile,
+			"--clientKeyFile", locketClientKeyFile,
+			"shell",
+		)
+		cmd.Stdin = strings.NewReader(input)
+
+		sess, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		return sess
+	}
+
+	Context("when commands are read from stdin", func() {
+		BeforeEach(func() {
+			bbsServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/domains/list"),
+					ghttp.RespondWithProto(200, &models.DomainsResponse{
+						Domains: []string{"domain-1"},
+					}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/domains/list"),
+					ghttp.RespondWithProto(200, &models.DomainsResponse{
+						Domains: []string{"domain-2"},
+					}),
+				),
+			)
+		})
+
+		It("runs each command in turn", func() {
+			sess := runShell("domains\n# a comment\n\ndomains\n")
+			Eventually(sess).Should(gexec.Exit(0))
+			Expect(sess.Out).To(gbytes.Say(`"domain-1"\n"domain-2"\n`))
+			Expect(bbsServer.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("stops at exit", func() {
+			sess := runShell("domains\nexit\ndomains\n")
+			Eventually(sess).Should(gexec.Exit(0))
+			Expect(sess.Out).To(gbytes.Say(`"domain-1"\n`))
+			Expect(bbsServer.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("keeps running after a command fails", func() {
+			sess := runShell("cell\ndomains\n")
+			Eventually(sess).Should(gexec.Exit(0))
+			Expect(sess.Err).To(gbytes.Say("Missing arguments"))
+			Expect(sess.Out).To(gbytes.Say(`"domain-1"\n`))
+		})
+
+		It("does not carry flags over to the next command", func() {
+			sess := runShell("domains --timeout -1\ndomains\n")
+			Eventually(sess).Should(gexec.Exit(0))
+			Expect(sess.Err).To(gbytes.Say("--timeout must be a non-negative number of seconds"))
+			Expect(sess.Out).To(gbytes.Say(`"domain-1"\n`))
+		})
+
+		It("refuses to start a nested shell", func() {
+			sess := runShell("shell\n")
+			Eventually(sess).Should(gexec.Exit(0))
+			Expect(sess.Err).To(gbytes.Say("Already running cfdot shell"))
+		})
+	})
+})
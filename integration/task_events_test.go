@@ -1,6 +1,8 @@
 package integration_test
 
 import (
+	"net/http"
+
 	"code.cloudfoundry.org/bbs/events"
 	"code.cloudfoundry.org/bbs/models"
 
@@ -37,6 +39,35 @@ var _ = Describe("task-events", func() {
 		})
 	})
 
+	Context("when interrupted while streaming", func() {
+		BeforeEach(func() {
+			task := models.Task{TaskGuid: "some-guid"}
+			taskEvent := models.NewTaskRemovedEvent(&task)
+			sseEvent, err := events.NewEventFromModelEvent(1, taskEvent)
+			Expect(err).ToNot(HaveOccurred())
+
+			bbsServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/events/tasks.r1"),
+					func(w http.ResponseWriter, req *http.Request) {
+						w.WriteHeader(200)
+						w.Write(sseEvent.Encode())
+						w.(http.Flusher).Flush()
+						<-req.Context().Done()
+					},
+				),
+			)
+		})
+
+		It("closes the event stream and exits successfully", func() {
+			sess := RunCFDot("task-events")
+			Eventually(sess.Out).Should(gbytes.Say("some-guid"))
+
+			sess.Interrupt()
+			Eventually(sess).Should(gexec.Exit(0))
+		})
+	})
+
 	Context("when there is a BBS error", func() {
 		BeforeEach(func() {
 			bbsServer.AppendHandlers(
@@ -0,0 +1,52 @@
+package integration_test
+
+import (
+	"code.cloudfoundry.org/bbs/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("task-stats", func() {
+	itValidatesBBSFlags("task-stats")
+
+	Context("when the output is invalid", func() {
+		It("exits with status 3 and prints the usage", func() {
+			sess := RunCFDot("task-stats", "--output", "yaml")
+			Eventually(sess).Should(gexec.Exit(3))
+			Expect(sess.Err).To(gbytes.Say("The value 'yaml' is not a valid output. Please specify json or table."))
+			Expect(sess.Err).To(gbytes.Say("cfdot task-stats \\[flags\\]"))
+		})
+	})
+
+	Context("when tasks exist", func() {
+		BeforeEach(func() {
+			bbsServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/tasks/list.r3"),
+					ghttp.VerifyProtoRepresenting(&models.TasksRequest{Domain: "cf-tasks"}),
+					ghttp.RespondWithProto(200, &models.TasksResponse{
+						Tasks: []*models.Task{
+							{TaskGuid: "task-1", Domain: "cf-tasks", State: models.Task_Running, CellId: "cell-1"},
+						},
+					}),
+				),
+			)
+		})
+
+		It("prints the stats as JSON", func() {
+			sess := RunCFDot("task-stats", "--domain", "cf-tasks")
+			Eventually(sess).Should(gexec.Exit(0))
+			Expect(sess.Out).To(gbytes.Say(`"total":1,"by_state":{"Running":1}`))
+		})
+
+		It("prints the stats as a table", func() {
+			sess := RunCFDot("task-stats", "--domain", "cf-tasks", "--output", "table")
+			Eventually(sess).Should(gexec.Exit(0))
+			Expect(sess.Out).To(gbytes.Say(`cell-1 +1\n`))
+		})
+	})
+})
@@ -24,7 +24,7 @@ var _ = Describe("update-desired-lrp", func() {
 			sess := RunCFDot("update-desired-lrp")
 			Eventually(sess).Should(gexec.Exit(3))
 			Expect(sess.Err).To(gbytes.Say(`Missing arguments`))
-			Expect(sess.Err).To(gbytes.Say("cfdot update-desired-lrp process-guid \\(SPEC\\|@FILE\\) .*"))
+			Expect(sess.Err).To(gbytes.Say("cfdot update-desired-lrp process-guid \\[\\(SPEC\\|@FILE\\|-\\)\\] .*"))
 		})
 	})
 
@@ -78,9 +78,9 @@ var _ = Describe("update-desired-lrp", func() {
 						serverTimeout = 2
 					})
 
-					It("exits with code 4 and a timeout message", func() {
+					It("exits with code 9 and a timeout message", func() {
 						sess := RunCFDot("update-desired-lrp", "process-guid", lrpArg, "--timeout", "1")
-						Eventually(sess, 2).Should(gexec.Exit(4))
+						Eventually(sess, 2).Should(gexec.Exit(9))
 						Expect(sess.Err).To(gbytes.Say(`Timeout exceeded`))
 					})
 				})
@@ -136,6 +136,71 @@ var _ = Describe("update-desired-lrp", func() {
 		})
 	})
 
+	Context("when update flags are provided", func() {
+		rawRoutes := func(routes string) *json.RawMessage {
+			raw := json.RawMessage(routes)
+			return &raw
+		}
+
+		BeforeEach(func() {
+			bbsServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/desired_lrps/get_by_process_guid.r3"),
+					ghttp.VerifyProtoRepresenting(&models.DesiredLRPByProcessGuidRequest{
+						ProcessGuid: "process-guid",
+					}),
+					ghttp.RespondWithProto(200, &models.DesiredLRPResponse{
+						DesiredLrp: &models.DesiredLRP{
+							ProcessGuid: "process-guid",
+							Ports:       []uint32{8080},
+							Routes: &models.Routes{
+								"cf-router":  rawRoutes(`[{"hostnames":["a.example.com"],"port":8080}]`),
+								"tcp-router": rawRoutes(`[{"external_port":61000,"container_port":8080}]`),
+							},
+						},
+					}),
+				),
+			)
+		})
+
+		It("submits the scale and the routes merged into the current ones", func() {
+			update := &models.DesiredLRPUpdate{
+				Routes: &models.Routes{
+					"cf-router":  rawRoutes(`[{"hostnames":["a.example.com","b.example.com"],"port":8080}]`),
+					"tcp-router": rawRoutes(`[{"external_port":61000,"container_port":8080}]`),
+				},
+			}
+			update.SetInstances(3)
+
+			bbsServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v1/desired_lrp/update"),
+					ghttp.VerifyProtoRepresenting(&models.UpdateDesiredLRPRequest{
+						Update:      update,
+						ProcessGuid: "process-guid",
+					}),
+					ghttp.RespondWithProto(200, &models.DesiredLRPLifecycleResponse{}),
+				),
+			)
+
+			sess := RunCFDot("update-desired-lrp", "process-guid", "--instances", "3", "--add-route", "cf-router:b.example.com:8080")
+			Eventually(sess).Should(gexec.Exit(0))
+		})
+
+		It("fails without updating when the route to remove does not exist", func() {
+			sess := RunCFDot("update-desired-lrp", "process-guid", "--remove-route", "cf-router:c.example.com:8080")
+			Eventually(sess).Should(gexec.Exit(5))
+			Expect(sess.Err).To(gbytes.Say("Cannot remove route cf-router:c.example.com:8080"))
+			Expect(bbsServer.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("rejects a spec together with update flags", func() {
+			sess := RunCFDot("update-desired-lrp", "process-guid", "{}", "--instances", "3")
+			Eventually(sess).Should(gexec.Exit(3))
+			Expect(sess.Err).To(gbytes.Say("Pass either a spec or update flags, not both"))
+		})
+	})
+
 	Context("when bbs responds with non-200 status code", func() {
 		JustBeforeEach(func() {
 			bbsServer.AppendHandlers(
@@ -0,0 +1,79 @@
+package integration_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("validate", func() {
+	var specFile string
+
+	runValidate := func(args ...string) *gexec.Session {
+		sess, err := gexec.Start(exec.Command(cfdotPath, append([]string{"validate"}, args...)...), GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		return sess
+	}
+
+	writeSpec := func(spec string) {
+		f, err := ioutil.TempFile(os.TempDir(), "validate_spec")
+		Expect(err).NotTo(HaveOccurred())
+		defer f.Close()
+		_, err = f.WriteString(spec)
+		Expect(err).NotTo(HaveOccurred())
+		specFile = f.Name()
+	}
+
+	AfterEach(func() {
+		os.Remove(specFile)
+	})
+
+	Context("when the spec only has warnings", func() {
+		BeforeEach(func() {
+			writeSpec(`
+process_guid: some-process-guid
+domain: some-domain
+rootfs: preloaded:cflinuxfs3
+instances: 1
+memory_mb: 256
+action:
+  run:
+    path: ls
+    user: vcap
+`)
+		})
+
+		It("prints them and exits with status code 0", func() {
+			sess := runValidate("desired-lrp", specFile)
+			Eventually(sess).Should(gexec.Exit(0))
+			Expect(sess.Out).To(gbytes.Say(`"field":"check_definition","severity":"warning"`))
+			Expect(sess.Out).To(gbytes.Say(`"field":"disk_mb","severity":"warning"`))
+		})
+	})
+
+	Context("when the spec has errors", func() {
+		BeforeEach(func() {
+			writeSpec(`{"process_guid":"some-process-guid"}`)
+		})
+
+		It("prints them and exits with status code 3", func() {
+			sess := runValidate("desired-lrp", specFile)
+			Eventually(sess).Should(gexec.Exit(3))
+			Expect(sess.Out).To(gbytes.Say(`"field":"domain","severity":"error","message":"is invalid"`))
+			Expect(sess.Err).To(gbytes.Say(`Found \d+ errors in the specs`))
+		})
+	})
+
+	Context("when the spec kind is unknown", func() {
+		It("exits with status code 3", func() {
+			sess := runValidate("actual-lrp", "spec.yml")
+			Eventually(sess).Should(gexec.Exit(3))
+			Expect(sess.Err).To(gbytes.Say("Unknown spec kind 'actual-lrp'"))
+		})
+	})
+})
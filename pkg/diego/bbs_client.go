@@ -0,0 +1,217 @@
+package diego
+
+import (
+	"context"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/events"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/lager"
+)
+
+// interceptor sends a request by calling send with the client that should
+// handle it. mutation tells whether the request changes state in the BBS, and
+// ctx is the context the client was bound to, if any.
+type interceptor func(ctx context.Context, logger lager.Logger, mutation bool, send func(client bbs.Client) error) error
+
+// interceptedBBSClient routes the requests cfdot makes through intercept.
+// Requests it does not wrap go straight to the embedded client.
+type interceptedBBSClient struct {
+	bbs.Client
+	intercept interceptor
+	ctx       context.Context
+}
+
+func (c *interceptedBBSClient) withContext(ctx context.Context) bbs.Client {
+	bound := *c
+	bound.ctx = ctx
+	return &bound
+}
+
+func (c *interceptedBBSClient) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+func (c *interceptedBBSClient) Domains(logger lager.Logger) ([]string, error) {
+	var domains []string
+	err := c.intercept(c.context(), logger, false, func(client bbs.Client) (err error) {
+		domains, err = client.Domains(logger)
+		return err
+	})
+	return domains, err
+}
+
+func (c *interceptedBBSClient) Cells(logger lager.Logger) ([]*models.CellPresence, error) {
+	var cells []*models.CellPresence
+	err := c.intercept(c.context(), logger, false, func(client bbs.Client) (err error) {
+		cells, err = client.Cells(logger)
+		return err
+	})
+	return cells, err
+}
+
+func (c *interceptedBBSClient) ActualLRPs(logger lager.Logger, filter models.ActualLRPFilter) ([]*models.ActualLRP, error) {
+	var actualLRPs []*models.ActualLRP
+	err := c.intercept(c.context(), logger, false, func(client bbs.Client) (err error) {
+		actualLRPs, err = client.ActualLRPs(logger, filter)
+		return err
+	})
+	return actualLRPs, err
+}
+
+func (c *interceptedBBSClient) ActualLRPGroups(logger lager.Logger, filter models.ActualLRPFilter) ([]*models.ActualLRPGroup, error) {
+	var groups []*models.ActualLRPGroup
+	err := c.intercept(c.context(), logger, false, func(client bbs.Client) (err error) {
+		groups, err = client.ActualLRPGroups(logger, filter)
+		return err
+	})
+	return groups, err
+}
+
+func (c *interceptedBBSClient) ActualLRPGroupsByProcessGuid(logger lager.Logger, processGuid string) ([]*models.ActualLRPGroup, error) {
+	var groups []*models.ActualLRPGroup
+	err := c.intercept(c.context(), logger, false, func(client bbs.Client) (err error) {
+		groups, err = client.ActualLRPGroupsByProcessGuid(logger, processGuid)
+		return err
+	})
+	return groups, err
+}
+
+func (c *interceptedBBSClient) ActualLRPGroupByProcessGuidAndIndex(logger lager.Logger, processGuid string, index int) (*models.ActualLRPGroup, error) {
+	var group *models.ActualLRPGroup
+	err := c.intercept(c.context(), logger, false, func(client bbs.Client) (err error) {
+		group, err = client.ActualLRPGroupByProcessGuidAndIndex(logger, processGuid, index)
+		return err
+	})
+	return group, err
+}
+
+func (c *interceptedBBSClient) DesiredLRPs(logger lager.Logger, filter models.DesiredLRPFilter) ([]*models.DesiredLRP, error) {
+	var desiredLRPs []*models.DesiredLRP
+	err := c.intercept(c.context(), logger, false, func(client bbs.Client) (err error) {
+		desiredLRPs, err = client.DesiredLRPs(logger, filter)
+		return err
+	})
+	return desiredLRPs, err
+}
+
+func (c *interceptedBBSClient) DesiredLRPByProcessGuid(logger lager.Logger, processGuid string) (*models.DesiredLRP, error) {
+	var desiredLRP *models.DesiredLRP
+	err := c.intercept(c.context(), logger, false, func(client bbs.Client) (err error) {
+		desiredLRP, err = client.DesiredLRPByProcessGuid(logger, processGuid)
+		return err
+	})
+	return desiredLRP, err
+}
+
+func (c *interceptedBBSClient) DesiredLRPSchedulingInfos(logger lager.Logger, filter models.DesiredLRPFilter) ([]*models.DesiredLRPSchedulingInfo, error) {
+	var infos []*models.DesiredLRPSchedulingInfo
+	err := c.intercept(c.context(), logger, false, func(client bbs.Client) (err error) {
+		infos, err = client.DesiredLRPSchedulingInfos(logger, filter)
+		return err
+	})
+	return infos, err
+}
+
+func (c *interceptedBBSClient) TasksWithFilter(logger lager.Logger, filter models.TaskFilter) ([]*models.Task, error) {
+	var tasks []*models.Task
+	err := c.intercept(c.context(), logger, false, func(client bbs.Client) (err error) {
+		tasks, err = client.TasksWithFilter(logger, filter)
+		return err
+	})
+	return tasks, err
+}
+
+func (c *interceptedBBSClient) TaskByGuid(logger lager.Logger, taskGuid string) (*models.Task, error) {
+	var task *models.Task
+	err := c.intercept(c.context(), logger, false, func(client bbs.Client) (err error) {
+		task, err = client.TaskByGuid(logger, taskGuid)
+		return err
+	})
+	return task, err
+}
+
+func (c *interceptedBBSClient) UpsertDomain(logger lager.Logger, domain string, ttl time.Duration) error {
+	return c.intercept(c.context(), logger, true, func(client bbs.Client) error {
+		return client.UpsertDomain(logger, domain, ttl)
+	})
+}
+
+func (c *interceptedBBSClient) RetireActualLRP(logger lager.Logger, key *models.ActualLRPKey) error {
+	return c.intercept(c.context(), logger, true, func(client bbs.Client) error {
+		return client.RetireActualLRP(logger, key)
+	})
+}
+
+func (c *interceptedBBSClient) DesireLRP(logger lager.Logger, desiredLRP *models.DesiredLRP) error {
+	return c.intercept(c.context(), logger, true, func(client bbs.Client) error {
+		return client.DesireLRP(logger, desiredLRP)
+	})
+}
+
+func (c *interceptedBBSClient) UpdateDesiredLRP(logger lager.Logger, processGuid string, update *models.DesiredLRPUpdate) error {
+	return c.intercept(c.context(), logger, true, func(client bbs.Client) error {
+		return client.UpdateDesiredLRP(logger, processGuid, update)
+	})
+}
+
+func (c *interceptedBBSClient) RemoveDesiredLRP(logger lager.Logger, processGuid string) error {
+	return c.intercept(c.context(), logger, true, func(client bbs.Client) error {
+		return client.RemoveDesiredLRP(logger, processGuid)
+	})
+}
+
+func (c *interceptedBBSClient) DesireTask(logger lager.Logger, taskGuid, domain string, taskDefinition *models.TaskDefinition) error {
+	return c.intercept(c.context(), logger, true, func(client bbs.Client) error {
+		return client.DesireTask(logger, taskGuid, domain, taskDefinition)
+	})
+}
+
+func (c *interceptedBBSClient) CancelTask(logger lager.Logger, taskGuid string) error {
+	return c.intercept(c.context(), logger, true, func(client bbs.Client) error {
+		return client.CancelTask(logger, taskGuid)
+	})
+}
+
+func (c *interceptedBBSClient) ResolvingTask(logger lager.Logger, taskGuid string) error {
+	return c.intercept(c.context(), logger, true, func(client bbs.Client) error {
+		return client.ResolvingTask(logger, taskGuid)
+	})
+}
+
+func (c *interceptedBBSClient) DeleteTask(logger lager.Logger, taskGuid string) error {
+	return c.intercept(c.context(), logger, true, func(client bbs.Client) error {
+		return client.DeleteTask(logger, taskGuid)
+	})
+}
+
+func (c *interceptedBBSClient) SubscribeToEventsByCellID(logger lager.Logger, cellID string) (events.EventSource, error) {
+	var source events.EventSource
+	err := c.intercept(c.context(), logger, false, func(client bbs.Client) (err error) {
+		source, err = client.SubscribeToEventsByCellID(logger, cellID)
+		return err
+	})
+	return source, err
+}
+
+func (c *interceptedBBSClient) SubscribeToInstanceEventsByCellID(logger lager.Logger, cellID string) (events.EventSource, error) {
+	var source events.EventSource
+	err := c.intercept(c.context(), logger, false, func(client bbs.Client) (err error) {
+		source, err = client.SubscribeToInstanceEventsByCellID(logger, cellID)
+		return err
+	})
+	return source, err
+}
+
+func (c *interceptedBBSClient) SubscribeToTaskEvents(logger lager.Logger) (events.EventSource, error) {
+	var source events.EventSource
+	err := c.intercept(c.context(), logger, false, func(client bbs.Client) (err error) {
+		source, err = client.SubscribeToTaskEvents(logger)
+		return err
+	})
+	return source, err
+}
@@ -0,0 +1,39 @@
+package diego
+
+import (
+	"context"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/lager"
+)
+
+// BBSEndpoint tells whether the BBS at URL answered a ping. Only the BBS
+// instance holding the lock serves requests, so at most one endpoint of a
+// healthy deployment is active.
+type BBSEndpoint struct {
+	URL    string `json:"url"`
+	Active bool   `json:"active"`
+}
+
+// BBSEndpoints pings each of urls using the client at the same index of
+// clients. If ctx is done part way through, the endpoints pinged so far are
+// returned along with ctx.Err().
+func BBSEndpoints(ctx context.Context, logger lager.Logger, urls []string, clients []bbs.Client) ([]BBSEndpoint, error) {
+	endpoints := make([]BBSEndpoint, 0, len(urls))
+	for i, url := range urls {
+		client := clients[i]
+
+		var active bool
+		err := call(ctx, client, func(client bbs.Client) error {
+			active = client.Ping(logger)
+			return nil
+		})
+		if err != nil {
+			return endpoints, err
+		}
+
+		endpoints = append(endpoints, BBSEndpoint{URL: url, Active: active})
+	}
+
+	return endpoints, nil
+}
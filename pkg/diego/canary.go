@@ -0,0 +1,251 @@
+package diego
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/events"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/lager"
+)
+
+// canaryCleanupTimeout bounds the removal of the canaries, which goes on
+// after the context of the canary is done.
+const canaryCleanupTimeout = 30 * time.Second
+
+// CanaryDesired is the state of a canary from the moment it is submitted
+// until the BBS reports its first state.
+const CanaryDesired = "DESIRED"
+
+// CanaryPhase is the time a canary took to go from one state to the next, as
+// seen from the event streams.
+type CanaryPhase struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// CanaryResult is the lifecycle of the canary LRP or task.
+type CanaryResult struct {
+	Guid       string        `json:"guid"`
+	CellID     string        `json:"cell_id,omitempty"`
+	Phases     []CanaryPhase `json:"phases"`
+	DurationMs int64         `json:"duration_ms"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// CanaryReport is the outcome of a canary run.
+type CanaryReport struct {
+	Domain        string       `json:"domain"`
+	Healthy       bool         `json:"healthy"`
+	LRP           CanaryResult `json:"lrp"`
+	Task          CanaryResult `json:"task"`
+	CleanupErrors []string     `json:"cleanup_errors,omitempty"`
+}
+
+// RunCanary desires lrp, which should have a single instance, and task at
+// the same time, times each state they go through until the instance is
+// running and the task completed, then removes both. The canaries are
+// removed even if ctx is done first.
+func RunCanary(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, lrp *models.DesiredLRP, task *models.Task) CanaryReport {
+	report := CanaryReport{Domain: lrp.Domain}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		report.LRP = canaryLRP(ctx, logger, bbsClient, lrp)
+	}()
+	go func() {
+		defer wg.Done()
+		report.Task = canaryTask(ctx, logger, bbsClient, task)
+	}()
+	wg.Wait()
+
+	report.Healthy = report.LRP.Error == "" && report.Task.Error == ""
+	report.CleanupErrors = removeCanaries(logger, bbsClient, lrp.ProcessGuid, task.TaskGuid)
+	return report
+}
+
+func canaryLRP(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, lrp *models.DesiredLRP) CanaryResult {
+	timer := newCanaryTimer(lrp.ProcessGuid)
+
+	err := followCanary(ctx, logger, bbsClient, timer,
+		func() (events.EventSource, error) { return bbsClient.SubscribeToInstanceEventsByCellID(logger, "") },
+		func() error { return DesireLRP(ctx, logger, bbsClient, lrp) },
+		func(event models.Event) (bool, error) {
+			switch e := event.(type) {
+			case *models.ActualLRPInstanceCreatedEvent:
+				if e.ActualLrp.ProcessGuid == lrp.ProcessGuid {
+					timer.reach(e.ActualLrp.State, e.ActualLrp.CellId)
+				}
+			case *models.ActualLRPInstanceChangedEvent:
+				if e.ProcessGuid != lrp.ProcessGuid {
+					break
+				}
+				if e.After.PlacementError != "" {
+					return false, fmt.Errorf("The LRP could not be placed: %s", e.After.PlacementError)
+				}
+				timer.reach(e.After.State, e.CellId)
+			case *models.ActualLRPCrashedEvent:
+				if e.ProcessGuid == lrp.ProcessGuid {
+					return false, fmt.Errorf("The LRP crashed on cell %s: %s", e.CellId, e.CrashReason)
+				}
+			case *models.ActualLRPInstanceRemovedEvent:
+				if e.ActualLrp.ProcessGuid == lrp.ProcessGuid {
+					return false, fmt.Errorf("The LRP instance was removed before it was running")
+				}
+			}
+			return timer.state == models.ActualLRPStateRunning, nil
+		},
+	)
+	return timer.result(err)
+}
+
+func canaryTask(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, task *models.Task) CanaryResult {
+	timer := newCanaryTimer(task.TaskGuid)
+
+	err := followCanary(ctx, logger, bbsClient, timer,
+		func() (events.EventSource, error) { return bbsClient.SubscribeToTaskEvents(logger) },
+		func() error { return DesireTask(ctx, logger, bbsClient, task) },
+		func(event models.Event) (bool, error) {
+			var current *models.Task
+			switch e := event.(type) {
+			case *models.TaskCreatedEvent:
+				current = e.Task
+			case *models.TaskChangedEvent:
+				current = e.After
+			case *models.TaskRemovedEvent:
+				if e.Task.TaskGuid == task.TaskGuid {
+					return false, fmt.Errorf("The task was removed before it completed")
+				}
+			}
+			if current == nil || current.TaskGuid != task.TaskGuid {
+				return false, nil
+			}
+
+			timer.reach(current.State.String(), current.CellId)
+			if current.State != models.Task_Completed {
+				return false, nil
+			}
+			if current.Failed {
+				return false, fmt.Errorf("The task failed: %s", current.FailureReason)
+			}
+			return true, nil
+		},
+	)
+	return timer.result(err)
+}
+
+// followCanary subscribes to the events, desires the canary and passes each
+// event to handle until it reports the canary done or fails.
+func followCanary(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, timer *canaryTimer, subscribe func() (events.EventSource, error), desire func() error, handle func(models.Event) (bool, error)) error {
+	es, err := subscribe()
+	if err != nil {
+		return models.ConvertError(err)
+	}
+	defer es.Close()
+	defer closeOnDone(ctx, es)()
+
+	timer.start()
+	err = desire()
+	if err != nil {
+		return err
+	}
+
+	for {
+		event, err := es.Next()
+		switch {
+		case err == nil:
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err == io.EOF:
+			return errEventStreamClosed
+		default:
+			return err
+		}
+
+		done, err := handle(event)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+func removeCanaries(logger lager.Logger, bbsClient bbs.Client, processGuid, taskGuid string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), canaryCleanupTimeout)
+	defer cancel()
+
+	var errs []string
+
+	err := RemoveDesiredLRP(ctx, logger, bbsClient, processGuid)
+	if err != nil && !models.ConvertError(err).Equal(models.ErrResourceNotFound) {
+		errs = append(errs, fmt.Sprintf("Failed to remove desired LRP %s: %s", processGuid, err))
+	}
+
+	task, err := TaskByGuid(ctx, logger, bbsClient, taskGuid)
+	switch {
+	case err != nil && models.ConvertError(err).Equal(models.ErrResourceNotFound):
+	case err != nil:
+		errs = append(errs, fmt.Sprintf("Failed to look up task %s: %s", taskGuid, err))
+	case task.State == models.Task_Completed:
+		err = DeleteTask(ctx, logger, bbsClient, taskGuid)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("Failed to delete task %s: %s", taskGuid, err))
+		}
+	default:
+		err = CancelTask(ctx, logger, bbsClient, taskGuid)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("Failed to cancel task %s: %s", taskGuid, err))
+		}
+	}
+
+	return errs
+}
+
+// canaryTimer records the phases of a canary as it reaches new states.
+type canaryTimer struct {
+	guid    string
+	started time.Time
+	since   time.Time
+	state   string
+	cellID  string
+	phases  []CanaryPhase
+}
+
+func newCanaryTimer(guid string) *canaryTimer {
+	return &canaryTimer{guid: guid, state: CanaryDesired, phases: []CanaryPhase{}}
+}
+
+func (t *canaryTimer) start() {
+	t.started = time.Now()
+	t.since = t.started
+}
+
+func (t *canaryTimer) reach(state, cellID string) {
+	if cellID != "" {
+		t.cellID = cellID
+	}
+	if state == t.state {
+		return
+	}
+
+	now := time.Now()
+	t.phases = append(t.phases, CanaryPhase{From: t.state, To: state, DurationMs: now.Sub(t.since).Milliseconds()})
+	t.state, t.since = state, now
+}
+
+func (t *canaryTimer) result(err error) CanaryResult {
+	result := CanaryResult{Guid: t.guid, CellID: t.cellID, Phases: t.phases}
+	if !t.started.IsZero() {
+		result.DurationMs = t.since.Sub(t.started).Milliseconds()
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,165 @@
+package diego_test
+
+import (
+	"context"
+	"io"
+
+	"code.cloudfoundry.org/bbs/events/eventfakes"
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Canary", func() {
+	var (
+		fakeBBSClient  *fake_bbs.FakeClient
+		lrpEvents      *eventfakes.FakeEventSource
+		taskEvents     *eventfakes.FakeEventSource
+		logger         *lagertest.TestLogger
+		lrp            *models.DesiredLRP
+		task           *models.Task
+		lrpEventQueue  []models.Event
+		taskEventQueue []models.Event
+	)
+
+	serve := func(queue *[]models.Event) func() (models.Event, error) {
+		return func() (models.Event, error) {
+			if len(*queue) == 0 {
+				return nil, io.EOF
+			}
+			event := (*queue)[0]
+			*queue = (*queue)[1:]
+			return event, nil
+		}
+	}
+
+	instance := func(guid, state string) *models.ActualLRP {
+		return &models.ActualLRP{
+			ActualLRPKey:         models.NewActualLRPKey(guid, 0, "cfdot-canary"),
+			ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-guid", "cell-1"),
+			State:                state,
+		}
+	}
+
+	taskIn := func(state models.Task_State) *models.Task {
+		return &models.Task{TaskGuid: "canary-guid", State: state, CellId: "cell-2"}
+	}
+
+	BeforeEach(func() {
+		fakeBBSClient = &fake_bbs.FakeClient{}
+		lrpEvents = &eventfakes.FakeEventSource{}
+		taskEvents = &eventfakes.FakeEventSource{}
+		fakeBBSClient.SubscribeToInstanceEventsByCellIDReturns(lrpEvents, nil)
+		fakeBBSClient.SubscribeToTaskEventsReturns(taskEvents, nil)
+		logger = lagertest.NewTestLogger("diego")
+
+		lrp = &models.DesiredLRP{ProcessGuid: "canary-guid", Domain: "cfdot-canary", Instances: 1}
+		task = &models.Task{TaskGuid: "canary-guid", Domain: "cfdot-canary", TaskDefinition: &models.TaskDefinition{}}
+
+		lrpEventQueue = []models.Event{
+			models.NewActualLRPInstanceCreatedEvent(instance("other-guid", models.ActualLRPStateRunning)),
+			models.NewActualLRPInstanceCreatedEvent(instance("canary-guid", models.ActualLRPStateUnclaimed)),
+			models.NewActualLRPInstanceChangedEvent(instance("canary-guid", models.ActualLRPStateUnclaimed), instance("canary-guid", models.ActualLRPStateClaimed)),
+			models.NewActualLRPInstanceChangedEvent(instance("canary-guid", models.ActualLRPStateClaimed), instance("canary-guid", models.ActualLRPStateRunning)),
+		}
+		taskEventQueue = []models.Event{
+			models.NewTaskCreatedEvent(taskIn(models.Task_Pending)),
+			models.NewTaskChangedEvent(taskIn(models.Task_Pending), taskIn(models.Task_Running)),
+			models.NewTaskChangedEvent(taskIn(models.Task_Running), taskIn(models.Task_Completed)),
+		}
+		lrpEvents.NextStub = serve(&lrpEventQueue)
+		taskEvents.NextStub = serve(&taskEventQueue)
+
+		fakeBBSClient.TaskByGuidReturns(taskIn(models.Task_Completed), nil)
+	})
+
+	phases := func(result diego.CanaryResult) []string {
+		var transitions []string
+		for _, phase := range result.Phases {
+			Expect(phase.DurationMs).To(BeNumerically(">=", 0))
+			transitions = append(transitions, phase.From+"->"+phase.To)
+		}
+		return transitions
+	}
+
+	It("times every phase of the LRP and the task and removes them", func() {
+		report := diego.RunCanary(context.Background(), logger, fakeBBSClient, lrp, task)
+		Expect(report.Healthy).To(BeTrue())
+		Expect(report.Domain).To(Equal("cfdot-canary"))
+		Expect(report.CleanupErrors).To(BeEmpty())
+
+		Expect(report.LRP.Guid).To(Equal("canary-guid"))
+		Expect(report.LRP.CellID).To(Equal("cell-1"))
+		Expect(report.LRP.Error).To(BeEmpty())
+		Expect(phases(report.LRP)).To(Equal([]string{"DESIRED->UNCLAIMED", "UNCLAIMED->CLAIMED", "CLAIMED->RUNNING"}))
+
+		Expect(report.Task.CellID).To(Equal("cell-2"))
+		Expect(phases(report.Task)).To(Equal([]string{"DESIRED->Pending", "Pending->Running", "Running->Completed"}))
+
+		_, desiredLRP := fakeBBSClient.DesireLRPArgsForCall(0)
+		Expect(desiredLRP).To(Equal(lrp))
+		_, taskGuid, _, _ := fakeBBSClient.DesireTaskArgsForCall(0)
+		Expect(taskGuid).To(Equal("canary-guid"))
+
+		_, processGuid := fakeBBSClient.RemoveDesiredLRPArgsForCall(0)
+		Expect(processGuid).To(Equal("canary-guid"))
+		Expect(fakeBBSClient.ResolvingTaskCallCount()).To(Equal(1))
+		Expect(fakeBBSClient.DeleteTaskCallCount()).To(Equal(1))
+	})
+
+	It("reports a crashing LRP", func() {
+		lrpEventQueue = []models.Event{
+			models.NewActualLRPCrashedEvent(instance("canary-guid", models.ActualLRPStateRunning), &models.ActualLRP{
+				ActualLRPKey:         models.NewActualLRPKey("canary-guid", 0, "cfdot-canary"),
+				ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-guid", "cell-1"),
+				State:                models.ActualLRPStateCrashed,
+				CrashReason:          "exit status 1",
+			}),
+		}
+
+		report := diego.RunCanary(context.Background(), logger, fakeBBSClient, lrp, task)
+		Expect(report.Healthy).To(BeFalse())
+		Expect(report.LRP.Error).To(Equal("The LRP crashed on cell cell-1: exit status 1"))
+		Expect(report.Task.Error).To(BeEmpty())
+		Expect(fakeBBSClient.RemoveDesiredLRPCallCount()).To(Equal(1))
+	})
+
+	It("reports a failed task and the stream closing early", func() {
+		failed := taskIn(models.Task_Completed)
+		failed.Failed = true
+		failed.FailureReason = "insufficient resources"
+		taskEventQueue = []models.Event{models.NewTaskChangedEvent(taskIn(models.Task_Pending), failed)}
+		lrpEventQueue = lrpEventQueue[:2]
+
+		report := diego.RunCanary(context.Background(), logger, fakeBBSClient, lrp, task)
+		Expect(report.Healthy).To(BeFalse())
+		Expect(report.Task.Error).To(Equal("The task failed: insufficient resources"))
+		Expect(report.LRP.Error).To(Equal("The BBS closed the event stream"))
+		Expect(phases(report.LRP)).To(Equal([]string{"DESIRED->UNCLAIMED"}))
+	})
+
+	It("cancels a task that has not completed", func() {
+		taskEventQueue = nil
+		fakeBBSClient.TaskByGuidReturns(taskIn(models.Task_Running), nil)
+		fakeBBSClient.RemoveDesiredLRPReturns(models.ErrUnknownError)
+
+		report := diego.RunCanary(context.Background(), logger, fakeBBSClient, lrp, task)
+		Expect(report.Healthy).To(BeFalse())
+		Expect(fakeBBSClient.CancelTaskCallCount()).To(Equal(1))
+		Expect(fakeBBSClient.DeleteTaskCallCount()).To(Equal(0))
+		Expect(report.CleanupErrors).To(ConsistOf(ContainSubstring("Failed to remove desired LRP canary-guid")))
+	})
+
+	It("does not wait for canaries that could not be desired", func() {
+		fakeBBSClient.DesireLRPReturns(models.ErrResourceExists)
+
+		report := diego.RunCanary(context.Background(), logger, fakeBBSClient, lrp, task)
+		Expect(report.LRP.Error).To(Equal(models.ErrResourceExists.Error()))
+		Expect(lrpEvents.NextCallCount()).To(Equal(0))
+		Expect(lrpEvents.CloseCallCount()).To(BeNumerically(">=", 1))
+	})
+})
@@ -0,0 +1,123 @@
+package diego
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep"
+)
+
+var ErrCellNotFound = errors.New("Cell not found")
+
+// CellStateError records a cell whose rep could not return its state.
+type CellStateError struct {
+	CellID string
+	Err    error
+}
+
+func (e CellStateError) Error() string {
+	return fmt.Sprintf("failed to get cell state for cell %s: %s", e.CellID, e.Err)
+}
+
+func Cells(ctx context.Context, logger lager.Logger, bbsClient bbs.Client) ([]*models.CellPresence, error) {
+	var cells []*models.CellPresence
+	err := call(ctx, bbsClient, func(client bbs.Client) error {
+		var err error
+		cells, err = client.Cells(logger)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return cells, nil
+}
+
+// CellRegistration returns the presence of the given cell, or ErrCellNotFound
+// when no such cell is registered with the BBS.
+func CellRegistration(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, cellID string) (*models.CellPresence, error) {
+	cells, err := Cells(ctx, logger, bbsClient)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cell := range cells {
+		if cell.CellId == cellID {
+			return cell, nil
+		}
+	}
+
+	return nil, ErrCellNotFound
+}
+
+func CellState(ctx context.Context, logger lager.Logger, clientFactory rep.ClientFactory, registration *models.CellPresence) (rep.CellState, error) {
+	repClient, err := clientFactory.CreateClient(registration.RepAddress, registration.RepUrl)
+	if err != nil {
+		return rep.CellState{}, err
+	}
+
+	var state rep.CellState
+	err = await(ctx, func() error {
+		var err error
+		state, err = repClient.State(logger)
+		return err
+	})
+	if err != nil {
+		return rep.CellState{}, err
+	}
+
+	return state, nil
+}
+
+// CellStates fetches the state of each of the given cells. The states of the
+// cells that responded are returned together with a CellStateError for each
+// cell that did not. If ctx is done part way through, the states gathered so
+// far are returned along with ctx.Err().
+func CellStates(ctx context.Context, logger lager.Logger, clientFactory rep.ClientFactory, registrations []*models.CellPresence) ([]rep.CellState, []CellStateError, error) {
+	states := []rep.CellState{}
+	failures, err := EachCellState(ctx, logger, clientFactory, registrations, func(state rep.CellState) error {
+		states = append(states, state)
+		return nil
+	})
+	return states, failures, err
+}
+
+// EachCellState fetches the state of each of the given cells in order of
+// cell ID and passes it to report as soon as it arrives, so that the states
+// already reported stand when ctx is done part way through. A CellStateError
+// is returned for each cell that did not respond. It stops with ctx.Err()
+// once ctx is done, or with the first error of report.
+func EachCellState(ctx context.Context, logger lager.Logger, clientFactory rep.ClientFactory, registrations []*models.CellPresence, report func(state rep.CellState) error) ([]CellStateError, error) {
+	sorted := make([]*models.CellPresence, len(registrations))
+	copy(sorted, registrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].CellId < sorted[j].CellId })
+
+	failures := []CellStateError{}
+	for _, registration := range sorted {
+		if err := ctx.Err(); err != nil {
+			return failures, err
+		}
+
+		state, err := CellState(ctx, logger, clientFactory, registration)
+		if err != nil {
+			if ctx.Err() != nil {
+				return failures, ctx.Err()
+			}
+
+			logger.Error("failed-to-fetch-cell-state", err, lager.Data{"cell-id": registration.CellId})
+			failures = append(failures, CellStateError{CellID: registration.CellId, Err: err})
+			continue
+		}
+
+		if err := report(state); err != nil {
+			return failures, err
+		}
+	}
+
+	return failures, nil
+}
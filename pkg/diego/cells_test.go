@@ -0,0 +1,129 @@
+package diego_test
+
+import (
+	"context"
+	"errors"
+
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/repfakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cells", func() {
+	var (
+		fakeBBSClient        *fake_bbs.FakeClient
+		fakeRepClientFactory *repfakes.FakeClientFactory
+		logger               *lagertest.TestLogger
+		registrations        []*models.CellPresence
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("diego")
+
+		registrations = []*models.CellPresence{
+			{CellId: "cell-1", RepAddress: "rep-address-1", RepUrl: "rep-url-1"},
+			{CellId: "cell-2", RepAddress: "rep-address-2", RepUrl: "rep-url-2"},
+		}
+		fakeBBSClient = &fake_bbs.FakeClient{}
+		fakeBBSClient.CellsReturns(registrations, nil)
+
+		fakeRepClientFactory = &repfakes.FakeClientFactory{}
+	})
+
+	Context("CellRegistration", func() {
+		It("returns the presence of the requested cell", func() {
+			cell, err := diego.CellRegistration(context.Background(), logger, fakeBBSClient, "cell-2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cell).To(Equal(registrations[1]))
+		})
+
+		It("returns ErrCellNotFound for an unknown cell", func() {
+			_, err := diego.CellRegistration(context.Background(), logger, fakeBBSClient, "cell-3")
+			Expect(err).To(Equal(diego.ErrCellNotFound))
+		})
+	})
+
+	Context("CellStates", func() {
+		var fakeRepClient1, fakeRepClient2 *repfakes.FakeClient
+
+		BeforeEach(func() {
+			fakeRepClient1 = &repfakes.FakeClient{}
+			fakeRepClient1.StateReturns(rep.CellState{CellID: "cell-1"}, nil)
+			fakeRepClient2 = &repfakes.FakeClient{}
+			fakeRepClient2.StateReturns(rep.CellState{CellID: "cell-2"}, nil)
+
+			fakeRepClientFactory.CreateClientReturnsOnCall(0, fakeRepClient1, nil)
+			fakeRepClientFactory.CreateClientReturnsOnCall(1, fakeRepClient2, nil)
+		})
+
+		It("returns the state of every cell", func() {
+			states, failures, err := diego.CellStates(context.Background(), logger, fakeRepClientFactory, registrations)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failures).To(BeEmpty())
+			Expect(states).To(Equal([]rep.CellState{{CellID: "cell-1"}, {CellID: "cell-2"}}))
+
+			address, url := fakeRepClientFactory.CreateClientArgsForCall(0)
+			Expect(address).To(Equal("rep-address-1"))
+			Expect(url).To(Equal("rep-url-1"))
+		})
+
+		It("reports each state as it arrives, in order of cell ID", func() {
+			var reported []rep.CellState
+			failures, err := diego.EachCellState(context.Background(), logger, fakeRepClientFactory, []*models.CellPresence{registrations[1], registrations[0]}, func(state rep.CellState) error {
+				reported = append(reported, state)
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failures).To(BeEmpty())
+			Expect(reported).To(Equal([]rep.CellState{{CellID: "cell-1"}, {CellID: "cell-2"}}))
+
+			address, _ := fakeRepClientFactory.CreateClientArgsForCall(0)
+			Expect(address).To(Equal("rep-address-1"))
+		})
+
+		It("stops with the error of report", func() {
+			_, err := diego.EachCellState(context.Background(), logger, fakeRepClientFactory, registrations, func(rep.CellState) error {
+				return errors.New("broken pipe")
+			})
+			Expect(err).To(MatchError("broken pipe"))
+			Expect(fakeRepClient2.StateCallCount()).To(Equal(0))
+		})
+
+		Context("when a rep fails to respond", func() {
+			BeforeEach(func() {
+				fakeRepClient1.StateReturns(rep.CellState{}, errors.New("boom"))
+			})
+
+			It("returns the states of the other cells along with the failure", func() {
+				states, failures, err := diego.CellStates(context.Background(), logger, fakeRepClientFactory, registrations)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(states).To(Equal([]rep.CellState{{CellID: "cell-2"}}))
+				Expect(failures).To(Equal([]diego.CellStateError{{CellID: "cell-1", Err: errors.New("boom")}}))
+				Expect(failures[0]).To(MatchError("failed to get cell state for cell cell-1: boom"))
+			})
+		})
+
+		Context("when the context is done", func() {
+			It("stops and returns the states gathered so far", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				fakeRepClient1.StateStub = func(_ lager.Logger) (rep.CellState, error) {
+					cancel()
+					return rep.CellState{CellID: "cell-1"}, nil
+				}
+
+				states, failures, err := diego.CellStates(ctx, logger, fakeRepClientFactory, registrations)
+				Expect(err).To(Equal(context.Canceled))
+				Expect(failures).To(BeEmpty())
+				Expect(fakeRepClient2.StateCallCount()).To(Equal(0))
+				Expect(len(states)).To(BeNumerically("<=", 1))
+			})
+		})
+	})
+})
@@ -0,0 +1,276 @@
+package diego
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/lager"
+)
+
+// Actions reported by Chaos.
+const (
+	ChaosRetire    = "retire"
+	ChaosDryRun    = "dry-run"
+	ChaosSkip      = "skip"
+	ChaosRecovered = "recovered"
+	ChaosStop      = "stop"
+)
+
+// ChaosConfig are the guard rails of Chaos.
+type ChaosConfig struct {
+	// Domain is the only domain whose instances are retired.
+	Domain string
+	// Interval is the time between two retirements.
+	Interval time.Duration
+	// MaxUnavailable returns how many of the desired instances of an LRP may
+	// be not running once an instance is retired.
+	MaxUnavailable func(desired int32) int32
+	// RecoveryTimeout is how long an LRP may take to run all its instances
+	// again after one was retired before Chaos stops.
+	RecoveryTimeout time.Duration
+	// MaxRetirements stops Chaos after that many retirements, unless 0.
+	MaxRetirements int
+	// DryRun only reports the instances that would be retired.
+	DryRun bool
+	// Rand picks the instances, a time seeded source is used if nil.
+	Rand *rand.Rand
+}
+
+// ChaosInstance identifies an actual LRP instance acted upon by Chaos.
+type ChaosInstance struct {
+	ProcessGuid string `json:"process_guid"`
+	Index       int32  `json:"index"`
+	CellID      string `json:"cell_id,omitempty"`
+}
+
+// ChaosAction is a step taken by Chaos.
+type ChaosAction struct {
+	Time     time.Time      `json:"time"`
+	Action   string         `json:"action"`
+	Instance *ChaosInstance `json:"instance,omitempty"`
+	Message  string         `json:"message"`
+}
+
+// ChaosConvergenceError is returned by Chaos when an LRP takes longer than
+// the recovery timeout to run all its instances again.
+type ChaosConvergenceError struct {
+	ProcessGuid string
+	Running     int32
+	Desired     int32
+	Elapsed     time.Duration
+}
+
+func (e ChaosConvergenceError) Error() string {
+	return fmt.Sprintf("Convergence lags: %s runs %d of %d instances %s after an instance was retired", e.ProcessGuid, e.Running, e.Desired, e.Elapsed.Round(time.Second))
+}
+
+// Chaos retires a random running instance of the LRPs of config.Domain every
+// config.Interval, as long as its LRP keeps enough running instances and has
+// recovered from the previous retirement, passing every action to report.
+// The LRPs that are recovering are checked on their own, more often than
+// config.Interval and when their recovery timeout expires, so that the time
+// they take to recover is reported as it happens. It returns a
+// ChaosConvergenceError as soon as an LRP does not recover in time, nil after
+// config.MaxRetirements, or ctx.Err() once ctx is done.
+func Chaos(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, config ChaosConfig, report func(ChaosAction) error) error {
+	random := config.Rand
+	if random == nil {
+		random = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	retiredAt := map[string]time.Time{}
+	retirements := 0
+
+	ticker := time.NewTicker(config.Interval)
+	defer ticker.Stop()
+
+	// recoveryTimer is only set while an LRP is recovering.
+	var recoveryTimer *time.Timer
+	scheduleRecoveryCheck := func(now time.Time) {
+		if recoveryTimer != nil {
+			recoveryTimer.Stop()
+		}
+		scheduleRecoveryCheck(now)
+	}
+	defer func() {
+		if recoveryTimer != nil {
+			recoveryTimer.Stop()
+		}
+	}()
+
+	for {
+		var recoveryCheck <-chan time.Time
+		if recoveryTimer != nil {
+			recoveryCheck = recoveryTimer.C
+		}
+
+		retire := false
+		select {
+		case <-ticker.C:
+			retire = true
+		case <-recoveryCheck:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		lrps, err := chaosLRPs(ctx, logger, bbsClient, config.Domain)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		err = checkRecoveries(now, lrps, retiredAt, config.RecoveryTimeout, report)
+		if err != nil {
+			return err
+		}
+
+		scheduleRecoveryCheck(now)
+		if !retire {
+			continue
+		}
+
+		var candidates []*models.ActualLRP
+		for processGuid, lrp := range lrps {
+			if _, recovering := retiredAt[processGuid]; recovering {
+				continue
+			}
+			unavailable := lrp.desired - int32(len(lrp.running)) + 1
+			if unavailable > config.MaxUnavailable(lrp.desired) {
+				continue
+			}
+			candidates = append(candidates, lrp.running...)
+		}
+
+		if len(candidates) == 0 {
+			err = report(ChaosAction{Time: now, Action: ChaosSkip, Message: "No instance can be retired without exceeding the unavailable instances allowed"})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		sort.Slice(candidates, func(i, j int) bool {
+			if candidates[i].ProcessGuid != candidates[j].ProcessGuid {
+				return candidates[i].ProcessGuid < candidates[j].ProcessGuid
+			}
+			return candidates[i].Index < candidates[j].Index
+		})
+		victim := candidates[random.Intn(len(candidates))]
+		lrp := lrps[victim.ProcessGuid]
+		instance := &ChaosInstance{ProcessGuid: victim.ProcessGuid, Index: victim.Index, CellID: victim.CellId}
+		message := fmt.Sprintf("%d of %d instances running", len(lrp.running), lrp.desired)
+
+		if config.DryRun {
+			err = report(ChaosAction{Time: now, Action: ChaosDryRun, Instance: instance, Message: message})
+		} else {
+			key := victim.ActualLRPKey
+			err = mutate(ctx, bbsClient, func(client bbs.Client) error {
+				return client.RetireActualLRP(logger, &key)
+			})
+			if err != nil {
+				return err
+			}
+			retiredAt[victim.ProcessGuid] = now
+			err = report(ChaosAction{Time: now, Action: ChaosRetire, Instance: instance, Message: message})
+		}
+		if err != nil {
+			return err
+		}
+		scheduleRecoveryCheck(now)
+
+		retirements++
+		if config.MaxRetirements > 0 && retirements >= config.MaxRetirements {
+			return report(ChaosAction{Time: time.Now(), Action: ChaosStop, Message: fmt.Sprintf("Retired %d instances", retirements)})
+		}
+	}
+}
+
+// checkRecoveries reports the LRPs in retiredAt that run all their instances
+// again in lrps as recovered and forgets them, along with those no longer
+// desired. It returns a ChaosConvergenceError for the first LRP that has been
+// recovering for longer than timeout.
+func checkRecoveries(now time.Time, lrps map[string]*chaosLRP, retiredAt map[string]time.Time, timeout time.Duration, report func(ChaosAction) error) error {
+	for _, processGuid := range sortedKeys(retiredAt) {
+		lrp, ok := lrps[processGuid]
+		elapsed := now.Sub(retiredAt[processGuid])
+		switch {
+		case !ok:
+			delete(retiredAt, processGuid)
+		case int32(len(lrp.running)) >= lrp.desired:
+			delete(retiredAt, processGuid)
+			err := report(ChaosAction{Time: now, Action: ChaosRecovered, Message: fmt.Sprintf("%s recovered within %s", processGuid, elapsed.Round(time.Second))})
+			if err != nil {
+				return err
+			}
+		case elapsed >= timeout:
+			return ChaosConvergenceError{ProcessGuid: processGuid, Running: int32(len(lrp.running)), Desired: lrp.desired, Elapsed: elapsed}
+		}
+	}
+	return nil
+}
+
+// nextRecoveryCheck returns a timer for the next check of the LRPs in
+// retiredAt, or nil when none is recovering. They are checked ten times
+// within timeout, and once the earliest of their recovery timeouts expires.
+func nextRecoveryCheck(now time.Time, retiredAt map[string]time.Time, timeout time.Duration) *time.Timer {
+	if len(retiredAt) == 0 {
+		return nil
+	}
+
+	next := now.Add(timeout / 10)
+	for _, at := range retiredAt {
+		if deadline := at.Add(timeout); deadline.Before(next) {
+			next = deadline
+		}
+	}
+	return time.NewTimer(next.Sub(now))
+}
+
+type chaosLRP struct {
+	desired int32
+	running []*models.ActualLRP
+}
+
+// chaosLRPs returns the desired LRPs of domain with their running instances.
+func chaosLRPs(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, domain string) (map[string]*chaosLRP, error) {
+	schedulingInfos, err := DesiredLRPSchedulingInfos(ctx, logger, bbsClient, models.DesiredLRPFilter{Domain: domain})
+	if err != nil {
+		return nil, err
+	}
+
+	actualLRPs, err := ActualLRPs(ctx, logger, bbsClient, models.ActualLRPFilter{Domain: domain})
+	if err != nil {
+		return nil, err
+	}
+
+	lrps := map[string]*chaosLRP{}
+	for _, info := range schedulingInfos {
+		lrps[info.ProcessGuid] = &chaosLRP{desired: info.Instances}
+	}
+
+	for _, actualLRP := range actualLRPs {
+		lrp, ok := lrps[actualLRP.ProcessGuid]
+		if !ok || actualLRP.Index >= lrp.desired {
+			continue
+		}
+		if actualLRP.State == models.ActualLRPStateRunning && actualLRP.Presence == models.ActualLRP_Ordinary {
+			lrp.running = append(lrp.running, actualLRP)
+		}
+	}
+
+	return lrps, nil
+}
+
+func sortedKeys(m map[string]time.Time) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
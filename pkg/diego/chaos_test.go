@@ -0,0 +1,181 @@
+package diego_test
+
+import (
+	"context"
+	"time"
+
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Chaos", func() {
+	var (
+		fakeBBSClient *fake_bbs.FakeClient
+		logger        *lagertest.TestLogger
+		config        diego.ChaosConfig
+		actions       []diego.ChaosAction
+		report        func(diego.ChaosAction) error
+	)
+
+	instances := func(processGuid string, count int, state string, presence models.ActualLRP_Presence) []*models.ActualLRP {
+		var lrps []*models.ActualLRP
+		for i := 0; i < count; i++ {
+			lrps = append(lrps, &models.ActualLRP{
+				ActualLRPKey:         models.NewActualLRPKey(processGuid, int32(i), "game-day"),
+				ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-guid", "cell-1"),
+				State:                state,
+				Presence:             presence,
+			})
+		}
+		return lrps
+	}
+
+	running := func(processGuid string, count int) []*models.ActualLRP {
+		return instances(processGuid, count, models.ActualLRPStateRunning, models.ActualLRP_Ordinary)
+	}
+
+	kinds := func() []string {
+		var kinds []string
+		for _, action := range actions {
+			kinds = append(kinds, action.Action)
+		}
+		return kinds
+	}
+
+	BeforeEach(func() {
+		fakeBBSClient = &fake_bbs.FakeClient{}
+		logger = lagertest.NewTestLogger("diego")
+		actions = nil
+		report = func(action diego.ChaosAction) error {
+			actions = append(actions, action)
+			return nil
+		}
+
+		config = diego.ChaosConfig{
+			Domain:          "game-day",
+			Interval:        time.Millisecond,
+			MaxUnavailable:  func(int32) int32 { return 1 },
+			RecoveryTimeout: time.Hour,
+			MaxRetirements:  1,
+		}
+
+		fakeBBSClient.DesiredLRPSchedulingInfosReturns([]*models.DesiredLRPSchedulingInfo{
+			{DesiredLRPKey: models.NewDesiredLRPKey("process-guid", "game-day", "log-guid"), Instances: 4},
+		}, nil)
+		fakeBBSClient.ActualLRPsReturns(running("process-guid", 4), nil)
+	})
+
+	It("retires a running instance of the domain", func() {
+		err := diego.Chaos(context.Background(), logger, fakeBBSClient, config, report)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, filter := fakeBBSClient.DesiredLRPSchedulingInfosArgsForCall(0)
+		Expect(filter).To(Equal(models.DesiredLRPFilter{Domain: "game-day"}))
+		_, actualFilter := fakeBBSClient.ActualLRPsArgsForCall(0)
+		Expect(actualFilter).To(Equal(models.ActualLRPFilter{Domain: "game-day"}))
+
+		Expect(fakeBBSClient.RetireActualLRPCallCount()).To(Equal(1))
+		_, key := fakeBBSClient.RetireActualLRPArgsForCall(0)
+		Expect(key.ProcessGuid).To(Equal("process-guid"))
+		Expect(key.Domain).To(Equal("game-day"))
+
+		Expect(kinds()).To(Equal([]string{diego.ChaosRetire, diego.ChaosStop}))
+		Expect(actions[0].Instance.ProcessGuid).To(Equal("process-guid"))
+		Expect(actions[0].Instance.Index).To(Equal(key.Index))
+		Expect(actions[0].Instance.CellID).To(Equal("cell-1"))
+		Expect(actions[0].Message).To(Equal("4 of 4 instances running"))
+	})
+
+	It("only reports the instance in a dry run", func() {
+		config.DryRun = true
+
+		err := diego.Chaos(context.Background(), logger, fakeBBSClient, config, report)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeBBSClient.RetireActualLRPCallCount()).To(Equal(0))
+		Expect(kinds()).To(Equal([]string{diego.ChaosDryRun, diego.ChaosStop}))
+	})
+
+	It("skips LRPs that would have too many instances unavailable", func() {
+		actualLRPs := append(running("process-guid", 3), instances("process-guid", 4, models.ActualLRPStateRunning, models.ActualLRP_Evacuating)[3])
+		fakeBBSClient.ActualLRPsReturns(actualLRPs, nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		err := diego.Chaos(ctx, logger, fakeBBSClient, config, func(action diego.ChaosAction) error {
+			cancel()
+			return report(action)
+		})
+		Expect(err).To(Equal(context.Canceled))
+		Expect(fakeBBSClient.RetireActualLRPCallCount()).To(Equal(0))
+		Expect(kinds()).To(Equal([]string{diego.ChaosSkip}))
+	})
+
+	It("waits for an LRP to recover before retiring another of its instances", func() {
+		config.MaxRetirements = 2
+		fakeBBSClient.ActualLRPsReturnsOnCall(1, running("process-guid", 3), nil)
+
+		err := diego.Chaos(context.Background(), logger, fakeBBSClient, config, report)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kinds()).To(Equal([]string{diego.ChaosRetire, diego.ChaosSkip, diego.ChaosRecovered, diego.ChaosRetire, diego.ChaosStop}))
+		Expect(fakeBBSClient.RetireActualLRPCallCount()).To(Equal(2))
+	})
+
+	It("stops when an LRP does not recover in time", func() {
+		config.MaxRetirements = 0
+		config.RecoveryTimeout = time.Nanosecond
+		fakeBBSClient.ActualLRPsReturnsOnCall(1, running("process-guid", 3), nil)
+
+		err := diego.Chaos(context.Background(), logger, fakeBBSClient, config, report)
+		Expect(err).To(BeAssignableToTypeOf(diego.ChaosConvergenceError{}))
+		Expect(err.(diego.ChaosConvergenceError).ProcessGuid).To(Equal("process-guid"))
+		Expect(err.(diego.ChaosConvergenceError).Running).To(Equal(int32(3)))
+		Expect(err).To(MatchError(ContainSubstring("Convergence lags: process-guid runs 3 of 4 instances")))
+		Expect(fakeBBSClient.RetireActualLRPCallCount()).To(Equal(1))
+	})
+
+	Context("when the interval is longer than the recovery timeout", func() {
+		BeforeEach(func() {
+			config.Interval = 300 * time.Millisecond
+			config.RecoveryTimeout = 30 * time.Millisecond
+			config.MaxRetirements = 0
+			fakeBBSClient.ActualLRPsReturnsOnCall(1, running("process-guid", 3), nil)
+		})
+
+		It("stops as soon as the recovery timeout expires", func() {
+			fakeBBSClient.ActualLRPsReturnsOnCall(0, running("process-guid", 4), nil)
+			fakeBBSClient.ActualLRPsReturns(running("process-guid", 3), nil)
+
+			err := diego.Chaos(context.Background(), logger, fakeBBSClient, config, report)
+			Expect(err).To(BeAssignableToTypeOf(diego.ChaosConvergenceError{}))
+			elapsed := err.(diego.ChaosConvergenceError).Elapsed
+			Expect(elapsed).To(BeNumerically(">=", config.RecoveryTimeout))
+			Expect(elapsed).To(BeNumerically("<", config.Interval))
+			Expect(fakeBBSClient.RetireActualLRPCallCount()).To(Equal(1))
+		})
+
+		It("reports the recovery before the next retirement is due", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			err := diego.Chaos(ctx, logger, fakeBBSClient, config, func(action diego.ChaosAction) error {
+				if action.Action == diego.ChaosRecovered {
+					cancel()
+				}
+				return report(action)
+			})
+			Expect(err).To(Equal(context.Canceled))
+			Expect(kinds()).To(Equal([]string{diego.ChaosRetire, diego.ChaosRecovered}))
+			Expect(actions[1].Time.Sub(actions[0].Time)).To(BeNumerically("<", config.RecoveryTimeout))
+		})
+	})
+
+	It("returns the BBS errors", func() {
+		fakeBBSClient.RetireActualLRPReturns(models.ErrUnknownError)
+
+		err := diego.Chaos(context.Background(), logger, fakeBBSClient, config, report)
+		Expect(err).To(Equal(models.ErrUnknownError))
+		Expect(actions).To(BeEmpty())
+	})
+})
@@ -0,0 +1,224 @@
+package diego
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	cfhttp "code.cloudfoundry.org/cfhttp/v2"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/locket"
+	locketmodels "code.cloudfoundry.org/locket/models"
+	"code.cloudfoundry.org/rep"
+)
+
+const (
+	clientSessionCacheSize int = -1
+	maxIdleConnsPerHost    int = -1
+
+	repStateTimeout = 10 * time.Second
+)
+
+// ClientConfig holds the addresses, TLS credentials, timeouts and retry policy
+// used to build the BBS, Locket and rep clients. Timeouts are in seconds; zero
+// means the client's default. Timeout is the overall timeout of a cfdot command
+// and is used for BBS requests when BBSTimeout is not set. A zero Retry makes
+// a single attempt per BBS request.
+type ClientConfig struct {
+	BBSUrl            string
+	LocketApiLocation string
+	CACertFile        string
+	CertFile          string
+	KeyFile           string
+	SkipCertVerify    bool
+	Timeout           int
+	BBSTimeout        int
+	LocketTimeout     int
+	RepTimeout        int
+	Retry             RetryPolicy
+}
+
+// NewBBSClient builds a BBS client that makes a single attempt per request,
+// wrapped so that requests are retried according to config.Retry. When
+// config.BBSUrl lists several comma separated endpoints, requests fail over
+// between them.
+func NewBBSClient(config ClientConfig) (bbs.Client, error) {
+	return NewBBSClientWithFailover(config, nil)
+}
+
+// NewBBSClientWithFailover is like NewBBSClient, calling onFailover whenever a
+// request moves on to another of the configured endpoints.
+func NewBBSClientWithFailover(config ClientConfig, onFailover FailoverFunc) (bbs.Client, error) {
+	urls := config.BBSURLs()
+	if len(urls) == 0 {
+		urls = []string{config.BBSUrl}
+	}
+
+	clients := make([]bbs.Client, 0, len(urls))
+	for _, url := range urls {
+		client, err := NewBBSEndpointClient(config, url)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+
+	var client bbs.Client
+	if len(clients) == 1 {
+		client = clients[0]
+	} else {
+		client = NewFailoverBBSClient(urls, clients, onFailover)
+	}
+
+	if config.Retry.MaxAttempts > 1 {
+		client = NewRetryingBBSClient(client, config.Retry)
+	}
+
+	return client, nil
+}
+
+// NewBBSEndpointClient builds a client for the single BBS endpoint url, using
+// the TLS settings and timeouts of config but neither retries nor failover.
+func NewBBSEndpointClient(config ClientConfig, url string) (bbs.Client, error) {
+	if !strings.HasPrefix(url, "https") {
+		return bbs.NewClientWithConfig(bbs.ClientConfig{
+			URL:            url,
+			Retries:        1,
+			RequestTimeout: config.bbsRequestTimeout(),
+		})
+	}
+
+	return bbs.NewClientWithConfig(bbs.ClientConfig{
+		URL:                    url,
+		IsTLS:                  true,
+		InsecureSkipVerify:     config.SkipCertVerify,
+		CAFile:                 config.CACertFile,
+		CertFile:               config.CertFile,
+		KeyFile:                config.KeyFile,
+		ClientSessionCacheSize: clientSessionCacheSize,
+		MaxIdleConnsPerHost:    maxIdleConnsPerHost,
+		Retries:                1,
+		RequestTimeout:         config.bbsRequestTimeout(),
+	})
+}
+
+// NewLocketClient connects to Locket and returns an *UnreachableError when no
+// connection can be established before the dial timeout.
+func NewLocketClient(logger lager.Logger, config ClientConfig) (locketmodels.LocketClient, error) {
+	locketConfig := locket.ClientLocketConfig{
+		LocketAddress:        config.LocketApiLocation,
+		LocketCACertFile:     config.CACertFile,
+		LocketClientCertFile: config.CertFile,
+		LocketClientKeyFile:  config.KeyFile,
+	}
+
+	var client locketmodels.LocketClient
+	var err error
+	if config.SkipCertVerify {
+		client, err = locket.NewClientSkipCertVerify(logger, locketConfig)
+	} else {
+		client, err = locket.NewClient(logger, locketConfig)
+	}
+	if err == context.DeadlineExceeded {
+		return nil, &UnreachableError{Address: config.LocketApiLocation, Err: err}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if config.LocketTimeout > 0 {
+		client = NewTimeoutLocketClient(client, time.Duration(config.LocketTimeout)*time.Second)
+	}
+
+	return client, nil
+}
+
+// UnreachableError is returned when no connection to the component at Address
+// could be established.
+type UnreachableError struct {
+	Address string
+	Err     error
+}
+
+func (e *UnreachableError) Error() string {
+	return fmt.Sprintf("Failed to connect to %s: %s", e.Address, e.Err)
+}
+
+func (e *UnreachableError) Unwrap() error {
+	return e.Err
+}
+
+func NewRepClientFactory(config ClientConfig) (rep.ClientFactory, error) {
+	httpClient := cfhttp.NewClient()
+	stateTimeout := repStateTimeout
+	if config.RepTimeout > 0 {
+		stateTimeout = time.Duration(config.RepTimeout) * time.Second
+	}
+	stateClient := cfhttp.NewClient(
+		cfhttp.WithRequestTimeout(stateTimeout),
+	)
+
+	repTLSConfig := &rep.TLSConfig{
+		CaCertFile: config.CACertFile,
+		CertFile:   config.CertFile,
+		KeyFile:    config.KeyFile,
+	}
+
+	return rep.NewClientFactory(httpClient, stateClient, repTLSConfig)
+}
+
+// Merge overwrites the fields of config with the ones set in newConfig.
+func (config *ClientConfig) Merge(newConfig ClientConfig) {
+	if newConfig.BBSUrl != "" {
+		config.BBSUrl = newConfig.BBSUrl
+	}
+	if newConfig.LocketApiLocation != "" {
+		config.LocketApiLocation = newConfig.LocketApiLocation
+	}
+	if newConfig.Timeout != 0 {
+		config.Timeout = newConfig.Timeout
+	}
+	if newConfig.BBSTimeout != 0 {
+		config.BBSTimeout = newConfig.BBSTimeout
+	}
+	if newConfig.LocketTimeout != 0 {
+		config.LocketTimeout = newConfig.LocketTimeout
+	}
+	if newConfig.RepTimeout != 0 {
+		config.RepTimeout = newConfig.RepTimeout
+	}
+	if newConfig.Retry != (RetryPolicy{}) {
+		config.Retry = newConfig.Retry
+	}
+	if newConfig.KeyFile != "" {
+		config.KeyFile = newConfig.KeyFile
+	}
+	if newConfig.CACertFile != "" {
+		config.CACertFile = newConfig.CACertFile
+	}
+	if newConfig.CertFile != "" {
+		config.CertFile = newConfig.CertFile
+	}
+	config.SkipCertVerify = config.SkipCertVerify || newConfig.SkipCertVerify
+}
+
+func (config ClientConfig) bbsRequestTimeout() time.Duration {
+	if config.BBSTimeout > 0 {
+		return time.Duration(config.BBSTimeout) * time.Second
+	}
+	return time.Duration(config.Timeout) * time.Second
+}
+
+// BBSURLs returns the BBS endpoints listed, comma separated, in BBSUrl.
+func (config ClientConfig) BBSURLs() []string {
+	var urls []string
+	for _, url := range strings.Split(config.BBSUrl, ",") {
+		url = strings.TrimSpace(url)
+		if url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
@@ -0,0 +1,85 @@
+package diego
+
+import (
+	"context"
+	"io"
+
+	"code.cloudfoundry.org/bbs"
+)
+
+// contextClient is implemented by the BBS clients cfdot wraps around the
+// plain BBS client, which can tie the requests they intercept to a context.
+type contextClient interface {
+	withContext(ctx context.Context) bbs.Client
+}
+
+// withContext returns bbsClient bound to ctx when it supports it, and
+// bbsClient itself otherwise.
+func withContext(ctx context.Context, bbsClient bbs.Client) bbs.Client {
+	if client, ok := bbsClient.(contextClient); ok {
+		return client.withContext(ctx)
+	}
+	return bbsClient
+}
+
+// call sends a read request with f, passing it bbsClient bound to ctx, and
+// returns early with ctx.Err() once ctx is done. See await.
+func call(ctx context.Context, bbsClient bbs.Client, f func(client bbs.Client) error) error {
+	return await(ctx, func() error {
+		return f(withContext(ctx, bbsClient))
+	})
+}
+
+// mutate sends a request that changes state in the BBS with f, passing it
+// bbsClient bound to ctx. Nothing is sent once ctx is done, but a request
+// already sent is waited for rather than abandoned: the BBS would apply it
+// anyway, and cfdot must not report a command as cancelled when its change
+// still lands. The wait is bounded by the request timeout of the BBS client.
+func mutate(ctx context.Context, bbsClient bbs.Client, f func(client bbs.Client) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return f(withContext(ctx, bbsClient))
+}
+
+// await runs f, returning early with ctx.Err() once ctx is done. The BBS and
+// rep clients do not accept a context, so an abandoned request keeps running
+// in the background until the client's own request timeout expires, and its
+// result is discarded. It must therefore only be used for reads.
+func await(ctx context.Context, f func() error) error {
+	if ctx.Done() == nil {
+		return f()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- f()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// closeOnDone closes source once ctx is done, unblocking any pending reads.
+// The returned function must be called to release the watcher.
+func closeOnDone(ctx context.Context, source io.Closer) func() {
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			source.Close()
+		case <-stop:
+		}
+	}()
+
+	return func() { close(stop) }
+}
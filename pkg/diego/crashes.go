@@ -0,0 +1,204 @@
+package diego
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/bbs/models"
+)
+
+const (
+	// maxCrashReasons is how many of the most common crash reasons of an LRP
+	// are reported.
+	maxCrashReasons = 3
+
+	// A cell is over-represented when at least minOverRepresentedInstances of
+	// its instances crash and the share of its instances that crash is at
+	// least overRepresentedFactor times the share across all cells.
+	minOverRepresentedInstances = 2
+	overRepresentedFactor       = 2
+)
+
+var (
+	reasonGuidPattern    = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	reasonAddressPattern = regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}(:\d+)?\b`)
+	reasonSpacePattern   = regexp.MustCompile(`\s+`)
+)
+
+// CrashReason is a normalized crash reason and the number of crashing
+// instances that last crashed with it.
+type CrashReason struct {
+	Reason    string `json:"reason"`
+	Instances int    `json:"instances"`
+}
+
+// CrashOffender sums up the crashing instances of an LRP. LastCrash is the
+// latest state change of its crashing instances, which closely follows their
+// last crash.
+type CrashOffender struct {
+	ProcessGuid       string        `json:"process_guid"`
+	Domain            string        `json:"domain"`
+	CrashedInstances  int           `json:"crashed_instances"`
+	CrashingInstances int           `json:"crashing_instances"`
+	CrashCount        int32         `json:"crash_count"`
+	Reasons           []CrashReason `json:"reasons"`
+	LastCrash         time.Time     `json:"last_crash"`
+	SinceLastCrashMs  int64         `json:"since_last_crash_ms"`
+	Cells             []string      `json:"cells,omitempty"`
+}
+
+// CellCrashes counts the instances of a cell and those of them crashing.
+type CellCrashes struct {
+	CellID            string `json:"cell_id"`
+	Instances         int    `json:"instances"`
+	CrashingInstances int    `json:"crashing_instances"`
+	CrashCount        int32  `json:"crash_count"`
+	OverRepresented   bool   `json:"over_represented"`
+}
+
+// CrashReport is the result of AnalyzeCrashes. Offenders are ranked by crash
+// count, then by crashed instances, and Cells by crashing instances.
+type CrashReport struct {
+	Instances         int             `json:"instances"`
+	CrashingInstances int             `json:"crashing_instances"`
+	Offenders         []CrashOffender `json:"offenders"`
+	Cells             []CellCrashes   `json:"cells"`
+}
+
+// AnalyzeCrashes groups the crashing instances of actualLRPs, those CRASHED
+// or with a nonzero crash count, by LRP and by cell. When since is positive,
+// only the instances whose state changed within since of now are counted as
+// crashing. Evacuating instances are left out, as they duplicate an ordinary
+// one. CRASHED instances are no longer on a cell, so only the crashing
+// instances still placed on a cell count towards the cells.
+func AnalyzeCrashes(actualLRPs []*models.ActualLRP, now time.Time, since time.Duration) CrashReport {
+	report := CrashReport{Offenders: []CrashOffender{}, Cells: []CellCrashes{}}
+	offenders := map[string]*CrashOffender{}
+	reasons := map[string]map[string]int{}
+	cells := map[string]*CellCrashes{}
+	offenderCells := map[string]map[string]bool{}
+
+	for _, actualLRP := range actualLRPs {
+		if actualLRP.Presence == models.ActualLRP_Evacuating {
+			continue
+		}
+		report.Instances++
+
+		var cell *CellCrashes
+		if actualLRP.CellId != "" {
+			cell = cells[actualLRP.CellId]
+			if cell == nil {
+				cell = &CellCrashes{CellID: actualLRP.CellId}
+				cells[actualLRP.CellId] = cell
+			}
+			cell.Instances++
+		}
+
+		crashed := actualLRP.State == models.ActualLRPStateCrashed
+		if !crashed && actualLRP.CrashCount == 0 {
+			continue
+		}
+		changedAt := time.Unix(0, actualLRP.Since)
+		if since > 0 && now.Sub(changedAt) > since {
+			continue
+		}
+		report.CrashingInstances++
+
+		offender := offenders[actualLRP.ProcessGuid]
+		if offender == nil {
+			offender = &CrashOffender{ProcessGuid: actualLRP.ProcessGuid, Domain: actualLRP.Domain}
+			offenders[actualLRP.ProcessGuid] = offender
+			reasons[actualLRP.ProcessGuid] = map[string]int{}
+			offenderCells[actualLRP.ProcessGuid] = map[string]bool{}
+		}
+		offender.CrashingInstances++
+		offender.CrashCount += actualLRP.CrashCount
+		if crashed {
+			offender.CrashedInstances++
+		}
+		if changedAt.After(offender.LastCrash) {
+			offender.LastCrash = changedAt
+		}
+		if reason := NormalizeReason(actualLRP.CrashReason); reason != "" {
+			reasons[actualLRP.ProcessGuid][reason]++
+		}
+
+		if cell != nil {
+			cell.CrashingInstances++
+			cell.CrashCount += actualLRP.CrashCount
+			offenderCells[actualLRP.ProcessGuid][cell.CellID] = true
+		}
+	}
+
+	for processGuid, offender := range offenders {
+		offender.Reasons = topCrashReasons(reasons[processGuid])
+		offender.SinceLastCrashMs = now.Sub(offender.LastCrash).Milliseconds()
+		for cellID := range offenderCells[processGuid] {
+			offender.Cells = append(offender.Cells, cellID)
+		}
+		sort.Strings(offender.Cells)
+		report.Offenders = append(report.Offenders, *offender)
+	}
+	sort.Slice(report.Offenders, func(i, j int) bool {
+		a, b := report.Offenders[i], report.Offenders[j]
+		if a.CrashCount != b.CrashCount {
+			return a.CrashCount > b.CrashCount
+		}
+		if a.CrashedInstances != b.CrashedInstances {
+			return a.CrashedInstances > b.CrashedInstances
+		}
+		return a.ProcessGuid < b.ProcessGuid
+	})
+
+	placed, crashingPlaced := 0, 0
+	for _, cell := range cells {
+		placed += cell.Instances
+		crashingPlaced += cell.CrashingInstances
+	}
+	for _, cell := range cells {
+		if cell.CrashingInstances == 0 {
+			continue
+		}
+		cell.OverRepresented = cell.CrashingInstances >= minOverRepresentedInstances &&
+			cell.CrashingInstances*placed >= overRepresentedFactor*crashingPlaced*cell.Instances
+		report.Cells = append(report.Cells, *cell)
+	}
+	sort.Slice(report.Cells, func(i, j int) bool {
+		a, b := report.Cells[i], report.Cells[j]
+		if a.CrashingInstances != b.CrashingInstances {
+			return a.CrashingInstances > b.CrashingInstances
+		}
+		return a.CellID < b.CellID
+	})
+
+	return report
+}
+
+// NormalizeReason replaces the guids and IP addresses in a crash or failure
+// reason by placeholders and collapses its white space, so that the same
+// crash of different instances, or failure of different tasks, has the same
+// reason.
+func NormalizeReason(reason string) string {
+	reason = reasonGuidPattern.ReplaceAllString(reason, "<guid>")
+	reason = reasonAddressPattern.ReplaceAllString(reason, "<address>")
+	return strings.TrimSpace(reasonSpacePattern.ReplaceAllString(reason, " "))
+}
+
+func topCrashReasons(counts map[string]int) []CrashReason {
+	reasons := []CrashReason{}
+	for reason, instances := range counts {
+		reasons = append(reasons, CrashReason{Reason: reason, Instances: instances})
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if reasons[i].Instances != reasons[j].Instances {
+			return reasons[i].Instances > reasons[j].Instances
+		}
+		return reasons[i].Reason < reasons[j].Reason
+	})
+	if len(reasons) > maxCrashReasons {
+		reasons = reasons[:maxCrashReasons]
+	}
+	return reasons
+}
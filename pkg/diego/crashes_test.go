@@ -0,0 +1,113 @@
+package diego_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Crashes", func() {
+	var now time.Time
+
+	instance := func(processGuid string, index int32, cellID, state string, crashCount int32, reason string, age time.Duration) *models.ActualLRP {
+		return &models.ActualLRP{
+			ActualLRPKey:         models.NewActualLRPKey(processGuid, index, "cf-apps"),
+			ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-guid", cellID),
+			State:                state,
+			CrashCount:           crashCount,
+			CrashReason:          reason,
+			Since:                now.Add(-age).UnixNano(),
+		}
+	}
+
+	BeforeEach(func() {
+		now = time.Unix(1600000000, 0)
+	})
+
+	Context("AnalyzeCrashes", func() {
+		var actualLRPs []*models.ActualLRP
+
+		BeforeEach(func() {
+			actualLRPs = []*models.ActualLRP{
+				instance("looping", 0, "", models.ActualLRPStateCrashed, 12, "APP/PROC/WEB: Exited with status 1", time.Minute),
+				instance("looping", 1, "cell-1", models.ActualLRPStateRunning, 5, "APP/PROC/WEB: Exited  with status 1", 10*time.Minute),
+				instance("looping", 2, "cell-1", models.ActualLRPStateRunning, 2, "Instance never healthy: failed to connect to 10.0.0.1:8080", time.Hour),
+				instance("flaky", 0, "cell-1", models.ActualLRPStateRunning, 1, "", 2*time.Hour),
+				instance("flaky", 1, "cell-3", models.ActualLRPStateRunning, 1, "", 3*time.Hour),
+				instance("healthy", 0, "cell-1", models.ActualLRPStateRunning, 0, "", time.Hour),
+				instance("healthy", 1, "cell-2", models.ActualLRPStateRunning, 0, "", time.Hour),
+				instance("healthy", 2, "cell-2", models.ActualLRPStateRunning, 0, "", time.Hour),
+				instance("healthy", 3, "cell-2", models.ActualLRPStateRunning, 0, "", time.Hour),
+				instance("healthy", 4, "cell-2", models.ActualLRPStateRunning, 0, "", time.Hour),
+				instance("healthy", 5, "cell-3", models.ActualLRPStateRunning, 0, "", time.Hour),
+				instance("healthy", 6, "cell-3", models.ActualLRPStateRunning, 0, "", time.Hour),
+				instance("healthy", 7, "cell-3", models.ActualLRPStateRunning, 0, "", time.Hour),
+			}
+		})
+
+		It("ranks the LRPs by crashes with their most common reasons", func() {
+			report := diego.AnalyzeCrashes(actualLRPs, now, 0)
+			Expect(report.Instances).To(Equal(13))
+			Expect(report.CrashingInstances).To(Equal(5))
+			Expect(report.Offenders).To(HaveLen(2))
+
+			looping := report.Offenders[0]
+			Expect(looping.ProcessGuid).To(Equal("looping"))
+			Expect(looping.Domain).To(Equal("cf-apps"))
+			Expect(looping.CrashedInstances).To(Equal(1))
+			Expect(looping.CrashingInstances).To(Equal(3))
+			Expect(looping.CrashCount).To(Equal(int32(19)))
+			Expect(looping.Reasons).To(Equal([]diego.CrashReason{
+				{Reason: "APP/PROC/WEB: Exited with status 1", Instances: 2},
+				{Reason: "Instance never healthy: failed to connect to <address>", Instances: 1},
+			}))
+			Expect(looping.LastCrash).To(BeTemporally("==", now.Add(-time.Minute)))
+			Expect(looping.SinceLastCrashMs).To(Equal(int64(60000)))
+			Expect(looping.Cells).To(Equal([]string{"cell-1"}))
+
+			Expect(report.Offenders[1].ProcessGuid).To(Equal("flaky"))
+			Expect(report.Offenders[1].Reasons).To(BeEmpty())
+		})
+
+		It("flags the cells with a share of crashing instances well above the others", func() {
+			report := diego.AnalyzeCrashes(actualLRPs, now, 0)
+			Expect(report.Cells).To(Equal([]diego.CellCrashes{
+				{CellID: "cell-1", Instances: 4, CrashingInstances: 3, CrashCount: 8, OverRepresented: true},
+				{CellID: "cell-3", Instances: 4, CrashingInstances: 1, CrashCount: 1},
+			}))
+		})
+
+		It("only counts the instances that changed within since", func() {
+			report := diego.AnalyzeCrashes(actualLRPs, now, 30*time.Minute)
+			Expect(report.CrashingInstances).To(Equal(2))
+			Expect(report.Offenders).To(HaveLen(1))
+			Expect(report.Offenders[0].CrashCount).To(Equal(int32(17)))
+		})
+
+		It("leaves out evacuating instances", func() {
+			evacuating := instance("looping", 1, "cell-4", models.ActualLRPStateRunning, 5, "", time.Minute)
+			evacuating.Presence = models.ActualLRP_Evacuating
+
+			report := diego.AnalyzeCrashes(append(actualLRPs, evacuating), now, 0)
+			Expect(report.Instances).To(Equal(13))
+			Expect(report.Offenders[0].CrashCount).To(Equal(int32(19)))
+		})
+
+		It("reports no offenders when nothing crashes", func() {
+			report := diego.AnalyzeCrashes(actualLRPs[5:], now, 0)
+			Expect(report.Offenders).To(BeEmpty())
+			Expect(report.Cells).To(BeEmpty())
+		})
+	})
+
+	Context("NormalizeReason", func() {
+		It("replaces guids and addresses and collapses white space", func() {
+			reason := diego.NormalizeReason("  container 1f2e3d4c-5b6a-4978-8695-a4b3c2d1e0f9 on 10.0.16.5:61002\n exited ")
+			Expect(reason).To(Equal("container <guid> on <address> exited"))
+		})
+	})
+})
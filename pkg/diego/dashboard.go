@@ -0,0 +1,484 @@
+package diego
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep"
+)
+
+var errEventStreamClosed = errors.New("The BBS closed the event stream")
+
+// Dashboard holds the live state shown by cfdot top: the cells with their
+// utilization, the instances of each LRP and a log of the latest events. It is
+// seeded from listings with SetLRPs and SetCells, kept up to date with Apply,
+// and is safe for concurrent use.
+type Dashboard struct {
+	mu        sync.Mutex
+	maxEvents int
+	cells     []DashboardCell
+	instances map[instanceKey]instance
+	events    []DashboardEvent
+}
+
+// DashboardCell is a registered cell and the share of its memory, disk and
+// containers in use, as reported by its rep. Error is set instead when the
+// rep could not be reached.
+type DashboardCell struct {
+	CellID         string  `json:"cell_id"`
+	Zone           string  `json:"zone"`
+	MemoryUsage    float64 `json:"memory_usage"`
+	DiskUsage      float64 `json:"disk_usage"`
+	ContainerUsage float64 `json:"container_usage"`
+	LRPs           int     `json:"lrps"`
+	Evacuating     bool    `json:"evacuating"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// DashboardLRP counts the instances of an LRP by state.
+type DashboardLRP struct {
+	ProcessGuid string `json:"process_guid"`
+	Domain      string `json:"domain"`
+	Instances   int    `json:"instances"`
+	Unclaimed   int    `json:"unclaimed"`
+	Claimed     int    `json:"claimed"`
+	Running     int    `json:"running"`
+	Crashed     int    `json:"crashed"`
+}
+
+// DashboardEvent is an entry of the event log. ProcessGuid is set for LRP
+// events and TaskGuid for task events.
+type DashboardEvent struct {
+	Time        time.Time `json:"time"`
+	Type        string    `json:"type"`
+	Domain      string    `json:"domain,omitempty"`
+	ProcessGuid string    `json:"process_guid,omitempty"`
+	TaskGuid    string    `json:"task_guid,omitempty"`
+	Message     string    `json:"message"`
+}
+
+// DashboardFilter restricts the LRPs and events of a DashboardView to those of
+// Domain and to process guids containing ProcessGuid. Empty fields match
+// everything.
+type DashboardFilter struct {
+	Domain      string
+	ProcessGuid string
+}
+
+func (f DashboardFilter) matches(domain, processGuid string) bool {
+	if f.Domain != "" && domain != f.Domain {
+		return false
+	}
+	return f.ProcessGuid == "" || (processGuid != "" && strings.Contains(processGuid, f.ProcessGuid))
+}
+
+// DashboardView is a snapshot of a Dashboard. Cells are ordered by id, LRPs by
+// process guid and events from oldest to newest.
+type DashboardView struct {
+	Cells  []DashboardCell
+	LRPs   []DashboardLRP
+	Events []DashboardEvent
+}
+
+type instanceKey struct {
+	processGuid string
+	index       int32
+	presence    models.ActualLRP_Presence
+}
+
+type instance struct {
+	domain string
+	state  string
+}
+
+// NewDashboard returns an empty dashboard keeping the latest maxEvents events.
+func NewDashboard(maxEvents int) *Dashboard {
+	return &Dashboard{
+		maxEvents: maxEvents,
+		instances: map[instanceKey]instance{},
+	}
+}
+
+// SetLRPs replaces the instances of every LRP with lrps.
+func (d *Dashboard) SetLRPs(lrps []*models.ActualLRP) {
+	d.setLRPs(lrps, nil)
+}
+
+// setLRPs replaces the instances of every LRP with lrps and updates them with
+// the LRP instance events of events, without adding those to the event log
+// again.
+func (d *Dashboard) setLRPs(lrps []*models.ActualLRP, events []models.Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.instances = map[instanceKey]instance{}
+	for _, lrp := range lrps {
+		d.instances[keyOf(lrp.ActualLRPKey, lrp.Presence)] = instance{domain: lrp.Domain, state: lrp.State}
+	}
+	for _, event := range events {
+		d.applyInstance(event)
+	}
+}
+
+// SetCells replaces the cells with registrations, taking their utilization
+// from the states returned by their reps and reporting the cells in failures
+// as unreachable.
+func (d *Dashboard) SetCells(registrations []*models.CellPresence, states []rep.CellState, failures []CellStateError) {
+	statesByID := map[string]rep.CellState{}
+	for _, state := range states {
+		statesByID[state.CellID] = state
+	}
+	failuresByID := map[string]error{}
+	for _, failure := range failures {
+		failuresByID[failure.CellID] = failure.Err
+	}
+
+	cells := make([]DashboardCell, 0, len(registrations))
+	for _, registration := range registrations {
+		cell := DashboardCell{CellID: registration.CellId, Zone: registration.Zone}
+
+		if state, ok := statesByID[registration.CellId]; ok {
+			cell.MemoryUsage = usage(state.AvailableResources.MemoryMB, state.TotalResources.MemoryMB)
+			cell.DiskUsage = usage(state.AvailableResources.DiskMB, state.TotalResources.DiskMB)
+			cell.ContainerUsage = usage(int32(state.AvailableResources.Containers), int32(state.TotalResources.Containers))
+			cell.LRPs = len(state.LRPs)
+			cell.Evacuating = state.Evacuating
+		} else if err, ok := failuresByID[registration.CellId]; ok {
+			cell.Error = err.Error()
+		}
+
+		cells = append(cells, cell)
+	}
+	sort.Slice(cells, func(i, j int) bool { return cells[i].CellID < cells[j].CellID })
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cells = cells
+}
+
+func usage(available, total int32) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(total-available) / float64(total)
+}
+
+// Apply updates the instances with an LRP instance event and adds an entry
+// for any LRP or task event to the event log.
+func (d *Dashboard) Apply(event models.Event, at time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.applyInstance(event)
+
+	entry := DashboardEvent{Time: at, Type: event.EventType()}
+
+	switch e := event.(type) {
+	case *models.ActualLRPInstanceCreatedEvent:
+		lrp := e.ActualLrp
+		entry.Domain, entry.ProcessGuid = lrp.Domain, lrp.ProcessGuid
+		entry.Message = fmt.Sprintf("%s/%d created %s%s", lrp.ProcessGuid, lrp.Index, lrp.State, onCell(lrp.CellId))
+	case *models.ActualLRPInstanceChangedEvent:
+		entry.Domain, entry.ProcessGuid = e.Domain, e.ProcessGuid
+		entry.Message = fmt.Sprintf("%s/%d %s -> %s%s", e.ProcessGuid, e.Index, e.Before.State, e.After.State, onCell(e.CellId))
+	case *models.ActualLRPInstanceRemovedEvent:
+		lrp := e.ActualLrp
+		entry.Domain, entry.ProcessGuid = lrp.Domain, lrp.ProcessGuid
+		entry.Message = fmt.Sprintf("%s/%d removed%s", lrp.ProcessGuid, lrp.Index, onCell(lrp.CellId))
+	case *models.ActualLRPCrashedEvent:
+		entry.Domain, entry.ProcessGuid = e.Domain, e.ProcessGuid
+		entry.Message = fmt.Sprintf("%s/%d crashed%s (crash count %d): %s", e.ProcessGuid, e.Index, onCell(e.CellId), e.CrashCount, e.CrashReason)
+	case *models.DesiredLRPCreatedEvent:
+		entry.Domain, entry.ProcessGuid = e.DesiredLrp.Domain, e.DesiredLrp.ProcessGuid
+		entry.Message = fmt.Sprintf("%s desired with %d instances", e.DesiredLrp.ProcessGuid, e.DesiredLrp.Instances)
+	case *models.DesiredLRPChangedEvent:
+		entry.Domain, entry.ProcessGuid = e.After.Domain, e.After.ProcessGuid
+		entry.Message = fmt.Sprintf("%s updated, %d -> %d instances", e.After.ProcessGuid, e.Before.Instances, e.After.Instances)
+	case *models.DesiredLRPRemovedEvent:
+		entry.Domain, entry.ProcessGuid = e.DesiredLrp.Domain, e.DesiredLrp.ProcessGuid
+		entry.Message = fmt.Sprintf("%s removed", e.DesiredLrp.ProcessGuid)
+	case *models.TaskCreatedEvent:
+		entry.Domain, entry.TaskGuid = e.Task.Domain, e.Task.TaskGuid
+		entry.Message = fmt.Sprintf("task %s created %s%s", e.Task.TaskGuid, e.Task.State, onCell(e.Task.CellId))
+	case *models.TaskChangedEvent:
+		entry.Domain, entry.TaskGuid = e.After.Domain, e.After.TaskGuid
+		entry.Message = fmt.Sprintf("task %s %s -> %s%s", e.After.TaskGuid, e.Before.State, e.After.State, onCell(e.After.CellId))
+		if e.After.Failed {
+			entry.Message += ": " + e.After.FailureReason
+		}
+	case *models.TaskRemovedEvent:
+		entry.Domain, entry.TaskGuid = e.Task.Domain, e.Task.TaskGuid
+		entry.Message = fmt.Sprintf("task %s removed", e.Task.TaskGuid)
+	default:
+		entry.Message = event.EventType()
+	}
+
+	d.events = append(d.events, entry)
+	if d.maxEvents > 0 && len(d.events) > d.maxEvents {
+		d.events = d.events[len(d.events)-d.maxEvents:]
+	}
+}
+
+// applyInstance updates the instances with an LRP instance event and ignores
+// any other event. The caller must hold d.mu.
+func (d *Dashboard) applyInstance(event models.Event) {
+	switch e := event.(type) {
+	case *models.ActualLRPInstanceCreatedEvent:
+		lrp := e.ActualLrp
+		d.instances[keyOf(lrp.ActualLRPKey, lrp.Presence)] = instance{domain: lrp.Domain, state: lrp.State}
+	case *models.ActualLRPInstanceChangedEvent:
+		delete(d.instances, keyOf(e.ActualLRPKey, e.Before.Presence))
+		d.instances[keyOf(e.ActualLRPKey, e.After.Presence)] = instance{domain: e.Domain, state: e.After.State}
+	case *models.ActualLRPInstanceRemovedEvent:
+		lrp := e.ActualLrp
+		delete(d.instances, keyOf(lrp.ActualLRPKey, lrp.Presence))
+	}
+}
+
+func keyOf(key models.ActualLRPKey, presence models.ActualLRP_Presence) instanceKey {
+	return instanceKey{processGuid: key.ProcessGuid, index: key.Index, presence: presence}
+}
+
+func onCell(cellID string) string {
+	if cellID == "" {
+		return ""
+	}
+	return " on " + cellID
+}
+
+// View returns the cells, and the LRPs and events matching filter.
+func (d *Dashboard) View(filter DashboardFilter) DashboardView {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	lrps := map[string]*DashboardLRP{}
+	for key, instance := range d.instances {
+		if !filter.matches(instance.domain, key.processGuid) {
+			continue
+		}
+
+		lrp, ok := lrps[key.processGuid]
+		if !ok {
+			lrp = &DashboardLRP{ProcessGuid: key.processGuid, Domain: instance.domain}
+			lrps[key.processGuid] = lrp
+		}
+
+		lrp.Instances++
+		switch instance.state {
+		case models.ActualLRPStateUnclaimed:
+			lrp.Unclaimed++
+		case models.ActualLRPStateClaimed:
+			lrp.Claimed++
+		case models.ActualLRPStateRunning:
+			lrp.Running++
+		case models.ActualLRPStateCrashed:
+			lrp.Crashed++
+		}
+	}
+
+	view := DashboardView{
+		Cells: append([]DashboardCell{}, d.cells...),
+		LRPs:  make([]DashboardLRP, 0, len(lrps)),
+	}
+	for _, lrp := range lrps {
+		view.LRPs = append(view.LRPs, *lrp)
+	}
+	sort.Slice(view.LRPs, func(i, j int) bool { return view.LRPs[i].ProcessGuid < view.LRPs[j].ProcessGuid })
+
+	for _, event := range d.events {
+		if filter.matches(event.Domain, event.ProcessGuid) {
+			view.Events = append(view.Events, event)
+		}
+	}
+
+	return view
+}
+
+// WatchDashboard seeds dashboard from the actual LRPs, the cells and the state
+// of their reps, and keeps it up to date with the LRP instance and task
+// events. The event streams are subscribed to before the listings are
+// fetched, and their events are held until the dashboard has been seeded, so
+// that no change made in between is lost. The listings are fetched again in
+// the background every refreshInterval, since the events do not cover cell
+// utilization, while the events keep being applied. changed is called after
+// every update. It returns the first error of the event streams, or
+// ctx.Err() once ctx is done.
+func WatchDashboard(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, clientFactory rep.ClientFactory, dashboard *Dashboard, refreshInterval time.Duration, changed func()) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	instanceES, err := bbsClient.SubscribeToInstanceEventsByCellID(logger, "")
+	if err != nil {
+		return models.ConvertError(err)
+	}
+	defer instanceES.Close()
+	defer closeOnDone(ctx, instanceES)()
+
+	taskES, err := bbsClient.SubscribeToTaskEvents(logger)
+	if err != nil {
+		return models.ConvertError(err)
+	}
+	defer taskES.Close()
+	defer closeOnDone(ctx, taskES)()
+
+	// The readers block on eventCh while the dashboard is being seeded, which
+	// holds the events back until they can be applied in order on top of the
+	// listings.
+	eventCh := make(chan models.Event)
+	errCh := make(chan error, 2)
+	forward := func(event models.Event) error {
+		select {
+		case eventCh <- event:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	go func() {
+		errCh <- readEvents(ctx, instanceES, forward)
+	}()
+	go func() {
+		errCh <- readEvents(ctx, taskES, forward)
+	}()
+
+	listings, err := fetchDashboardListings(ctx, logger, bbsClient, clientFactory)
+	if err != nil {
+		return err
+	}
+	listings.apply(dashboard, nil)
+	changed()
+
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	// The events applied while a refresh is in flight may be missing from its
+	// listings, so they are applied again on top of them. refreshCh is
+	// buffered so that a refresh still in flight on return does not block.
+	refreshCh := make(chan dashboardRefresh, 1)
+	refreshing := false
+	var pending []models.Event
+
+	for {
+		select {
+		case event := <-eventCh:
+			dashboard.Apply(event, time.Now())
+			if refreshing {
+				pending = append(pending, event)
+			}
+			changed()
+		case err := <-errCh:
+			if err == nil {
+				err = errEventStreamClosed
+			}
+			return err
+		case <-ticker.C:
+			if refreshing {
+				continue
+			}
+			refreshing = true
+			go func() {
+				listings, err := fetchDashboardListings(ctx, logger, bbsClient, clientFactory)
+				refreshCh <- dashboardRefresh{listings: listings, err: err}
+			}()
+		case refresh := <-refreshCh:
+			refreshing = false
+			events := pending
+			pending = nil
+			if refresh.err != nil {
+				logger.Error("failed-to-refresh-dashboard", refresh.err)
+				continue
+			}
+			refresh.listings.apply(dashboard, events)
+			changed()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// dashboardListings are the listings the instances and cells of a dashboard
+// are replaced with.
+type dashboardListings struct {
+	lrps          []*models.ActualLRP
+	registrations []*models.CellPresence
+	states        []rep.CellState
+	failures      []CellStateError
+}
+
+type dashboardRefresh struct {
+	listings dashboardListings
+	err      error
+}
+
+// apply replaces the instances and cells of dashboard with the listings and
+// applies the instance events of events on top of them again.
+func (l dashboardListings) apply(dashboard *Dashboard, events []models.Event) {
+	dashboard.setLRPs(l.lrps, events)
+	dashboard.SetCells(l.registrations, l.states, l.failures)
+}
+
+func fetchDashboardListings(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, clientFactory rep.ClientFactory) (dashboardListings, error) {
+	lrps, err := ActualLRPs(ctx, logger, bbsClient, models.ActualLRPFilter{})
+	if err != nil {
+		return dashboardListings{}, err
+	}
+
+	registrations, err := Cells(ctx, logger, bbsClient)
+	if err != nil {
+		return dashboardListings{}, err
+	}
+
+	states, failures, err := concurrentCellStates(ctx, logger, clientFactory, registrations)
+	if err != nil {
+		return dashboardListings{}, err
+	}
+
+	return dashboardListings{lrps: lrps, registrations: registrations, states: states, failures: failures}, nil
+}
+
+// concurrentCellStates fetches the state of each of the given cells at the
+// same time, so that a refresh takes as long as the slowest rep rather than
+// all of them together. Like CellStates, it returns the states of the cells
+// that responded and a CellStateError for each cell that did not, in order of
+// cell ID, or ctx.Err() once ctx is done.
+func concurrentCellStates(ctx context.Context, logger lager.Logger, clientFactory rep.ClientFactory, registrations []*models.CellPresence) ([]rep.CellState, []CellStateError, error) {
+	sorted := make([]*models.CellPresence, len(registrations))
+	copy(sorted, registrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].CellId < sorted[j].CellId })
+
+	results := make([]rep.CellState, len(sorted))
+	errs := make([]error, len(sorted))
+	var wg sync.WaitGroup
+	wg.Add(len(sorted))
+	for i, registration := range sorted {
+		go func(i int, registration *models.CellPresence) {
+			defer wg.Done()
+			results[i], errs[i] = CellState(ctx, logger, clientFactory, registration)
+		}(i, registration)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	states := []rep.CellState{}
+	failures := []CellStateError{}
+	for i, registration := range sorted {
+		if errs[i] != nil {
+			logger.Error("failed-to-fetch-cell-state", errs[i], lager.Data{"cell-id": registration.CellId})
+			failures = append(failures, CellStateError{CellID: registration.CellId, Err: errs[i]})
+			continue
+		}
+		states = append(states, results[i])
+	}
+	return states, failures, nil
+}
@@ -0,0 +1,257 @@
+package diego_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/bbs/events/eventfakes"
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/repfakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dashboard", func() {
+	var (
+		dashboard *diego.Dashboard
+		now       time.Time
+	)
+
+	actualLRP := func(processGuid, domain string, index int32, state string) *models.ActualLRP {
+		return &models.ActualLRP{
+			ActualLRPKey:         models.NewActualLRPKey(processGuid, index, domain),
+			ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-guid", "cell-1"),
+			State:                state,
+			Presence:             models.ActualLRP_Ordinary,
+		}
+	}
+
+	BeforeEach(func() {
+		dashboard = diego.NewDashboard(3)
+		now = time.Unix(1600000000, 0)
+
+		dashboard.SetLRPs([]*models.ActualLRP{
+			actualLRP("guid-1", "cf-apps", 0, models.ActualLRPStateRunning),
+			actualLRP("guid-1", "cf-apps", 1, models.ActualLRPStateClaimed),
+			actualLRP("guid-2", "cf-tasks", 0, models.ActualLRPStateCrashed),
+		})
+	})
+
+	Describe("View", func() {
+		It("counts the instances of each LRP by state", func() {
+			Expect(dashboard.View(diego.DashboardFilter{}).LRPs).To(Equal([]diego.DashboardLRP{
+				{ProcessGuid: "guid-1", Domain: "cf-apps", Instances: 2, Running: 1, Claimed: 1},
+				{ProcessGuid: "guid-2", Domain: "cf-tasks", Instances: 1, Crashed: 1},
+			}))
+		})
+
+		It("filters the LRPs and events by domain", func() {
+			dashboard.Apply(models.NewTaskCreatedEvent(&models.Task{TaskGuid: "task-1", Domain: "cf-tasks"}), now)
+
+			view := dashboard.View(diego.DashboardFilter{Domain: "cf-tasks"})
+			Expect(view.LRPs).To(HaveLen(1))
+			Expect(view.LRPs[0].ProcessGuid).To(Equal("guid-2"))
+			Expect(view.Events).To(HaveLen(1))
+			Expect(view.Events[0].TaskGuid).To(Equal("task-1"))
+		})
+
+		It("filters the LRPs and events by part of the process guid", func() {
+			dashboard.Apply(models.NewTaskCreatedEvent(&models.Task{TaskGuid: "task-1", Domain: "cf-apps"}), now)
+			dashboard.Apply(models.NewActualLRPInstanceRemovedEvent(actualLRP("guid-1", "cf-apps", 1, models.ActualLRPStateClaimed)), now)
+
+			view := dashboard.View(diego.DashboardFilter{ProcessGuid: "d-1"})
+			Expect(view.LRPs).To(HaveLen(1))
+			Expect(view.LRPs[0].ProcessGuid).To(Equal("guid-1"))
+			Expect(view.Events).To(HaveLen(1))
+			Expect(view.Events[0].ProcessGuid).To(Equal("guid-1"))
+		})
+	})
+
+	Describe("Apply", func() {
+		It("tracks instances as they are created, change and are removed", func() {
+			created := actualLRP("guid-3", "cf-apps", 0, models.ActualLRPStateUnclaimed)
+			dashboard.Apply(models.NewActualLRPInstanceCreatedEvent(created), now)
+
+			running := actualLRP("guid-3", "cf-apps", 0, models.ActualLRPStateRunning)
+			dashboard.Apply(models.NewActualLRPInstanceChangedEvent(created, running), now)
+
+			view := dashboard.View(diego.DashboardFilter{ProcessGuid: "guid-3"})
+			Expect(view.LRPs).To(Equal([]diego.DashboardLRP{
+				{ProcessGuid: "guid-3", Domain: "cf-apps", Instances: 1, Running: 1},
+			}))
+			Expect(view.Events).To(HaveLen(2))
+			Expect(view.Events[1].Time).To(Equal(now))
+			Expect(view.Events[1].Message).To(Equal("guid-3/0 UNCLAIMED -> RUNNING on cell-1"))
+
+			dashboard.Apply(models.NewActualLRPInstanceRemovedEvent(running), now)
+			Expect(dashboard.View(diego.DashboardFilter{ProcessGuid: "guid-3"}).LRPs).To(BeEmpty())
+		})
+
+		It("keeps only the latest events", func() {
+			for _, guid := range []string{"task-1", "task-2", "task-3", "task-4"} {
+				dashboard.Apply(models.NewTaskCreatedEvent(&models.Task{TaskGuid: guid}), now)
+			}
+
+			events := dashboard.View(diego.DashboardFilter{}).Events
+			Expect(events).To(HaveLen(3))
+			Expect(events[0].TaskGuid).To(Equal("task-2"))
+			Expect(events[2].TaskGuid).To(Equal("task-4"))
+		})
+	})
+
+	Describe("SetCells", func() {
+		It("reports the utilization of each cell and the cells whose rep failed", func() {
+			dashboard.SetCells(
+				[]*models.CellPresence{
+					{CellId: "cell-2", Zone: "z2"},
+					{CellId: "cell-1", Zone: "z1"},
+				},
+				[]rep.CellState{{
+					CellID:             "cell-1",
+					AvailableResources: rep.Resources{MemoryMB: 256, DiskMB: 512, Containers: 75},
+					TotalResources:     rep.Resources{MemoryMB: 1024, DiskMB: 1024, Containers: 100},
+					LRPs:               []rep.LRP{{}, {}},
+					Evacuating:         true,
+				}},
+				[]diego.CellStateError{{CellID: "cell-2", Err: errors.New("boom")}},
+			)
+
+			Expect(dashboard.View(diego.DashboardFilter{}).Cells).To(Equal([]diego.DashboardCell{
+				{CellID: "cell-1", Zone: "z1", MemoryUsage: 0.75, DiskUsage: 0.5, ContainerUsage: 0.25, LRPs: 2, Evacuating: true},
+				{CellID: "cell-2", Zone: "z2", Error: "boom"},
+			}))
+		})
+	})
+
+	Describe("WatchDashboard", func() {
+		var (
+			fakeBBSClient  *fake_bbs.FakeClient
+			instanceEvents chan models.Event
+		)
+
+		eventSource := func(events chan models.Event) *eventfakes.FakeEventSource {
+			es := &eventfakes.FakeEventSource{}
+			closed := make(chan struct{})
+			var once sync.Once
+			es.CloseStub = func() error {
+				once.Do(func() { close(closed) })
+				return nil
+			}
+			es.NextStub = func() (models.Event, error) {
+				select {
+				case event := <-events:
+					return event, nil
+				case <-closed:
+					return nil, io.EOF
+				}
+			}
+			return es
+		}
+
+		BeforeEach(func() {
+			fakeBBSClient = &fake_bbs.FakeClient{}
+			instanceEvents = make(chan models.Event, 1)
+			fakeBBSClient.SubscribeToInstanceEventsByCellIDReturns(eventSource(instanceEvents), nil)
+			fakeBBSClient.SubscribeToTaskEventsReturns(eventSource(nil), nil)
+		})
+
+		It("applies the events of changes made while the dashboard is seeded", func() {
+			fakeBBSClient.ActualLRPsStub = func(lager.Logger, models.ActualLRPFilter) ([]*models.ActualLRP, error) {
+				// The BBS only sends the event to the streams already
+				// subscribed to when the change is made.
+				if fakeBBSClient.SubscribeToInstanceEventsByCellIDCallCount() > 0 {
+					instanceEvents <- models.NewActualLRPInstanceCreatedEvent(actualLRP("guid-3", "cf-apps", 0, models.ActualLRPStateUnclaimed))
+				}
+				return []*models.ActualLRP{actualLRP("guid-1", "cf-apps", 0, models.ActualLRPStateRunning)}, nil
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- diego.WatchDashboard(ctx, lagertest.NewTestLogger("diego"), fakeBBSClient, nil, dashboard, time.Hour, func() {})
+			}()
+
+			Eventually(func() []diego.DashboardLRP {
+				return dashboard.View(diego.DashboardFilter{}).LRPs
+			}).Should(Equal([]diego.DashboardLRP{
+				{ProcessGuid: "guid-1", Domain: "cf-apps", Instances: 1, Running: 1},
+				{ProcessGuid: "guid-3", Domain: "cf-apps", Instances: 1, Unclaimed: 1},
+			}))
+
+			cancel()
+			Eventually(errCh).Should(Receive(Equal(context.Canceled)))
+		})
+
+		It("keeps applying events while the reps are queried for a refresh", func() {
+			running := actualLRP("guid-1", "cf-apps", 0, models.ActualLRPStateRunning)
+			created := actualLRP("guid-3", "cf-apps", 0, models.ActualLRPStateUnclaimed)
+			fakeBBSClient.ActualLRPsStub = func(lager.Logger, models.ActualLRPFilter) ([]*models.ActualLRP, error) {
+				// Only the listings fetched after the refresh held up below
+				// hold the instance created while it is in flight.
+				if fakeBBSClient.ActualLRPsCallCount() > 2 {
+					return []*models.ActualLRP{running, created}, nil
+				}
+				return []*models.ActualLRP{running}, nil
+			}
+			fakeBBSClient.CellsReturns([]*models.CellPresence{{CellId: "cell-1"}, {CellId: "cell-2"}}, nil)
+
+			release := make(chan struct{})
+			fakeRepClient := &repfakes.FakeClient{}
+			fakeRepClient.StateStub = func(lager.Logger) (rep.CellState, error) {
+				if fakeRepClient.StateCallCount() > 2 {
+					<-release
+				}
+				return rep.CellState{}, nil
+			}
+			fakeRepClientFactory := &repfakes.FakeClientFactory{}
+			fakeRepClientFactory.CreateClientReturns(fakeRepClient, nil)
+
+			var mu sync.Mutex
+			var views [][]diego.DashboardLRP
+			viewed := func() [][]diego.DashboardLRP {
+				mu.Lock()
+				defer mu.Unlock()
+				return append([][]diego.DashboardLRP{}, views...)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- diego.WatchDashboard(ctx, lagertest.NewTestLogger("diego"), fakeBBSClient, fakeRepClientFactory, dashboard, 10*time.Millisecond, func() {
+					mu.Lock()
+					defer mu.Unlock()
+					views = append(views, dashboard.View(diego.DashboardFilter{}).LRPs)
+				})
+			}()
+
+			// Both reps are queried at once, so the refresh waits on both.
+			Eventually(fakeRepClient.StateCallCount).Should(Equal(4))
+			instanceEvents <- models.NewActualLRPInstanceCreatedEvent(created)
+
+			both := []diego.DashboardLRP{
+				{ProcessGuid: "guid-1", Domain: "cf-apps", Instances: 1, Running: 1},
+				{ProcessGuid: "guid-3", Domain: "cf-apps", Instances: 1, Unclaimed: 1},
+			}
+			Eventually(viewed).Should(HaveLen(2))
+			Expect(viewed()[1]).To(Equal(both))
+
+			close(release)
+			Eventually(fakeBBSClient.ActualLRPsCallCount).Should(BeNumerically(">", 3))
+			for _, view := range viewed()[1:] {
+				Expect(view).To(Equal(both))
+			}
+
+			cancel()
+			Eventually(errCh).Should(Receive(Equal(context.Canceled)))
+		})
+	})
+})
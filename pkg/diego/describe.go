@@ -0,0 +1,201 @@
+package diego
+
+import (
+	"context"
+	"sort"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep"
+)
+
+// CellCapacity is the free and total resources of a cell as reported by its
+// rep. Error is set instead when the cell is not registered or its rep could
+// not be reached.
+type CellCapacity struct {
+	CellID     string        `json:"cell_id"`
+	Zone       string        `json:"zone,omitempty"`
+	Available  rep.Resources `json:"available"`
+	Total      rep.Resources `json:"total"`
+	Evacuating bool          `json:"evacuating"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// LRPDescription is a desired LRP with its actual LRPs, ordered by index with
+// evacuating instances after the ordinary ones, and the capacity of the cells
+// they are on, ordered by cell id.
+type LRPDescription struct {
+	DesiredLRP *models.DesiredLRP
+	ActualLRPs []*models.ActualLRP
+	Cells      []CellCapacity
+}
+
+// TaskDescription is a task with the capacity of its cell, which is nil until
+// the task is placed.
+type TaskDescription struct {
+	Task *models.Task
+	Cell *CellCapacity
+}
+
+// CellDescription is a registered cell with its capacity and the actual LRPs
+// and tasks placed on it, ordered by guid.
+type CellDescription struct {
+	Presence   *models.CellPresence
+	Capacity   CellCapacity
+	ActualLRPs []*models.ActualLRP
+	Tasks      []*models.Task
+}
+
+// DescribeLRP gathers the desired LRP of processGuid, its actual LRPs and the
+// capacity of their cells. A cell whose rep cannot be reached is reported in
+// its CellCapacity rather than failing the description.
+func DescribeLRP(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, clientFactory rep.ClientFactory, processGuid string) (LRPDescription, error) {
+	desiredLRP, err := DesiredLRP(ctx, logger, bbsClient, processGuid)
+	if err != nil {
+		return LRPDescription{}, err
+	}
+
+	actualLRPs, err := ActualLRPs(ctx, logger, bbsClient, models.ActualLRPFilter{ProcessGuid: processGuid})
+	if err != nil {
+		return LRPDescription{}, err
+	}
+	sortActualLRPs(actualLRPs)
+
+	var cellIDs []string
+	for _, actualLRP := range actualLRPs {
+		cellIDs = append(cellIDs, actualLRP.CellId)
+	}
+	cells, err := cellCapacities(ctx, logger, bbsClient, clientFactory, cellIDs)
+	if err != nil {
+		return LRPDescription{}, err
+	}
+
+	return LRPDescription{DesiredLRP: desiredLRP, ActualLRPs: actualLRPs, Cells: cells}, nil
+}
+
+// DescribeTask gathers the task of taskGuid and the capacity of its cell.
+func DescribeTask(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, clientFactory rep.ClientFactory, taskGuid string) (TaskDescription, error) {
+	task, err := TaskByGuid(ctx, logger, bbsClient, taskGuid)
+	if err != nil {
+		return TaskDescription{}, err
+	}
+
+	cells, err := cellCapacities(ctx, logger, bbsClient, clientFactory, []string{task.CellId})
+	if err != nil {
+		return TaskDescription{}, err
+	}
+
+	description := TaskDescription{Task: task}
+	if len(cells) > 0 {
+		description.Cell = &cells[0]
+	}
+	return description, nil
+}
+
+// DescribeCell gathers the presence and capacity of cellID and the actual LRPs
+// and tasks placed on it. It returns ErrCellNotFound when no such cell is
+// registered.
+func DescribeCell(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, clientFactory rep.ClientFactory, cellID string) (CellDescription, error) {
+	presence, err := CellRegistration(ctx, logger, bbsClient, cellID)
+	if err != nil {
+		return CellDescription{}, err
+	}
+
+	capacity, err := cellCapacity(ctx, logger, clientFactory, presence)
+	if err != nil {
+		return CellDescription{}, err
+	}
+
+	actualLRPs, err := ActualLRPs(ctx, logger, bbsClient, models.ActualLRPFilter{CellID: cellID})
+	if err != nil {
+		return CellDescription{}, err
+	}
+	sortActualLRPs(actualLRPs)
+
+	tasks, err := Tasks(ctx, logger, bbsClient, models.TaskFilter{CellID: cellID})
+	if err != nil {
+		return CellDescription{}, err
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].TaskGuid < tasks[j].TaskGuid })
+
+	return CellDescription{Presence: presence, Capacity: capacity, ActualLRPs: actualLRPs, Tasks: tasks}, nil
+}
+
+// cellCapacities returns the capacity of each distinct non empty id of
+// cellIDs, ordered by cell id. It only fails when the cells cannot be listed
+// or ctx is done.
+func cellCapacities(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, clientFactory rep.ClientFactory, cellIDs []string) ([]CellCapacity, error) {
+	wanted := map[string]bool{}
+	for _, cellID := range cellIDs {
+		if cellID != "" {
+			wanted[cellID] = true
+		}
+	}
+	if len(wanted) == 0 {
+		return nil, nil
+	}
+
+	registrations, err := Cells(ctx, logger, bbsClient)
+	if err != nil {
+		return nil, err
+	}
+	registered := map[string]*models.CellPresence{}
+	for _, registration := range registrations {
+		registered[registration.CellId] = registration
+	}
+
+	ids := make([]string, 0, len(wanted))
+	for cellID := range wanted {
+		ids = append(ids, cellID)
+	}
+	sort.Strings(ids)
+
+	capacities := make([]CellCapacity, 0, len(ids))
+	for _, cellID := range ids {
+		registration, ok := registered[cellID]
+		if !ok {
+			capacities = append(capacities, CellCapacity{CellID: cellID, Error: ErrCellNotFound.Error()})
+			continue
+		}
+
+		capacity, err := cellCapacity(ctx, logger, clientFactory, registration)
+		if err != nil {
+			return nil, err
+		}
+		capacities = append(capacities, capacity)
+	}
+	return capacities, nil
+}
+
+func cellCapacity(ctx context.Context, logger lager.Logger, clientFactory rep.ClientFactory, registration *models.CellPresence) (CellCapacity, error) {
+	capacity := CellCapacity{CellID: registration.CellId, Zone: registration.Zone}
+
+	state, err := CellState(ctx, logger, clientFactory, registration)
+	if err != nil {
+		if ctx.Err() != nil {
+			return CellCapacity{}, ctx.Err()
+		}
+		logger.Error("failed-to-fetch-cell-state", err, lager.Data{"cell-id": registration.CellId})
+		capacity.Error = CellStateError{CellID: registration.CellId, Err: err}.Error()
+		return capacity, nil
+	}
+
+	capacity.Available = state.AvailableResources
+	capacity.Total = state.TotalResources
+	capacity.Evacuating = state.Evacuating
+	return capacity, nil
+}
+
+func sortActualLRPs(actualLRPs []*models.ActualLRP) {
+	sort.Slice(actualLRPs, func(i, j int) bool {
+		a, b := actualLRPs[i], actualLRPs[j]
+		if a.ProcessGuid != b.ProcessGuid {
+			return a.ProcessGuid < b.ProcessGuid
+		}
+		if a.Index != b.Index {
+			return a.Index < b.Index
+		}
+		return a.Presence < b.Presence
+	})
+}
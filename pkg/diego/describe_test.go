@@ -0,0 +1,148 @@
+package diego_test
+
+import (
+	"context"
+	"errors"
+
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/rep"
+	"code.cloudfoundry.org/rep/repfakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Describe", func() {
+	var (
+		fakeBBSClient        *fake_bbs.FakeClient
+		fakeRepClientFactory *repfakes.FakeClientFactory
+		fakeRepClient        *repfakes.FakeClient
+		logger               *lagertest.TestLogger
+	)
+
+	instance := func(index int32, cellID string, presence models.ActualLRP_Presence) *models.ActualLRP {
+		return &models.ActualLRP{
+			ActualLRPKey:         models.NewActualLRPKey("process-guid", index, "domain"),
+			ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-guid", cellID),
+			State:                models.ActualLRPStateRunning,
+			Presence:             presence,
+		}
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("diego")
+		fakeBBSClient = &fake_bbs.FakeClient{}
+		fakeBBSClient.CellsReturns([]*models.CellPresence{
+			{CellId: "cell-1", Zone: "z1", RepAddress: "rep-address-1"},
+			{CellId: "cell-2", Zone: "z2", RepAddress: "rep-address-2"},
+		}, nil)
+
+		fakeRepClient = &repfakes.FakeClient{}
+		fakeRepClient.StateReturns(rep.CellState{
+			CellID:             "cell-1",
+			AvailableResources: rep.Resources{MemoryMB: 1024, DiskMB: 2048, Containers: 10},
+			TotalResources:     rep.Resources{MemoryMB: 4096, DiskMB: 8192, Containers: 250},
+		}, nil)
+		failingRepClient := &repfakes.FakeClient{}
+		failingRepClient.StateReturns(rep.CellState{}, errors.New("boom"))
+
+		fakeRepClientFactory = &repfakes.FakeClientFactory{}
+		fakeRepClientFactory.CreateClientStub = func(address, url string) (rep.Client, error) {
+			if address == "rep-address-1" {
+				return fakeRepClient, nil
+			}
+			return failingRepClient, nil
+		}
+	})
+
+	Context("DescribeLRP", func() {
+		BeforeEach(func() {
+			fakeBBSClient.DesiredLRPByProcessGuidReturns(&models.DesiredLRP{ProcessGuid: "process-guid", Instances: 2}, nil)
+			fakeBBSClient.ActualLRPsReturns([]*models.ActualLRP{
+				instance(1, "cell-3", models.ActualLRP_Ordinary),
+				instance(0, "cell-2", models.ActualLRP_Evacuating),
+				instance(0, "cell-1", models.ActualLRP_Ordinary),
+			}, nil)
+		})
+
+		It("joins the desired LRP, its ordered instances and the capacity of their cells", func() {
+			description, err := diego.DescribeLRP(context.Background(), logger, fakeBBSClient, fakeRepClientFactory, "process-guid")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(description.DesiredLRP.ProcessGuid).To(Equal("process-guid"))
+			_, filter := fakeBBSClient.ActualLRPsArgsForCall(0)
+			Expect(filter).To(Equal(models.ActualLRPFilter{ProcessGuid: "process-guid"}))
+
+			Expect(description.ActualLRPs).To(HaveLen(3))
+			Expect(description.ActualLRPs[0].CellId).To(Equal("cell-1"))
+			Expect(description.ActualLRPs[1].CellId).To(Equal("cell-2"))
+			Expect(description.ActualLRPs[2].CellId).To(Equal("cell-3"))
+
+			Expect(description.Cells).To(Equal([]diego.CellCapacity{
+				{
+					CellID:    "cell-1",
+					Zone:      "z1",
+					Available: rep.Resources{MemoryMB: 1024, DiskMB: 2048, Containers: 10},
+					Total:     rep.Resources{MemoryMB: 4096, DiskMB: 8192, Containers: 250},
+				},
+				{CellID: "cell-2", Zone: "z2", Error: "failed to get cell state for cell cell-2: boom"},
+				{CellID: "cell-3", Error: "Cell not found"},
+			}))
+		})
+
+		It("returns the BBS errors", func() {
+			fakeBBSClient.DesiredLRPByProcessGuidReturns(nil, models.ErrResourceNotFound)
+
+			_, err := diego.DescribeLRP(context.Background(), logger, fakeBBSClient, fakeRepClientFactory, "process-guid")
+			Expect(err).To(Equal(models.ErrResourceNotFound))
+		})
+	})
+
+	Context("DescribeTask", func() {
+		It("includes the capacity of the cell of the task", func() {
+			fakeBBSClient.TaskByGuidReturns(&models.Task{TaskGuid: "task-guid", CellId: "cell-1"}, nil)
+
+			description, err := diego.DescribeTask(context.Background(), logger, fakeBBSClient, fakeRepClientFactory, "task-guid")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(description.Task.TaskGuid).To(Equal("task-guid"))
+			Expect(description.Cell.CellID).To(Equal("cell-1"))
+			Expect(description.Cell.Available.MemoryMB).To(Equal(int32(1024)))
+		})
+
+		It("has no cell until the task is placed", func() {
+			fakeBBSClient.TaskByGuidReturns(&models.Task{TaskGuid: "task-guid"}, nil)
+
+			description, err := diego.DescribeTask(context.Background(), logger, fakeBBSClient, fakeRepClientFactory, "task-guid")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(description.Cell).To(BeNil())
+			Expect(fakeBBSClient.CellsCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("DescribeCell", func() {
+		It("gathers the capacity, instances and tasks of the cell", func() {
+			fakeBBSClient.ActualLRPsReturns([]*models.ActualLRP{instance(0, "cell-1", models.ActualLRP_Ordinary)}, nil)
+			fakeBBSClient.TasksWithFilterReturns([]*models.Task{{TaskGuid: "task-2"}, {TaskGuid: "task-1"}}, nil)
+
+			description, err := diego.DescribeCell(context.Background(), logger, fakeBBSClient, fakeRepClientFactory, "cell-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(description.Presence.CellId).To(Equal("cell-1"))
+			Expect(description.Capacity.Total.Containers).To(Equal(250))
+			Expect(description.ActualLRPs).To(HaveLen(1))
+			Expect(description.Tasks[0].TaskGuid).To(Equal("task-1"))
+
+			_, lrpFilter := fakeBBSClient.ActualLRPsArgsForCall(0)
+			Expect(lrpFilter).To(Equal(models.ActualLRPFilter{CellID: "cell-1"}))
+			_, taskFilter := fakeBBSClient.TasksWithFilterArgsForCall(0)
+			Expect(taskFilter).To(Equal(models.TaskFilter{CellID: "cell-1"}))
+		})
+
+		It("returns ErrCellNotFound for an unknown cell", func() {
+			_, err := diego.DescribeCell(context.Background(), logger, fakeBBSClient, fakeRepClientFactory, "cell-3")
+			Expect(err).To(Equal(diego.ErrCellNotFound))
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package diego_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestDiego(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Diego Suite")
+}
@@ -0,0 +1,484 @@
+package diego
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/lager"
+)
+
+const (
+	checkTimeout = 5 * time.Second
+
+	// rejectionWait is how long to wait for a TLS 1.3 server to reject the
+	// client certificate once the handshake has completed.
+	rejectionWait = 250 * time.Millisecond
+)
+
+// CheckStatus is the outcome of a single diagnostic check.
+type CheckStatus string
+
+const (
+	CheckPassed  CheckStatus = "pass"
+	CheckFailed  CheckStatus = "fail"
+	CheckSkipped CheckStatus = "skip"
+)
+
+// Names of the checks run by Diagnose.
+const (
+	CheckAddress           = "address"
+	CheckCACertificate     = "ca-certificate"
+	CheckClientCertificate = "client-certificate"
+	CheckDNS               = "dns"
+	CheckTCP               = "tcp"
+	CheckTLSHandshake      = "tls-handshake"
+	CheckCertificateChain  = "certificate-chain"
+	CheckCertificateExpiry = "certificate-expiry"
+	CheckCertificateSANs   = "certificate-sans"
+	CheckPing              = "ping"
+	CheckCells             = "cells"
+)
+
+// Check is the result of one diagnostic check of a component. A failed check
+// carries a Hint on how to fix the problem.
+type Check struct {
+	Component string      `json:"component"`
+	Address   string      `json:"address,omitempty"`
+	Name      string      `json:"check"`
+	Status    CheckStatus `json:"status"`
+	Detail    string      `json:"detail,omitempty"`
+	Hint      string      `json:"hint,omitempty"`
+}
+
+// Diagnose checks the TLS credentials in config, then the DNS resolution, TCP
+// reachability, TLS handshake and server certificate of each BBS endpoint, of
+// the Locket server when config.LocketApiLocation is set and of up to
+// repSample reps registered with the BBS. Each BBS endpoint is also pinged
+// using the client at the same index of bbsClients, which must hold one
+// client for each of config.BBSURLs(), or none when they could not be created
+// from the credentials in config, in which case the pings are skipped.
+//
+// Every check is passed to report as soon as it completes. Failing checks do
+// not make Diagnose return an error; it only returns ctx.Err() when ctx is
+// done before all the checks have run.
+func Diagnose(ctx context.Context, logger lager.Logger, config ClientConfig, bbsClients []bbs.Client, repSample int, report func(Check)) error {
+	d := &diagnosis{ctx: ctx, report: report}
+	d.checkCredentials(config)
+
+	bbsURLs := config.BBSURLs()
+	reachable := make([]bool, len(bbsURLs))
+	for i, bbsURL := range bbsURLs {
+		reachable[i] = d.checkURL("bbs", bbsURL)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	activeClient := d.checkBBSPings(logger, bbsURLs, bbsClients, reachable)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if config.LocketApiLocation == "" {
+		d.add(Check{
+			Component: "locket",
+			Name:      CheckAddress,
+			Status:    CheckSkipped,
+			Detail:    "no Locket API location given",
+			Hint:      "Set --locketAPILocation or LOCKET_API_LOCATION to check Locket",
+		})
+	} else {
+		d.checkAddress("locket", config.LocketApiLocation, config.LocketApiLocation, true)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if repSample <= 0 {
+		return nil
+	}
+
+	if activeClient == nil {
+		d.add(Check{
+			Component: "rep",
+			Name:      CheckCells,
+			Status:    CheckSkipped,
+			Detail:    "no active BBS to list the registered cells from",
+			Hint:      "Reps are found through the BBS; fix the BBS checks above first",
+		})
+		return nil
+	}
+
+	cells, err := Cells(ctx, logger, activeClient)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		d.add(Check{
+			Component: "rep",
+			Name:      CheckCells,
+			Status:    CheckFailed,
+			Detail:    err.Error(),
+			Hint:      "Check the BBS logs for why it cannot list the registered cells",
+		})
+		return nil
+	}
+	d.add(Check{
+		Component: "rep",
+		Name:      CheckCells,
+		Status:    CheckPassed,
+		Detail:    fmt.Sprintf("%d cells registered", len(cells)),
+	})
+
+	for _, cell := range sampleCells(cells, repSample) {
+		repURL := cell.RepUrl
+		if repURL == "" {
+			repURL = cell.RepAddress
+		}
+		d.checkURL("rep", repURL)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sampleCells returns the first n of cells, ordered by cell ID so that
+// repeated runs check the same reps.
+func sampleCells(cells []*models.CellPresence, n int) []*models.CellPresence {
+	sorted := make([]*models.CellPresence, len(cells))
+	copy(sorted, cells)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].CellId < sorted[j].CellId
+	})
+
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// componentName returns how hints refer to component.
+func componentName(component string) string {
+	switch component {
+	case "bbs":
+		return "BBS"
+	case "locket":
+		return "Locket server"
+	default:
+		return component
+	}
+}
+
+type diagnosis struct {
+	ctx    context.Context
+	report func(Check)
+
+	// verify is false when certificate verification is disabled, in which
+	// case the checks that depend on roots are skipped.
+	verify bool
+	roots  *x509.CertPool
+	certs  []tls.Certificate
+}
+
+// add reports check unless ctx is done, since a check that failed because
+// the command was interrupted says nothing about the component.
+func (d *diagnosis) add(check Check) {
+	if d.ctx.Err() != nil {
+		return
+	}
+	d.report(check)
+}
+
+func (d *diagnosis) pass(component, address, name, detail string) {
+	d.add(Check{Component: component, Address: address, Name: name, Status: CheckPassed, Detail: detail})
+}
+
+func (d *diagnosis) fail(component, address, name, detail, hint string) {
+	d.add(Check{Component: component, Address: address, Name: name, Status: CheckFailed, Detail: detail, Hint: hint})
+}
+
+func (d *diagnosis) skip(component, address, name, detail string) {
+	d.add(Check{Component: component, Address: address, Name: name, Status: CheckSkipped, Detail: detail})
+}
+
+func (d *diagnosis) checkCredentials(config ClientConfig) {
+	d.verify = !config.SkipCertVerify
+	d.roots = x509.NewCertPool()
+
+	if config.SkipCertVerify {
+		d.skip("cfdot", config.CACertFile, CheckCACertificate, "certificate verification is disabled with --skipCertVerify")
+	} else {
+		caPEM, err := ioutil.ReadFile(config.CACertFile)
+		if err == nil && !d.roots.AppendCertsFromPEM(caPEM) {
+			err = errors.New("no PEM encoded certificate found")
+		}
+		if err != nil {
+			d.fail("cfdot", config.CACertFile, CheckCACertificate, err.Error(),
+				"Check that --caCertFile (CA_CERT_FILE) holds the PEM encoded certificate of the deployment's CA")
+		} else {
+			d.pass("cfdot", config.CACertFile, CheckCACertificate, "loaded")
+		}
+	}
+
+	cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+	if err != nil {
+		d.fail("cfdot", config.CertFile, CheckClientCertificate, err.Error(),
+			"Check that --clientCertFile (CLIENT_CERT_FILE) and --clientKeyFile (CLIENT_KEY_FILE) hold a PEM encoded certificate and its private key")
+		return
+	}
+	d.certs = []tls.Certificate{cert}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err == nil && time.Now().After(leaf.NotAfter) {
+		d.fail("cfdot", config.CertFile, CheckClientCertificate, fmt.Sprintf("expired on %s", leaf.NotAfter.UTC().Format(time.RFC3339)),
+			"Renew the client certificate in --clientCertFile (CLIENT_CERT_FILE); the components reject expired certificates")
+		return
+	}
+	d.pass("cfdot", config.CertFile, CheckClientCertificate, "loaded")
+}
+
+// checkURL checks the host of rawURL, using TLS when its scheme is https. It
+// returns whether none of the checks failed.
+func (d *diagnosis) checkURL(component, rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		detail := fmt.Sprintf("'%s' is not a valid URL", rawURL)
+		if err != nil {
+			detail = err.Error()
+		}
+		d.fail(component, rawURL, CheckAddress, detail, "Specify the URL as scheme://host:port")
+		return false
+	}
+
+	useTLS := parsed.Scheme == "https"
+	hostPort := parsed.Host
+	if parsed.Port() == "" {
+		port := "80"
+		if useTLS {
+			port = "443"
+		}
+		hostPort = net.JoinHostPort(parsed.Hostname(), port)
+	}
+
+	return d.checkAddress(component, rawURL, hostPort, useTLS)
+}
+
+// checkAddress checks that hostPort resolves and accepts TCP connections and,
+// when useTLS is set, that it completes a TLS handshake with a valid
+// certificate. Checks stop at the first step that fails. It returns whether
+// none of the checks failed.
+func (d *diagnosis) checkAddress(component, address, hostPort string, useTLS bool) bool {
+	host, port, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		d.fail(component, address, CheckAddress, err.Error(), "Specify the address as host:port")
+		return false
+	}
+
+	if net.ParseIP(host) != nil {
+		d.pass(component, address, CheckDNS, fmt.Sprintf("%s is an IP address", host))
+	} else {
+		ctx, cancel := context.WithTimeout(d.ctx, checkTimeout)
+		addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+		cancel()
+		if err != nil {
+			d.fail(component, address, CheckDNS, err.Error(),
+				fmt.Sprintf("Check that %s is spelled correctly and that this machine can resolve the deployment's internal hostnames, for example by running cfdot on one of its VMs", host))
+			return false
+		}
+		d.pass(component, address, CheckDNS, fmt.Sprintf("resolved to %s", strings.Join(addrs, ", ")))
+	}
+
+	ctx, cancel := context.WithTimeout(d.ctx, checkTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", hostPort)
+	if err != nil {
+		d.fail(component, address, CheckTCP, err.Error(),
+			fmt.Sprintf("Check that the %s is running and listening on port %s, and that no firewall or security group blocks the connection", componentName(component), port))
+		return false
+	}
+	defer conn.Close()
+	d.pass(component, address, CheckTCP, fmt.Sprintf("connected to %s", conn.RemoteAddr()))
+
+	if !useTLS {
+		return true
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	// The certificate is verified by the checks below rather than during the
+	// handshake, so that each problem with it is reported separately.
+	tlsConn := tls.Client(conn, &tls.Config{
+		ServerName:         host,
+		Certificates:       d.certs,
+		InsecureSkipVerify: true,
+	})
+	err = tlsConn.Handshake()
+	if err == nil {
+		err = awaitRejection(tlsConn)
+	}
+	if err != nil {
+		d.fail(component, address, CheckTLSHandshake, err.Error(),
+			fmt.Sprintf("Check that the %s serves TLS on port %s and that --clientCertFile holds a certificate issued by a CA the %s trusts", componentName(component), port, componentName(component)))
+		return false
+	}
+	d.pass(component, address, CheckTLSHandshake, "completed")
+
+	return d.checkCertificates(component, address, host, tlsConn.ConnectionState().PeerCertificates)
+}
+
+// awaitRejection waits briefly for the server to reject the client
+// certificate. With TLS 1.3 the server only verifies the client certificate
+// after the client considers the handshake complete, so a rejection surfaces
+// on the next read instead.
+func awaitRejection(conn *tls.Conn) error {
+	if conn.ConnectionState().Version != tls.VersionTLS13 {
+		return nil
+	}
+
+	conn.SetReadDeadline(time.Now().Add(rejectionWait))
+	_, err := conn.Read(make([]byte, 1))
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return nil
+	}
+	return err
+}
+
+func (d *diagnosis) checkCertificates(component, address, host string, certs []*x509.Certificate) bool {
+	if len(certs) == 0 {
+		d.fail(component, address, CheckCertificateChain, "the server presented no certificate",
+			fmt.Sprintf("Check the TLS configuration of the %s", componentName(component)))
+		return false
+	}
+
+	ok := true
+	leaf := certs[0]
+	now := time.Now()
+
+	if !d.verify {
+		d.skip(component, address, CheckCertificateChain, "certificate verification is disabled with --skipCertVerify")
+	} else {
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+
+		// Expiry is reported by its own check, so verify the chain at a
+		// time the certificate is valid.
+		verifyTime := now
+		if now.Before(leaf.NotBefore) || now.After(leaf.NotAfter) {
+			verifyTime = leaf.NotBefore
+		}
+
+		_, err := leaf.Verify(x509.VerifyOptions{
+			Roots:         d.roots,
+			Intermediates: intermediates,
+			CurrentTime:   verifyTime,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err != nil {
+			ok = false
+			d.fail(component, address, CheckCertificateChain,
+				fmt.Sprintf("%s; the certificate was issued by %s", err, leaf.Issuer),
+				fmt.Sprintf("Check that --caCertFile (CA_CERT_FILE) holds the CA that issued the %s's certificate", componentName(component)))
+		} else {
+			d.pass(component, address, CheckCertificateChain, fmt.Sprintf("issued by %s", leaf.Issuer))
+		}
+	}
+
+	switch {
+	case now.After(leaf.NotAfter):
+		ok = false
+		d.fail(component, address, CheckCertificateExpiry,
+			fmt.Sprintf("expired on %s", leaf.NotAfter.UTC().Format(time.RFC3339)),
+			fmt.Sprintf("Rotate the %s's certificate", componentName(component)))
+	case now.Before(leaf.NotBefore):
+		ok = false
+		d.fail(component, address, CheckCertificateExpiry,
+			fmt.Sprintf("not valid before %s", leaf.NotBefore.UTC().Format(time.RFC3339)),
+			fmt.Sprintf("Check that the clocks of this machine and of the %s's VM are in sync", componentName(component)))
+	default:
+		d.pass(component, address, CheckCertificateExpiry,
+			fmt.Sprintf("valid until %s", leaf.NotAfter.UTC().Format(time.RFC3339)))
+	}
+
+	if !d.verify {
+		d.skip(component, address, CheckCertificateSANs, "certificate verification is disabled with --skipCertVerify")
+	} else if err := leaf.VerifyHostname(host); err != nil {
+		ok = false
+		d.fail(component, address, CheckCertificateSANs, err.Error(),
+			fmt.Sprintf("Connect to the %s using one of the names its certificate is valid for, or reissue the certificate with %s as a subject alternative name", componentName(component), host))
+	} else {
+		d.pass(component, address, CheckCertificateSANs, fmt.Sprintf("valid for %s", host))
+	}
+
+	return ok
+}
+
+// checkBBSPings pings each reachable BBS endpoint and returns the client of
+// the first one that is active. Only the BBS instance holding the BBS lock is
+// active, so the other instances are reported as standbys as long as one of
+// them is active.
+func (d *diagnosis) checkBBSPings(logger lager.Logger, urls []string, clients []bbs.Client, reachable []bool) bbs.Client {
+	if len(clients) == 0 {
+		for _, bbsURL := range urls {
+			d.skip("bbs", bbsURL, CheckPing, "no BBS client could be created from the certificates")
+		}
+		return nil
+	}
+
+	active := make([]bool, len(urls))
+	var activeClient bbs.Client
+	for i := range urls {
+		if !reachable[i] {
+			continue
+		}
+
+		client := clients[i]
+		var available bool
+		err := call(d.ctx, client, func(client bbs.Client) error {
+			available = client.Ping(logger)
+			return nil
+		})
+		if err != nil {
+			return nil
+		}
+
+		active[i] = available
+		if available && activeClient == nil {
+			activeClient = client
+		}
+	}
+
+	for i, bbsURL := range urls {
+		switch {
+		case !reachable[i]:
+			d.skip("bbs", bbsURL, CheckPing, "an earlier check of this endpoint failed")
+		case active[i]:
+			d.pass("bbs", bbsURL, CheckPing, "available")
+		case activeClient != nil:
+			d.pass("bbs", bbsURL, CheckPing, "standby: another endpoint holds the BBS lock")
+		default:
+			d.fail("bbs", bbsURL, CheckPing, "the BBS did not report itself as available",
+				"The BBS may not have acquired the BBS lock yet, or may be failing, for example on database errors or rejected client certificates. Check the BBS logs")
+		}
+	}
+
+	return activeClient
+}
@@ -0,0 +1,380 @@
+package diego_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Diagnose", func() {
+	var (
+		logger     *lagertest.TestLogger
+		tmpDir     string
+		ca         *x509.Certificate
+		caKey      *ecdsa.PrivateKey
+		server     *httptest.Server
+		bbsClient  *fake_bbs.FakeClient
+		bbsClients []bbs.Client
+		config     diego.ClientConfig
+		repSample  int
+		checks     []diego.Check
+	)
+
+	startServer := func(template *x509.Certificate) {
+		cert, key := newCertificate(template, ca, caKey)
+		server = httptest.NewUnstartedServer(http.NotFoundHandler())
+		server.TLS = &tls.Config{
+			Certificates: []tls.Certificate{{Certificate: [][]byte{cert.Raw}, PrivateKey: key}},
+		}
+		server.StartTLS()
+		config.BBSUrl = server.URL
+	}
+
+	find := func(component, name string) diego.Check {
+		for _, check := range checks {
+			if check.Component == component && check.Name == name {
+				return check
+			}
+		}
+		Fail("no " + name + " check for " + component)
+		return diego.Check{}
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("doctor")
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "doctor")
+		Expect(err).NotTo(HaveOccurred())
+
+		ca, caKey = newCertificate(&x509.Certificate{
+			Subject:               pkix.Name{CommonName: "diegoCA"},
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign,
+		}, nil, nil)
+		client, clientKey := newCertificate(&x509.Certificate{Subject: pkix.Name{CommonName: "cfdot"}}, ca, caKey)
+
+		config = diego.ClientConfig{
+			CACertFile: writeCertificate(tmpDir, "ca", ca, nil),
+			CertFile:   writeCertificate(tmpDir, "client", client, nil),
+			KeyFile:    writeCertificate(tmpDir, "client", nil, clientKey),
+		}
+
+		bbsClient = &fake_bbs.FakeClient{}
+		bbsClient.PingReturns(true)
+		bbsClients = []bbs.Client{bbsClient}
+		repSample = 0
+		checks = nil
+
+		startServer(&x509.Certificate{
+			Subject:     pkix.Name{CommonName: "bbs"},
+			IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		})
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(tmpDir)
+	})
+
+	JustBeforeEach(func() {
+		err := diego.Diagnose(context.Background(), logger, config, bbsClients, repSample, func(check diego.Check) {
+			checks = append(checks, check)
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("passes every check of a healthy BBS", func() {
+		var names []string
+		for _, check := range checks {
+			if check.Component == "bbs" {
+				Expect(check.Status).To(Equal(diego.CheckPassed), check.Name+": "+check.Detail)
+				Expect(check.Address).To(Equal(server.URL))
+				names = append(names, check.Name)
+			}
+		}
+
+		Expect(names).To(Equal([]string{
+			diego.CheckDNS,
+			diego.CheckTCP,
+			diego.CheckTLSHandshake,
+			diego.CheckCertificateChain,
+			diego.CheckCertificateExpiry,
+			diego.CheckCertificateSANs,
+			diego.CheckPing,
+		}))
+		Expect(find("cfdot", diego.CheckCACertificate).Status).To(Equal(diego.CheckPassed))
+		Expect(find("cfdot", diego.CheckClientCertificate).Status).To(Equal(diego.CheckPassed))
+	})
+
+	It("skips Locket when no Locket API location is given", func() {
+		Expect(find("locket", diego.CheckAddress).Status).To(Equal(diego.CheckSkipped))
+	})
+
+	Context("when the CA file does not hold the CA of the server", func() {
+		BeforeEach(func() {
+			otherCA, _ := newCertificate(&x509.Certificate{
+				Subject:               pkix.Name{CommonName: "otherCA"},
+				IsCA:                  true,
+				BasicConstraintsValid: true,
+				KeyUsage:              x509.KeyUsageCertSign,
+			}, nil, nil)
+			config.CACertFile = writeCertificate(tmpDir, "other-ca", otherCA, nil)
+		})
+
+		It("fails the certificate chain check with a hint about --caCertFile", func() {
+			check := find("bbs", diego.CheckCertificateChain)
+			Expect(check.Status).To(Equal(diego.CheckFailed))
+			Expect(check.Detail).To(ContainSubstring("CN=diegoCA"))
+			Expect(check.Hint).To(ContainSubstring("--caCertFile"))
+		})
+
+		It("skips the ping", func() {
+			Expect(find("bbs", diego.CheckPing).Status).To(Equal(diego.CheckSkipped))
+			Expect(bbsClient.PingCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the client certificate has expired", func() {
+		BeforeEach(func() {
+			client, clientKey := newCertificate(&x509.Certificate{
+				Subject:   pkix.Name{CommonName: "cfdot"},
+				NotBefore: time.Now().Add(-72 * time.Hour),
+				NotAfter:  time.Now().Add(-time.Hour),
+			}, ca, caKey)
+			config.CertFile = writeCertificate(tmpDir, "expired-client", client, nil)
+			config.KeyFile = writeCertificate(tmpDir, "expired-client", nil, clientKey)
+		})
+
+		It("fails the client certificate check with a hint about --clientCertFile", func() {
+			check := find("cfdot", diego.CheckClientCertificate)
+			Expect(check.Status).To(Equal(diego.CheckFailed))
+			Expect(check.Detail).To(HavePrefix("expired on "))
+			Expect(check.Hint).To(ContainSubstring("--clientCertFile"))
+		})
+	})
+
+	Context("when no BBS client could be created", func() {
+		BeforeEach(func() {
+			bbsClients = nil
+		})
+
+		It("still diagnoses the BBS and skips the ping", func() {
+			Expect(find("bbs", diego.CheckTLSHandshake).Status).To(Equal(diego.CheckPassed))
+			Expect(find("bbs", diego.CheckPing).Status).To(Equal(diego.CheckSkipped))
+		})
+	})
+
+	Context("when certificate verification is disabled", func() {
+		BeforeEach(func() {
+			config.SkipCertVerify = true
+			config.CACertFile = ""
+		})
+
+		It("skips the checks that verify the certificate", func() {
+			Expect(find("cfdot", diego.CheckCACertificate).Status).To(Equal(diego.CheckSkipped))
+			Expect(find("bbs", diego.CheckCertificateChain).Status).To(Equal(diego.CheckSkipped))
+			Expect(find("bbs", diego.CheckCertificateSANs).Status).To(Equal(diego.CheckSkipped))
+			Expect(find("bbs", diego.CheckPing).Status).To(Equal(diego.CheckPassed))
+		})
+	})
+
+	Context("when the server certificate has expired", func() {
+		BeforeEach(func() {
+			server.Close()
+			startServer(&x509.Certificate{
+				Subject:     pkix.Name{CommonName: "bbs"},
+				IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+				NotBefore:   time.Now().Add(-48 * time.Hour),
+				NotAfter:    time.Now().Add(-24 * time.Hour),
+			})
+		})
+
+		It("fails the expiry check but not the chain check", func() {
+			check := find("bbs", diego.CheckCertificateExpiry)
+			Expect(check.Status).To(Equal(diego.CheckFailed))
+			Expect(check.Detail).To(HavePrefix("expired on"))
+			Expect(find("bbs", diego.CheckCertificateChain).Status).To(Equal(diego.CheckPassed))
+		})
+	})
+
+	Context("when the server certificate is not valid for the host", func() {
+		BeforeEach(func() {
+			server.Close()
+			startServer(&x509.Certificate{
+				Subject:  pkix.Name{CommonName: "bbs"},
+				DNSNames: []string{"bbs.service.cf.internal"},
+			})
+		})
+
+		It("fails the SANs check", func() {
+			check := find("bbs", diego.CheckCertificateSANs)
+			Expect(check.Status).To(Equal(diego.CheckFailed))
+			Expect(check.Detail).To(ContainSubstring("127.0.0.1"))
+			Expect(check.Hint).To(ContainSubstring("subject alternative name"))
+		})
+	})
+
+	Context("when nothing listens at the BBS URL", func() {
+		BeforeEach(func() {
+			config.BBSUrl = "https://127.0.0.1:1"
+		})
+
+		It("fails the TCP check and skips the rest", func() {
+			Expect(find("bbs", diego.CheckTCP).Status).To(Equal(diego.CheckFailed))
+			Expect(find("bbs", diego.CheckPing).Status).To(Equal(diego.CheckSkipped))
+			for _, check := range checks {
+				Expect(check.Name).NotTo(Equal(diego.CheckTLSHandshake))
+			}
+		})
+	})
+
+	Context("when the BBS host does not resolve", func() {
+		BeforeEach(func() {
+			config.BBSUrl = "https://bbs.invalid:8889"
+		})
+
+		It("fails the DNS check", func() {
+			check := find("bbs", diego.CheckDNS)
+			Expect(check.Status).To(Equal(diego.CheckFailed))
+			Expect(check.Hint).To(ContainSubstring("bbs.invalid"))
+		})
+	})
+
+	Context("when the BBS is not active", func() {
+		BeforeEach(func() {
+			bbsClient.PingReturns(false)
+		})
+
+		It("fails the ping", func() {
+			Expect(find("bbs", diego.CheckPing).Status).To(Equal(diego.CheckFailed))
+		})
+	})
+
+	Context("when another endpoint is the active BBS", func() {
+		BeforeEach(func() {
+			standby := &fake_bbs.FakeClient{}
+			standby.PingReturns(false)
+			bbsClients = []bbs.Client{standby, bbsClient}
+			config.BBSUrl = server.URL + "," + server.URL
+		})
+
+		It("reports the inactive endpoint as a standby", func() {
+			var pings []diego.Check
+			for _, check := range checks {
+				if check.Name == diego.CheckPing {
+					pings = append(pings, check)
+				}
+			}
+
+			Expect(pings).To(HaveLen(2))
+			Expect(pings[0].Status).To(Equal(diego.CheckPassed))
+			Expect(pings[0].Detail).To(HavePrefix("standby"))
+			Expect(pings[1].Status).To(Equal(diego.CheckPassed))
+			Expect(pings[1].Detail).To(Equal("available"))
+		})
+	})
+
+	Context("when reps are sampled", func() {
+		BeforeEach(func() {
+			repSample = 1
+			bbsClient.CellsReturns([]*models.CellPresence{
+				{CellId: "cell-2", RepUrl: "https://127.0.0.1:1"},
+				{CellId: "cell-1", RepUrl: server.URL},
+			}, nil)
+		})
+
+		It("checks the reps of the first cells by ID", func() {
+			Expect(find("rep", diego.CheckCells).Detail).To(Equal("2 cells registered"))
+
+			check := find("rep", diego.CheckTLSHandshake)
+			Expect(check.Status).To(Equal(diego.CheckPassed))
+			Expect(check.Address).To(Equal(server.URL))
+
+			for _, check := range checks {
+				Expect(check.Address).NotTo(Equal("https://127.0.0.1:1"))
+			}
+		})
+
+		Context("when no BBS is active", func() {
+			BeforeEach(func() {
+				bbsClient.PingReturns(false)
+			})
+
+			It("skips the reps", func() {
+				Expect(find("rep", diego.CheckCells).Status).To(Equal(diego.CheckSkipped))
+				Expect(bbsClient.CellsCallCount()).To(Equal(0))
+			})
+		})
+	})
+})
+
+// newCertificate signs template with parentKey, or self-signs it when parent
+// is nil, filling in the fields the tests do not care about.
+func newCertificate(template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	Expect(err).NotTo(HaveOccurred())
+	template.SerialNumber = serial
+	if template.NotBefore.IsZero() {
+		template.NotBefore = time.Now().Add(-72 * time.Hour)
+	}
+	if template.NotAfter.IsZero() {
+		template.NotAfter = time.Now().Add(time.Hour)
+	}
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	Expect(err).NotTo(HaveOccurred())
+
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+	return cert, key
+}
+
+// writeCertificate writes either cert or key as PEM into dir and returns the
+// path of the file.
+func writeCertificate(dir, name string, cert *x509.Certificate, key *ecdsa.PrivateKey) string {
+	var block *pem.Block
+	var path string
+	if cert != nil {
+		block = &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}
+		path = filepath.Join(dir, name+".crt")
+	} else {
+		der, err := x509.MarshalECPrivateKey(key)
+		Expect(err).NotTo(HaveOccurred())
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+		path = filepath.Join(dir, name+".key")
+	}
+
+	Expect(ioutil.WriteFile(path, pem.EncodeToMemory(block), 0600)).To(Succeed())
+	return path
+}
@@ -0,0 +1,29 @@
+package diego
+
+import (
+	"context"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/lager"
+)
+
+func Domains(ctx context.Context, logger lager.Logger, bbsClient bbs.Client) ([]string, error) {
+	var domains []string
+	err := call(ctx, bbsClient, func(client bbs.Client) error {
+		var err error
+		domains, err = client.Domains(logger)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return domains, nil
+}
+
+func UpsertDomain(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, domain string, ttl time.Duration) error {
+	return mutate(ctx, bbsClient, func(client bbs.Client) error {
+		return client.UpsertDomain(logger, domain, ttl)
+	})
+}
@@ -0,0 +1,150 @@
+package diego
+
+import (
+	"context"
+	"io"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/events"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/lager"
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// LRPEvents subscribes to the actual LRP instance events for the given cell,
+// or for all cells when cellID is empty, and passes each event to handle.
+// When includeActualLRPGroups is set the deprecated actual LRP group events
+// are subscribed to as well. It returns nil once every stream has been closed
+// by the BBS, the first error returned by handle, or ctx.Err() once ctx is
+// done.
+func LRPEvents(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, cellID string, includeActualLRPGroups bool, handle func(models.Event) error) error {
+	oldEventStream := make(chan models.Event)
+	newEventStream := make(chan models.Event)
+	oldErrChan := make(chan error)
+	newErrChan := make(chan error)
+	done := make(chan struct{})
+	defer close(done)
+
+	readEvent := func(es events.EventSource, eventStreamChan chan models.Event, errChan chan error) {
+		for {
+			event, err := es.Next()
+			if err != nil {
+				select {
+				case errChan <- err:
+				case <-done:
+				}
+				return
+			}
+
+			select {
+			case eventStreamChan <- event:
+			case <-done:
+				return
+			}
+		}
+	}
+
+	eventStreamCount := 1
+
+	if includeActualLRPGroups {
+		oldES, err := bbsClient.SubscribeToEventsByCellID(logger, cellID)
+		if err != nil {
+			return models.ConvertError(err)
+		}
+		defer oldES.Close()
+		defer closeOnDone(ctx, oldES)()
+
+		eventStreamCount += 1
+
+		go readEvent(oldES, oldEventStream, oldErrChan)
+	}
+
+	instanceES, err := bbsClient.SubscribeToInstanceEventsByCellID(logger, cellID)
+	if err != nil {
+		return models.ConvertError(err)
+	}
+	defer instanceES.Close()
+	defer closeOnDone(ctx, instanceES)()
+
+	go readEvent(instanceES, newEventStream, newErrChan)
+
+	ret := &multierror.Error{}
+	var event models.Event
+	for {
+		var err error
+		select {
+		case e := <-oldEventStream:
+			switch e.EventType() {
+			case models.EventTypeActualLRPCreated, models.EventTypeActualLRPChanged, models.EventTypeActualLRPRemoved:
+				event = e
+			default:
+				continue
+			}
+		case event = <-newEventStream:
+		case err = <-oldErrChan:
+			multierror.Append(ret, err)
+		case err = <-newErrChan:
+			multierror.Append(ret, err)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if len(ret.Errors) >= eventStreamCount {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			for _, err := range ret.Errors {
+				if err != io.EOF {
+					return ret.ErrorOrNil()
+				}
+			}
+			return nil
+		}
+
+		if err != nil {
+			continue
+		}
+
+		err = handle(event)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// TaskEvents subscribes to the task events and passes each event to handle.
+// It returns nil once the BBS closes the stream, the first error returned by
+// handle, or ctx.Err() once ctx is done.
+func TaskEvents(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, handle func(models.Event) error) error {
+	es, err := bbsClient.SubscribeToTaskEvents(logger)
+	if err != nil {
+		return models.ConvertError(err)
+	}
+	defer es.Close()
+	defer closeOnDone(ctx, es)()
+
+	return readEvents(ctx, es, handle)
+}
+
+// readEvents passes each event of es to handle until the stream ends. It
+// returns nil once the BBS closes the stream, the first error returned by
+// handle, or ctx.Err() once ctx is done.
+func readEvents(ctx context.Context, es events.EventSource, handle func(models.Event) error) error {
+	for {
+		event, err := es.Next()
+		switch {
+		case err == nil:
+			err = handle(event)
+			if err != nil {
+				return err
+			}
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err == io.EOF:
+			return nil
+		default:
+			return err
+		}
+	}
+}
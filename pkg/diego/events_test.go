@@ -0,0 +1,128 @@
+package diego_test
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"code.cloudfoundry.org/bbs/events/eventfakes"
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Events", func() {
+	var (
+		fakeBBSClient   *fake_bbs.FakeClient
+		fakeEventSource *eventfakes.FakeEventSource
+		logger          *lagertest.TestLogger
+		received        []models.Event
+		handle          func(models.Event) error
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("diego")
+		fakeBBSClient = &fake_bbs.FakeClient{}
+		fakeEventSource = &eventfakes.FakeEventSource{}
+		received = []models.Event{}
+		handle = func(event models.Event) error {
+			received = append(received, event)
+			return nil
+		}
+	})
+
+	Context("TaskEvents", func() {
+		BeforeEach(func() {
+			fakeBBSClient.SubscribeToTaskEventsReturns(fakeEventSource, nil)
+		})
+
+		It("passes each event to the handler until the stream ends", func() {
+			event := models.NewTaskCreatedEvent(&models.Task{TaskGuid: "task-1"})
+			fakeEventSource.NextReturnsOnCall(0, event, nil)
+			fakeEventSource.NextReturnsOnCall(1, nil, io.EOF)
+
+			err := diego.TaskEvents(context.Background(), logger, fakeBBSClient, handle)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(received).To(Equal([]models.Event{event}))
+			Expect(fakeEventSource.CloseCallCount()).To(BeNumerically(">=", 1))
+		})
+
+		It("returns the error from the handler", func() {
+			fakeEventSource.NextReturns(models.NewTaskCreatedEvent(&models.Task{}), nil)
+
+			err := diego.TaskEvents(context.Background(), logger, fakeBBSClient, func(models.Event) error {
+				return errors.New("boom")
+			})
+			Expect(err).To(MatchError("boom"))
+		})
+
+		Context("when the context is cancelled", func() {
+			var closed chan struct{}
+
+			BeforeEach(func() {
+				closed = make(chan struct{})
+				fakeEventSource.NextStub = func() (models.Event, error) {
+					<-closed
+					return nil, errors.New("source closed")
+				}
+				fakeEventSource.CloseStub = func() error {
+					select {
+					case <-closed:
+					default:
+						close(closed)
+					}
+					return nil
+				}
+			})
+
+			It("closes the event source and returns the context error", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				errCh := make(chan error)
+				go func() {
+					errCh <- diego.TaskEvents(ctx, logger, fakeBBSClient, handle)
+				}()
+
+				Eventually(fakeEventSource.NextCallCount).Should(Equal(1))
+				cancel()
+				Eventually(errCh).Should(Receive(Equal(context.Canceled)))
+			})
+		})
+	})
+
+	Context("LRPEvents", func() {
+		var fakeInstanceEventSource *eventfakes.FakeEventSource
+
+		BeforeEach(func() {
+			fakeInstanceEventSource = &eventfakes.FakeEventSource{}
+			fakeBBSClient.SubscribeToEventsByCellIDReturns(fakeEventSource, nil)
+			fakeBBSClient.SubscribeToInstanceEventsByCellIDReturns(fakeInstanceEventSource, nil)
+
+			fakeEventSource.NextReturns(nil, io.EOF)
+			fakeInstanceEventSource.NextReturnsOnCall(0, models.NewActualLRPInstanceCreatedEvent(&models.ActualLRP{}), nil)
+			fakeInstanceEventSource.NextReturns(nil, io.EOF)
+		})
+
+		It("only subscribes to the instance events unless asked for the group events", func() {
+			err := diego.LRPEvents(context.Background(), logger, fakeBBSClient, "cell-id", false, handle)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeBBSClient.SubscribeToEventsByCellIDCallCount()).To(Equal(0))
+			_, cellID := fakeBBSClient.SubscribeToInstanceEventsByCellIDArgsForCall(0)
+			Expect(cellID).To(Equal("cell-id"))
+			Expect(received).To(HaveLen(1))
+		})
+
+		It("subscribes to both streams when asked for the group events", func() {
+			err := diego.LRPEvents(context.Background(), logger, fakeBBSClient, "", true, handle)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeBBSClient.SubscribeToEventsByCellIDCallCount()).To(Equal(1))
+			Expect(fakeEventSource.CloseCallCount()).To(Equal(1))
+			Expect(fakeInstanceEventSource.CloseCallCount()).To(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,82 @@
+package diego
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"sync"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/lager"
+)
+
+// FailoverFunc is called when a BBS request could not connect to failedURL and
+// is sent to nextURL instead.
+type FailoverFunc func(failedURL string, err error, nextURL string)
+
+// NewFailoverBBSClient returns a client that sends each request to the
+// endpoint that last answered, moving on to the next of urls whenever a
+// connection cannot be established. clients[i] must target urls[i]. Since a
+// failed connection means the request was never sent, mutations fail over as
+// well.
+func NewFailoverBBSClient(urls []string, clients []bbs.Client, onFailover FailoverFunc) bbs.Client {
+	f := &failover{urls: urls, clients: clients, onFailover: onFailover}
+	return &interceptedBBSClient{Client: clients[0], intercept: f.do}
+}
+
+type failover struct {
+	urls       []string
+	clients    []bbs.Client
+	onFailover FailoverFunc
+
+	mu     sync.Mutex
+	active int
+}
+
+func (f *failover) do(_ context.Context, logger lager.Logger, _ bool, send func(bbs.Client) error) error {
+	f.mu.Lock()
+	start := f.active
+	f.mu.Unlock()
+
+	var err error
+	for i := range f.clients {
+		current := (start + i) % len(f.clients)
+		err = send(f.clients[current])
+		if !isConnectionError(err) {
+			f.mu.Lock()
+			f.active = current
+			f.mu.Unlock()
+			return err
+		}
+
+		if i == len(f.clients)-1 {
+			break
+		}
+
+		next := (current + 1) % len(f.clients)
+		logger.Info("failing-over-bbs-endpoint", lager.Data{"failed-url": f.urls[current], "error": err.Error(), "next-url": f.urls[next]})
+		if f.onFailover != nil {
+			f.onFailover(f.urls[current], err, f.urls[next])
+		}
+	}
+
+	return err
+}
+
+// isConnectionError reports whether err means the BBS at the requested URL
+// could not be reached at all.
+func isConnectionError(err error) bool {
+	urlErr, ok := err.(*url.Error)
+	if !ok {
+		return false
+	}
+
+	switch netErr := urlErr.Err.(type) {
+	case *net.OpError:
+		return netErr.Op == "dial"
+	case *net.DNSError:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,83 @@
+package diego_test
+
+import (
+	"errors"
+	"net"
+	"net/url"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Failover", func() {
+	var (
+		down, up   *fake_bbs.FakeClient
+		logger     *lagertest.TestLogger
+		failovers  []string
+		client     bbs.Client
+		refusedErr error
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("failover")
+		refusedErr = &url.Error{Op: "Post", URL: "https://bbs-0", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+
+		down = &fake_bbs.FakeClient{}
+		down.DomainsReturns(nil, refusedErr)
+		down.CancelTaskReturns(refusedErr)
+		up = &fake_bbs.FakeClient{}
+		up.DomainsReturns([]string{"domain"}, nil)
+
+		failovers = nil
+		client = diego.NewFailoverBBSClient(
+			[]string{"https://bbs-0", "https://bbs-1"},
+			[]bbs.Client{down, up},
+			func(failedURL string, err error, nextURL string) {
+				failovers = append(failovers, failedURL+" -> "+nextURL)
+			},
+		)
+	})
+
+	It("moves on to the next endpoint when a connection cannot be established", func() {
+		domains, err := client.Domains(logger)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(domains).To(Equal([]string{"domain"}))
+		Expect(failovers).To(Equal([]string{"https://bbs-0 -> https://bbs-1"}))
+	})
+
+	It("keeps using the endpoint that answered", func() {
+		_, err := client.Domains(logger)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.Domains(logger)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(down.DomainsCallCount()).To(Equal(1))
+		Expect(up.DomainsCallCount()).To(Equal(2))
+	})
+
+	It("fails over mutations as well", func() {
+		Expect(client.CancelTask(logger, "task-guid")).To(Succeed())
+		Expect(up.CancelTaskCallCount()).To(Equal(1))
+	})
+
+	It("does not fail over errors returned by a reachable BBS", func() {
+		down.DomainsReturns(nil, models.ErrDeadlock)
+
+		_, err := client.Domains(logger)
+		Expect(err).To(Equal(models.ErrDeadlock))
+		Expect(up.DomainsCallCount()).To(BeZero())
+	})
+
+	It("returns the last error when no endpoint can be reached", func() {
+		up.DomainsReturns(nil, refusedErr)
+
+		_, err := client.Domains(logger)
+		Expect(err).To(Equal(refusedErr))
+	})
+})
@@ -0,0 +1,227 @@
+package diego
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"code.cloudfoundry.org/locket/models"
+)
+
+// wellKnownLocks maps the lock keys claimed by Diego and CF components to the
+// component name and the TTL the component claims its lock with by default.
+// Locket does not return the TTL of a lock, so only the default is known.
+var wellKnownLocks = map[string]struct {
+	Component           string
+	DefaultTTLInSeconds int64
+}{
+	"bbs":                   {"bbs", 15},
+	"auctioneer":            {"auctioneer", 15},
+	"tps_watcher":           {"tps-watcher", 15},
+	"route_emitter":         {"route-emitter", 15},
+	"cc-deployment-updater": {"cc-deployment-updater", 15},
+	"routing_api_lock":      {"routing-api", 15},
+}
+
+// Leader is the current owner of a Locket lock, or a well-known lock nobody
+// holds, in which case Owner is empty and Absent is set. Locket does not
+// record when a lock was acquired, so ObservedSince is only set by a
+// LeaderHistory, as the time the owner was first observed.
+type Leader struct {
+	Key                 string      `json:"key"`
+	Component           string      `json:"component"`
+	Owner               string      `json:"owner"`
+	Absent              bool        `json:"absent,omitempty"`
+	Value               interface{} `json:"value,omitempty"`
+	DefaultTTLInSeconds int64       `json:"default_ttl_in_seconds,omitempty"`
+	ObservedSince       *time.Time  `json:"observed_since,omitempty"`
+}
+
+// LeaderChange records a lock changing owner. Owner is empty when the lock
+// was released and PreviousOwner is empty when it was newly claimed.
+type LeaderChange struct {
+	Key           string    `json:"key"`
+	Component     string    `json:"component"`
+	PreviousOwner string    `json:"previous_owner"`
+	Owner         string    `json:"owner"`
+	ObservedAt    time.Time `json:"observed_at"`
+}
+
+// LeaderHistory tracks the owners of locks across successive calls to
+// Observe. It only keeps the latest owner of each lock, not the changes.
+type LeaderHistory struct {
+	owners map[string]*Leader
+}
+
+// NewLeaderHistory starts a history from the given leaders, recording now as
+// the time each was first observed.
+func NewLeaderHistory(leaders []*Leader, now time.Time) *LeaderHistory {
+	history := &LeaderHistory{owners: map[string]*Leader{}}
+	for _, leader := range leaders {
+		observed := now
+		leader.ObservedSince = &observed
+		history.owners[leader.Key] = leader
+	}
+
+	return history
+}
+
+// Observe compares latest with the previously observed owners and returns the
+// changes, ordered by key.
+func (h *LeaderHistory) Observe(latest []*Leader, now time.Time) []LeaderChange {
+	changes := []LeaderChange{}
+	seen := map[string]bool{}
+
+	for _, leader := range latest {
+		seen[leader.Key] = true
+
+		previous, ok := h.owners[leader.Key]
+		if ok && previous.Owner == leader.Owner {
+			continue
+		}
+		if !ok && leader.Absent {
+			h.owners[leader.Key] = leader
+			continue
+		}
+
+		change := LeaderChange{
+			Key:        leader.Key,
+			Component:  leader.Component,
+			Owner:      leader.Owner,
+			ObservedAt: now,
+		}
+		if ok {
+			change.PreviousOwner = previous.Owner
+		}
+		changes = append(changes, change)
+
+		observed := now
+		leader.ObservedSince = &observed
+		h.owners[leader.Key] = leader
+	}
+
+	for key, previous := range h.owners {
+		if seen[key] {
+			continue
+		}
+
+		changes = append(changes, LeaderChange{
+			Key:           key,
+			Component:     previous.Component,
+			PreviousOwner: previous.Owner,
+			ObservedAt:    now,
+		})
+		delete(h.owners, key)
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+func Locks(ctx context.Context, locketClient models.LocketClient) ([]*models.Resource, error) {
+	return fetchAll(ctx, locketClient, models.LOCK)
+}
+
+func Presences(ctx context.Context, locketClient models.LocketClient) ([]*models.Resource, error) {
+	return fetchAll(ctx, locketClient, models.PRESENCE)
+}
+
+// Leaders returns the owner of every lock, sorted by key, naming the
+// component behind well-known lock keys. The well-known locks nobody holds
+// are returned as absent.
+func Leaders(ctx context.Context, locketClient models.LocketClient) ([]*Leader, error) {
+	locks, err := Locks(ctx, locketClient)
+	if err != nil {
+		return nil, err
+	}
+
+	leaders := []*Leader{}
+	held := map[string]bool{}
+	for _, lock := range locks {
+		leaders = append(leaders, newLeader(lock))
+		held[lock.Key] = true
+	}
+
+	for key, known := range wellKnownLocks {
+		if held[key] {
+			continue
+		}
+		leaders = append(leaders, &Leader{
+			Key:                 key,
+			Component:           known.Component,
+			Absent:              true,
+			DefaultTTLInSeconds: known.DefaultTTLInSeconds,
+		})
+	}
+
+	sort.Slice(leaders, func(i, j int) bool { return leaders[i].Key < leaders[j].Key })
+	return leaders, nil
+}
+
+func ClaimLock(ctx context.Context, locketClient models.LocketClient, key, owner, value string, ttlInSeconds int64) error {
+	return claim(ctx, locketClient, key, owner, value, ttlInSeconds, models.LOCK)
+}
+
+func ClaimPresence(ctx context.Context, locketClient models.LocketClient, key, owner, value string, ttlInSeconds int64) error {
+	return claim(ctx, locketClient, key, owner, value, ttlInSeconds, models.PRESENCE)
+}
+
+func ReleaseLock(ctx context.Context, locketClient models.LocketClient, key, owner string) error {
+	req := &models.ReleaseRequest{
+		Resource: &models.Resource{
+			Key:   key,
+			Owner: owner,
+		},
+	}
+	_, err := locketClient.Release(ctx, req)
+	return err
+}
+
+func fetchAll(ctx context.Context, locketClient models.LocketClient, typeCode models.TypeCode) ([]*models.Resource, error) {
+	req := &models.FetchAllRequest{TypeCode: typeCode}
+	resp, err := locketClient.FetchAll(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Resources, nil
+}
+
+func claim(ctx context.Context, locketClient models.LocketClient, key, owner, value string, ttlInSeconds int64, typeCode models.TypeCode) error {
+	req := &models.LockRequest{
+		Resource: &models.Resource{
+			Key:      key,
+			Owner:    owner,
+			Value:    value,
+			TypeCode: typeCode,
+		},
+		TtlInSeconds: ttlInSeconds,
+	}
+	_, err := locketClient.Lock(ctx, req)
+	return err
+}
+
+func newLeader(lock *models.Resource) *Leader {
+	leader := &Leader{
+		Key:       lock.Key,
+		Component: lock.Key,
+		Owner:     lock.Owner,
+	}
+
+	if known, ok := wellKnownLocks[lock.Key]; ok {
+		leader.Component = known.Component
+		leader.DefaultTTLInSeconds = known.DefaultTTLInSeconds
+	}
+
+	if lock.Value != "" {
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(lock.Value), &decoded); err == nil {
+			leader.Value = decoded
+		} else {
+			leader.Value = lock.Value
+		}
+	}
+
+	return leader
+}
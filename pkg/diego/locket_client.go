@@ -0,0 +1,44 @@
+package diego
+
+import (
+	"context"
+	"time"
+
+	locketmodels "code.cloudfoundry.org/locket/models"
+	"google.golang.org/grpc"
+)
+
+type timeoutLocketClient struct {
+	locketmodels.LocketClient
+	timeout time.Duration
+}
+
+// NewTimeoutLocketClient wraps client so that every request fails once
+// timeout has elapsed, or earlier if the caller's context is done first.
+func NewTimeoutLocketClient(client locketmodels.LocketClient, timeout time.Duration) locketmodels.LocketClient {
+	return &timeoutLocketClient{LocketClient: client, timeout: timeout}
+}
+
+func (c *timeoutLocketClient) Lock(ctx context.Context, in *locketmodels.LockRequest, opts ...grpc.CallOption) (*locketmodels.LockResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.LocketClient.Lock(ctx, in, opts...)
+}
+
+func (c *timeoutLocketClient) Fetch(ctx context.Context, in *locketmodels.FetchRequest, opts ...grpc.CallOption) (*locketmodels.FetchResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.LocketClient.Fetch(ctx, in, opts...)
+}
+
+func (c *timeoutLocketClient) Release(ctx context.Context, in *locketmodels.ReleaseRequest, opts ...grpc.CallOption) (*locketmodels.ReleaseResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.LocketClient.Release(ctx, in, opts...)
+}
+
+func (c *timeoutLocketClient) FetchAll(ctx context.Context, in *locketmodels.FetchAllRequest, opts ...grpc.CallOption) (*locketmodels.FetchAllResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.LocketClient.FetchAll(ctx, in, opts...)
+}
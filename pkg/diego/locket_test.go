@@ -0,0 +1,170 @@
+package diego_test
+
+import (
+	"context"
+	"time"
+
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"code.cloudfoundry.org/locket/models"
+	"code.cloudfoundry.org/locket/models/modelsfakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Locket", func() {
+	var fakeLocketClient *modelsfakes.FakeLocketClient
+
+	BeforeEach(func() {
+		fakeLocketClient = &modelsfakes.FakeLocketClient{}
+	})
+
+	Context("ClaimLock", func() {
+		It("claims a lock with the given ttl", func() {
+			err := diego.ClaimLock(context.Background(), fakeLocketClient, "key", "owner", "value", 10)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, req, _ := fakeLocketClient.LockArgsForCall(0)
+			Expect(req).To(Equal(&models.LockRequest{
+				Resource: &models.Resource{
+					Key:      "key",
+					Owner:    "owner",
+					Value:    "value",
+					TypeCode: models.LOCK,
+				},
+				TtlInSeconds: 10,
+			}))
+		})
+	})
+
+	Context("ClaimPresence", func() {
+		It("claims a presence with the given ttl", func() {
+			err := diego.ClaimPresence(context.Background(), fakeLocketClient, "key", "owner", "value", 10)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, req, _ := fakeLocketClient.LockArgsForCall(0)
+			Expect(req.Resource.TypeCode).To(Equal(models.PRESENCE))
+		})
+	})
+
+	Context("Leaders", func() {
+		BeforeEach(func() {
+			fakeLocketClient.FetchAllReturns(&models.FetchAllResponse{
+				Resources: []*models.Resource{
+					{Key: "tps_watcher", Owner: "tps-guid", TypeCode: models.LOCK},
+					{Key: "bbs", Owner: "bbs-guid", Value: `{"id":1}`, TypeCode: models.LOCK},
+				},
+			}, nil)
+		})
+
+		It("names the components behind well-known lock keys", func() {
+			leaders, err := diego.Leaders(context.Background(), fakeLocketClient)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(leaders).To(ContainElement(&diego.Leader{Key: "bbs", Component: "bbs", Owner: "bbs-guid", Value: map[string]interface{}{"id": float64(1)}, DefaultTTLInSeconds: 15}))
+			Expect(leaders).To(ContainElement(&diego.Leader{Key: "tps_watcher", Component: "tps-watcher", Owner: "tps-guid", DefaultTTLInSeconds: 15}))
+		})
+
+		It("lists the well-known locks nobody holds as absent, sorted by key", func() {
+			leaders, err := diego.Leaders(context.Background(), fakeLocketClient)
+			Expect(err).NotTo(HaveOccurred())
+
+			var keys []string
+			for _, leader := range leaders {
+				keys = append(keys, leader.Key)
+			}
+			Expect(keys).To(Equal([]string{"auctioneer", "bbs", "cc-deployment-updater", "route_emitter", "routing_api_lock", "tps_watcher"}))
+			Expect(leaders[0]).To(Equal(&diego.Leader{Key: "auctioneer", Component: "auctioneer", Absent: true, DefaultTTLInSeconds: 15}))
+		})
+	})
+
+	Context("LeaderHistory", func() {
+		var (
+			start   time.Time
+			history *diego.LeaderHistory
+		)
+
+		BeforeEach(func() {
+			start = time.Now()
+			history = diego.NewLeaderHistory([]*diego.Leader{
+				{Key: "bbs", Component: "bbs", Owner: "bbs-1"},
+			}, start)
+		})
+
+		It("records nothing while the owner stays the same", func() {
+			changes := history.Observe([]*diego.Leader{{Key: "bbs", Component: "bbs", Owner: "bbs-1"}}, start.Add(time.Second))
+			Expect(changes).To(BeEmpty())
+		})
+
+		It("records new owners, claimed and released locks", func() {
+			later := start.Add(time.Second)
+			changes := history.Observe([]*diego.Leader{
+				{Key: "auctioneer", Component: "auctioneer", Owner: "auctioneer-1"},
+			}, later)
+
+			Expect(changes).To(Equal([]diego.LeaderChange{
+				{Key: "auctioneer", Component: "auctioneer", Owner: "auctioneer-1", ObservedAt: later},
+				{Key: "bbs", Component: "bbs", PreviousOwner: "bbs-1", ObservedAt: later},
+			}))
+
+			evenLater := later.Add(time.Second)
+			changes = history.Observe([]*diego.Leader{
+				{Key: "auctioneer", Component: "auctioneer", Owner: "auctioneer-2"},
+			}, evenLater)
+
+			Expect(changes).To(Equal([]diego.LeaderChange{
+				{Key: "auctioneer", Component: "auctioneer", PreviousOwner: "auctioneer-1", Owner: "auctioneer-2", ObservedAt: evenLater},
+			}))
+		})
+
+		It("records a well-known lock becoming absent and being claimed again", func() {
+			later := start.Add(time.Second)
+			changes := history.Observe([]*diego.Leader{
+				{Key: "bbs", Component: "bbs", Absent: true},
+				{Key: "auctioneer", Component: "auctioneer", Absent: true},
+			}, later)
+
+			Expect(changes).To(Equal([]diego.LeaderChange{
+				{Key: "bbs", Component: "bbs", PreviousOwner: "bbs-1", ObservedAt: later},
+			}))
+
+			evenLater := later.Add(time.Second)
+			changes = history.Observe([]*diego.Leader{
+				{Key: "bbs", Component: "bbs", Absent: true},
+				{Key: "auctioneer", Component: "auctioneer", Owner: "auctioneer-1"},
+			}, evenLater)
+
+			Expect(changes).To(Equal([]diego.LeaderChange{
+				{Key: "auctioneer", Component: "auctioneer", Owner: "auctioneer-1", ObservedAt: evenLater},
+			}))
+		})
+	})
+
+	Context("NewTimeoutLocketClient", func() {
+		It("bounds each request by the timeout", func() {
+			fakeLocketClient.FetchAllReturns(&models.FetchAllResponse{}, nil)
+			client := diego.NewTimeoutLocketClient(fakeLocketClient, time.Minute)
+
+			_, err := client.FetchAll(context.Background(), &models.FetchAllRequest{})
+			Expect(err).NotTo(HaveOccurred())
+
+			ctx, _, _ := fakeLocketClient.FetchAllArgsForCall(0)
+			deadline, ok := ctx.Deadline()
+			Expect(ok).To(BeTrue())
+			Expect(deadline).To(BeTemporally("~", time.Now().Add(time.Minute), time.Second))
+		})
+
+		It("keeps an earlier deadline of the caller", func() {
+			client := diego.NewTimeoutLocketClient(fakeLocketClient, time.Minute)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			_, err := client.Lock(ctx, &models.LockRequest{})
+			Expect(err).NotTo(HaveOccurred())
+
+			lockCtx, _, _ := fakeLocketClient.LockArgsForCall(0)
+			deadline, _ := lockCtx.Deadline()
+			Expect(deadline).To(BeTemporally("~", time.Now().Add(time.Second), time.Second))
+		})
+	})
+})
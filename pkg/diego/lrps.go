@@ -0,0 +1,201 @@
+package diego
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/lager"
+)
+
+func ActualLRPs(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, filter models.ActualLRPFilter) ([]*models.ActualLRP, error) {
+	var actualLRPs []*models.ActualLRP
+	err := call(ctx, bbsClient, func(client bbs.Client) error {
+		var err error
+		actualLRPs, err = client.ActualLRPs(logger, filter)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return actualLRPs, nil
+}
+
+func ActualLRPGroups(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, filter models.ActualLRPFilter) ([]*models.ActualLRPGroup, error) {
+	var groups []*models.ActualLRPGroup
+	err := call(ctx, bbsClient, func(client bbs.Client) error {
+		var err error
+		groups, err = client.ActualLRPGroups(logger, filter)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func ActualLRPGroupsByProcessGuid(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, processGuid string) ([]*models.ActualLRPGroup, error) {
+	var groups []*models.ActualLRPGroup
+	err := call(ctx, bbsClient, func(client bbs.Client) error {
+		var err error
+		groups, err = client.ActualLRPGroupsByProcessGuid(logger, processGuid)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func ActualLRPGroupByProcessGuidAndIndex(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, processGuid string, index int) (*models.ActualLRPGroup, error) {
+	var group *models.ActualLRPGroup
+	err := call(ctx, bbsClient, func(client bbs.Client) error {
+		var err error
+		group, err = client.ActualLRPGroupByProcessGuidAndIndex(logger, processGuid, index)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+// RetireActualLRP looks up the domain of the desired LRP and retires the
+// actual LRP at the given index.
+func RetireActualLRP(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, processGuid string, index int32) error {
+	desiredLRP, err := DesiredLRP(ctx, logger, bbsClient, processGuid)
+	if err != nil {
+		return err
+	}
+
+	actualLRPKey := models.ActualLRPKey{ProcessGuid: processGuid, Index: index, Domain: desiredLRP.Domain}
+	return mutate(ctx, bbsClient, func(client bbs.Client) error {
+		return client.RetireActualLRP(logger, &actualLRPKey)
+	})
+}
+
+func DesiredLRP(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, processGuid string) (*models.DesiredLRP, error) {
+	var desiredLRP *models.DesiredLRP
+	err := call(ctx, bbsClient, func(client bbs.Client) error {
+		var err error
+		desiredLRP, err = client.DesiredLRPByProcessGuid(logger, processGuid)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return desiredLRP, nil
+}
+
+func DesiredLRPs(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, filter models.DesiredLRPFilter) ([]*models.DesiredLRP, error) {
+	var desiredLRPs []*models.DesiredLRP
+	err := call(ctx, bbsClient, func(client bbs.Client) error {
+		var err error
+		desiredLRPs, err = client.DesiredLRPs(logger, filter)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return desiredLRPs, nil
+}
+
+func DesiredLRPSchedulingInfos(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, filter models.DesiredLRPFilter) ([]*models.DesiredLRPSchedulingInfo, error) {
+	var infos []*models.DesiredLRPSchedulingInfo
+	err := call(ctx, bbsClient, func(client bbs.Client) error {
+		var err error
+		infos, err = client.DesiredLRPSchedulingInfos(logger, filter)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+func DesireLRP(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, desiredLRP *models.DesiredLRP) error {
+	return mutate(ctx, bbsClient, func(client bbs.Client) error {
+		return client.DesireLRP(logger, desiredLRP)
+	})
+}
+
+func UpdateDesiredLRP(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, processGuid string, update *models.DesiredLRPUpdate) error {
+	return mutate(ctx, bbsClient, func(client bbs.Client) error {
+		return client.UpdateDesiredLRP(logger, processGuid, update)
+	})
+}
+
+func RemoveDesiredLRP(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, processGuid string) error {
+	return mutate(ctx, bbsClient, func(client bbs.Client) error {
+		return client.RemoveDesiredLRP(logger, processGuid)
+	})
+}
+
+// InstanceCrashedError is returned by WaitForRunningInstances when an
+// instance crashed before all of them were running.
+type InstanceCrashedError struct {
+	ProcessGuid string
+	Index       int32
+	CrashReason string
+}
+
+func (e *InstanceCrashedError) Error() string {
+	if e.CrashReason == "" {
+		return fmt.Sprintf("Instance %d of %s crashed", e.Index, e.ProcessGuid)
+	}
+	return fmt.Sprintf("Instance %d of %s crashed: %s", e.Index, e.ProcessGuid, e.CrashReason)
+}
+
+// WaitForRunningInstances polls the actual LRPs of processGuid every interval
+// until its instances are running, passing the number running to progress
+// after every poll. Only the ordinary instances of the first instances
+// indices count, as evacuating or suspect ones are about to go away and
+// higher indices are being stopped. It returns an *InstanceCrashedError as
+// soon as one of them crashed, and ctx.Err() once ctx is done.
+func WaitForRunningInstances(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, processGuid string, instances int, interval time.Duration, progress func(running int)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		actualLRPs, err := ActualLRPs(ctx, logger, bbsClient, models.ActualLRPFilter{ProcessGuid: processGuid})
+		if err != nil {
+			return err
+		}
+
+		runningIndices := map[int32]bool{}
+		for _, actualLRP := range actualLRPs {
+			if actualLRP.Presence != models.ActualLRP_Ordinary || int(actualLRP.Index) >= instances {
+				continue
+			}
+
+			switch actualLRP.State {
+			case models.ActualLRPStateRunning:
+				runningIndices[actualLRP.Index] = true
+			case models.ActualLRPStateCrashed:
+				return &InstanceCrashedError{ProcessGuid: processGuid, Index: actualLRP.Index, CrashReason: actualLRP.CrashReason}
+			}
+		}
+
+		if progress != nil {
+			progress(len(runningIndices))
+		}
+		if len(runningIndices) >= instances {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
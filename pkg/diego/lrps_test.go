@@ -0,0 +1,124 @@
+package diego_test
+
+import (
+	"context"
+	"time"
+
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LRPs", func() {
+	var (
+		fakeBBSClient *fake_bbs.FakeClient
+		logger        *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		fakeBBSClient = &fake_bbs.FakeClient{}
+		logger = lagertest.NewTestLogger("diego")
+	})
+
+	Context("WaitForRunningInstances", func() {
+		actualLRP := func(index int32, state string) *models.ActualLRP {
+			return &models.ActualLRP{
+				ActualLRPKey: models.NewActualLRPKey("process-guid", index, "domain"),
+				State:        state,
+				Presence:     models.ActualLRP_Ordinary,
+			}
+		}
+
+		BeforeEach(func() {
+			fakeBBSClient.ActualLRPsReturnsOnCall(0, []*models.ActualLRP{
+				actualLRP(0, models.ActualLRPStateUnclaimed),
+				actualLRP(1, models.ActualLRPStateClaimed),
+			}, nil)
+			fakeBBSClient.ActualLRPsReturnsOnCall(1, []*models.ActualLRP{
+				actualLRP(0, models.ActualLRPStateRunning),
+				actualLRP(1, models.ActualLRPStateClaimed),
+			}, nil)
+			fakeBBSClient.ActualLRPsReturnsOnCall(2, []*models.ActualLRP{
+				actualLRP(0, models.ActualLRPStateRunning),
+				actualLRP(1, models.ActualLRPStateRunning),
+			}, nil)
+		})
+
+		It("polls until enough instances are running", func() {
+			var progress []int
+			err := diego.WaitForRunningInstances(context.Background(), logger, fakeBBSClient, "process-guid", 2, time.Millisecond, func(running int) {
+				progress = append(progress, running)
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(progress).To(Equal([]int{0, 1, 2}))
+
+			_, filter := fakeBBSClient.ActualLRPsArgsForCall(0)
+			Expect(filter).To(Equal(models.ActualLRPFilter{ProcessGuid: "process-guid"}))
+		})
+
+		It("counts every index once", func() {
+			fakeBBSClient.ActualLRPsReturnsOnCall(0, []*models.ActualLRP{
+				actualLRP(0, models.ActualLRPStateRunning),
+				actualLRP(0, models.ActualLRPStateRunning),
+			}, nil)
+
+			var progress []int
+			err := diego.WaitForRunningInstances(context.Background(), logger, fakeBBSClient, "process-guid", 2, time.Millisecond, func(running int) {
+				progress = append(progress, running)
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(progress).To(Equal([]int{1, 1, 2}))
+		})
+
+		It("counts only the ordinary instances of the desired indices", func() {
+			evacuating := actualLRP(1, models.ActualLRPStateRunning)
+			evacuating.Presence = models.ActualLRP_Evacuating
+			fakeBBSClient.ActualLRPsReturnsOnCall(0, []*models.ActualLRP{
+				actualLRP(0, models.ActualLRPStateRunning),
+				evacuating,
+				actualLRP(2, models.ActualLRPStateRunning),
+				actualLRP(3, models.ActualLRPStateCrashed),
+			}, nil)
+
+			var progress []int
+			err := diego.WaitForRunningInstances(context.Background(), logger, fakeBBSClient, "process-guid", 2, time.Millisecond, func(running int) {
+				progress = append(progress, running)
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(progress).To(Equal([]int{1, 1, 2}))
+		})
+
+		It("fails once an instance crashed", func() {
+			crashed := actualLRP(1, models.ActualLRPStateCrashed)
+			crashed.CrashReason = "APP/PROC/WEB: Exited with status 1"
+			fakeBBSClient.ActualLRPsReturnsOnCall(1, []*models.ActualLRP{
+				actualLRP(0, models.ActualLRPStateRunning),
+				crashed,
+			}, nil)
+
+			err := diego.WaitForRunningInstances(context.Background(), logger, fakeBBSClient, "process-guid", 2, time.Millisecond, nil)
+			Expect(err).To(Equal(&diego.InstanceCrashedError{ProcessGuid: "process-guid", Index: 1, CrashReason: "APP/PROC/WEB: Exited with status 1"}))
+			Expect(err).To(MatchError("Instance 1 of process-guid crashed: APP/PROC/WEB: Exited with status 1"))
+			Expect(fakeBBSClient.ActualLRPsCallCount()).To(Equal(2))
+		})
+
+		It("returns the BBS error", func() {
+			fakeBBSClient.ActualLRPsReturnsOnCall(1, nil, models.ErrUnknownError)
+
+			err := diego.WaitForRunningInstances(context.Background(), logger, fakeBBSClient, "process-guid", 2, time.Millisecond, nil)
+			Expect(err).To(Equal(models.ErrUnknownError))
+		})
+
+		It("stops waiting once the context is done", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			err := diego.WaitForRunningInstances(ctx, logger, fakeBBSClient, "process-guid", 3, time.Hour, nil)
+			Expect(err).To(Equal(context.DeadlineExceeded))
+		})
+	})
+})
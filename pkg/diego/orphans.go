@@ -0,0 +1,133 @@
+package diego
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/lager"
+	locketmodels "code.cloudfoundry.org/locket/models"
+)
+
+// OrphanedInstance is an actual LRP instance placed on a cell that is no
+// longer live. AgeMs is how long ago the instance last changed state, which
+// is how long it has been in its current state on that cell.
+type OrphanedInstance struct {
+	ProcessGuid string `json:"process_guid"`
+	Index       int32  `json:"index"`
+	Domain      string `json:"domain"`
+	CellID      string `json:"cell_id"`
+	State       string `json:"state"`
+	Evacuating  bool   `json:"evacuating,omitempty"`
+	AgeMs       int64  `json:"age_ms"`
+}
+
+// OrphanedTask is a running task placed on a cell that is no longer live.
+// AgeMs is how long ago the task was last updated, which for a running task
+// is when it started running on that cell.
+type OrphanedTask struct {
+	TaskGuid string `json:"task_guid"`
+	Domain   string `json:"domain"`
+	CellID   string `json:"cell_id"`
+	AgeMs    int64  `json:"age_ms"`
+}
+
+// Orphans is the result of FindOrphans. Instances and Tasks are sorted by
+// cell, then by age, the oldest first.
+type Orphans struct {
+	LiveCells int                `json:"live_cells"`
+	Instances []OrphanedInstance `json:"instances"`
+	Tasks     []OrphanedTask     `json:"tasks"`
+}
+
+// LiveCells returns the IDs of the cells registered in the BBS. When
+// locketClient is not nil, a cell is only live if it also holds a presence
+// in Locket.
+func LiveCells(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, locketClient locketmodels.LocketClient) (map[string]bool, error) {
+	cells, err := Cells(ctx, logger, bbsClient)
+	if err != nil {
+		return nil, err
+	}
+
+	var present map[string]bool
+	if locketClient != nil {
+		presences, err := Presences(ctx, locketClient)
+		if err != nil {
+			return nil, err
+		}
+		present = map[string]bool{}
+		for _, presence := range presences {
+			present[presence.Key] = true
+		}
+	}
+
+	live := map[string]bool{}
+	for _, cell := range cells {
+		if present == nil || present[cell.CellId] {
+			live[cell.CellId] = true
+		}
+	}
+	return live, nil
+}
+
+// FindOrphans returns the actual LRPs and the running tasks of the given
+// lists that are placed on a cell missing from liveCells, with ages relative
+// to now. Instances and tasks not placed on any cell are not orphans.
+func FindOrphans(actualLRPs []*models.ActualLRP, tasks []*models.Task, liveCells map[string]bool, now time.Time) Orphans {
+	orphans := Orphans{LiveCells: len(liveCells), Instances: []OrphanedInstance{}, Tasks: []OrphanedTask{}}
+
+	for _, actualLRP := range actualLRPs {
+		if actualLRP.CellId == "" || liveCells[actualLRP.CellId] {
+			continue
+		}
+		orphans.Instances = append(orphans.Instances, OrphanedInstance{
+			ProcessGuid: actualLRP.ProcessGuid,
+			Index:       actualLRP.Index,
+			Domain:      actualLRP.Domain,
+			CellID:      actualLRP.CellId,
+			State:       actualLRP.State,
+			Evacuating:  actualLRP.Presence == models.ActualLRP_Evacuating,
+			AgeMs:       now.Sub(time.Unix(0, actualLRP.Since)).Milliseconds(),
+		})
+	}
+
+	for _, task := range tasks {
+		if task.State != models.Task_Running || task.CellId == "" || liveCells[task.CellId] {
+			continue
+		}
+		orphans.Tasks = append(orphans.Tasks, OrphanedTask{
+			TaskGuid: task.TaskGuid,
+			Domain:   task.Domain,
+			CellID:   task.CellId,
+			AgeMs:    now.Sub(time.Unix(0, task.UpdatedAt)).Milliseconds(),
+		})
+	}
+
+	sort.Slice(orphans.Instances, func(i, j int) bool {
+		a, b := orphans.Instances[i], orphans.Instances[j]
+		if a.CellID != b.CellID {
+			return a.CellID < b.CellID
+		}
+		if a.AgeMs != b.AgeMs {
+			return a.AgeMs > b.AgeMs
+		}
+		if a.ProcessGuid != b.ProcessGuid {
+			return a.ProcessGuid < b.ProcessGuid
+		}
+		return a.Index < b.Index
+	})
+	sort.Slice(orphans.Tasks, func(i, j int) bool {
+		a, b := orphans.Tasks[i], orphans.Tasks[j]
+		if a.CellID != b.CellID {
+			return a.CellID < b.CellID
+		}
+		if a.AgeMs != b.AgeMs {
+			return a.AgeMs > b.AgeMs
+		}
+		return a.TaskGuid < b.TaskGuid
+	})
+
+	return orphans
+}
@@ -0,0 +1,138 @@
+package diego_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"code.cloudfoundry.org/lager/lagertest"
+	locketmodels "code.cloudfoundry.org/locket/models"
+	"code.cloudfoundry.org/locket/models/modelsfakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Orphans", func() {
+	Context("LiveCells", func() {
+		var (
+			fakeBBSClient    *fake_bbs.FakeClient
+			fakeLocketClient *modelsfakes.FakeLocketClient
+			logger           *lagertest.TestLogger
+		)
+
+		BeforeEach(func() {
+			logger = lagertest.NewTestLogger("diego")
+
+			fakeBBSClient = &fake_bbs.FakeClient{}
+			fakeBBSClient.CellsReturns([]*models.CellPresence{
+				{CellId: "cell-1"},
+				{CellId: "cell-2"},
+			}, nil)
+
+			fakeLocketClient = &modelsfakes.FakeLocketClient{}
+			fakeLocketClient.FetchAllReturns(&locketmodels.FetchAllResponse{
+				Resources: []*locketmodels.Resource{
+					{Key: "cell-2", TypeCode: locketmodels.PRESENCE},
+					{Key: "cell-3", TypeCode: locketmodels.PRESENCE},
+				},
+			}, nil)
+		})
+
+		It("returns the cells registered in the BBS", func() {
+			live, err := diego.LiveCells(context.Background(), logger, fakeBBSClient, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(live).To(Equal(map[string]bool{"cell-1": true, "cell-2": true}))
+			Expect(fakeLocketClient.FetchAllCallCount()).To(Equal(0))
+		})
+
+		It("only keeps the registered cells with a Locket presence when given a Locket client", func() {
+			live, err := diego.LiveCells(context.Background(), logger, fakeBBSClient, fakeLocketClient)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(live).To(Equal(map[string]bool{"cell-2": true}))
+
+			_, req, _ := fakeLocketClient.FetchAllArgsForCall(0)
+			Expect(req.TypeCode).To(Equal(locketmodels.PRESENCE))
+		})
+
+		It("returns the error of fetching the presences", func() {
+			fakeLocketClient.FetchAllReturns(nil, errors.New("boom"))
+			_, err := diego.LiveCells(context.Background(), logger, fakeBBSClient, fakeLocketClient)
+			Expect(err).To(MatchError("boom"))
+		})
+	})
+
+	Context("FindOrphans", func() {
+		var now time.Time
+
+		instance := func(processGuid string, index int32, cellID string, age time.Duration) *models.ActualLRP {
+			return &models.ActualLRP{
+				ActualLRPKey:         models.NewActualLRPKey(processGuid, index, "cf-apps"),
+				ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-guid", cellID),
+				State:                models.ActualLRPStateRunning,
+				Since:                now.Add(-age).UnixNano(),
+			}
+		}
+
+		task := func(taskGuid, cellID string, state models.Task_State, age time.Duration) *models.Task {
+			return &models.Task{
+				TaskGuid:  taskGuid,
+				Domain:    "cf-tasks",
+				CellId:    cellID,
+				State:     state,
+				UpdatedAt: now.Add(-age).UnixNano(),
+			}
+		}
+
+		BeforeEach(func() {
+			now = time.Unix(1600000000, 0)
+		})
+
+		It("returns the instances and running tasks placed on cells that are not live", func() {
+			evacuating := instance("app-2", 0, "cell-gone", time.Hour)
+			evacuating.Presence = models.ActualLRP_Evacuating
+			unclaimed := instance("app-3", 0, "", time.Hour)
+			unclaimed.State = models.ActualLRPStateUnclaimed
+
+			orphans := diego.FindOrphans(
+				[]*models.ActualLRP{
+					instance("app-1", 0, "cell-1", time.Hour),
+					instance("app-1", 1, "cell-gone", time.Minute),
+					evacuating,
+					unclaimed,
+				},
+				[]*models.Task{
+					task("task-1", "cell-1", models.Task_Running, time.Hour),
+					task("task-2", "cell-gone", models.Task_Running, 10*time.Minute),
+					task("task-3", "cell-gone", models.Task_Completed, time.Hour),
+					task("task-4", "", models.Task_Pending, time.Hour),
+				},
+				map[string]bool{"cell-1": true},
+				now,
+			)
+
+			Expect(orphans.LiveCells).To(Equal(1))
+			Expect(orphans.Instances).To(Equal([]diego.OrphanedInstance{
+				{ProcessGuid: "app-2", Index: 0, Domain: "cf-apps", CellID: "cell-gone", State: models.ActualLRPStateRunning, Evacuating: true, AgeMs: 3600000},
+				{ProcessGuid: "app-1", Index: 1, Domain: "cf-apps", CellID: "cell-gone", State: models.ActualLRPStateRunning, AgeMs: 60000},
+			}))
+			Expect(orphans.Tasks).To(Equal([]diego.OrphanedTask{
+				{TaskGuid: "task-2", Domain: "cf-tasks", CellID: "cell-gone", AgeMs: 600000},
+			}))
+		})
+
+		It("returns no orphans when every cell is live", func() {
+			orphans := diego.FindOrphans(
+				[]*models.ActualLRP{instance("app-1", 0, "cell-1", time.Hour)},
+				[]*models.Task{task("task-1", "cell-1", models.Task_Running, time.Hour)},
+				map[string]bool{"cell-1": true},
+				now,
+			)
+			Expect(orphans.Instances).To(BeEmpty())
+			Expect(orphans.Tasks).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1 @@
+package diego // import "code.cloudfoundry.org/cfdot/pkg/diego"
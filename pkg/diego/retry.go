@@ -0,0 +1,122 @@
+package diego
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	"net/url"
+	"regexp"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/lager"
+)
+
+// RetryPolicy controls how BBS requests that fail with a retryable error are
+// retried. Reads are retried up to MaxAttempts times in total; mutations are
+// only retried when RetryMutations is set, since a mutation whose response was
+// lost may already have been applied.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	RetryMutations bool
+}
+
+// DefaultRetryPolicy retries reads three times, backing off from half a second
+// up to five seconds.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+}
+
+// Backoff returns how long to wait after the given failed attempt, counting
+// from 1. The wait doubles with every attempt up to MaxBackoff, and a random
+// jitter of up to half the wait is subtracted so that concurrent clients do not
+// retry in lockstep.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || backoff < p.MaxBackoff); i++ {
+		backoff *= 2
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+
+	half := int64(backoff / 2)
+	if half <= 0 {
+		return backoff
+	}
+	return backoff - time.Duration(rand.Int63n(half+1))
+}
+
+var serverErrorStatus = regexp.MustCompile(`status code: 5\d\d`)
+
+// IsRetryable reports whether err is likely to be transient, such as a refused
+// connection or a timeout while the BBS fails over to a new leader, as opposed
+// to a failure that would recur, like a missing resource or an invalid request.
+func IsRetryable(err error) bool {
+	switch err := err.(type) {
+	case nil:
+		return false
+	case *models.Error:
+		switch err.Type {
+		case models.Error_Timeout, models.Error_Deadlock, models.Error_RouterError:
+			return true
+		case models.Error_InvalidResponse:
+			return serverErrorStatus.MatchString(err.Message)
+		default:
+			return false
+		}
+	case *url.Error:
+		return IsRetryable(err.Err)
+	case net.Error:
+		return true
+	}
+
+	return err == io.EOF || err == io.ErrUnexpectedEOF
+}
+
+// NewRetryingBBSClient wraps client so that the requests cfdot makes are
+// retried according to policy.
+func NewRetryingBBSClient(client bbs.Client, policy RetryPolicy) bbs.Client {
+	r := retrier{policy: policy}
+	return &interceptedBBSClient{
+		Client: client,
+		intercept: func(ctx context.Context, logger lager.Logger, mutation bool, send func(bbs.Client) error) error {
+			return r.do(ctx, logger, mutation, func() error { return send(client) })
+		},
+	}
+}
+
+type retrier struct {
+	policy RetryPolicy
+}
+
+// do calls f until it succeeds, fails with an error that is not retryable or
+// runs out of attempts. It stops waiting between attempts with ctx.Err() once
+// ctx is done.
+func (r retrier) do(ctx context.Context, logger lager.Logger, mutation bool, f func() error) error {
+	attempts := r.policy.MaxAttempts
+	if mutation && !r.policy.RetryMutations {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil || attempt >= attempts || !IsRetryable(err) {
+			return err
+		}
+
+		backoff := r.policy.Backoff(attempt)
+		logger.Info("retrying-bbs-request", lager.Data{"attempt": attempt, "error": err.Error(), "backoff": backoff.String()})
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
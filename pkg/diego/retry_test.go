@@ -0,0 +1,125 @@
+package diego_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"time"
+
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Retry", func() {
+	Context("IsRetryable", func() {
+		It("retries transient failures", func() {
+			Expect(diego.IsRetryable(models.NewError(models.Error_Timeout, "timed out"))).To(BeTrue())
+			Expect(diego.IsRetryable(models.ErrDeadlock)).To(BeTrue())
+			Expect(diego.IsRetryable(models.NewError(models.Error_RouterError, "no endpoints"))).To(BeTrue())
+			Expect(diego.IsRetryable(models.NewError(models.Error_InvalidResponse, "Invalid Response with status code: 503"))).To(BeTrue())
+			Expect(diego.IsRetryable(&url.Error{Op: "Post", URL: "https://bbs", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}})).To(BeTrue())
+			Expect(diego.IsRetryable(io.EOF)).To(BeTrue())
+		})
+
+		It("does not retry failures that would recur", func() {
+			Expect(diego.IsRetryable(nil)).To(BeFalse())
+			Expect(diego.IsRetryable(models.NewError(models.Error_InvalidResponse, "Invalid Response with status code: 404"))).To(BeFalse())
+			Expect(diego.IsRetryable(models.ErrResourceNotFound)).To(BeFalse())
+			Expect(diego.IsRetryable(models.ErrBadRequest)).To(BeFalse())
+			Expect(diego.IsRetryable(&url.Error{Op: "Post", URL: "https://bbs", Err: errors.New("x509: certificate signed by unknown authority")})).To(BeFalse())
+			Expect(diego.IsRetryable(errors.New("boom"))).To(BeFalse())
+		})
+	})
+
+	Context("Backoff", func() {
+		It("doubles the wait up to the maximum, minus up to half of it as jitter", func() {
+			policy := diego.RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 3 * time.Second}
+
+			Expect(policy.Backoff(1)).To(BeNumerically("~", 750*time.Millisecond, 250*time.Millisecond))
+			Expect(policy.Backoff(2)).To(BeNumerically("~", 1500*time.Millisecond, 500*time.Millisecond))
+			Expect(policy.Backoff(10)).To(BeNumerically("~", 2250*time.Millisecond, 750*time.Millisecond))
+		})
+	})
+
+	Context("NewRetryingBBSClient", func() {
+		var (
+			fakeBBSClient *fake_bbs.FakeClient
+			logger        *lagertest.TestLogger
+			policy        diego.RetryPolicy
+		)
+
+		BeforeEach(func() {
+			fakeBBSClient = &fake_bbs.FakeClient{}
+			logger = lagertest.NewTestLogger("retry")
+			policy = diego.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+		})
+
+		It("retries reads that fail with a retryable error", func() {
+			fakeBBSClient.DomainsReturnsOnCall(0, nil, models.ErrDeadlock)
+			fakeBBSClient.DomainsReturnsOnCall(1, []string{"domain"}, nil)
+
+			domains, err := diego.NewRetryingBBSClient(fakeBBSClient, policy).Domains(logger)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(domains).To(Equal([]string{"domain"}))
+			Expect(fakeBBSClient.DomainsCallCount()).To(Equal(2))
+			Expect(logger).To(gbytes.Say("retrying-bbs-request"))
+		})
+
+		It("gives up after the maximum number of attempts", func() {
+			fakeBBSClient.CellsReturns(nil, models.ErrDeadlock)
+
+			_, err := diego.NewRetryingBBSClient(fakeBBSClient, policy).Cells(logger)
+			Expect(err).To(Equal(models.ErrDeadlock))
+			Expect(fakeBBSClient.CellsCallCount()).To(Equal(3))
+		})
+
+		It("does not retry errors that would recur", func() {
+			fakeBBSClient.TaskByGuidReturns(nil, models.ErrResourceNotFound)
+
+			_, err := diego.NewRetryingBBSClient(fakeBBSClient, policy).TaskByGuid(logger, "task-guid")
+			Expect(err).To(Equal(models.ErrResourceNotFound))
+			Expect(fakeBBSClient.TaskByGuidCallCount()).To(Equal(1))
+		})
+
+		It("does not retry mutations by default", func() {
+			fakeBBSClient.CancelTaskReturns(models.ErrDeadlock)
+
+			err := diego.NewRetryingBBSClient(fakeBBSClient, policy).CancelTask(logger, "task-guid")
+			Expect(err).To(Equal(models.ErrDeadlock))
+			Expect(fakeBBSClient.CancelTaskCallCount()).To(Equal(1))
+		})
+
+		It("retries mutations when asked to", func() {
+			policy.RetryMutations = true
+			fakeBBSClient.CancelTaskReturnsOnCall(0, models.ErrDeadlock)
+
+			err := diego.NewRetryingBBSClient(fakeBBSClient, policy).CancelTask(logger, "task-guid")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeBBSClient.CancelTaskCallCount()).To(Equal(2))
+		})
+
+		It("stops backing off once the context of the request is done", func() {
+			policy = diego.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour, MaxBackoff: time.Hour, RetryMutations: true}
+			fakeBBSClient.CancelTaskReturns(models.ErrDeadlock)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- diego.CancelTask(ctx, logger, diego.NewRetryingBBSClient(fakeBBSClient, policy), "task-guid")
+			}()
+
+			Eventually(fakeBBSClient.CancelTaskCallCount).Should(Equal(1))
+			cancel()
+			Eventually(errCh).Should(Receive(Equal(context.Canceled)))
+			Expect(fakeBBSClient.CancelTaskCallCount()).To(Equal(1))
+		})
+	})
+})
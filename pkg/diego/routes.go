@@ -0,0 +1,219 @@
+package diego
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/bbs/models"
+)
+
+// CFRouter is the key of the routes of the cf-router in the Routes of a
+// desired LRP.
+const CFRouter = "cf-router"
+
+// CFRoute is a hostname routed by the cf-router to a port of a desired LRP.
+type CFRoute struct {
+	Hostname string
+	Port     uint32
+}
+
+func (r CFRoute) String() string {
+	return fmt.Sprintf("%s:%s:%d", CFRouter, r.Hostname, r.Port)
+}
+
+// ParseCFRoute parses a route given as cf-router:HOSTNAME:PORT.
+func ParseCFRoute(route string) (CFRoute, error) {
+	parts := strings.Split(route, ":")
+	if len(parts) != 3 || parts[0] != CFRouter || parts[1] == "" {
+		return CFRoute{}, fmt.Errorf("Invalid route '%s', expected cf-router:HOSTNAME:PORT", route)
+	}
+
+	port, err := strconv.ParseUint(parts[2], 10, 16)
+	if err != nil || port == 0 {
+		return CFRoute{}, fmt.Errorf("Invalid port in route '%s'", route)
+	}
+
+	return CFRoute{Hostname: parts[1], Port: uint32(port)}, nil
+}
+
+// EditCFRoutes returns the routes of lrp with the routes in add added to, and
+// those in remove removed from, the routes of the cf-router. The routes of
+// other routers and any other field of the cf-router routes are kept as
+// they are. The routes of lrp are not modified.
+func EditCFRoutes(lrp *models.DesiredLRP, add, remove []CFRoute) (*models.Routes, error) {
+	routes := models.Routes{}
+	if lrp.Routes != nil {
+		for router, raw := range *lrp.Routes {
+			routes[router] = raw
+		}
+	}
+
+	var entries []map[string]json.RawMessage
+	if raw := routes[CFRouter]; raw != nil {
+		if err := json.Unmarshal(*raw, &entries); err != nil {
+			return nil, fmt.Errorf("Invalid %s routes: %s", CFRouter, err)
+		}
+	}
+
+	for _, route := range remove {
+		var err error
+		entries, err = removeCFRoute(entries, route)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, route := range add {
+		if !hasPort(lrp.Ports, route.Port) {
+			return nil, fmt.Errorf("Cannot add route %s: %d is not one of the ports of the desired LRP", route, route.Port)
+		}
+
+		var err error
+		entries, err = addCFRoute(entries, route)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if entries == nil {
+		entries = []map[string]json.RawMessage{}
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+	raw := json.RawMessage(data)
+	routes[CFRouter] = &raw
+
+	return &routes, nil
+}
+
+// CFRoutes returns the routes of the cf-router of lrp, in the order of its
+// route entries.
+func CFRoutes(lrp *models.DesiredLRP) ([]CFRoute, error) {
+	if lrp.Routes == nil || (*lrp.Routes)[CFRouter] == nil {
+		return nil, nil
+	}
+
+	var entries []map[string]json.RawMessage
+	if err := json.Unmarshal(*(*lrp.Routes)[CFRouter], &entries); err != nil {
+		return nil, fmt.Errorf("Invalid %s routes: %s", CFRouter, err)
+	}
+
+	var routes []CFRoute
+	for _, entry := range entries {
+		hostnames, port, err := decodeCFRouteEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		for _, hostname := range hostnames {
+			routes = append(routes, CFRoute{Hostname: hostname, Port: port})
+		}
+	}
+	return routes, nil
+}
+
+// addCFRoute adds the hostname of route to the first entry of its port that
+// only holds hostnames and a port, or to a new entry. Entries with other
+// fields, such as a route_service_url, are left alone so that the new
+// hostname does not take on their settings.
+func addCFRoute(entries []map[string]json.RawMessage, route CFRoute) ([]map[string]json.RawMessage, error) {
+	var plain map[string]json.RawMessage
+	for _, entry := range entries {
+		hostnames, port, err := decodeCFRouteEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		if port != route.Port {
+			continue
+		}
+
+		for _, hostname := range hostnames {
+			if hostname == route.Hostname {
+				return entries, nil
+			}
+		}
+		if plain == nil && isPlainCFRouteEntry(entry) {
+			plain = entry
+		}
+	}
+
+	if plain != nil {
+		hostnames, _, err := decodeCFRouteEntry(plain)
+		if err != nil {
+			return nil, err
+		}
+		plain["hostnames"], err = json.Marshal(append(hostnames, route.Hostname))
+		return entries, err
+	}
+
+	hostnames, _ := json.Marshal([]string{route.Hostname})
+	port, _ := json.Marshal(route.Port)
+	return append(entries, map[string]json.RawMessage{"hostnames": hostnames, "port": port}), nil
+}
+
+func isPlainCFRouteEntry(entry map[string]json.RawMessage) bool {
+	for key := range entry {
+		if key != "hostnames" && key != "port" {
+			return false
+		}
+	}
+	return true
+}
+
+func removeCFRoute(entries []map[string]json.RawMessage, route CFRoute) ([]map[string]json.RawMessage, error) {
+	removed := false
+	kept := entries[:0]
+
+	for _, entry := range entries {
+		hostnames, port, err := decodeCFRouteEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		if port == route.Port {
+			remaining := []string{}
+			for _, hostname := range hostnames {
+				if hostname == route.Hostname {
+					removed = true
+				} else {
+					remaining = append(remaining, hostname)
+				}
+			}
+			if len(remaining) == 0 {
+				continue
+			}
+			entry["hostnames"], err = json.Marshal(remaining)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		kept = append(kept, entry)
+	}
+
+	if !removed {
+		return nil, fmt.Errorf("Cannot remove route %s: the desired LRP has no such route", route)
+	}
+	return kept, nil
+}
+
+func decodeCFRouteEntry(entry map[string]json.RawMessage) ([]string, uint32, error) {
+	var hostnames []string
+	var port uint32
+
+	if raw, ok := entry["hostnames"]; ok {
+		if err := json.Unmarshal(raw, &hostnames); err != nil {
+			return nil, 0, fmt.Errorf("Invalid %s routes: %s", CFRouter, err)
+		}
+	}
+	if raw, ok := entry["port"]; ok {
+		if err := json.Unmarshal(raw, &port); err != nil {
+			return nil, 0, fmt.Errorf("Invalid %s routes: %s", CFRouter, err)
+		}
+	}
+
+	return hostnames, port, nil
+}
@@ -0,0 +1,138 @@
+package diego_test
+
+import (
+	"encoding/json"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Routes", func() {
+	Context("ParseCFRoute", func() {
+		It("parses a cf-router route", func() {
+			route, err := diego.ParseCFRoute("cf-router:app.example.com:8080")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(route).To(Equal(diego.CFRoute{Hostname: "app.example.com", Port: 8080}))
+			Expect(route.String()).To(Equal("cf-router:app.example.com:8080"))
+		})
+
+		It("rejects malformed routes and ports", func() {
+			_, err := diego.ParseCFRoute("app.example.com:8080")
+			Expect(err).To(MatchError("Invalid route 'app.example.com:8080', expected cf-router:HOSTNAME:PORT"))
+
+			_, err = diego.ParseCFRoute("cf-router:app.example.com:70000")
+			Expect(err).To(MatchError("Invalid port in route 'cf-router:app.example.com:70000'"))
+		})
+	})
+
+	Context("EditCFRoutes", func() {
+		var (
+			lrp            *models.DesiredLRP
+			sshRoutes      json.RawMessage
+			cfRoutesBefore string
+		)
+
+		cfRoutes := func(routes *models.Routes) string {
+			return string(*(*routes)[diego.CFRouter])
+		}
+
+		BeforeEach(func() {
+			cfRoutesBefore = `[{"hostnames":["a.example.com","b.example.com"],"port":8080,"route_service_url":"https://rs.example.com"}]`
+			cf := json.RawMessage(cfRoutesBefore)
+			sshRoutes = json.RawMessage(`{"container_port":2222}`)
+			lrp = &models.DesiredLRP{
+				Ports:  []uint32{8080, 9090},
+				Routes: &models.Routes{diego.CFRouter: &cf, "diego-ssh": &sshRoutes},
+			}
+		})
+
+		It("adds hostnames to an entry of their port that holds only hostnames", func() {
+			cf := json.RawMessage(`[{"hostnames":["a.example.com"],"port":8080}]`)
+			(*lrp.Routes)[diego.CFRouter] = &cf
+
+			routes, err := diego.EditCFRoutes(lrp, []diego.CFRoute{{Hostname: "c.example.com", Port: 8080}}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfRoutes(routes)).To(MatchJSON(`[{"hostnames":["a.example.com","c.example.com"],"port":8080}]`))
+			Expect((*routes)["diego-ssh"]).To(Equal(&sshRoutes))
+		})
+
+		It("adds an entry rather than sharing the route_service_url of an entry of their port", func() {
+			routes, err := diego.EditCFRoutes(lrp, []diego.CFRoute{{Hostname: "c.example.com", Port: 8080}}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfRoutes(routes)).To(MatchJSON(`[
+				{"hostnames":["a.example.com","b.example.com"],"port":8080,"route_service_url":"https://rs.example.com"},
+				{"hostnames":["c.example.com"],"port":8080}
+			]`))
+		})
+
+		It("leaves hostnames that already route to their port alone", func() {
+			routes, err := diego.EditCFRoutes(lrp, []diego.CFRoute{{Hostname: "a.example.com", Port: 8080}}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfRoutes(routes)).To(MatchJSON(cfRoutesBefore))
+		})
+
+		It("adds an entry for a port without routes", func() {
+			routes, err := diego.EditCFRoutes(lrp, []diego.CFRoute{{Hostname: "c.example.com", Port: 9090}}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfRoutes(routes)).To(MatchJSON(`[
+				{"hostnames":["a.example.com","b.example.com"],"port":8080,"route_service_url":"https://rs.example.com"},
+				{"hostnames":["c.example.com"],"port":9090}
+			]`))
+		})
+
+		It("removes hostnames and the entries left without any", func() {
+			routes, err := diego.EditCFRoutes(lrp, nil, []diego.CFRoute{{Hostname: "a.example.com", Port: 8080}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfRoutes(routes)).To(MatchJSON(`[{"hostnames":["b.example.com"],"port":8080,"route_service_url":"https://rs.example.com"}]`))
+
+			lrp.Routes = routes
+			routes, err = diego.EditCFRoutes(lrp, nil, []diego.CFRoute{{Hostname: "b.example.com", Port: 8080}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfRoutes(routes)).To(MatchJSON(`[]`))
+		})
+
+		It("does not modify the routes of the desired LRP", func() {
+			_, err := diego.EditCFRoutes(lrp, []diego.CFRoute{{Hostname: "c.example.com", Port: 8080}}, []diego.CFRoute{{Hostname: "a.example.com", Port: 8080}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfRoutes(lrp.Routes)).To(Equal(cfRoutesBefore))
+		})
+
+		It("adds routes to a desired LRP without routes", func() {
+			lrp.Routes = nil
+			routes, err := diego.EditCFRoutes(lrp, []diego.CFRoute{{Hostname: "a.example.com", Port: 8080}}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*routes).To(HaveLen(1))
+			Expect(cfRoutes(routes)).To(MatchJSON(`[{"hostnames":["a.example.com"],"port":8080}]`))
+		})
+
+		It("rejects routes to ports the desired LRP does not expose", func() {
+			_, err := diego.EditCFRoutes(lrp, []diego.CFRoute{{Hostname: "c.example.com", Port: 7070}}, nil)
+			Expect(err).To(MatchError("Cannot add route cf-router:c.example.com:7070: 7070 is not one of the ports of the desired LRP"))
+		})
+
+		It("rejects removing routes the desired LRP does not have", func() {
+			_, err := diego.EditCFRoutes(lrp, nil, []diego.CFRoute{{Hostname: "a.example.com", Port: 9090}})
+			Expect(err).To(MatchError("Cannot remove route cf-router:a.example.com:9090: the desired LRP has no such route"))
+		})
+	})
+
+	Context("CFRoutes", func() {
+		It("lists the hostnames of every cf-router entry with their port", func() {
+			cf := json.RawMessage(`[{"hostnames":["a.example.com","b.example.com"],"port":8080},{"hostnames":["c.example.com"],"port":9090}]`)
+			routes, err := diego.CFRoutes(&models.DesiredLRP{Routes: &models.Routes{diego.CFRouter: &cf}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(routes).To(Equal([]diego.CFRoute{
+				{Hostname: "a.example.com", Port: 8080},
+				{Hostname: "b.example.com", Port: 8080},
+				{Hostname: "c.example.com", Port: 9090},
+			}))
+		})
+
+		It("returns no routes without cf-router routes", func() {
+			Expect(diego.CFRoutes(&models.DesiredLRP{})).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,357 @@
+package diego
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+
+	"code.cloudfoundry.org/bbs/models"
+)
+
+// Severities of a SpecProblem.
+const (
+	SpecError   = "error"
+	SpecWarning = "warning"
+)
+
+// knownRootFSSchemes are the rootfs schemes the Diego cells support.
+var knownRootFSSchemes = []string{"preloaded", "preloaded+layer", "docker"}
+
+// SpecProblem is a problem found in a task or desired LRP spec. The BBS
+// rejects specs with errors, or they fail once running; warnings point to
+// likely mistakes.
+type SpecProblem struct {
+	Field    string `json:"field,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func (p SpecProblem) String() string {
+	if p.Field == "" {
+		return p.Message
+	}
+	return fmt.Sprintf("%s: %s", p.Field, p.Message)
+}
+
+// HasSpecErrors reports whether any of problems is an error.
+func HasSpecErrors(problems []SpecProblem) bool {
+	for _, problem := range problems {
+		if problem.Severity == SpecError {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckTask returns the errors the BBS validation finds in task, with the
+// path of the field of each, followed by warnings about zero resources, an
+// unknown rootfs scheme and an action that runs nothing.
+func CheckTask(task *models.Task) []SpecProblem {
+	if task.TaskDefinition == nil {
+		return validationProblems(nestedErrors("", task.Validate()))
+	}
+
+	problems := validationProblems(nestedErrors("", task.Validate(), actionErrors("action", task.Action)))
+
+	problems = append(problems, resourceProblems(task.MemoryMb, task.DiskMb)...)
+	problems = append(problems, rootFSProblems(task.RootFs)...)
+	if task.Action != nil && !hasRunAction(task.Action) {
+		problems = append(problems, SpecProblem{Field: "action", Severity: SpecWarning, Message: "has no run action, so the task runs nothing"})
+	}
+	return problems
+}
+
+// CheckDesiredLRP returns the errors the BBS validation finds in lrp, with
+// the path of the field of each, and the errors in its routes, followed by
+// warnings about a missing health check, zero resources, an unknown rootfs
+// scheme and an action that runs nothing.
+func CheckDesiredLRP(lrp *models.DesiredLRP) []SpecProblem {
+	var checkDefinitionErrors []fieldError
+	if lrp.CheckDefinition != nil {
+		checkDefinitionErrors = nestedErrors("check_definition", lrp.CheckDefinition.Validate())
+	}
+
+	problems := validationProblems(nestedErrors("", lrp.Validate(),
+		actionErrors("setup", lrp.Setup),
+		actionErrors("action", lrp.Action),
+		actionErrors("monitor", lrp.Monitor),
+		checkDefinitionErrors,
+	))
+	problems = append(problems, routeProblems(lrp)...)
+
+	if lrp.Monitor == nil && (lrp.CheckDefinition == nil || len(lrp.CheckDefinition.Checks) == 0) {
+		problems = append(problems, SpecProblem{Field: "check_definition", Severity: SpecWarning, Message: "has no health check, so instances are running as soon as they start"})
+	}
+	problems = append(problems, resourceProblems(lrp.MemoryMb, lrp.DiskMb)...)
+	problems = append(problems, rootFSProblems(lrp.RootFs)...)
+	if lrp.Action != nil && !hasRunAction(lrp.Action) {
+		problems = append(problems, SpecProblem{Field: "action", Severity: SpecWarning, Message: "has no run action, so instances run nothing"})
+	}
+	return problems
+}
+
+// fieldError is an error returned by the Validate method of a model, with
+// the path of the field of the spec it was found in.
+type fieldError struct {
+	path string
+	err  error
+}
+
+// validationProblems lists errs as errors of the fields they were found in.
+func validationProblems(errs []fieldError) []SpecProblem {
+	var problems []SpecProblem
+	for _, fieldErr := range errs {
+		problem := SpecProblem{Field: fieldErr.path, Severity: SpecError, Message: fieldErr.err.Error()}
+		if invalidField, ok := fieldErr.err.(models.ErrInvalidField); ok {
+			problem.Field, problem.Message = joinField(fieldErr.path, invalidField.Field), "is invalid"
+		}
+		problems = append(problems, problem)
+	}
+	return problems
+}
+
+// nestedErrors returns the errors err, returned by the Validate method of
+// the model at path, holds. The models validate their nested models as well
+// and merge their errors into their own, so the errors found by validating
+// the nested models on their own, in nested, are matched back to keep the
+// path of the nested field they come from.
+func nestedErrors(path string, err error, nested ...[]fieldError) []fieldError {
+	var pending []fieldError
+	for _, errs := range nested {
+		pending = append(pending, errs...)
+	}
+
+	var errs []fieldError
+	for _, err := range flattenValidationError(err) {
+		fieldErr := fieldError{path: path, err: err}
+		for i, nestedErr := range pending {
+			if nestedErr.err.Error() == err.Error() {
+				fieldErr = nestedErr
+				pending = append(pending[:i], pending[i+1:]...)
+				break
+			}
+		}
+		errs = append(errs, fieldErr)
+	}
+	return errs
+}
+
+func flattenValidationError(err error) []error {
+	if err == nil {
+		return nil
+	}
+
+	validationErr, ok := err.(models.ValidationError)
+	if !ok {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, err := range validationErr {
+		errs = append(errs, flattenValidationError(err)...)
+	}
+	return errs
+}
+
+// actionErrors returns the validation errors of the action tree of action,
+// found at path, with the path of the action each comes from, such as
+// action.serial.actions[1].run.path.
+func actionErrors(path string, action *models.Action) []fieldError {
+	if action == nil {
+		return nil
+	}
+
+	var nested [][]fieldError
+	switch {
+	case action.DownloadAction != nil:
+		path += ".download"
+	case action.UploadAction != nil:
+		path += ".upload"
+	case action.RunAction != nil:
+		path += ".run"
+	case action.TimeoutAction != nil:
+		path += ".timeout"
+		nested = append(nested, actionErrors(path+".action", action.TimeoutAction.Action))
+	case action.EmitProgressAction != nil:
+		path += ".emit_progress"
+		nested = append(nested, actionErrors(path+".action", action.EmitProgressAction.Action))
+	case action.TryAction != nil:
+		path += ".try"
+		nested = append(nested, actionErrors(path+".action", action.TryAction.Action))
+	case action.ParallelAction != nil:
+		path += ".parallel"
+		nested = append(nested, actionListErrors(path, action.ParallelAction.Actions))
+	case action.SerialAction != nil:
+		path += ".serial"
+		nested = append(nested, actionListErrors(path, action.SerialAction.Actions))
+	case action.CodependentAction != nil:
+		path += ".codependent"
+		nested = append(nested, actionListErrors(path, action.CodependentAction.Actions))
+	}
+
+	return nestedErrors(path, action.Validate(), nested...)
+}
+
+func actionListErrors(path string, actions []*models.Action) []fieldError {
+	var errs []fieldError
+	for i, action := range actions {
+		errs = append(errs, actionErrors(fmt.Sprintf("%s.actions[%d]", path, i), action)...)
+	}
+	return errs
+}
+
+func joinField(path, field string) string {
+	switch {
+	case path == "":
+		return field
+	case field == "":
+		return path
+	default:
+		return path + "." + field
+	}
+}
+
+func resourceProblems(memoryMB, diskMB int32) []SpecProblem {
+	var problems []SpecProblem
+	if memoryMB == 0 {
+		problems = append(problems, SpecProblem{Field: "memory_mb", Severity: SpecWarning, Message: "is 0, so memory is not limited"})
+	}
+	if diskMB == 0 {
+		problems = append(problems, SpecProblem{Field: "disk_mb", Severity: SpecWarning, Message: "is 0, so disk is not limited"})
+	}
+	return problems
+}
+
+func rootFSProblems(rootFS string) []SpecProblem {
+	rootFSURL, err := url.Parse(rootFS)
+	if err != nil || rootFSURL.Scheme == "" {
+		// Reported by the BBS validation.
+		return nil
+	}
+
+	for _, scheme := range knownRootFSSchemes {
+		if rootFSURL.Scheme == scheme {
+			return nil
+		}
+	}
+	return []SpecProblem{{
+		Field:    "rootfs",
+		Severity: SpecWarning,
+		Message:  fmt.Sprintf("has unknown scheme %q, no cell may be able to run it", rootFSURL.Scheme),
+	}}
+}
+
+// hasRunAction reports whether the action tree of action holds a run action.
+func hasRunAction(action *models.Action) bool {
+	switch {
+	case action == nil:
+		return false
+	case action.RunAction != nil:
+		return true
+	case action.TimeoutAction != nil:
+		return hasRunAction(action.TimeoutAction.Action)
+	case action.EmitProgressAction != nil:
+		return hasRunAction(action.EmitProgressAction.Action)
+	case action.TryAction != nil:
+		return hasRunAction(action.TryAction.Action)
+	case action.ParallelAction != nil:
+		return anyRunAction(action.ParallelAction.Actions)
+	case action.SerialAction != nil:
+		return anyRunAction(action.SerialAction.Actions)
+	case action.CodependentAction != nil:
+		return anyRunAction(action.CodependentAction.Actions)
+	default:
+		return false
+	}
+}
+
+func anyRunAction(actions []*models.Action) bool {
+	for _, action := range actions {
+		if hasRunAction(action) {
+			return true
+		}
+	}
+	return false
+}
+
+type cfRoute struct {
+	Hostnames []string `json:"hostnames"`
+	Port      uint32   `json:"port"`
+}
+
+type tcpRoute struct {
+	ExternalPort  uint32 `json:"external_port"`
+	ContainerPort uint32 `json:"container_port"`
+}
+
+// routeProblems checks that the routes of lrp are valid JSON and that the
+// routes of the cf and tcp routers are well formed, and warns about those that
+// have no hostname or do not go to a port of lrp, which the routers accept but
+// never route any traffic to.
+func routeProblems(lrp *models.DesiredLRP) []SpecProblem {
+	if lrp.Routes == nil {
+		return nil
+	}
+
+	routers := make([]string, 0, len(*lrp.Routes))
+	for router := range *lrp.Routes {
+		routers = append(routers, router)
+	}
+	sort.Strings(routers)
+
+	var problems []SpecProblem
+	invalid := func(field, format string, args ...interface{}) {
+		problems = append(problems, SpecProblem{Field: field, Severity: SpecError, Message: fmt.Sprintf(format, args...)})
+	}
+	warn := func(field, format string, args ...interface{}) {
+		problems = append(problems, SpecProblem{Field: field, Severity: SpecWarning, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, router := range routers {
+		field := "routes." + router
+		raw := (*lrp.Routes)[router]
+		if raw == nil || !json.Valid(*raw) {
+			invalid(field, "is not valid JSON")
+			continue
+		}
+
+		switch router {
+		case "cf-router":
+			var routes []cfRoute
+			if err := json.Unmarshal(*raw, &routes); err != nil {
+				invalid(field, "is not a list of hostnames and ports: %s", err)
+				continue
+			}
+			for i, route := range routes {
+				if len(route.Hostnames) == 0 {
+					warn(fmt.Sprintf("%s[%d].hostnames", field, i), "is empty, so the route matches no request")
+				}
+				if !hasPort(lrp.Ports, route.Port) {
+					warn(fmt.Sprintf("%s[%d].port", field, i), "%d is not one of the ports of the LRP, so the route reaches nothing", route.Port)
+				}
+			}
+		case "tcp-router":
+			var routes []tcpRoute
+			if err := json.Unmarshal(*raw, &routes); err != nil {
+				invalid(field, "is not a list of external and container ports: %s", err)
+				continue
+			}
+			for i, route := range routes {
+				if !hasPort(lrp.Ports, route.ContainerPort) {
+					warn(fmt.Sprintf("%s[%d].container_port", field, i), "%d is not one of the ports of the LRP, so the route reaches nothing", route.ContainerPort)
+				}
+			}
+		}
+	}
+	return problems
+}
+
+func hasPort(ports []uint32, port uint32) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
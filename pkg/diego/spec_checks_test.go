@@ -0,0 +1,147 @@
+package diego_test
+
+import (
+	"encoding/json"
+	"time"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/bbs/models/test/model_helpers"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Spec checks", func() {
+	warning := func(field, message string) diego.SpecProblem {
+		return diego.SpecProblem{Field: field, Severity: diego.SpecWarning, Message: message}
+	}
+
+	Context("CheckTask", func() {
+		var task *models.Task
+
+		BeforeEach(func() {
+			task = &models.Task{
+				TaskGuid:       "task-guid",
+				Domain:         "domain",
+				TaskDefinition: model_helpers.NewValidTaskDefinition(),
+			}
+			task.RootFs = "preloaded:cflinuxfs3"
+			task.MemoryMb, task.DiskMb = 256, 1024
+			task.Action = models.WrapAction(&models.RunAction{Path: "ls", User: "vcap"})
+		})
+
+		It("finds no problem in a valid task", func() {
+			Expect(diego.CheckTask(task)).To(BeEmpty())
+		})
+
+		It("reports the fields the BBS rejects", func() {
+			task.Domain = ""
+
+			problems := diego.CheckTask(task)
+			Expect(problems).To(ContainElement(diego.SpecProblem{Field: "domain", Severity: diego.SpecError, Message: "is invalid"}))
+			Expect(diego.HasSpecErrors(problems)).To(BeTrue())
+		})
+
+		It("reports the path of the fields of nested actions the BBS rejects", func() {
+			task.Action = models.WrapAction(models.Serial(
+				&models.RunAction{Path: "ls", User: "vcap"},
+				models.Timeout(&models.RunAction{User: "vcap"}, time.Minute),
+			))
+
+			Expect(diego.CheckTask(task)).To(ContainElement(
+				diego.SpecProblem{Field: "action.serial.actions[1].timeout.action.run.path", Severity: diego.SpecError, Message: "is invalid"},
+			))
+		})
+
+		It("warns about zero resources, unknown rootfs schemes and actions running nothing", func() {
+			task.MemoryMb = 0
+			task.DiskMb = 0
+			task.RootFs = "oci:///some/image"
+			task.Action = models.WrapAction(models.Serial(&models.DownloadAction{From: "http://example.com", To: "/tmp", User: "vcap"}))
+
+			problems := diego.CheckTask(task)
+			Expect(problems).To(ConsistOf(
+				warning("memory_mb", "is 0, so memory is not limited"),
+				warning("disk_mb", "is 0, so disk is not limited"),
+				warning("rootfs", `has unknown scheme "oci", no cell may be able to run it`),
+				warning("action", "has no run action, so the task runs nothing"),
+			))
+			Expect(diego.HasSpecErrors(problems)).To(BeFalse())
+		})
+	})
+
+	Context("CheckDesiredLRP", func() {
+		var lrp *models.DesiredLRP
+
+		setRoutes := func(router, routes string) {
+			raw := json.RawMessage(routes)
+			lrp.Routes = &models.Routes{router: &raw}
+		}
+
+		BeforeEach(func() {
+			lrp = model_helpers.NewValidDesiredLRP("process-guid")
+			lrp.RootFs = "preloaded:cflinuxfs3"
+			lrp.MemoryMb, lrp.DiskMb = 256, 1024
+			lrp.Ports = []uint32{8080}
+			lrp.Routes = nil
+			lrp.Action = models.WrapAction(&models.RunAction{Path: "ls", User: "vcap"})
+			lrp.Monitor = models.WrapAction(&models.RunAction{Path: "nc", User: "vcap"})
+		})
+
+		It("finds no problem in a valid desired LRP", func() {
+			Expect(diego.CheckDesiredLRP(lrp)).To(BeEmpty())
+		})
+
+		It("tells apart the same errors in different actions", func() {
+			lrp.Action = models.WrapAction(&models.RunAction{User: "vcap"})
+			lrp.Monitor = models.WrapAction(models.Parallel(
+				&models.RunAction{Path: "nc", User: "vcap"},
+				&models.RunAction{User: "vcap"},
+			))
+
+			problems := diego.CheckDesiredLRP(lrp)
+			Expect(problems).To(ContainElement(diego.SpecProblem{Field: "action.run.path", Severity: diego.SpecError, Message: "is invalid"}))
+			Expect(problems).To(ContainElement(diego.SpecProblem{Field: "monitor.parallel.actions[1].run.path", Severity: diego.SpecError, Message: "is invalid"}))
+			Expect(problems).NotTo(ContainElement(diego.SpecProblem{Field: "path", Severity: diego.SpecError, Message: "is invalid"}))
+		})
+
+		It("warns about a missing health check", func() {
+			lrp.Monitor = nil
+			lrp.CheckDefinition = nil
+
+			Expect(diego.CheckDesiredLRP(lrp)).To(ConsistOf(
+				warning("check_definition", "has no health check, so instances are running as soon as they start"),
+			))
+		})
+
+		It("accepts routes to the ports of the LRP", func() {
+			setRoutes("cf-router", `[{"hostnames":["app.example.com"],"port":8080}]`)
+			Expect(diego.CheckDesiredLRP(lrp)).To(BeEmpty())
+		})
+
+		It("reports malformed routes", func() {
+			setRoutes("cf-router", `{"hostnames":["app.example.com"]}`)
+
+			problems := diego.CheckDesiredLRP(lrp)
+			Expect(problems).To(HaveLen(1))
+			Expect(problems[0].Field).To(Equal("routes.cf-router"))
+			Expect(problems[0].Severity).To(Equal(diego.SpecError))
+		})
+
+		It("warns about routes without hostnames or to ports the LRP does not expose", func() {
+			setRoutes("cf-router", `[{"hostnames":[],"port":9090}]`)
+			problems := diego.CheckDesiredLRP(lrp)
+			Expect(problems).To(ConsistOf(
+				diego.SpecProblem{Field: "routes.cf-router[0].hostnames", Severity: diego.SpecWarning, Message: "is empty, so the route matches no request"},
+				diego.SpecProblem{Field: "routes.cf-router[0].port", Severity: diego.SpecWarning, Message: "9090 is not one of the ports of the LRP, so the route reaches nothing"},
+			))
+			Expect(diego.HasSpecErrors(problems)).To(BeFalse())
+
+			setRoutes("tcp-router", `[{"external_port":61000,"container_port":9090}]`)
+			Expect(diego.CheckDesiredLRP(lrp)).To(ConsistOf(
+				diego.SpecProblem{Field: "routes.tcp-router[0].container_port", Severity: diego.SpecWarning, Message: "9090 is not one of the ports of the LRP, so the route reaches nothing"},
+			))
+		})
+	})
+})
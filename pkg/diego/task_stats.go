@@ -0,0 +1,138 @@
+package diego
+
+import (
+	"sort"
+	"time"
+
+	"code.cloudfoundry.org/bbs/models"
+)
+
+// maxTaskFailureReasons is how many of the most common failure reasons are
+// reported.
+const maxTaskFailureReasons = 5
+
+// taskAgeBuckets are the upper bounds of the ranges of ages the running
+// tasks are counted in, the last range being unbounded.
+var taskAgeBuckets = []time.Duration{time.Minute, 10 * time.Minute, time.Hour, 6 * time.Hour, 24 * time.Hour}
+
+// TaskAge is a task and how long ago it was created.
+type TaskAge struct {
+	TaskGuid string `json:"task_guid"`
+	Domain   string `json:"domain"`
+	AgeMs    int64  `json:"age_ms"`
+}
+
+// TaskAgeBucket counts the tasks created at least Min and less than Max ago.
+// Max is empty for the last, unbounded, bucket.
+type TaskAgeBucket struct {
+	Min   string `json:"min"`
+	Max   string `json:"max,omitempty"`
+	Tasks int    `json:"tasks"`
+}
+
+// TaskFailureReason is a normalized failure reason and the number of failed
+// tasks with it.
+type TaskFailureReason struct {
+	Reason string `json:"reason"`
+	Tasks  int    `json:"tasks"`
+}
+
+// TaskStats sums up a list of tasks. The states are named as by
+// models.Task_State, e.g. Pending. CompletedUnresolved counts the tasks that
+// are completed but whose result was not yet picked up to be resolved.
+type TaskStats struct {
+	Total               int                       `json:"total"`
+	ByState             map[string]int            `json:"by_state"`
+	ByDomain            map[string]map[string]int `json:"by_domain"`
+	ByCell              map[string]int            `json:"by_cell"`
+	OldestPending       *TaskAge                  `json:"oldest_pending,omitempty"`
+	RunningAges         []TaskAgeBucket           `json:"running_ages"`
+	FailureReasons      []TaskFailureReason       `json:"failure_reasons"`
+	CompletedUnresolved int                       `json:"completed_unresolved"`
+	OldestUnresolved    *TaskAge                  `json:"oldest_unresolved,omitempty"`
+}
+
+// AnalyzeTasks computes the TaskStats of tasks, with ages relative to now.
+func AnalyzeTasks(tasks []*models.Task, now time.Time) TaskStats {
+	stats := TaskStats{
+		Total:          len(tasks),
+		ByState:        map[string]int{},
+		ByDomain:       map[string]map[string]int{},
+		ByCell:         map[string]int{},
+		RunningAges:    newTaskAgeBuckets(),
+		FailureReasons: []TaskFailureReason{},
+	}
+	reasons := map[string]int{}
+
+	for _, task := range tasks {
+		state := task.State.String()
+		age := now.Sub(time.Unix(0, task.CreatedAt))
+
+		stats.ByState[state]++
+		if stats.ByDomain[task.Domain] == nil {
+			stats.ByDomain[task.Domain] = map[string]int{}
+		}
+		stats.ByDomain[task.Domain][state]++
+		if task.CellId != "" {
+			stats.ByCell[task.CellId]++
+		}
+
+		switch task.State {
+		case models.Task_Pending:
+			stats.OldestPending = olderTask(stats.OldestPending, task, age)
+		case models.Task_Running:
+			stats.RunningAges[taskAgeBucket(age)].Tasks++
+		case models.Task_Completed:
+			stats.CompletedUnresolved++
+			stats.OldestUnresolved = olderTask(stats.OldestUnresolved, task, age)
+		}
+
+		if task.Failed {
+			if reason := NormalizeReason(task.FailureReason); reason != "" {
+				reasons[reason]++
+			}
+		}
+	}
+
+	for reason, count := range reasons {
+		stats.FailureReasons = append(stats.FailureReasons, TaskFailureReason{Reason: reason, Tasks: count})
+	}
+	sort.Slice(stats.FailureReasons, func(i, j int) bool {
+		a, b := stats.FailureReasons[i], stats.FailureReasons[j]
+		if a.Tasks != b.Tasks {
+			return a.Tasks > b.Tasks
+		}
+		return a.Reason < b.Reason
+	})
+	if len(stats.FailureReasons) > maxTaskFailureReasons {
+		stats.FailureReasons = stats.FailureReasons[:maxTaskFailureReasons]
+	}
+
+	return stats
+}
+
+func newTaskAgeBuckets() []TaskAgeBucket {
+	buckets := make([]TaskAgeBucket, 0, len(taskAgeBuckets)+1)
+	min := time.Duration(0)
+	for _, max := range taskAgeBuckets {
+		buckets = append(buckets, TaskAgeBucket{Min: min.String(), Max: max.String()})
+		min = max
+	}
+	return append(buckets, TaskAgeBucket{Min: min.String()})
+}
+
+func taskAgeBucket(age time.Duration) int {
+	for i, max := range taskAgeBuckets {
+		if age < max {
+			return i
+		}
+	}
+	return len(taskAgeBuckets)
+}
+
+func olderTask(oldest *TaskAge, task *models.Task, age time.Duration) *TaskAge {
+	if oldest != nil && oldest.AgeMs >= age.Milliseconds() {
+		return oldest
+	}
+	return &TaskAge{TaskGuid: task.TaskGuid, Domain: task.Domain, AgeMs: age.Milliseconds()}
+}
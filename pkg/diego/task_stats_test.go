@@ -0,0 +1,94 @@
+package diego_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TaskStats", func() {
+	var (
+		now   time.Time
+		tasks []*models.Task
+	)
+
+	task := func(guid, domain, cellID string, state models.Task_State, age time.Duration) *models.Task {
+		return &models.Task{
+			TaskGuid:  guid,
+			Domain:    domain,
+			CellId:    cellID,
+			State:     state,
+			CreatedAt: now.Add(-age).UnixNano(),
+		}
+	}
+
+	failed := func(task *models.Task, reason string) *models.Task {
+		task.Failed = true
+		task.FailureReason = reason
+		return task
+	}
+
+	BeforeEach(func() {
+		now = time.Unix(1600000000, 0)
+		tasks = []*models.Task{
+			task("pending-1", "cf-tasks", "", models.Task_Pending, time.Minute),
+			task("pending-2", "cf-tasks", "", models.Task_Pending, time.Hour),
+			task("running-1", "cf-tasks", "cell-1", models.Task_Running, 30*time.Second),
+			task("running-2", "cf-tasks", "cell-1", models.Task_Running, 2*time.Hour),
+			task("running-3", "staging", "cell-2", models.Task_Running, 48*time.Hour),
+			failed(task("completed-1", "staging", "cell-2", models.Task_Completed, 5*time.Minute), "exit status 1"),
+			failed(task("resolving-1", "cf-tasks", "cell-2", models.Task_Resolving, time.Minute), "exit  status 1"),
+			failed(task("completed-2", "cf-tasks", "cell-1", models.Task_Completed, time.Minute), "insufficient resources"),
+		}
+	})
+
+	It("counts the tasks by state, domain and cell", func() {
+		stats := diego.AnalyzeTasks(tasks, now)
+		Expect(stats.Total).To(Equal(8))
+		Expect(stats.ByState).To(Equal(map[string]int{"Pending": 2, "Running": 3, "Completed": 2, "Resolving": 1}))
+		Expect(stats.ByDomain).To(Equal(map[string]map[string]int{
+			"cf-tasks": {"Pending": 2, "Running": 2, "Completed": 1, "Resolving": 1},
+			"staging":  {"Running": 1, "Completed": 1},
+		}))
+		Expect(stats.ByCell).To(Equal(map[string]int{"cell-1": 3, "cell-2": 3}))
+	})
+
+	It("reports the oldest pending and unresolved tasks", func() {
+		stats := diego.AnalyzeTasks(tasks, now)
+		Expect(stats.OldestPending).To(Equal(&diego.TaskAge{TaskGuid: "pending-2", Domain: "cf-tasks", AgeMs: 3600000}))
+		Expect(stats.CompletedUnresolved).To(Equal(2))
+		Expect(stats.OldestUnresolved).To(Equal(&diego.TaskAge{TaskGuid: "completed-1", Domain: "staging", AgeMs: 300000}))
+	})
+
+	It("distributes the running tasks by age", func() {
+		stats := diego.AnalyzeTasks(tasks, now)
+		Expect(stats.RunningAges).To(Equal([]diego.TaskAgeBucket{
+			{Min: "0s", Max: "1m0s", Tasks: 1},
+			{Min: "1m0s", Max: "10m0s", Tasks: 0},
+			{Min: "10m0s", Max: "1h0m0s", Tasks: 0},
+			{Min: "1h0m0s", Max: "6h0m0s", Tasks: 1},
+			{Min: "6h0m0s", Max: "24h0m0s", Tasks: 0},
+			{Min: "24h0m0s", Tasks: 1},
+		}))
+	})
+
+	It("ranks the normalized failure reasons", func() {
+		stats := diego.AnalyzeTasks(tasks, now)
+		Expect(stats.FailureReasons).To(Equal([]diego.TaskFailureReason{
+			{Reason: "exit status 1", Tasks: 2},
+			{Reason: "insufficient resources", Tasks: 1},
+		}))
+	})
+
+	It("reports no oldest tasks without pending or completed tasks", func() {
+		stats := diego.AnalyzeTasks(nil, now)
+		Expect(stats.Total).To(Equal(0))
+		Expect(stats.OldestPending).To(BeNil())
+		Expect(stats.OldestUnresolved).To(BeNil())
+		Expect(stats.FailureReasons).To(BeEmpty())
+	})
+})
@@ -0,0 +1,125 @@
+package diego
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/lager"
+)
+
+func Tasks(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, filter models.TaskFilter) ([]*models.Task, error) {
+	var tasks []*models.Task
+	err := call(ctx, bbsClient, func(client bbs.Client) error {
+		var err error
+		tasks, err = client.TasksWithFilter(logger, filter)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func TaskByGuid(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, taskGuid string) (*models.Task, error) {
+	var task *models.Task
+	err := call(ctx, bbsClient, func(client bbs.Client) error {
+		var err error
+		task, err = client.TaskByGuid(logger, taskGuid)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func DesireTask(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, task *models.Task) error {
+	return mutate(ctx, bbsClient, func(client bbs.Client) error {
+		return client.DesireTask(logger, task.TaskGuid, task.Domain, task.TaskDefinition)
+	})
+}
+
+func CancelTask(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, taskGuid string) error {
+	return mutate(ctx, bbsClient, func(client bbs.Client) error {
+		return client.CancelTask(logger, taskGuid)
+	})
+}
+
+// DeleteTask moves a completed task to the resolving state and deletes it.
+func DeleteTask(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, taskGuid string) error {
+	err := mutate(ctx, bbsClient, func(client bbs.Client) error {
+		return client.ResolvingTask(logger, taskGuid)
+	})
+	if err != nil {
+		return err
+	}
+
+	return mutate(ctx, bbsClient, func(client bbs.Client) error {
+		return client.DeleteTask(logger, taskGuid)
+	})
+}
+
+// RunTask desires task and waits for it to complete, passing every change of
+// its state to changed, and returns the completed task. The task events are
+// subscribed to before the task is desired so that no change is missed. If
+// the BBS closes the event stream first, the task is looked up once to tell
+// whether it completed meanwhile. The returned bool reports whether the BBS
+// accepted the task, which it then holds even when an error is returned.
+func RunTask(ctx context.Context, logger lager.Logger, bbsClient bbs.Client, task *models.Task, changed func(*models.Task)) (*models.Task, bool, error) {
+	es, err := bbsClient.SubscribeToTaskEvents(logger)
+	if err != nil {
+		return nil, false, models.ConvertError(err)
+	}
+	defer es.Close()
+	defer closeOnDone(ctx, es)()
+
+	err = DesireTask(ctx, logger, bbsClient, task)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for {
+		event, err := es.Next()
+		switch {
+		case err == nil:
+		case ctx.Err() != nil:
+			return nil, true, ctx.Err()
+		case err == io.EOF:
+			current, err := TaskByGuid(ctx, logger, bbsClient, task.TaskGuid)
+			if err != nil {
+				return nil, true, err
+			}
+			if current.State != models.Task_Completed {
+				return nil, true, fmt.Errorf("The task event stream was closed before task %s completed", task.TaskGuid)
+			}
+			return current, true, nil
+		default:
+			return nil, true, err
+		}
+
+		var current *models.Task
+		switch event := event.(type) {
+		case *models.TaskChangedEvent:
+			current = event.After
+		case *models.TaskRemovedEvent:
+			if event.Task != nil && event.Task.TaskGuid == task.TaskGuid {
+				return nil, true, fmt.Errorf("Task %s was removed before it completed", task.TaskGuid)
+			}
+		}
+		if current == nil || current.TaskGuid != task.TaskGuid {
+			continue
+		}
+
+		if changed != nil {
+			changed(current)
+		}
+		if current.State == models.Task_Completed {
+			return current, true, nil
+		}
+	}
+}
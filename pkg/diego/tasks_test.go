@@ -0,0 +1,233 @@
+package diego_test
+
+import (
+	"context"
+	"io"
+
+	"code.cloudfoundry.org/bbs/events/eventfakes"
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/cfdot/pkg/diego"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tasks", func() {
+	var (
+		fakeBBSClient *fake_bbs.FakeClient
+		logger        *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		fakeBBSClient = &fake_bbs.FakeClient{}
+		logger = lagertest.NewTestLogger("diego")
+	})
+
+	It("returns the tasks matching the filter", func() {
+		fakeBBSClient.TasksWithFilterReturns([]*models.Task{{TaskGuid: "task-1"}}, nil)
+
+		tasks, err := diego.Tasks(context.Background(), logger, fakeBBSClient, models.TaskFilter{Domain: "domain"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tasks).To(Equal([]*models.Task{{TaskGuid: "task-1"}}))
+
+		_, filter := fakeBBSClient.TasksWithFilterArgsForCall(0)
+		Expect(filter).To(Equal(models.TaskFilter{Domain: "domain"}))
+	})
+
+	It("returns the BBS error unchanged", func() {
+		fakeBBSClient.TasksWithFilterReturns(nil, models.ErrUnknownError)
+
+		_, err := diego.Tasks(context.Background(), logger, fakeBBSClient, models.TaskFilter{})
+		Expect(err).To(Equal(models.ErrUnknownError))
+	})
+
+	Context("when the context is cancelled during the request", func() {
+		var release chan struct{}
+
+		BeforeEach(func() {
+			release = make(chan struct{})
+			fakeBBSClient.TasksWithFilterStub = func(lager.Logger, models.TaskFilter) ([]*models.Task, error) {
+				<-release
+				return nil, nil
+			}
+		})
+
+		AfterEach(func() {
+			close(release)
+		})
+
+		It("returns the context error without waiting for the BBS", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			errCh := make(chan error)
+			go func() {
+				_, err := diego.Tasks(ctx, logger, fakeBBSClient, models.TaskFilter{})
+				errCh <- err
+			}()
+
+			Eventually(fakeBBSClient.TasksWithFilterCallCount).Should(Equal(1))
+			cancel()
+			Eventually(errCh).Should(Receive(Equal(context.Canceled)))
+		})
+	})
+
+	Context("when the context is cancelled during a mutation", func() {
+		It("waits for the BBS and returns its result", func() {
+			release := make(chan struct{})
+			fakeBBSClient.CancelTaskStub = func(lager.Logger, string) error {
+				<-release
+				return nil
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			errCh := make(chan error)
+			go func() {
+				errCh <- diego.CancelTask(ctx, logger, fakeBBSClient, "task-guid")
+			}()
+
+			Eventually(fakeBBSClient.CancelTaskCallCount).Should(Equal(1))
+			cancel()
+			Consistently(errCh).ShouldNot(Receive())
+
+			close(release)
+			Eventually(errCh).Should(Receive(BeNil()))
+		})
+
+		It("is not sent once the context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			err := diego.CancelTask(ctx, logger, fakeBBSClient, "task-guid")
+			Expect(err).To(Equal(context.Canceled))
+			Expect(fakeBBSClient.CancelTaskCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the context is already done", func() {
+		It("does not call the BBS", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := diego.Tasks(ctx, logger, fakeBBSClient, models.TaskFilter{})
+			Expect(err).To(Equal(context.Canceled))
+			Expect(fakeBBSClient.TasksWithFilterCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("DeleteTask", func() {
+		It("resolves and then deletes the task", func() {
+			err := diego.DeleteTask(context.Background(), logger, fakeBBSClient, "task-guid")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeBBSClient.ResolvingTaskCallCount()).To(Equal(1))
+			_, guid := fakeBBSClient.ResolvingTaskArgsForCall(0)
+			Expect(guid).To(Equal("task-guid"))
+
+			Expect(fakeBBSClient.DeleteTaskCallCount()).To(Equal(1))
+			_, guid = fakeBBSClient.DeleteTaskArgsForCall(0)
+			Expect(guid).To(Equal("task-guid"))
+		})
+
+		It("does not delete a task that could not be resolved", func() {
+			fakeBBSClient.ResolvingTaskReturns(models.ErrResourceNotFound)
+
+			err := diego.DeleteTask(context.Background(), logger, fakeBBSClient, "task-guid")
+			Expect(err).To(Equal(models.ErrResourceNotFound))
+			Expect(fakeBBSClient.DeleteTaskCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("RunTask", func() {
+		var (
+			fakeEventSource *eventfakes.FakeEventSource
+			task            *models.Task
+			events          []models.Event
+		)
+
+		taskIn := func(state models.Task_State) *models.Task {
+			return &models.Task{TaskGuid: "task-guid", State: state}
+		}
+
+		BeforeEach(func() {
+			task = &models.Task{TaskGuid: "task-guid", Domain: "domain", TaskDefinition: &models.TaskDefinition{}}
+			fakeEventSource = &eventfakes.FakeEventSource{}
+			fakeBBSClient.SubscribeToTaskEventsReturns(fakeEventSource, nil)
+
+			events = []models.Event{
+				models.NewTaskCreatedEvent(taskIn(models.Task_Pending)),
+				models.NewTaskChangedEvent(&models.Task{TaskGuid: "other-guid"}, &models.Task{TaskGuid: "other-guid", State: models.Task_Completed}),
+				models.NewTaskChangedEvent(taskIn(models.Task_Pending), taskIn(models.Task_Running)),
+				models.NewTaskChangedEvent(taskIn(models.Task_Running), taskIn(models.Task_Completed)),
+			}
+			fakeEventSource.NextStub = func() (models.Event, error) {
+				if len(events) == 0 {
+					return nil, io.EOF
+				}
+				event := events[0]
+				events = events[1:]
+				return event, nil
+			}
+		})
+
+		It("desires the task after subscribing and returns it once completed", func() {
+			fakeBBSClient.DesireTaskStub = func(lager.Logger, string, string, *models.TaskDefinition) error {
+				Expect(fakeBBSClient.SubscribeToTaskEventsCallCount()).To(Equal(1))
+				return nil
+			}
+
+			var states []models.Task_State
+			completed, created, err := diego.RunTask(context.Background(), logger, fakeBBSClient, task, func(t *models.Task) {
+				states = append(states, t.State)
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created).To(BeTrue())
+			Expect(completed).To(Equal(taskIn(models.Task_Completed)))
+			Expect(states).To(Equal([]models.Task_State{models.Task_Running, models.Task_Completed}))
+
+			_, guid, domain, _ := fakeBBSClient.DesireTaskArgsForCall(0)
+			Expect(guid).To(Equal("task-guid"))
+			Expect(domain).To(Equal("domain"))
+			Expect(fakeEventSource.CloseCallCount()).To(BeNumerically(">=", 1))
+		})
+
+		It("does not wait when the task cannot be desired", func() {
+			fakeBBSClient.DesireTaskReturns(models.ErrResourceExists)
+
+			_, created, err := diego.RunTask(context.Background(), logger, fakeBBSClient, task, nil)
+			Expect(err).To(Equal(models.ErrResourceExists))
+			Expect(created).To(BeFalse())
+			Expect(fakeEventSource.NextCallCount()).To(Equal(0))
+		})
+
+		It("fails when the task is removed before completing", func() {
+			events = []models.Event{models.NewTaskRemovedEvent(taskIn(models.Task_Pending))}
+
+			_, created, err := diego.RunTask(context.Background(), logger, fakeBBSClient, task, nil)
+			Expect(err).To(MatchError("Task task-guid was removed before it completed"))
+			Expect(created).To(BeTrue())
+		})
+
+		Context("when the event stream is closed first", func() {
+			BeforeEach(func() {
+				events = nil
+			})
+
+			It("returns the task if it completed meanwhile", func() {
+				fakeBBSClient.TaskByGuidReturns(taskIn(models.Task_Completed), nil)
+
+				completed, _, err := diego.RunTask(context.Background(), logger, fakeBBSClient, task, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(completed).To(Equal(taskIn(models.Task_Completed)))
+			})
+
+			It("fails if the task is still running", func() {
+				fakeBBSClient.TaskByGuidReturns(taskIn(models.Task_Running), nil)
+
+				_, _, err := diego.RunTask(context.Background(), logger, fakeBBSClient, task, nil)
+				Expect(err).To(MatchError("The task event stream was closed before task task-guid completed"))
+			})
+		})
+	})
+})